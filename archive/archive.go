@@ -0,0 +1,419 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package archive
+
+import (
+	"chronokeep/remote/auth"
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// readBatchSize is how many reads are added to the database at once while importing.
+const readBatchSize = 500
+
+// ErrAccountNotFound is returned when exporting an account that doesn't exist.
+var ErrAccountNotFound = errors.New("account not found")
+
+// Export Writes an archive of the account with the given email to w. The archive is newline delimited JSON,
+// a header followed by a record for the account, each of its keys, and every read and notification saved
+// with those keys. Only the database.Database interface is used so any implementation can be exported from.
+func Export(db database.Database, email string, w io.Writer) error {
+	account, err := db.GetAccount(email)
+	if err != nil {
+		return fmt.Errorf("error retrieving account: %v", err)
+	}
+	if account == nil {
+		return ErrAccountNotFound
+	}
+	keys, err := db.GetAccountKeys(email)
+	if err != nil {
+		return fmt.Errorf("error retrieving keys: %v", err)
+	}
+	enc := json.NewEncoder(w)
+	err = enc.Encode(types.ArchiveHeader{
+		Format:     types.ArchiveFormat,
+		Version:    types.ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error writing archive header: %v", err)
+	}
+	if err = enc.Encode(types.ArchiveRecord{Type: types.ArchiveRecordAccount, Account: account}); err != nil {
+		return fmt.Errorf("error writing account: %v", err)
+	}
+	for _, key := range keys {
+		// Identifiers belong to this instance and mean nothing to the one the archive is imported on.
+		key.AccountIdentifier = 0
+		key.Organization = nil
+		if err = enc.Encode(types.ArchiveRecord{Type: types.ArchiveRecordKey, Key: &key}); err != nil {
+			return fmt.Errorf("error writing key: %v", err)
+		}
+	}
+	for _, key := range keys {
+		var after *types.ReadCursor
+		for {
+			reads, err := db.GetReadsPage(account.Identifier, key.Organization, key.Name, 0, math.MaxInt64, after, database.MaxReadsPageSize)
+			if err != nil {
+				return fmt.Errorf("error retrieving reads for key %s: %v", key.Name, err)
+			}
+			for _, read := range reads {
+				err = enc.Encode(types.ArchiveRecord{Type: types.ArchiveRecordRead, KeyName: key.Name, Read: &read})
+				if err != nil {
+					return fmt.Errorf("error writing read: %v", err)
+				}
+			}
+			if len(reads) < database.MaxReadsPageSize {
+				break
+			}
+			cursor := reads[len(reads)-1].Cursor()
+			after = &cursor
+		}
+		var before *types.NotificationCursor
+		for {
+			notifications, err := db.GetNotificationHistory(account.Identifier, key.Organization, key.Name, 0, math.MaxInt64, false, before, database.MaxNotificationsPageSize)
+			if err != nil {
+				return fmt.Errorf("error retrieving notifications for key %s: %v", key.Name, err)
+			}
+			for _, notification := range notifications {
+				notification.Identifier = 0
+				err = enc.Encode(types.ArchiveRecord{Type: types.ArchiveRecordNotification, KeyName: key.Name, Notification: &notification})
+				if err != nil {
+					return fmt.Errorf("error writing notification: %v", err)
+				}
+			}
+			if len(notifications) < database.MaxNotificationsPageSize {
+				break
+			}
+			cursor := notifications[len(notifications)-1].Cursor()
+			before = &cursor
+		}
+	}
+	return nil
+}
+
+// Import Recreates the account in an archive written by Export. Data is added to the account with the same
+// email if there is one, otherwise the account is created with a random password that has to be reset before
+// it can be logged in to. Keys are given new values and are renamed when the account already has a key with
+// the same name. Only the database.Database interface is used so there's no transaction around the import,
+// if it fails part way through whatever was imported up to that point stays.
+func Import(db database.Database, r io.Reader) (*types.ArchiveImport, error) {
+	validate := validator.New()
+	dec := json.NewDecoder(r)
+	var header types.ArchiveHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("error reading archive header: %v", err)
+	}
+	if header.Format != types.ArchiveFormat {
+		return nil, errors.New("not an account archive")
+	}
+	if header.Version < 1 || header.Version > types.ArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", header.Version)
+	}
+	var record types.ArchiveRecord
+	if err := dec.Decode(&record); err != nil {
+		return nil, fmt.Errorf("error reading account: %v", err)
+	}
+	if record.Type != types.ArchiveRecordAccount || record.Account == nil {
+		return nil, errors.New("archive doesn't start with an account")
+	}
+	account, created, err := importAccount(db, validate, *record.Account)
+	if err != nil {
+		return nil, err
+	}
+	out := &types.ArchiveImport{
+		Account:     *account,
+		Created:     created,
+		Keys:        make([]types.Key, 0),
+		RenamedKeys: make(map[string]string),
+	}
+	taken, err := keyNames(db, *account)
+	if err != nil {
+		return nil, err
+	}
+	// Keys are looked up by the name they had in the archive.
+	keys := make(map[string]*types.Key)
+	reads := make(map[string][]types.Read)
+	// Notifications acknowledged in the archive for each key, counted by what identifies them.
+	acknowledged := make(map[string]map[notificationMatch]int)
+	// Notifications the keys already had, which an import never acknowledges.
+	existing := make(map[string]map[int64]bool)
+	addReads := func(name string) error {
+		added, err := db.AddReads(keys[name].Value, reads[name])
+		if err != nil {
+			return fmt.Errorf("error adding reads for key %s: %v", name, err)
+		}
+		out.Reads += int64(len(added))
+		reads[name] = reads[name][:0]
+		return nil
+	}
+	for {
+		record = types.ArchiveRecord{}
+		err = dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %v", err)
+		}
+		switch record.Type {
+		case types.ArchiveRecordKey:
+			if record.Key == nil {
+				return nil, errors.New("key record without a key")
+			}
+			if _, ok := keys[record.Key.Name]; ok {
+				return nil, fmt.Errorf("key %s is in the archive more than once", record.Key.Name)
+			}
+			key, err := importKey(db, validate, *account, *record.Key, taken)
+			if err != nil {
+				return nil, err
+			}
+			if key.Name != record.Key.Name {
+				out.RenamedKeys[record.Key.Name] = key.Name
+			}
+			keys[record.Key.Name] = key
+			out.Keys = append(out.Keys, *key)
+			existing[record.Key.Name], err = unacknowledgedIDs(db, *account, key.Name)
+			if err != nil {
+				return nil, err
+			}
+		case types.ArchiveRecordRead:
+			if record.Read == nil {
+				return nil, errors.New("read record without a read")
+			}
+			if _, ok := keys[record.KeyName]; !ok {
+				return nil, fmt.Errorf("read for unknown key %s", record.KeyName)
+			}
+			if err = record.Read.Validate(validate); err != nil {
+				return nil, fmt.Errorf("invalid read: %v", err)
+			}
+			reads[record.KeyName] = append(reads[record.KeyName], *record.Read)
+			if len(reads[record.KeyName]) >= readBatchSize {
+				if err = addReads(record.KeyName); err != nil {
+					return nil, err
+				}
+			}
+		case types.ArchiveRecordNotification:
+			if record.Notification == nil {
+				return nil, errors.New("notification record without a notification")
+			}
+			key, ok := keys[record.KeyName]
+			if !ok {
+				return nil, fmt.Errorf("notification for unknown key %s", record.KeyName)
+			}
+			saved, err := db.SaveNotification(&types.RequestNotification{
+				Type: record.Notification.Type,
+				When: record.Notification.When.UTC().Format(time.RFC3339),
+			}, key.Value)
+			if err != nil {
+				return nil, fmt.Errorf("error adding notification for key %s: %v", record.KeyName, err)
+			}
+			// A notification for a time the key already has one for is ignored.
+			if !saved {
+				continue
+			}
+			out.Notifications++
+			if record.Notification.Acknowledged != nil {
+				if acknowledged[record.KeyName] == nil {
+					acknowledged[record.KeyName] = make(map[notificationMatch]int)
+				}
+				acknowledged[record.KeyName][matchNotification(*record.Notification)]++
+			}
+		default:
+			return nil, fmt.Errorf("unknown archive record type %s", record.Type)
+		}
+	}
+	for name := range reads {
+		if len(reads[name]) > 0 {
+			if err = addReads(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, matches := range acknowledged {
+		if err = acknowledge(db, *account, keys[name].Name, matches, existing[name]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// importAccount Gets the account an archive is imported to, creating it when there isn't one with its email.
+func importAccount(db database.Database, validate *validator.Validate, account types.Account) (*types.Account, bool, error) {
+	if err := account.Validate(validate); err != nil {
+		return nil, false, fmt.Errorf("invalid account: %v", err)
+	}
+	existing, err := db.GetAccount(account.Email)
+	if err != nil {
+		return nil, false, fmt.Errorf("error retrieving account: %v", err)
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+	deleted, err := db.GetDeletedAccount(account.Email)
+	if err != nil {
+		return nil, false, fmt.Errorf("error retrieving deleted account: %v", err)
+	}
+	if deleted != nil {
+		return nil, false, fmt.Errorf("account %s has been deleted and must be restored first", account.Email)
+	}
+	role, err := db.GetRole(account.Type)
+	if err != nil {
+		return nil, false, fmt.Errorf("error retrieving role: %v", err)
+	}
+	if role == nil {
+		return nil, false, fmt.Errorf("role %s doesn't exist", account.Type)
+	}
+	password, err := uuid.NewRandom()
+	if err != nil {
+		return nil, false, fmt.Errorf("error generating password: %v", err)
+	}
+	hash, err := auth.HashPassword(password.String())
+	if err != nil {
+		return nil, false, err
+	}
+	created, err := db.AddAccount(types.Account{
+		Name:     account.Name,
+		Email:    account.Email,
+		Type:     account.Type,
+		Password: hash,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("error adding account: %v", err)
+	}
+	return created, true, nil
+}
+
+// keyNames Gets the names of every key on the account, deleted keys included as their names are still taken.
+func keyNames(db database.Database, account types.Account) (map[string]bool, error) {
+	active, err := db.GetAccountKeys(account.Email)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving keys: %v", err)
+	}
+	deleted, err := db.GetDeletedKeys(account.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving deleted keys: %v", err)
+	}
+	out := make(map[string]bool)
+	for _, key := range append(active, deleted...) {
+		out[key.Name] = true
+	}
+	return out, nil
+}
+
+// importKey Adds a key from an archive to the account with a new value, renaming it if its name is taken.
+func importKey(db database.Database, validate *validator.Validate, account types.Account, key types.Key, taken map[string]bool) (*types.Key, error) {
+	if err := key.Validate(validate); err != nil {
+		return nil, fmt.Errorf("invalid key %s: %v", key.Name, err)
+	}
+	name := key.Name
+	for i := 2; taken[name]; i++ {
+		name = key.Name + "-" + strconv.Itoa(i)
+	}
+	value, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %v", err)
+	}
+	added, err := db.AddKey(types.Key{
+		AccountIdentifier: account.Identifier,
+		Name:              name,
+		Value:             value.String(),
+		Type:              key.Type,
+		AllowedHosts:      key.AllowedHosts,
+		ValidUntil:        key.ValidUntil,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error adding key %s: %v", name, err)
+	}
+	taken[name] = true
+	return added, nil
+}
+
+// notificationMatch is what identifies a notification in an archive, as it's given a new identifier when imported.
+type notificationMatch struct {
+	Type string
+	When int64
+}
+
+func matchNotification(notification types.Notification) notificationMatch {
+	return notificationMatch{
+		Type: notification.Type,
+		When: notification.When.Unix(),
+	}
+}
+
+// acknowledge Acknowledges the imported notifications for a key that were acknowledged when exported, one for
+// each acknowledged in the archive. Notifications the key had before the import are left alone.
+func acknowledge(db database.Database, account types.Account, keyName string, matches map[notificationMatch]int, existing map[int64]bool) error {
+	return eachUnacknowledged(db, account, keyName, func(notifications []types.Notification) error {
+		var ids []int64
+		for _, notification := range notifications {
+			match := matchNotification(notification)
+			if existing[notification.Identifier] || matches[match] < 1 {
+				continue
+			}
+			matches[match]--
+			ids = append(ids, notification.Identifier)
+		}
+		if _, err := db.AcknowledgeNotifications(account.Identifier, nil, ids); err != nil {
+			return fmt.Errorf("error acknowledging notifications for key %s: %v", keyName, err)
+		}
+		return nil
+	})
+}
+
+// unacknowledgedIDs Gets the identifiers of a key's unacknowledged notifications.
+func unacknowledgedIDs(db database.Database, account types.Account, keyName string) (map[int64]bool, error) {
+	ids := make(map[int64]bool)
+	err := eachUnacknowledged(db, account, keyName, func(notifications []types.Notification) error {
+		for _, notification := range notifications {
+			ids[notification.Identifier] = true
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// eachUnacknowledged Calls page with each page of a key's unacknowledged notifications.
+func eachUnacknowledged(db database.Database, account types.Account, keyName string, page func(notifications []types.Notification) error) error {
+	var before *types.NotificationCursor
+	for {
+		notifications, err := db.GetNotificationHistory(account.Identifier, nil, keyName, 0, math.MaxInt64, true, before, database.MaxNotificationsPageSize)
+		if err != nil {
+			return fmt.Errorf("error retrieving notifications for key %s: %v", keyName, err)
+		}
+		if err = page(notifications); err != nil {
+			return err
+		}
+		if len(notifications) < database.MaxNotificationsPageSize {
+			return nil
+		}
+		cursor := notifications[len(notifications)-1].Cursor()
+		before = &cursor
+	}
+}
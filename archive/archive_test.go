@@ -0,0 +1,291 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"chronokeep/remote/auth"
+	"chronokeep/remote/auth/authtest"
+	"chronokeep/remote/database"
+	"chronokeep/remote/database/sqlite"
+	"chronokeep/remote/types"
+	"chronokeep/remote/util"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	authtest.Main(m)
+}
+
+func setupDatabase(t *testing.T, name string) database.Database {
+	db := &sqlite.SQLite{}
+	err := db.Setup(&util.Config{
+		DBName:     filepath.Join(t.TempDir(), name),
+		DBDriver:   "sqlite3",
+		AdminEmail: "admin@test.com",
+		AdminName:  "tester number 1",
+		AdminPass:  "password",
+	})
+	if err != nil {
+		t.Fatalf("Error setting up database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+func setupSource(t *testing.T) (database.Database, *types.Account) {
+	db := setupDatabase(t, "source.sqlite")
+	hash, _ := auth.HashPassword("password")
+	account, err := db.AddAccount(types.Account{
+		Name:     "Jerry Garcia",
+		Email:    "jgarcia@test.com",
+		Type:     types.RoleFree,
+		Password: hash,
+	})
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	keys := []types.Key{
+		{
+			AccountIdentifier: account.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+			AllowedHosts:      []string{"10.0.0.0/8"},
+		},
+		{
+			AccountIdentifier: account.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "read",
+			Name:              "reader2",
+		},
+		{
+			AccountIdentifier: account.Identifier,
+			Value:             "030001-1ACSDD-K2389A-33123B",
+			Type:              "write",
+			Name:              "deleted",
+		},
+	}
+	for _, key := range keys {
+		if _, err = db.AddKey(key); err != nil {
+			t.Fatalf("Error adding key: %v", err)
+		}
+	}
+	db.DeleteKey(keys[2])
+	now := time.Now().Unix()
+	reads := []types.Read{
+		{Identifier: "1", Seconds: now, Milliseconds: 100, IdentType: "chip", Type: "reader", Antenna: 1, Reader: "test", RSSI: "-50"},
+		{Identifier: "2", Seconds: now + 10, Milliseconds: 200, IdentType: "chip", Type: "reader", Antenna: 1, Reader: "test", RSSI: "-50"},
+		{Identifier: "3", Seconds: now + 20, Milliseconds: 300, IdentType: "bib", Type: "manual", Antenna: 0, Reader: "test", RSSI: ""},
+	}
+	if _, err = db.AddReads(keys[0].Value, reads); err != nil {
+		t.Fatalf("Error adding reads: %v", err)
+	}
+	when := time.Now().Add(time.Hour * -2)
+	db.SaveNotification(&types.RequestNotification{Type: "UPS_CONNECTED", When: when.UTC().Format(time.RFC3339)}, keys[0].Value)
+	db.SaveNotification(&types.RequestNotification{Type: "UPS_ON_BATTERY", When: when.Add(time.Hour).UTC().Format(time.RFC3339)}, keys[0].Value)
+	notifications, _ := db.GetNotificationHistory(account.Identifier, nil, keys[0].Name, 0, now, false, nil, 10)
+	if len(notifications) != 2 {
+		t.Fatalf("Expected %v notifications, found %v.", 2, len(notifications))
+	}
+	db.AcknowledgeNotifications(account.Identifier, nil, []int64{notifications[1].Identifier})
+	return db, account
+}
+
+func TestExport(t *testing.T) {
+	source, account := setupSource(t)
+	var buf bytes.Buffer
+	if err := Export(source, account.Email, &buf); err != nil {
+		t.Fatalf("Error exporting account: %v", err)
+	}
+	scanner := bufio.NewScanner(&buf)
+	var header types.ArchiveHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil {
+		t.Fatal("Expected archive to start with a header.")
+	}
+	if header.Format != types.ArchiveFormat || header.Version != types.ArchiveVersion {
+		t.Errorf("Expected header for format %v version %v, found %+v.", types.ArchiveFormat, types.ArchiveVersion, header)
+	}
+	found := make(map[string]int)
+	for scanner.Scan() {
+		var record types.ArchiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Error reading archive record: %v", err)
+		}
+		found[record.Type]++
+		if record.Type == types.ArchiveRecordKey && (record.Key.AccountIdentifier != 0 || record.Key.Value != "") {
+			t.Errorf("Expected key without identifiers or value, found %+v.", *record.Key)
+		}
+	}
+	if found[types.ArchiveRecordAccount] != 1 {
+		t.Errorf("Expected %v account records, found %v.", 1, found[types.ArchiveRecordAccount])
+	}
+	if found[types.ArchiveRecordKey] != 2 {
+		t.Errorf("Expected %v key records, found %v.", 2, found[types.ArchiveRecordKey])
+	}
+	if found[types.ArchiveRecordRead] != 3 {
+		t.Errorf("Expected %v read records, found %v.", 3, found[types.ArchiveRecordRead])
+	}
+	if found[types.ArchiveRecordNotification] != 2 {
+		t.Errorf("Expected %v notification records, found %v.", 2, found[types.ArchiveRecordNotification])
+	}
+	err := Export(source, "unknown@test.com", &buf)
+	if err != ErrAccountNotFound {
+		t.Errorf("Expected account not found error, found %v.", err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	source, account := setupSource(t)
+	var buf bytes.Buffer
+	if err := Export(source, account.Email, &buf); err != nil {
+		t.Fatalf("Error exporting account: %v", err)
+	}
+	archive := buf.String()
+	// Import into an instance without the account.
+	target := setupDatabase(t, "target.sqlite")
+	imported, err := Import(target, strings.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Error importing account: %v", err)
+	}
+	if !imported.Created || imported.Account.Email != account.Email || imported.Account.Type != account.Type {
+		t.Errorf("Expected account %v to be created, found %+v.", account.Email, *imported)
+	}
+	if len(imported.Keys) != 2 || len(imported.RenamedKeys) != 0 || imported.Reads != 3 || imported.Notifications != 2 {
+		t.Errorf("Expected %v keys, %v reads and %v notifications imported, found %+v.", 2, 3, 2, *imported)
+	}
+	for _, key := range imported.Keys {
+		if key.Value == "" || key.AccountIdentifier != imported.Account.Identifier {
+			t.Errorf("Expected key with a new value on account %v, found %+v.", imported.Account.Identifier, key)
+		}
+		if key.Name == "reader1" && (len(key.AllowedHosts) != 1 || key.AllowedHosts[0] != "10.0.0.0/8") {
+			t.Errorf("Expected allowed hosts to be imported, found %+v.", key)
+		}
+	}
+	reads, _ := target.GetReads(imported.Account.Identifier, nil, "reader1", 0, time.Now().Unix()+100)
+	if len(reads) != 3 {
+		t.Errorf("Expected %v reads, found %v.", 3, len(reads))
+	}
+	unacknowledged, _ := target.GetNotificationHistory(imported.Account.Identifier, nil, "reader1", 0, time.Now().Unix(), true, nil, 10)
+	if len(unacknowledged) != 1 || unacknowledged[0].Type != "UPS_ON_BATTERY" {
+		t.Errorf("Expected only the unacknowledged notification to be unacknowledged, found %+v.", unacknowledged)
+	}
+	// Importing again adds to the existing account and renames the keys, deleted keys' names are still taken.
+	for _, key := range imported.Keys {
+		if key.Name == "reader2" {
+			target.DeleteKey(key)
+		}
+	}
+	imported, err = Import(target, strings.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Error importing account: %v", err)
+	}
+	if imported.Created {
+		t.Error("Expected existing account to be used.")
+	}
+	if imported.RenamedKeys["reader1"] != "reader1-2" || imported.RenamedKeys["reader2"] != "reader2-2" {
+		t.Errorf("Expected conflicting keys to be renamed, found %+v.", imported.RenamedKeys)
+	}
+	reads, _ = target.GetReads(imported.Account.Identifier, nil, "reader1-2", 0, time.Now().Unix()+100)
+	if len(reads) != 3 {
+		t.Errorf("Expected %v reads on the renamed key, found %v.", 3, len(reads))
+	}
+	if imported.Reads != 3 || imported.Notifications != 2 {
+		t.Errorf("Expected %v reads and %v notifications imported again, found %+v.", 3, 2, *imported)
+	}
+	// Notifications repeated in an archive are only counted once, as only one is added.
+	var repeated strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(archive, "\n"), "\n") {
+		repeated.WriteString(line + "\n")
+		if strings.Contains(line, "\"type\":\""+types.ArchiveRecordNotification+"\"") {
+			repeated.WriteString(line + "\n")
+		}
+	}
+	imported, err = Import(target, strings.NewReader(repeated.String()))
+	if err != nil {
+		t.Fatalf("Error importing account: %v", err)
+	}
+	if imported.Reads != 3 || imported.Notifications != 2 {
+		t.Errorf("Expected %v reads and %v notifications imported from repeated records, found %+v.", 3, 2, *imported)
+	}
+	notifications, _ := target.GetNotificationHistory(imported.Account.Identifier, nil, imported.RenamedKeys["reader1"], 0, time.Now().Unix(), false, nil, 10)
+	if len(notifications) != 2 {
+		t.Errorf("Expected %v notifications, found %+v.", 2, notifications)
+	}
+	// Bad archives.
+	_, err = Import(target, strings.NewReader("{\"format\":\"other\",\"version\":1}\n"))
+	if err == nil {
+		t.Error("Expected error importing an archive of another format.")
+	}
+	_, err = Import(target, strings.NewReader("{\"format\":\""+types.ArchiveFormat+"\",\"version\":100}\n"))
+	if err == nil {
+		t.Error("Expected error importing an archive from a newer version.")
+	}
+	_, err = Import(target, strings.NewReader("{\"format\":\""+types.ArchiveFormat+"\",\"version\":1}\n{\"type\":\"key\",\"key\":{\"name\":\"reader3\",\"type\":\"write\"}}\n"))
+	if err == nil {
+		t.Error("Expected error importing an archive without an account.")
+	}
+}
+
+func TestImportAcknowledgements(t *testing.T) {
+	db, account := setupSource(t)
+	// The acknowledged notification is UPS_CONNECTED, the other is UPS_ON_BATTERY an hour later.
+	notifications, _ := db.GetNotificationHistory(account.Identifier, nil, "reader1", 0, time.Now().Unix(), false, nil, 10)
+	if len(notifications) != 2 {
+		t.Fatalf("Expected %v notifications, found %v.", 2, len(notifications))
+	}
+	onBattery := notifications[0]
+	// Notifications the key already had aren't acknowledged, even when they match.
+	err := acknowledge(db, *account, "reader1", map[notificationMatch]int{
+		matchNotification(onBattery): 1,
+	}, map[int64]bool{onBattery.Identifier: true})
+	if err != nil {
+		t.Fatalf("Error acknowledging notifications: %v", err)
+	}
+	unacknowledged, _ := db.GetNotificationHistory(account.Identifier, nil, "reader1", 0, time.Now().Unix(), true, nil, 10)
+	if len(unacknowledged) != 1 {
+		t.Errorf("Expected existing notification to stay unacknowledged, found %+v.", unacknowledged)
+	}
+	// Notifications sent at the same time but of another type don't match.
+	err = acknowledge(db, *account, "reader1", map[notificationMatch]int{
+		{Type: "MAX_TEMP", When: onBattery.When.Unix()}: 1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Error acknowledging notifications: %v", err)
+	}
+	unacknowledged, _ = db.GetNotificationHistory(account.Identifier, nil, "reader1", 0, time.Now().Unix(), true, nil, 10)
+	if len(unacknowledged) != 1 {
+		t.Errorf("Expected notification of another type to stay unacknowledged, found %+v.", unacknowledged)
+	}
+	err = acknowledge(db, *account, "reader1", map[notificationMatch]int{
+		matchNotification(onBattery): 1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Error acknowledging notifications: %v", err)
+	}
+	unacknowledged, _ = db.GetNotificationHistory(account.Identifier, nil, "reader1", 0, time.Now().Unix(), true, nil, 10)
+	if len(unacknowledged) != 0 {
+		t.Errorf("Expected matching notification to be acknowledged, found %+v.", unacknowledged)
+	}
+}
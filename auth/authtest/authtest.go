@@ -0,0 +1,36 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+
+// Package authtest has helpers for the tests of packages that hash passwords.
+package authtest
+
+import (
+	"chronokeep/remote/auth"
+	"os"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Main runs the tests with passwords hashed at the lowest bcrypt cost. Hashing at full cost is most of what
+// the tests would otherwise spend their time on. Call it from the package's TestMain.
+func Main(m *testing.M) {
+	auth.SetPasswordCost(bcrypt.MinCost)
+	os.Exit(m.Run())
+}
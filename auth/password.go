@@ -24,9 +24,18 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// passwordCost is the bcrypt cost passwords are hashed with.
+var passwordCost = 14
+
+// SetPasswordCost changes the bcrypt cost passwords are hashed with from then on. Passwords that were already
+// hashed are still verified at the cost they were hashed with.
+func SetPasswordCost(cost int) {
+	passwordCost = cost
+}
+
 // HashPassword is used to encrypt the password before it is stored in the Database
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
 	if err != nil {
 		return "", fmt.Errorf("unable to hash password: %v", err)
 	}
@@ -0,0 +1,166 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"chronokeep/remote/types"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinRSAKeyBits is the smallest RSA key allowed for signing tokens.
+const MinRSAKeyBits = 2048
+
+// SigningKey is an asymmetric key used to sign or verify tokens. Keys loaded from a public key
+// can only be used to verify tokens, which lets a retired key keep working until its tokens expire.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// LoadSigningKeys Loads the keys in the PEM files given. The first key is the one new tokens
+// are signed with so it has to be a private key, the rest are only used to verify tokens.
+func LoadSigningKeys(paths []string) ([]*SigningKey, error) {
+	var keys []*SigningKey
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read signing key %s: %v", path, err)
+		}
+		key, err := ParseSigningKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load signing key %s: %v", path, err)
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("signing key %s is listed more than once", path)
+		}
+		seen[key.ID] = true
+		keys = append(keys, key)
+	}
+	if len(keys) > 0 && keys[0].Private == nil {
+		return nil, errors.New("the first signing key has to be a private key")
+	}
+	return keys, nil
+}
+
+// ParseSigningKey Parses a PEM encoded Ed25519 or RSA key. Private keys can be PKCS #8 or PKCS #1 (RSA only),
+// public keys have to be PKIX.
+func ParseSigningKey(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem data found")
+	}
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem block type %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := &SigningKey{}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.Private = k
+		key.Public = k.Public()
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.Public = k
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.Private = k
+		key.Public = k.Public()
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+		key.Public = k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only Ed25519 and RSA keys can be used", parsed)
+	}
+	if public, ok := key.Public.(*rsa.PublicKey); ok && public.N.BitLen() < MinRSAKeyBits {
+		return nil, fmt.Errorf("rsa keys need at least %d bits", MinRSAKeyBits)
+	}
+	key.ID = thumbprint(key.JWK())
+	return key, nil
+}
+
+// JWK Returns the public part of the key in the form published in a JWKS.
+func (k *SigningKey) JWK() types.JSONWebKey {
+	jwk := types.JSONWebKey{
+		KeyID:     k.ID,
+		Use:       "sig",
+		Algorithm: k.Method.Alg(),
+	}
+	switch public := k.Public.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	}
+	return jwk
+}
+
+// thumbprint Returns the RFC 7638 thumbprint of a key, used as its key id.
+func thumbprint(jwk types.JSONWebKey) string {
+	// Only the required members in lexicographic order go into the thumbprint.
+	var members any
+	switch jwk.KeyType {
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	default:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
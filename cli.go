@@ -0,0 +1,187 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"chronokeep/remote/archive"
+	"chronokeep/remote/database"
+	"chronokeep/remote/handlers"
+	"chronokeep/remote/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// commands are the subcommands that can be given to the binary, serve being the one run without one.
+var commands = map[string]func(args []string) error{
+	"serve":   serveCommand,
+	"migrate": migrateCommand,
+	"account": accountCommand,
+	"key":     keyCommand,
+	"reads":   readsCommand,
+	"config":  configCommand,
+	"export":  exportCommand,
+	"import":  importCommand,
+}
+
+// runCommand Runs the subcommand named by the first argument, returning the exit code.
+func runCommand(args []string) int {
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %s\n", args[0])
+		return 2
+	}
+	// Output from commands goes to stdout, so keep the logs out of it.
+	if args[0] != "serve" {
+		log.SetOutput(os.Stderr)
+	}
+	if err := command(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// runSubcommand Runs the subcommand named by the first argument, for commands that group several.
+func runSubcommand(subcommands map[string]func(args []string) error, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("expected one of %s", strings.Join(slices.Sorted(maps.Keys(subcommands)), ", "))
+	}
+	subcommand, ok := subcommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown subcommand %s", args[0])
+	}
+	return subcommand(args[1:])
+}
+
+// setupDatabase Connects to the configured database for a command using handlers.Setup, or handlers.Connect
+// when the command shouldn't create or update the tables.
+func setupDatabase(source *util.ConfigSource, setup func(config *util.Config) error) (func(), error) {
+	config, err := source.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration: %v", err)
+	}
+	if err = setup(config); err != nil {
+		return nil, fmt.Errorf("error setting up database: %v", err)
+	}
+	return handlers.Finalize, nil
+}
+
+// printJSON Writes the value to stdout as indented JSON.
+func printJSON(value any) error {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+func migrateCommand(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	to := flags.Int("to", database.CurrentVersion, "version to migrate the database to")
+	dryRun := flags.Bool("dry-run", false, "print the migrations that would run without running them")
+	history := flags.Bool("history", false, "print the migrations that have been run instead of migrating")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	finalize, err := setupDatabase(source, handlers.Connect)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	if *history {
+		records, err := handlers.Database().GetMigrationHistory()
+		if err != nil {
+			return err
+		}
+		return printJSON(records)
+	}
+	plan, err := handlers.Database().Migrate(*to, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printJSON(plan)
+}
+
+func exportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account to export")
+	out := flags.String("out", "", "file to write the archive to, stdout when not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return fmt.Errorf("-email is required")
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return archive.Export(handlers.Database(), *email, w)
+}
+
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	in := flags.String("in", "", "file to read the archive from, stdin when not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	var r io.Reader = os.Stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	imported, err := archive.Import(handlers.Database(), r)
+	if err != nil {
+		return err
+	}
+	// Imported keys have new values, this is the only place they're shown.
+	return printJSON(imported)
+}
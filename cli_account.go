@@ -0,0 +1,195 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"chronokeep/remote/auth"
+	"chronokeep/remote/handlers"
+	"chronokeep/remote/types"
+	"chronokeep/remote/util"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var accountCommands = map[string]func(args []string) error{
+	"create":         accountCreateCommand,
+	"unlock":         accountUnlockCommand,
+	"reset-password": accountResetPasswordCommand,
+	"list":           accountListCommand,
+}
+
+func accountCommand(args []string) error {
+	return runSubcommand(accountCommands, args)
+}
+
+// readPassword Returns the password given, or the first line of stdin when one wasn't, so it can be
+// kept out of the shell history.
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("error reading password: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// getAccount Returns the account with the email, erroring when there isn't one.
+func getAccount(email string) (*types.Account, error) {
+	account, err := handlers.Database().GetAccount(email)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, fmt.Errorf("account %s not found", email)
+	}
+	return account, nil
+}
+
+func accountCreateCommand(args []string) error {
+	flags := flag.NewFlagSet("account create", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account")
+	name := flags.String("name", "", "name of the account")
+	accountType := flags.String("type", types.RoleFree, "type of the account, the name of its role")
+	password := flags.String("password", "", "password of the account, read from stdin when not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	account := types.Account{
+		Name:  *name,
+		Email: *email,
+		Type:  *accountType,
+	}
+	if err := account.Validate(validator.New()); err != nil {
+		return fmt.Errorf("invalid account information: %v", err)
+	}
+	pass, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	if len(pass) < 8 {
+		return errors.New("minimum password length (8) not met")
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	role, err := handlers.Database().GetRole(account.Type)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return fmt.Errorf("role '%s' does not exist", account.Type)
+	}
+	account.Password, err = auth.HashPassword(pass)
+	if err != nil {
+		return err
+	}
+	added, err := handlers.Database().AddAccount(account)
+	if err != nil {
+		return err
+	}
+	return printJSON(added)
+}
+
+func accountUnlockCommand(args []string) error {
+	flags := flag.NewFlagSet("account unlock", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account to unlock")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	account, err := getAccount(*email)
+	if err != nil {
+		return err
+	}
+	return handlers.Database().UnlockAccount(*account)
+}
+
+func accountResetPasswordCommand(args []string) error {
+	flags := flag.NewFlagSet("account reset-password", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account")
+	password := flags.String("password", "", "new password of the account, read from stdin when not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	pass, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	if len(pass) < 8 {
+		return errors.New("minimum password length (8) not met")
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	account, err := getAccount(*email)
+	if err != nil {
+		return err
+	}
+	hashed, err := auth.HashPassword(pass)
+	if err != nil {
+		return err
+	}
+	// Anyone logged in with the old password is logged out.
+	return handlers.Database().ChangePassword(account.Email, hashed, true)
+}
+
+func accountListCommand(args []string) error {
+	flags := flag.NewFlagSet("account list", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	accounts, err := handlers.Database().GetAccounts()
+	if err != nil {
+		return err
+	}
+	return printJSON(accounts)
+}
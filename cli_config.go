@@ -0,0 +1,66 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"chronokeep/remote/auth"
+	"chronokeep/remote/util"
+)
+
+var configCommands = map[string]func(args []string) error{
+	"check": configCheckCommand,
+}
+
+func configCommand(args []string) error {
+	return runSubcommand(configCommands, args)
+}
+
+// configCheckCommand Validates the configuration and prints the value of every setting, in a form that can
+// be used as a YAML config file, with where it came from and secrets redacted.
+func configCheckCommand(args []string) error {
+	flags := flag.NewFlagSet("config check", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	config, values, err := source.Check()
+	if err != nil {
+		return err
+	}
+	// Key files aren't read until the server starts, make sure they can be.
+	if _, err = auth.LoadSigningKeys(config.JWTKeyFiles); err != nil {
+		return err
+	}
+	for _, value := range values {
+		fmt.Printf("%s: %s # %s\n", value.Key, configValue(value.Value), value.Source)
+	}
+	return nil
+}
+
+// configValue Quotes a value unless YAML reads it as the number it is.
+func configValue(value string) string {
+	if _, err := strconv.Atoi(value); err == nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
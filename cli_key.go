@@ -0,0 +1,152 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"chronokeep/remote/handlers"
+	"chronokeep/remote/types"
+	"chronokeep/remote/util"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+var keyCommands = map[string]func(args []string) error{
+	"create": keyCreateCommand,
+	"revoke": keyRevokeCommand,
+	"list":   keyListCommand,
+}
+
+func keyCommand(args []string) error {
+	return runSubcommand(keyCommands, args)
+}
+
+func keyCreateCommand(args []string) error {
+	flags := flag.NewFlagSet("key create", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account the key belongs to")
+	name := flags.String("name", "", "name of the key")
+	keyType := flags.String("type", "read", "type of the key, read, write, or delete")
+	hosts := flags.String("hosts", "", "comma separated hosts the key can be used from, any host when not set")
+	validUntil := flags.String("valid-until", "", "date the key expires, never when not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	request := types.RequestKey{
+		Name:       *name,
+		Type:       *keyType,
+		ValidUntil: *validUntil,
+	}
+	if *hosts != "" {
+		request.AllowedHosts = strings.Split(*hosts, ",")
+	}
+	if err := request.Validate(validator.New()); err != nil {
+		return fmt.Errorf("invalid key information: %v", err)
+	}
+	key := request.ToKey()
+	if *validUntil != "" && key.ValidUntil == nil {
+		return fmt.Errorf("unknown date format '%s'", *validUntil)
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	account, err := getAccount(*email)
+	if err != nil {
+		return err
+	}
+	value, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+	key.AccountIdentifier = account.Identifier
+	key.Value = value.String()
+	added, err := handlers.Database().AddKey(key)
+	if err != nil {
+		return err
+	}
+	// Only a hash of the value is stored, this is the only place it's shown.
+	return printJSON(added)
+}
+
+func keyRevokeCommand(args []string) error {
+	flags := flag.NewFlagSet("key revoke", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account the key belongs to")
+	prefix := flags.String("prefix", "", "prefix of the key to revoke")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || *prefix == "" {
+		return errors.New("-email and -prefix are required")
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	keys, err := handlers.Database().GetAccountKeys(*email)
+	if err != nil {
+		return err
+	}
+	var found []types.Key
+	for _, key := range keys {
+		if key.Prefix == *prefix {
+			found = append(found, key)
+		}
+	}
+	if len(found) < 1 {
+		return fmt.Errorf("key %s not found", *prefix)
+	}
+	if len(found) > 1 {
+		return fmt.Errorf("more than one key starts with %s", *prefix)
+	}
+	return handlers.Database().DeleteKey(found[0])
+}
+
+func keyListCommand(args []string) error {
+	flags := flag.NewFlagSet("key list", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account to list the keys of")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	keys, err := handlers.Database().GetAccountKeys(*email)
+	if err != nil {
+		return err
+	}
+	return printJSON(keys)
+}
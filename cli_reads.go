@@ -0,0 +1,86 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"chronokeep/remote/handlers"
+	"chronokeep/remote/types"
+	"chronokeep/remote/util"
+)
+
+var readsCommands = map[string]func(args []string) error{
+	"purge": readsPurgeCommand,
+}
+
+func readsCommand(args []string) error {
+	return runSubcommand(readsCommands, args)
+}
+
+func readsPurgeCommand(args []string) error {
+	flags := flag.NewFlagSet("reads purge", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	email := flags.String("email", "", "email of the account the reads belong to")
+	reader := flags.String("reader", "", "name of the reader to purge the reads of")
+	organization := flags.Int64("organization", 0, "id of the organization the reader belongs to, if any")
+	start := flags.Int64("start", 0, "unix time in seconds of the first read to purge")
+	end := flags.Int64("end", 0, "unix time in seconds of the last read to purge, every read when not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || *reader == "" {
+		return errors.New("-email and -reader are required")
+	}
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if set["start"] && !set["end"] {
+		return errors.New("-start requires -end")
+	}
+	finalize, err := setupDatabase(source, handlers.Setup)
+	if err != nil {
+		return err
+	}
+	defer finalize()
+	account, err := getAccount(*email)
+	if err != nil {
+		return err
+	}
+	var org *int64
+	if set["organization"] {
+		org = organization
+	}
+	var count int64
+	if set["start"] {
+		count, err = handlers.Database().DeleteReaderReads(account.Identifier, org, *reader, *start, *end)
+	} else if set["end"] {
+		count, err = handlers.Database().DeleteReaderReadsBefore(account.Identifier, org, *reader, *end)
+	} else {
+		count, err = handlers.Database().DeleteReaderReadsBetween(account.Identifier, org, *reader)
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(types.UploadReadsResponse{
+		Count: count,
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runCommandOutput Runs a command and returns its exit code and what it wrote to stdout.
+func runCommandOutput(t *testing.T, args ...string) (int, []byte) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("error creating pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	code := runCommand(args)
+	os.Stdout = stdout
+	w.Close()
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("error reading output: %v", err)
+	}
+	return code, output
+}
+
+func TestMigrateEmptyDatabase(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "empty.sqlite")
+	dbArgs := []string{
+		"-db-connector", "sqlite",
+		"-db-name", dbFile,
+		"-secret-key", "test-secret-key-for-tokens",
+		"-refresh-key", "test-refresh-key-for-tokens",
+	}
+	t.Log("Testing history of an empty database.")
+	code, output := runCommandOutput(t, append([]string{"migrate", "-history"}, dbArgs...)...)
+	if assert.Equal(t, 0, code) {
+		var records []types.MigrationRecord
+		if assert.NoError(t, json.Unmarshal(output, &records)) && assert.Len(t, records, 1) {
+			assert.Equal(t, database.CurrentVersion, records[0].Version)
+			assert.Equal(t, types.MigrationUp, records[0].Direction)
+		}
+	}
+	t.Log("Testing dry run against an empty database.")
+	dbFile = filepath.Join(t.TempDir(), "empty.sqlite")
+	dbArgs[3] = dbFile
+	code, output = runCommandOutput(t, append([]string{"migrate", "-dry-run"}, dbArgs...)...)
+	if assert.Equal(t, 0, code) {
+		var plan types.MigrationPlan
+		if assert.NoError(t, json.Unmarshal(output, &plan)) {
+			assert.Equal(t, database.CurrentVersion, plan.From)
+			assert.Equal(t, database.CurrentVersion, plan.To)
+			assert.Empty(t, plan.Steps)
+		}
+	}
+	t.Log("Testing migrating down from a new database.")
+	previous := strconv.Itoa(database.CurrentVersion - 1)
+	code, output = runCommandOutput(t, append([]string{"migrate", "-to", previous}, dbArgs...)...)
+	if assert.Equal(t, 0, code) {
+		var plan types.MigrationPlan
+		if assert.NoError(t, json.Unmarshal(output, &plan)) {
+			assert.Equal(t, database.CurrentVersion-1, plan.To)
+			assert.Len(t, plan.Steps, 1)
+		}
+	}
+	code, output = runCommandOutput(t, append([]string{"migrate", "-history"}, dbArgs...)...)
+	if assert.Equal(t, 0, code) {
+		var records []types.MigrationRecord
+		if assert.NoError(t, json.Unmarshal(output, &records)) && assert.Len(t, records, 2) {
+			assert.Equal(t, types.MigrationDown, records[1].Direction)
+		}
+	}
+}
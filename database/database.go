@@ -19,6 +19,7 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package database
 
 import (
+	"errors"
 	"time"
 
 	"chronokeep/remote/types"
@@ -26,17 +27,36 @@ import (
 )
 
 const (
-	MaxOpenConnections    = 20
-	MaxIdleConnections    = 20
-	MaxConnectionLifetime = time.Minute * 5
-	CurrentVersion        = 2
-	MaxLoginAttempts      = 4
+	MaxOpenConnections           = 20
+	MaxIdleConnections           = 20
+	MaxConnectionLifetime        = time.Minute * 5
+	SQLiteBusyTimeout            = time.Second * 5
+	MigrationTimeout             = time.Minute * 10
+	MigrationLockName            = "chronokeep_remote_migration"
+	CurrentVersion               = 18
+	MaxLoginAttempts             = 4
+	MaxReadsPageSize             = 10000
+	MaxNotificationsPageSize     = 1000
+	MaxWebhookDeliveriesPageSize = 1000
 )
 
+// ErrOwnsOrganization is returned when purging an account that still owns an organization. The organization's
+// keys and reads are kept under its owner, so it has to be deleted first.
+var ErrOwnsOrganization = errors.New("account owns an organization")
+
+// ErrInvalidResetToken is returned when a password reset token can't be used, whether it's unknown, already
+// used, expired, or for an account that has since been deleted.
+var ErrInvalidResetToken = errors.New("password reset token not valid")
+
 type Database interface {
 	// Database Base Functions
 	Setup(config *util.Config) error
 	SetSetting(name, value string) error
+	GetSetting(name string) (string, error)
+	// Migration Functions
+	// Instances migrating the same database take turns. A dry run returns the plan without running it.
+	Migrate(target int, dryRun bool) (*types.MigrationPlan, error)
+	GetMigrationHistory() ([]types.MigrationRecord, error)
 	// Account Functions
 	GetAccount(email string) (*types.Account, error)
 	GetAccountByKey(key string) (*types.Account, error)
@@ -46,33 +66,107 @@ type Database interface {
 	DeleteAccount(id int64) error
 	ResurrectAccount(email string) error
 	GetDeletedAccount(email string) (*types.Account, error)
+	GetDeletedAccounts() ([]types.Account, error)
+	RestoreAccount(id int64) error
+	PurgeAccount(id int64, dryRun bool) (*types.PurgeCounts, error)
 	UpdateAccount(account types.Account) error
 	ChangePassword(email, newPassword string, logout ...bool) error
 	ChangeEmail(oldEmail, newEmail string) error
 	InvalidPassword(account types.Account) error
 	ValidPassword(account types.Account) error
 	UnlockAccount(account types.Account) error
-	UpdateTokens(account types.Account) error
+	// Login Failure Functions
+	// Failures from an address are forgotten, and the address unblocked, once the configured IP lockout has passed.
+	GetLoginFailures(ip string) (*types.LoginFailures, error)
+	AddLoginFailure(ip string) (*types.LoginFailures, error)
+	// Password Reset Functions
+	// Only the hash of a reset token is stored. Functions taking a token hash it before looking it up.
+	AddPasswordReset(reset types.PasswordReset) error
+	ResetPassword(token, newPassword string, now time.Time) (*types.Account, error)
+	// Reset requests are counted by key, an address or an account, apart from failed logins.
+	AddPasswordResetRequest(key string, window time.Duration, now time.Time) (int, error)
+	// Two-Factor Functions
+	// Only the hashes of recovery codes are stored. Functions taking a code hash it before looking it up.
+	GetTwoFactor(account int64) (*types.TwoFactor, error)
+	AddTwoFactor(twoFactor types.TwoFactor) error
+	EnableTwoFactor(account, step int64, recoveryCodes []string) error
+	SetRecoveryCodes(account int64, recoveryCodes []string) error
+	UseTwoFactorStep(account, step int64) (bool, error)
+	UseRecoveryCode(account int64, code string) (bool, error)
+	DeleteTwoFactor(account int64) error
 	// Read Functions
-	GetReads(account int64, reader_name string, from, to int64) ([]types.Read, error)
+	// Readers are looked up among the account's keys in the organization given, or outside of any when it's nil.
+	GetReads(account int64, org *int64, reader_name string, from, to int64) ([]types.Read, error)
+	GetReadsPage(account int64, org *int64, reader_name string, from, to int64, after *types.ReadCursor, limit int) ([]types.Read, error)
+	GetReadsAfter(account int64, org *int64, reader_name string, after int64, limit int) ([]types.Read, error)
 	AddReads(key string, reads []types.Read) ([]types.Read, error)
-	DeleteReaderReads(account int64, reader_name string, from, to int64) (int64, error)
+	DeleteReaderReads(account int64, org *int64, reader_name string, from, to int64) (int64, error)
 	DeleteKeyReads(key string) (int64, error)
-	DeleteReaderReadsBefore(account int64, reader_name string, to int64) (int64, error)
-	DeleteReaderReadsBetween(account int64, reader_name string) (int64, error)
+	DeleteReaderReadsBefore(account int64, org *int64, reader_name string, to int64) (int64, error)
+	DeleteReaderReadsBetween(account int64, org *int64, reader_name string) (int64, error)
+	DeleteAccountTypeReadsBefore(account_type string, before int64) (int64, error)
 	// Key Functions
+	// Only the hash of a key value is stored. Functions taking a key value hash it before looking it up.
 	GetAccountKeys(email string) ([]types.Key, error)
 	GetAccountKeysByKey(key string) ([]types.Key, error)
+	GetMemberKeys(account int64) ([]types.Key, error)
+	GetOrganizationKeys(org int64) ([]types.Key, error)
 	GetKey(key string) (*types.Key, error)
 	AddKey(key types.Key) (*types.Key, error)
 	DeleteKey(key types.Key) error
 	UpdateKey(key types.Key) error
+	RotateKey(key, newValue string, graceUntil *time.Time) (*types.Key, error)
+	GetKeysExpiredBetween(from, to time.Time) ([]types.Key, error)
+	GetDeletedKeys(account int64) ([]types.Key, error)
+	RestoreKey(account int64, name string) error
+	// Organization Functions
+	// An organization's keys are kept under the account that created it, which is always one of its owners.
+	AddOrganization(org types.Organization) (*types.Organization, error)
+	GetOrganization(id int64) (*types.Organization, error)
+	UpdateOrganization(org types.Organization) error
+	DeleteOrganization(id int64) error
+	GetMembership(org, account int64) (*types.Membership, error)
+	GetAccountMemberships(account int64) ([]types.Membership, error)
+	GetOrganizationMembers(org int64) ([]types.OrganizationMember, error)
+	SetOrganizationMember(member types.OrganizationMember) error
+	RemoveOrganizationMember(org, account int64) error
+	// Role Functions
+	// An account's type is the name of its role.
+	GetRoles() ([]types.Role, error)
+	GetRole(name string) (*types.Role, error)
+	SaveRole(role types.Role) error
+	DeleteRole(name string) error
 	// Multi-get Functions
 	GetKeyAndAccount(key string) (*types.MultiKey, error)
+	// Session Functions
+	// Only the hash of a token is stored. Functions taking a token hash it before looking it up.
+	AddSession(session types.Session) (*types.Session, error)
+	GetSession(token string) (*types.Session, error)
+	GetSessionByRefreshToken(refreshToken string) (*types.Session, error)
+	GetAccountSessions(account int64) ([]types.Session, error)
+	UpdateSession(session types.Session) error
+	UseSession(session int64) error
+	DeleteSession(account, session int64) error
+	DeleteAccountSessions(account int64, keep ...int64) (int64, error)
 	// Notification settings
-	GetNotification(account int64, reader_name string) (*types.Notification, error)
-	SaveNotification(notificaiton *types.RequestNotification, key string) error
+	// Notifications are scoped to an organization the same way reads are.
+	GetNotification(account int64, org *int64, reader_name string) (*types.Notification, error)
+	// SaveNotification reports whether the notification was added, one the key already has for the same time is ignored.
+	SaveNotification(notificaiton *types.RequestNotification, key string) (bool, error)
+	GetNotificationHistory(account int64, org *int64, reader_name string, from, to int64, unacknowledged bool, after *types.NotificationCursor, limit int) ([]types.Notification, error)
+	AcknowledgeNotifications(account int64, org *int64, notifications []int64) (int64, error)
+	// Webhook Functions
+	AddWebhook(webhook types.Webhook) (*types.Webhook, error)
+	GetAccountWebhooks(account int64) ([]types.Webhook, error)
+	DeleteWebhook(account, webhook int64) error
+	AddWebhookDeliveries(deliveries []types.WebhookDelivery) error
+	ClaimWebhookDeliveries(now, until time.Time, limit int) ([]types.WebhookDelivery, error)
+	UpdateWebhookDelivery(delivery types.WebhookDelivery) error
+	GetWebhookDeliveries(account, webhook int64, limit int) ([]types.WebhookDelivery, error)
+	// Alert Rule Functions
+	AddAlertRule(rule types.AlertRule) (*types.AlertRule, error)
+	GetAccountAlertRules(account int64) ([]types.AlertRule, error)
+	DeleteAlertRule(account, rule int64) error
 	// Close the database
 	Close()
 }
-
@@ -0,0 +1,508 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type accountRow struct {
+	id          int64
+	name        string
+	email       string
+	password    string
+	accountType string
+	wrongPass   int
+	locked      bool
+	lockReason  string
+	lockedUntil int64
+	lockCount   int
+	deleted     bool
+	deletedAt   int64
+}
+
+// toAccount Returns the account the way it is read from the database.
+func (a *accountRow) toAccount() *types.Account {
+	out := &types.Account{
+		Identifier:        a.id,
+		Name:              a.name,
+		Email:             a.email,
+		Type:              a.accountType,
+		Password:          a.password,
+		Locked:            a.locked,
+		WrongPassAttempts: a.wrongPass,
+		LockReason:        a.lockReason,
+		LockCount:         a.lockCount,
+	}
+	out.SetLockedUntil(a.lockedUntil)
+	return out
+}
+
+// toDeletedAccount Returns the account the way deleted accounts are read from the database.
+func (a *accountRow) toDeletedAccount() *types.Account {
+	out := &types.Account{
+		Identifier: a.id,
+		Name:       a.name,
+		Email:      a.email,
+		Type:       a.accountType,
+	}
+	out.SetDeletedAt(a.deletedAt)
+	return out
+}
+
+// account Returns the first account, deleted or not, that matches.
+func (t *tables) account(match func(a *accountRow) bool) *accountRow {
+	for _, a := range t.accounts {
+		if match(a) {
+			return a
+		}
+	}
+	return nil
+}
+
+// activeAccount Returns the first account that hasn't been deleted and matches.
+func (t *tables) activeAccount(match func(a *accountRow) bool) *accountRow {
+	return t.account(func(a *accountRow) bool {
+		return !a.deleted && match(a)
+	})
+}
+
+// accountByID Returns an account by its identifier whether or not it has been deleted.
+func (t *tables) accountByID(id int64) *accountRow {
+	return t.account(func(a *accountRow) bool {
+		return a.id == id
+	})
+}
+
+// accountByEmail Returns an account by its email whether or not it has been deleted.
+func (t *tables) accountByEmail(email string) *accountRow {
+	return t.account(func(a *accountRow) bool {
+		return a.email == email
+	})
+}
+
+// GetAccount Gets an account based on the email address provided.
+func (m *Memory) GetAccount(email string) (*types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if a := t.activeAccount(func(a *accountRow) bool { return a.email == email }); a != nil {
+		return a.toAccount(), nil
+	}
+	return nil, nil
+}
+
+// GetAccountByKey Gets an account based upon an API key provided.
+func (m *Memory) GetAccountByKey(key string) (*types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	hash := types.HashKey(key)
+	for _, k := range t.keys {
+		if k.deleted || k.value != hash {
+			continue
+		}
+		if a := t.activeAccount(func(a *accountRow) bool { return a.id == k.accountID }); a != nil {
+			return a.toAccount(), nil
+		}
+	}
+	return nil, nil
+}
+
+// GetAccoutByID Gets an account based upon the Account ID.
+func (m *Memory) GetAccountByID(id int64) (*types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if a := t.activeAccount(func(a *accountRow) bool { return a.id == id }); a != nil {
+		return a.toAccount(), nil
+	}
+	return nil, nil
+}
+
+// GetAccounts Get all accounts that have not been deleted.
+func (m *Memory) GetAccounts() ([]types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outAccounts []types.Account
+	for _, a := range t.accounts {
+		if !a.deleted {
+			outAccounts = append(outAccounts, *a.toAccount())
+		}
+	}
+	return outAccounts, nil
+}
+
+// AddAccount Adds an account to the database.
+func (m *Memory) AddAccount(account types.Account) (*types.Account, error) {
+	// Check if password has been hashed.
+	if !account.PasswordIsHashed() {
+		return nil, errors.New("password not hashed")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if t.accountByEmail(account.Email) != nil {
+		return nil, fmt.Errorf("unable to add account: email %v already in use", account.Email)
+	}
+	id := t.nextID("account")
+	t.accounts = append(t.accounts, &accountRow{
+		id:          id,
+		name:        account.Name,
+		email:       account.Email,
+		password:    account.Password,
+		accountType: account.Type,
+	})
+	return &types.Account{
+		Identifier: id,
+		Name:       account.Name,
+		Email:      account.Email,
+		Type:       account.Type,
+	}, nil
+}
+
+// DeleteAccount Deletes an account from view, does not permanently delete from database.
+// This does not delete events associated with this account, but does set keys to deleted and ends its sessions.
+func (m *Memory) DeleteAccount(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.accountByID(id)
+	if a == nil {
+		return fmt.Errorf("error deleting account, rows affected: %v", 0)
+	}
+	deletedAt := time.Now().UnixMilli()
+	a.deleted = true
+	a.deletedAt = deletedAt
+	for _, k := range t.keys {
+		if !k.deleted && k.accountID == id {
+			k.deleted = true
+			k.deletedAt = deletedAt
+		}
+	}
+	t.deleteAccountSessions(id)
+	return nil
+}
+
+// ResurrectAccount Brings an account out of the deleted state.
+func (m *Memory) ResurrectAccount(email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.accountByEmail(email)
+	if a == nil {
+		return fmt.Errorf("error resurrecting account, rows affected: %v", 0)
+	}
+	a.deleted = false
+	a.deletedAt = 0
+	return nil
+}
+
+// GetDeletedAccount Returns a deleted account.
+func (m *Memory) GetDeletedAccount(email string) (*types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if a := t.accountByEmail(email); a != nil && a.deleted {
+		return a.toDeletedAccount(), nil
+	}
+	return nil, nil
+}
+
+// UpdateAccount Updates account information in the database.
+func (m *Memory) UpdateAccount(account types.Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.activeAccount(func(a *accountRow) bool { return a.email == account.Email })
+	if a == nil {
+		return fmt.Errorf("error updating account, rows affected: %v", 0)
+	}
+	a.name = account.Name
+	a.accountType = account.Type
+	return nil
+}
+
+// ChangePassword Updates a user's password. It can also force a logout of the user. Only checks first value in the logout array if values are specified.
+func (m *Memory) ChangePassword(email, newPassword string, logout ...bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.accountByEmail(email)
+	if a == nil {
+		return fmt.Errorf("error changing password, rows affected: %v", 0)
+	}
+	a.password = newPassword
+	// Changing someone else's password logs them out everywhere.
+	if len(logout) > 0 && logout[0] {
+		t.deleteAccountSessions(a.id)
+	}
+	return nil
+}
+
+// ChangeEmail Updates an account email. Also forces a logout of the impacted account.
+func (m *Memory) ChangeEmail(oldEmail, newEmail string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.accountByEmail(oldEmail)
+	if a == nil {
+		return fmt.Errorf("error changing email, rows affected: %v", 0)
+	}
+	if other := t.accountByEmail(newEmail); other != nil && other != a {
+		return fmt.Errorf("error updating account email: email %v already in use", newEmail)
+	}
+	a.email = newEmail
+	t.deleteAccountSessions(a.id)
+	return nil
+}
+
+// InvalidPassword Increments/locks an account due to an invalid password. Under the backoff lockout policy
+// the lock expires on its own, lasting twice as long each time the account is locked before a valid password.
+func (m *Memory) InvalidPassword(account types.Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.activeAccount(func(a *accountRow) bool { return a.email == account.Email })
+	if a == nil {
+		return errors.New("account not found")
+	}
+	pAcc := a.toAccount()
+	locked := pAcc.Locked
+	var lockedUntil int64
+	if pAcc.LockedUntil != nil {
+		lockedUntil = pAcc.LockedUntil.Unix()
+	}
+	wrongPass := pAcc.WrongPassAttempts + 1
+	lockCount := pAcc.LockCount
+	if !locked && pAcc.WrongPassAttempts >= database.MaxLoginAttempts {
+		locked = true
+		lockCount++
+		if duration := m.config.LockoutDuration(pAcc.LockCount); duration > 0 {
+			lockedUntil = time.Now().Add(duration).Unix()
+			// Wrong passwords are counted again from zero once the lock expires.
+			wrongPass = 0
+		}
+	}
+	lockReason := ""
+	if locked {
+		lockReason = types.LockReasonWrongPassword
+	}
+	a.locked = locked
+	a.lockReason = lockReason
+	a.lockedUntil = lockedUntil
+	a.lockCount = lockCount
+	a.wrongPass = wrongPass
+	// Locking an account logs it out everywhere.
+	if locked {
+		t.deleteAccountSessions(a.id)
+	}
+	return nil
+}
+
+// ValidPassword Resets the incorrect password on an account.
+func (m *Memory) ValidPassword(account types.Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.activeAccount(func(a *accountRow) bool { return a.email == account.Email })
+	if a == nil {
+		return errors.New("account not found")
+	}
+	if a.toAccount().Locked {
+		return errors.New("account locked")
+	}
+	a.unlock()
+	return nil
+}
+
+// UnlockAccount Unlocks an account that's been locked.
+func (m *Memory) UnlockAccount(account types.Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	if !account.Locked {
+		return errors.New("account not locked")
+	}
+	a := t.accountByEmail(account.Email)
+	if a == nil {
+		return fmt.Errorf("error unlocking account, rows affected: %v", 0)
+	}
+	a.unlock()
+	return nil
+}
+
+// unlock Clears the lock and wrong password count of an account.
+func (a *accountRow) unlock() {
+	a.wrongPass = 0
+	a.locked = false
+	a.lockReason = ""
+	a.lockedUntil = 0
+	a.lockCount = 0
+}
+
+// GetDeletedAccounts Gets every account that has been deleted.
+func (m *Memory) GetDeletedAccounts() ([]types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outAccounts []types.Account
+	for _, a := range t.accounts {
+		if a.deleted {
+			outAccounts = append(outAccounts, *a.toDeletedAccount())
+		}
+	}
+	return outAccounts, nil
+}
+
+// RestoreAccount Brings a deleted account back along with the keys deleted with it, those having been given the
+// same deletion time. Keys that were deleted before the account stay deleted.
+func (m *Memory) RestoreAccount(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	a := t.accountByID(id)
+	if a == nil || !a.deleted {
+		return errors.New("error retrieving deleted account: account not found")
+	}
+	for _, k := range t.keys {
+		if k.deleted && k.accountID == id && k.deletedAt == a.deletedAt {
+			k.deleted = false
+			k.deletedAt = 0
+		}
+	}
+	a.deleted = false
+	a.deletedAt = 0
+	return nil
+}
+
+// PurgeAccount Permanently removes an account along with its keys, reads, notifications, sessions and everything
+// else attached to it. With dryRun set nothing is removed and the counts are of the rows that would have been.
+// Accounts that own an organization aren't purged, the organization has to be deleted first.
+func (m *Memory) PurgeAccount(id int64, dryRun bool) (*types.PurgeCounts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[int64]bool)
+	for _, k := range t.keys {
+		if k.accountID == id {
+			keys[k.id] = true
+		}
+	}
+	webhooks := make(map[int64]bool)
+	for _, w := range t.webhooks {
+		if w.accountID == id {
+			webhooks[w.id] = true
+		}
+	}
+	for _, o := range t.organizations {
+		if o.accountID == id {
+			return nil, database.ErrOwnsOrganization
+		}
+	}
+	counts := &types.PurgeCounts{}
+	// Everything is counted before anything is removed so nothing is removed if the account doesn't exist.
+	counts.Reads = countRows(t.reads, func(r *readRow) bool { return keys[r.keyID] })
+	counts.Notifications = countRows(t.notifications, func(n *notificationRow) bool { return keys[n.keyID] })
+	counts.WebhookDeliveries = countRows(t.deliveries, func(d *deliveryRow) bool { return webhooks[d.webhookID] })
+	counts.Webhooks = int64(len(webhooks))
+	counts.AlertRules = countRows(t.alertRules, func(r *alertRuleRow) bool { return r.accountID == id })
+	counts.PasswordResets = countRows(t.passwordResets, func(r *resetRow) bool { return r.accountID == id })
+	counts.RecoveryCodes = countRows(t.recoveryCodes, func(c *recoveryCodeRow) bool { return c.accountID == id })
+	counts.TwoFactor = countRows(t.twoFactors, func(f *twoFactorRow) bool { return f.accountID == id })
+	counts.Sessions = countRows(t.sessions, func(s *sessionRow) bool { return s.accountID == id })
+	counts.Memberships = countRows(t.members, func(o *memberRow) bool { return o.accountID == id })
+	counts.Keys = int64(len(keys))
+	counts.Accounts = countRows(t.accounts, func(a *accountRow) bool { return a.id == id })
+	if counts.Accounts != 1 {
+		return nil, fmt.Errorf("error purging account, rows affected: %v", counts.Accounts)
+	}
+	if dryRun {
+		return counts, nil
+	}
+	t.deleteReads(func(r *readRow) bool { return keys[r.keyID] })
+	t.notifications = deleteRows(t.notifications, func(n *notificationRow) bool { return keys[n.keyID] })
+	t.deliveries = deleteRows(t.deliveries, func(d *deliveryRow) bool { return webhooks[d.webhookID] })
+	t.webhooks = deleteRows(t.webhooks, func(w *webhookRow) bool { return webhooks[w.id] })
+	t.alertRules = deleteRows(t.alertRules, func(r *alertRuleRow) bool { return r.accountID == id })
+	t.passwordResets = deleteRows(t.passwordResets, func(r *resetRow) bool { return r.accountID == id })
+	t.recoveryCodes = deleteRows(t.recoveryCodes, func(c *recoveryCodeRow) bool { return c.accountID == id })
+	t.twoFactors = deleteRows(t.twoFactors, func(f *twoFactorRow) bool { return f.accountID == id })
+	t.deleteAccountSessions(id)
+	t.members = deleteRows(t.members, func(o *memberRow) bool { return o.accountID == id })
+	t.keys = deleteRows(t.keys, func(k *keyRow) bool { return keys[k.id] })
+	t.accounts = deleteRows(t.accounts, func(a *accountRow) bool { return a.id == id })
+	return counts, nil
+}
@@ -0,0 +1,1168 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/auth"
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"chronokeep/remote/util"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	accounts      []types.Account
+	testPassword1 string = "password"
+	testPassword2 string = "newpassword"
+)
+
+func setupAccountTests() {
+	if len(accounts) < 1 {
+		accounts = []types.Account{
+			{
+				Name:     "John Smith",
+				Email:    "j@test.com",
+				Type:     "admin",
+				Password: testHashPassword(testPassword1),
+			},
+			{
+				Name:     "Jerry Garcia",
+				Email:    "jgarcia@test.com",
+				Type:     "free",
+				Password: testHashPassword(testPassword1),
+			},
+			{
+				Name:     "Rose MacDonald",
+				Email:    "rose2004@test.com",
+				Type:     "paid",
+				Password: testHashPassword(testPassword1),
+			},
+			{
+				Name:     "Tia Johnson",
+				Email:    "tiatheway@test.com",
+				Type:     "free",
+				Password: testHashPassword(testPassword1),
+			},
+			{
+				Name:     "Thomas Donaldson",
+				Email:    "tdon@test.com",
+				Type:     "admin",
+				Password: testHashPassword(testPassword1),
+			},
+			{
+				Name:     "Ester White",
+				Email:    "white@test.com",
+				Type:     "test",
+				Password: testHashPassword(testPassword1),
+			},
+			{
+				Name:     "Ricky Reagan",
+				Email:    "rreagan@test.com",
+				Type:     "free",
+				Password: testHashPassword(testPassword1),
+			},
+		}
+	}
+}
+
+func TestAddAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	// Ensure adding accounts works properly.
+	t.Log("Adding accounts")
+	setupAccountTests()
+	oAccount := accounts[0]
+	nAccount, err := db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	if !oAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", oAccount, *nAccount)
+	}
+	oAccount = accounts[1]
+	nAccount, err = db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	if !oAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", oAccount, *nAccount)
+	}
+	oAccount = accounts[2]
+	nAccount, err = db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	if !oAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", oAccount, *nAccount)
+	}
+	oAccount = accounts[3]
+	nAccount, err = db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	if !oAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", oAccount, *nAccount)
+	}
+	// Test for collisions.
+	_, err = db.AddAccount(accounts[2])
+	if err == nil {
+		t.Error("Expected error adding account with duplicate email.")
+	}
+}
+
+func TestGetAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	// Test getting known accounts.
+	oAccount := accounts[0]
+	nAccount, err := db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	dAccount, err := db.GetAccount(oAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if !dAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount, *dAccount)
+	}
+	if dAccount.Identifier != nAccount.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount.Identifier, dAccount.Identifier)
+	}
+	oAccount = accounts[1]
+	nAccount, err = db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	dAccount, err = db.GetAccount(oAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if !dAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount, *dAccount)
+	}
+	if dAccount.Identifier != nAccount.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount.Identifier, dAccount.Identifier)
+	}
+	oAccount = accounts[2]
+	nAccount, err = db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	dAccount, err = db.GetAccount(oAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if !dAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount, *dAccount)
+	}
+	if dAccount.Identifier != nAccount.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount.Identifier, dAccount.Identifier)
+	}
+	oAccount = accounts[3]
+	nAccount, err = db.AddAccount(oAccount)
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	t.Logf("New account ID: %v", nAccount.Identifier)
+	dAccount, err = db.GetAccount(oAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if !dAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount, *dAccount)
+	}
+	if dAccount.Identifier != nAccount.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount.Identifier, dAccount.Identifier)
+	}
+	// Test getting unknown accounts.
+	dAccount, err = db.GetAccount("random@test.com")
+	if err != nil {
+		t.Fatalf("Error finding account not in existence: %v", err)
+	}
+	if dAccount != nil {
+		t.Error("Expected not to find an account but one was found.")
+	}
+}
+
+func TestGetAccounts(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	retAccounts, err := db.GetAccounts()
+	if err != nil {
+		t.Fatalf("Error getting accounts: %v", err)
+	}
+	if len(retAccounts) != 0 {
+		t.Errorf("Expected number of accounts is %v but %v were found.", 0, len(retAccounts))
+	}
+	db.AddAccount(accounts[0])
+	db.AddAccount(accounts[1])
+	db.AddAccount(accounts[2])
+	retAccounts, err = db.GetAccounts()
+	if err != nil {
+		t.Fatalf("Error getting accounts: %v", err)
+	}
+	if len(retAccounts) != 3 {
+		t.Errorf("Expected number of accounts is %v but %v were found.", 3, len(retAccounts))
+	}
+	db.AddAccount(accounts[3])
+	db.AddAccount(accounts[4])
+	db.AddAccount(accounts[5])
+	db.AddAccount(accounts[6])
+	retAccounts, err = db.GetAccounts()
+	if err != nil {
+		t.Fatalf("Error getting accounts: %v", err)
+	}
+	if len(retAccounts) != 7 {
+		t.Errorf("Expected number of accounts is %v but %v were found.", 7, len(retAccounts))
+	}
+}
+
+func TestUpdateAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	// Ensure adding accounts works properly.
+	nAccount, err := db.AddAccount(accounts[0])
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	nAccount.Name = "New Name 1"
+	err = db.UpdateAccount(*nAccount)
+	if err != nil {
+		t.Fatalf("Error updating account: %v", err)
+	}
+	dAccount, _ := db.GetAccount(nAccount.Email)
+	if nAccount.Identifier != dAccount.Identifier {
+		t.Errorf("Account ID expected to be %v but found %v instead.", nAccount.Identifier, dAccount.Identifier)
+	}
+	if dAccount.Name != "New Name 1" {
+		t.Errorf("Account name expected to be %v but found %v instead.", "New Name 1", dAccount.Name)
+	}
+	nAccount, err = db.AddAccount(accounts[1])
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	nAccount.Type = "New Type 1"
+	err = db.UpdateAccount(*nAccount)
+	if err != nil {
+		t.Fatalf("Error updating account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount.Identifier != dAccount.Identifier {
+		t.Errorf("Account ID expected to be %v but found %v instead.", nAccount.Identifier, dAccount.Identifier)
+	}
+	if dAccount.Type != "New Type 1" {
+		t.Errorf("Account name expected to be %v but found %v instead.", "New Type 1", dAccount.Type)
+	}
+	nAccount, err = db.AddAccount(accounts[2])
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	nAccount.Name = "New Name 2"
+	err = db.UpdateAccount(*nAccount)
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error updating account: %v", err)
+	}
+	if nAccount.Identifier != dAccount.Identifier {
+		t.Errorf("Account ID expected to be %v but found %v instead.", nAccount.Identifier, dAccount.Identifier)
+	}
+	if dAccount.Name != "New Name 2" {
+		t.Errorf("Account name expected to be %v but found %v instead.", "New Name 2", dAccount.Name)
+	}
+	nAccount, err = db.AddAccount(accounts[3])
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	nAccount.Type = "New Type 2"
+	err = db.UpdateAccount(*nAccount)
+	if err != nil {
+		t.Fatalf("Error updating account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount.Identifier != dAccount.Identifier {
+		t.Errorf("Account ID expected to be %v but found %v instead.", nAccount.Identifier, dAccount.Identifier)
+	}
+	if dAccount.Type != "New Type 2" {
+		t.Errorf("Account name expected to be %v but found %v instead.", "New Type 2", dAccount.Type)
+	}
+	// Test for collisions.
+	_, err = db.AddAccount(accounts[2])
+	if err == nil {
+		t.Error("Expected error adding account with duplicate email.")
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	times := []time.Time{
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Now().Add(time.Hour * 20).Truncate(time.Second),
+	}
+	keys := []types.Key{
+		{
+			AccountIdentifier: nAccount.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "default",
+			Name:              "reader1",
+			ValidUntil:        &times[0],
+		},
+		{
+			AccountIdentifier: nAccount.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+			ValidUntil:        &times[1],
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	err = db.DeleteAccount(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting account: %v", err)
+	}
+	dAccount, _ := db.GetAccount(nAccount.Email)
+	if dAccount != nil {
+		t.Error("Unexpectedly found a deleted account.")
+	}
+	keys, _ = db.GetAccountKeys(nAccount.Email)
+	if len(keys) != 0 {
+		t.Errorf("expected to find %v keys after deleting account, found %v", 0, len(keys))
+	}
+	_, err = db.AddAccount(accounts[0])
+	if err == nil {
+		t.Error("No error found when trying to add a deleted account.")
+	}
+	nAccount, _ = db.AddAccount(accounts[1])
+	err = db.DeleteAccount(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if dAccount != nil {
+		t.Error("Unexpectedly found a deleted account.")
+	}
+	_, err = db.AddAccount(accounts[1])
+	if err == nil {
+		t.Error("No error found when trying to add a deleted account.")
+	}
+	nAccount, _ = db.AddAccount(accounts[2])
+	err = db.DeleteAccount(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if dAccount != nil {
+		t.Error("Unexpectedly found a deleted account.")
+	}
+	_, err = db.AddAccount(accounts[2])
+	if err == nil {
+		t.Error("No error found when trying to add a deleted account.")
+	}
+}
+
+func TestResurrectAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	db.DeleteAccount(nAccount.Identifier)
+	err = db.ResurrectAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error resurrecting account: %v", err)
+	}
+	dAccount, _ := db.GetAccount(nAccount.Email)
+	if dAccount == nil {
+		t.Error("Account was not resurrected.")
+	}
+	nAccount, _ = db.AddAccount(accounts[1])
+	db.DeleteAccount(nAccount.Identifier)
+	err = db.ResurrectAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error resurrecting account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if dAccount == nil {
+		t.Error("Account was not resurrected.")
+	}
+	nAccount, _ = db.AddAccount(accounts[4])
+	db.DeleteAccount(nAccount.Identifier)
+	err = db.ResurrectAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error resurrecting account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if dAccount == nil {
+		t.Error("Account was not resurrected.")
+	}
+}
+
+func TestGetDeletedAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	dAccount, err := db.GetDeletedAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting deleted account %v", err)
+	}
+	if dAccount != nil {
+		t.Errorf("Deleted account found: %v", nAccount.Email)
+	}
+	db.DeleteAccount(nAccount.Identifier)
+	dAccount, err = db.GetDeletedAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting deleted account %v", err)
+	}
+	if dAccount == nil {
+		t.Error("Deleted account not found.")
+	}
+	nAccount, _ = db.AddAccount(accounts[3])
+	db.DeleteAccount(nAccount.Identifier)
+	dAccount, err = db.GetDeletedAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting deleted account %v", err)
+	}
+	if dAccount == nil {
+		t.Error("Deleted account not found.")
+	}
+	nAccount, _ = db.AddAccount(accounts[5])
+	db.DeleteAccount(nAccount.Identifier)
+	dAccount, err = db.GetDeletedAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting deleted account %v", err)
+	}
+	if dAccount == nil {
+		t.Error("Deleted account not found.")
+	}
+	nAccount, _ = db.AddAccount(accounts[6])
+	db.DeleteAccount(nAccount.Identifier)
+	dAccount, err = db.GetDeletedAccount(nAccount.Email)
+	if err != nil {
+		t.Fatalf("Error getting deleted account %v", err)
+	}
+	if dAccount == nil {
+		t.Error("Deleted account not found.")
+	}
+}
+
+func TestRestoreAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	keys := []types.Key{
+		{
+			AccountIdentifier: nAccount.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: nAccount.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	// Keys deleted before the account stay deleted when it's restored.
+	err = db.DeleteKey(keys[0])
+	if err != nil {
+		t.Fatalf("Error deleting key: %v", err)
+	}
+	time.Sleep(time.Millisecond * 5)
+	err = db.DeleteAccount(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting account: %v", err)
+	}
+	deleted, err := db.GetDeletedAccounts()
+	if err != nil {
+		t.Fatalf("Error getting deleted accounts: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Email != nAccount.Email || deleted[0].DeletedAt == nil {
+		t.Errorf("Expected deleted account with its deletion time, found %+v.", deleted)
+	}
+	// A deleted account keeps its email, so no other account can take it before it's restored.
+	_, err = db.AddAccount(types.Account{
+		Name:     "Jane Smith",
+		Email:    nAccount.Email,
+		Type:     types.RoleFree,
+		Password: accounts[1].Password,
+	})
+	if err == nil {
+		t.Error("Expected error adding an account with a deleted account's email.")
+	}
+	oAccount, err := db.AddAccount(accounts[1])
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	err = db.ChangeEmail(oAccount.Email, nAccount.Email)
+	if err == nil {
+		t.Error("Expected error changing an email to a deleted account's email.")
+	}
+	err = db.RestoreAccount(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error restoring account: %v", err)
+	}
+	account, _ := db.GetAccount(nAccount.Email)
+	if account == nil || account.DeletedAt != nil {
+		t.Errorf("Expected account to be restored, found %+v.", account)
+	}
+	restoredKeys, _ := db.GetAccountKeys(nAccount.Email)
+	if len(restoredKeys) != 1 || restoredKeys[0].Name != "reader2" {
+		t.Errorf("Expected the key deleted with the account to be restored, found %+v.", restoredKeys)
+	}
+	deletedKeys, _ := db.GetDeletedKeys(nAccount.Identifier)
+	if len(deletedKeys) != 1 || deletedKeys[0].Name != "reader1" || deletedKeys[0].DeletedAt == nil {
+		t.Errorf("Expected the key deleted before the account to stay deleted, found %+v.", deletedKeys)
+	}
+	deleted, _ = db.GetDeletedAccounts()
+	if len(deleted) != 0 {
+		t.Errorf("Expected no deleted accounts, found %+v.", deleted)
+	}
+	err = db.RestoreAccount(nAccount.Identifier)
+	if err == nil {
+		t.Error("Expected error restoring an account that isn't deleted.")
+	}
+}
+
+func TestPurgeAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-K2389A-33123B",
+			Type:              "write",
+			Name:              "reader3",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	db.AddKey(keys[2])
+	db.DeleteKey(keys[1])
+	now := time.Now().Unix()
+	reads := []types.Read{
+		{
+			Identifier:   "1",
+			Seconds:      now,
+			Milliseconds: 100,
+			IdentType:    "chip",
+			Type:         "reader",
+			Antenna:      1,
+			Reader:       "test",
+			RSSI:         "-50",
+		},
+		{
+			Identifier:   "2",
+			Seconds:      now + 10,
+			Milliseconds: 200,
+			IdentType:    "chip",
+			Type:         "reader",
+			Antenna:      1,
+			Reader:       "test",
+			RSSI:         "-50",
+		},
+	}
+	db.AddReads(keys[0].Value, reads)
+	db.AddReads(keys[2].Value, reads[0:1])
+	db.SaveNotification(&types.RequestNotification{
+		Type: "UPS_CONNECTED",
+		When: time.Now().UTC().Format(time.RFC3339),
+	}, keys[0].Value)
+	db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token1", RefreshToken: "refresh1"})
+	db.AddSession(types.Session{AccountIdentifier: account2.Identifier, Token: "token2", RefreshToken: "refresh2"})
+	webhook, _ := db.AddWebhook(types.Webhook{
+		AccountIdentifier: account1.Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret1",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	db.AddWebhookDeliveries([]types.WebhookDelivery{
+		{
+			WebhookIdentifier: webhook.Identifier,
+			Event:             types.WebhookReadsAdded,
+			Payload:           "{}",
+			NextAttempt:       time.Now(),
+		},
+	})
+	org, _ := db.AddOrganization(types.Organization{
+		AccountIdentifier: account2.Identifier,
+		Name:              "Timing Company",
+	})
+	db.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      account1.Identifier,
+		Role:                   types.OrgRoleViewer,
+	})
+	_, err = db.PurgeAccount(account2.Identifier, true)
+	if !errors.Is(err, database.ErrOwnsOrganization) {
+		t.Errorf("Expected error purging an account that owns an organization, found %v.", err)
+	}
+	_, err = db.PurgeAccount(account2.Identifier, false)
+	if !errors.Is(err, database.ErrOwnsOrganization) {
+		t.Errorf("Expected error purging an account that owns an organization, found %v.", err)
+	}
+	account, _ := db.GetAccount(account2.Email)
+	if account == nil {
+		t.Fatal("Expected an account that owns an organization to remain.")
+	}
+	expected := types.PurgeCounts{
+		Accounts:          1,
+		Keys:              2,
+		Reads:             2,
+		Notifications:     1,
+		Sessions:          1,
+		Webhooks:          1,
+		WebhookDeliveries: 1,
+		Memberships:       1,
+	}
+	counts, err := db.PurgeAccount(account1.Identifier, true)
+	if err != nil {
+		t.Fatalf("Error on purge account dry run: %v", err)
+	}
+	if *counts != expected {
+		t.Errorf("Expected dry run counts %+v, found %+v.", expected, *counts)
+	}
+	account, _ = db.GetAccount(account1.Email)
+	if account == nil {
+		t.Fatal("Expected account to remain after a dry run.")
+	}
+	found, _ := db.GetReads(account1.Identifier, nil, keys[0].Name, now, now+100)
+	if len(found) != 2 {
+		t.Errorf("Expected %v reads to remain after a dry run, found %v.", 2, len(found))
+	}
+	counts, err = db.PurgeAccount(account1.Identifier, false)
+	if err != nil {
+		t.Fatalf("Error purging account: %v", err)
+	}
+	if *counts != expected {
+		t.Errorf("Expected purge counts %+v, found %+v.", expected, *counts)
+	}
+	account, _ = db.GetAccount(account1.Email)
+	if account != nil {
+		t.Errorf("Expected account to be purged, found %+v.", *account)
+	}
+	account, _ = db.GetDeletedAccount(account1.Email)
+	if account != nil {
+		t.Errorf("Expected no deleted account after purge, found %+v.", *account)
+	}
+	key, _ := db.GetKey(keys[0].Value)
+	if key != nil {
+		t.Errorf("Expected key to be purged, found %+v.", *key)
+	}
+	found, _ = db.GetReads(account1.Identifier, nil, keys[0].Name, now, now+100)
+	if len(found) != 0 {
+		t.Errorf("Expected reads to be purged, found %v.", len(found))
+	}
+	found, _ = db.GetReads(account2.Identifier, nil, keys[2].Name, now, now+100)
+	if len(found) != 1 {
+		t.Errorf("Expected %v reads for another account to remain, found %v.", 1, len(found))
+	}
+	sessions, _ := db.GetAccountSessions(account2.Identifier)
+	if len(sessions) != 1 {
+		t.Errorf("Expected %v sessions for another account to remain, found %v.", 1, len(sessions))
+	}
+	members, _ := db.GetOrganizationMembers(org.Identifier)
+	if len(members) != 1 || members[0].AccountIdentifier != account2.Identifier {
+		t.Errorf("Expected only the owner to remain in the organization, found %+v.", members)
+	}
+	_, err = db.PurgeAccount(account1.Identifier, true)
+	if err == nil {
+		t.Error("Expected error purging an account that doesn't exist.")
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	hashPass, _ := auth.HashPassword(testPassword2)
+	err = db.ChangePassword(nAccount.Email, hashPass)
+	if err != nil {
+		t.Fatalf("error changing password: %v", err)
+	}
+	nAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount == nil {
+		t.Fatal("get account failure")
+	}
+	err = auth.VerifyPassword(nAccount.Password, testPassword2)
+	if err != nil {
+		t.Errorf("password doesn't match: %v", err)
+	}
+	_, _ = db.AddSession(types.Session{AccountIdentifier: nAccount.Identifier, Token: "testToken1", RefreshToken: "testToken2"})
+	sessions, _ := db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 1 {
+		t.Error("Expected a session to be set.")
+	}
+	err = db.ChangePassword(nAccount.Email, hashPass, true)
+	if err != nil {
+		t.Fatalf("error changing password: %v", err)
+	}
+	sessions, _ = db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 0 {
+		t.Errorf("Expected sessions to be removed. Found %v.", sessions)
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	newEmail := "new_email2020@test.com"
+	_, _ = db.AddSession(types.Session{AccountIdentifier: nAccount.Identifier, Token: "testToken1", RefreshToken: "testToken2"})
+	sessions, _ := db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 1 {
+		t.Error("Expected a session to be set.")
+	}
+	err = db.ChangeEmail(nAccount.Email, newEmail)
+	if err != nil {
+		t.Fatalf("error changing email: %v", err)
+	}
+	nAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount != nil {
+		t.Errorf("account retrieved when the email should have changed: %v", nAccount)
+	}
+	nAccount, _ = db.GetAccount(newEmail)
+	if nAccount == nil {
+		t.Error("account with new email not found")
+	} else if sessions, _ = db.GetAccountSessions(nAccount.Identifier); len(sessions) != 0 {
+		t.Errorf("Expected sessions to be removed. Found %v.", sessions)
+	}
+}
+
+func TestInvalidPassword(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	_, _ = db.AddSession(types.Session{AccountIdentifier: nAccount.Identifier, Token: "testToken1", RefreshToken: "testToken2"})
+	sessions, _ := db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 1 {
+		t.Error("Expected a session to be set.")
+	}
+	var dAccount *types.Account
+	for i := 1; i <= database.MaxLoginAttempts+3; i++ {
+		err = db.InvalidPassword(*nAccount)
+		if err != nil {
+			t.Fatalf("(%v) error telling the database about an invalid password: %v", i, err)
+		}
+		dAccount, _ = db.GetAccount(nAccount.Email)
+		if dAccount.WrongPassAttempts > database.MaxLoginAttempts && dAccount.Locked == false {
+			t.Errorf("account is not locked after (%v) invalid password attempts; should be after (%v)", i, database.MaxLoginAttempts+1)
+			if sessions, _ = db.GetAccountSessions(dAccount.Identifier); len(sessions) != 0 {
+				t.Errorf("Expected sessions to be removed. Found %v.", sessions)
+			}
+		} else if dAccount.WrongPassAttempts <= database.MaxLoginAttempts && dAccount.Locked == true {
+			t.Errorf("account is locked after (%v) invalid password attempts; should be (%v)", i, database.MaxLoginAttempts+1)
+			if sessions, _ = db.GetAccountSessions(dAccount.Identifier); len(sessions) != 1 {
+				t.Error("Expected a session to be set.")
+			}
+		}
+		if dAccount.WrongPassAttempts != i {
+			t.Errorf("wrong password attempts set to %v, should be %v", dAccount.WrongPassAttempts, i)
+		}
+	}
+	if sessions, _ = db.GetAccountSessions(nAccount.Identifier); len(sessions) != 0 {
+		t.Errorf("Expected sessions to be removed once the account was locked. Found %v.", sessions)
+	}
+	// Without the backoff policy the lock lasts until the account is unlocked.
+	if dAccount.LockReason != types.LockReasonWrongPassword || dAccount.LockedUntil != nil {
+		t.Errorf("Expected a lock without an expiry, found %+v.", dAccount)
+	}
+}
+
+func TestInvalidPasswordBackoff(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	db.config.LockoutPolicy = util.LockoutBackoff
+	db.config.LockoutMinutes = 5
+	db.config.LockoutMaxMinutes = 15
+	defer func() {
+		db.config.LockoutPolicy = ""
+		db.config.LockoutMinutes = 0
+		db.config.LockoutMaxMinutes = 0
+	}()
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	// Each lock lasts twice as long as the one before it, up to the maximum.
+	for lock, minutes := range []int{5, 10, 15, 15} {
+		for i := 1; i <= database.MaxLoginAttempts+1; i++ {
+			err = db.InvalidPassword(*nAccount)
+			if err != nil {
+				t.Fatalf("(%v) error telling the database about an invalid password: %v", i, err)
+			}
+		}
+		dAccount, _ := db.GetAccount(nAccount.Email)
+		if !dAccount.Locked || dAccount.LockReason != types.LockReasonWrongPassword || dAccount.LockedUntil == nil {
+			t.Fatalf("(%v) Expected account to be locked until a set time, found %+v.", lock, dAccount)
+		}
+		expected := time.Now().Add(time.Duration(minutes) * time.Minute)
+		if dAccount.LockedUntil.Before(expected.Add(-time.Minute)) || dAccount.LockedUntil.After(expected) {
+			t.Errorf("(%v) Expected account to be locked for %v minutes, locked until %v.", lock, minutes, dAccount.LockedUntil)
+		}
+		if dAccount.WrongPassAttempts != 0 || dAccount.LockCount != lock+1 {
+			t.Errorf("(%v) Expected wrong passwords to start over and the lock to be counted, found %+v.", lock, dAccount)
+		}
+		err = db.ValidPassword(*dAccount)
+		if err == nil {
+			t.Errorf("(%v) Expected an error on valid password attempt for locked account.", lock)
+		}
+		// Expire the lock.
+		db.tables.accountByID(nAccount.Identifier).lockedUntil = time.Now().Add(-time.Second).Unix()
+		dAccount, _ = db.GetAccount(nAccount.Email)
+		if dAccount.Locked || dAccount.LockReason != "" || dAccount.LockedUntil != nil {
+			t.Errorf("(%v) Expected account to be unlocked once the lock expired, found %+v.", lock, dAccount)
+		}
+	}
+	err = db.ValidPassword(*nAccount)
+	if err != nil {
+		t.Fatalf("Valid password threw an error: %v", err)
+	}
+	dAccount, _ := db.GetAccount(nAccount.Email)
+	if dAccount.Locked || dAccount.LockCount != 0 {
+		t.Errorf("Expected a valid password to reset the lock count, found %+v.", dAccount)
+	}
+	// Unexpired locks can still be lifted by an admin.
+	for i := 1; i <= database.MaxLoginAttempts+1; i++ {
+		db.InvalidPassword(*nAccount)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	err = db.UnlockAccount(*dAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error on unlock account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if dAccount.Locked || dAccount.LockedUntil != nil || dAccount.LockCount != 0 {
+		t.Errorf("Expected account to be unlocked, found %+v.", dAccount)
+	}
+}
+
+func TestGetAccountByKey(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	// Test getting known accounts.
+	nAccount1, _ := db.AddAccount(accounts[0])
+	nAccount2, _ := db.AddAccount(accounts[1])
+	times := []time.Time{
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Now().Add(time.Hour * 20).Truncate(time.Second),
+	}
+	keys := []types.Key{
+		{
+			AccountIdentifier: nAccount1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "default",
+			Name:              "reader1",
+			ValidUntil:        &times[0],
+		},
+		{
+			AccountIdentifier: nAccount2.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+			ValidUntil:        &times[1],
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	dAccount, err := db.GetAccountByKey(keys[0].Value)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if dAccount == nil {
+		t.Fatalf("Account not found. (1)")
+	}
+	if !dAccount.Equals(nAccount1) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount1, *dAccount)
+	}
+	if dAccount.Identifier != nAccount1.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount1.Identifier, dAccount.Identifier)
+	}
+	dAccount, err = db.GetAccountByKey(keys[1].Value)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if dAccount == nil {
+		t.Fatalf("Account not found. (2)")
+	}
+	if !dAccount.Equals(nAccount2) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount2, *dAccount)
+	}
+	if dAccount.Identifier != nAccount2.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount2.Identifier, dAccount.Identifier)
+	}
+}
+
+func TestGetAccountByID(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	// Test getting known accounts.
+	oAccount := accounts[0]
+	nAccount, _ := db.AddAccount(oAccount)
+	dAccount, err := db.GetAccountByID(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if !dAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount, *dAccount)
+	}
+	if dAccount.Identifier != nAccount.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount.Identifier, dAccount.Identifier)
+	}
+	oAccount = accounts[1]
+	nAccount, _ = db.AddAccount(oAccount)
+	dAccount, err = db.GetAccountByID(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting account: %v", err)
+	}
+	if !dAccount.Equals(nAccount) {
+		t.Errorf("Account expected to be equal. %+v was expected, found %+v", *nAccount, *dAccount)
+	}
+	if dAccount.Identifier != nAccount.Identifier {
+		t.Errorf("Account id expected to be %v but found %v.", nAccount.Identifier, dAccount.Identifier)
+	}
+}
+
+func TestValidPassword(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	for i := 1; i <= database.MaxLoginAttempts-2; i++ {
+		err = db.InvalidPassword(*nAccount)
+		if err != nil {
+			t.Fatalf("(%v) error telling the database about an invalid password: %v", i, err)
+		}
+	}
+	nAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount.WrongPassAttempts < 1 {
+		t.Errorf("Expected more than 1 wrong pass attempts; found %v.", nAccount.WrongPassAttempts)
+	}
+	err = db.ValidPassword(*nAccount)
+	if err != nil {
+		t.Fatalf("Valid password threw an error: %v", err)
+	}
+	nAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount.WrongPassAttempts != 0 {
+		t.Errorf("Expected zero wrong pass attempts; found %v.", nAccount.WrongPassAttempts)
+	}
+	// Test to make sure we don't unlock if locked.
+	for i := 1; i <= database.MaxLoginAttempts+3; i++ {
+		err = db.InvalidPassword(*nAccount)
+		if err != nil {
+			t.Fatalf("(%v) error telling the database about an invalid password: %v", i, err)
+		}
+	}
+	err = db.ValidPassword(*nAccount)
+	if err == nil {
+		t.Fatal("Expected an error on valid password attempt for locked account.")
+	}
+	nAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount.WrongPassAttempts == 0 {
+		t.Errorf("Expected wrong password attempts; found %v.", nAccount.WrongPassAttempts)
+	}
+}
+
+func TestUnlockAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	// Should throw error if account isn't locked
+	err = db.UnlockAccount(*nAccount)
+	if err == nil {
+		t.Fatal("no error thrown on unlock of unlocked account")
+	}
+	for i := 1; i <= database.MaxLoginAttempts+3; i++ {
+		err = db.InvalidPassword(*nAccount)
+		if err != nil {
+			t.Fatalf("(%v) error telling the database about an invalid password: %v", i, err)
+		}
+	}
+	nAccount, _ = db.GetAccount(nAccount.Email)
+	err = db.UnlockAccount(*nAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error on unlock account: %v", err)
+	}
+	nAccount, _ = db.GetAccount(nAccount.Email)
+	if nAccount.WrongPassAttempts != 0 {
+		t.Errorf("Expected wrong pass attempts to be reset to 0; found %v.", nAccount.WrongPassAttempts)
+	}
+}
+
+func TestNoDatabaseAccount(t *testing.T) {
+	// test whether or not we've connected to a database
+	db := Memory{}
+	_, err := db.GetAccount("")
+	if err == nil {
+		t.Fatalf("Expected error getting account by email.")
+	}
+	_, err = db.GetAccountByKey("")
+	if err == nil {
+		t.Fatalf("Expected error getting account by key.")
+	}
+	_, err = db.GetAccountByID(0)
+	if err == nil {
+		t.Fatalf("Expected error getting account by id.")
+	}
+	_, err = db.GetAccounts()
+	if err == nil {
+		t.Fatalf("Expected error getting accounts.")
+	}
+	_, err = db.AddAccount(types.Account{})
+	if err == nil {
+		t.Fatalf("Expected error adding empty account.")
+	}
+	err = db.DeleteAccount(0)
+	if err == nil {
+		t.Fatalf("Expected error deleting account.")
+	}
+	err = db.ResurrectAccount("")
+	if err == nil {
+		t.Fatalf("Expected error resurrecting account.")
+	}
+	_, err = db.GetDeletedAccount("")
+	if err == nil {
+		t.Fatalf("Expected error getting deleted account.")
+	}
+	_, err = db.GetDeletedAccounts()
+	if err == nil {
+		t.Fatalf("Expected error getting deleted accounts.")
+	}
+	err = db.RestoreAccount(0)
+	if err == nil {
+		t.Fatalf("Expected error restoring account.")
+	}
+	_, err = db.PurgeAccount(0, false)
+	if err == nil {
+		t.Fatalf("Expected error purging account.")
+	}
+	err = db.UpdateAccount(types.Account{})
+	if err == nil {
+		t.Fatalf("Expected error updating account.")
+	}
+	err = db.ChangePassword("", "", true)
+	if err == nil {
+		t.Fatalf("Expected error changing password.")
+	}
+	err = db.ChangeEmail("", "")
+	if err == nil {
+		t.Fatalf("Expected error changing email.")
+	}
+	err = db.InvalidPassword(types.Account{})
+	if err == nil {
+		t.Fatalf("Expected error setting invalid password.")
+	}
+	err = db.ValidPassword(types.Account{})
+	if err == nil {
+		t.Fatalf("Expected error setting valid password.")
+	}
+	err = db.UnlockAccount(types.Account{})
+	if err == nil {
+		t.Fatalf("Expected error unlocking account.")
+	}
+}
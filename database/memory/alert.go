@@ -0,0 +1,101 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type alertRuleRow struct {
+	id         int64
+	accountID  int64
+	types      string
+	readers    string
+	recipients string
+	createdAt  time.Time
+}
+
+// AddAlertRule Adds an alert rule to an account.
+func (m *Memory) AddAlertRule(rule types.AlertRule) (*types.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if t.accountByID(rule.AccountIdentifier) == nil {
+		return nil, errors.New("unable to add alert rule: account not found")
+	}
+	rule.Identifier = t.nextID("alert_rule")
+	rule.CreatedAt = currentTime()
+	t.alertRules = append(t.alertRules, &alertRuleRow{
+		id:         rule.Identifier,
+		accountID:  rule.AccountIdentifier,
+		types:      rule.TypesValue(),
+		readers:    rule.ReadersValue(),
+		recipients: rule.RecipientsValue(),
+		createdAt:  rule.CreatedAt,
+	})
+	return &rule, nil
+}
+
+// GetAccountAlertRules Gets all alert rules for an account.
+func (m *Memory) GetAccountAlertRules(account int64) ([]types.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outRules []types.AlertRule
+	for _, r := range t.alertRules {
+		if r.accountID != account {
+			continue
+		}
+		rule := types.AlertRule{
+			Identifier:        r.id,
+			AccountIdentifier: r.accountID,
+			CreatedAt:         r.createdAt,
+		}
+		rule.SetValues(r.types, r.readers, r.recipients)
+		outRules = append(outRules, rule)
+	}
+	return outRules, nil
+}
+
+// DeleteAlertRule Deletes an alert rule belonging to an account.
+func (m *Memory) DeleteAlertRule(account, rule int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	match := func(r *alertRuleRow) bool {
+		return r.accountID == account && r.id == rule
+	}
+	if rows := countRows(t.alertRules, match); rows != 1 {
+		return fmt.Errorf("error deleting alert rule, rows affected: %v", rows)
+	}
+	t.alertRules = deleteRows(t.alertRules, match)
+	return nil
+}
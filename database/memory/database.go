@@ -0,0 +1,233 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/auth"
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"chronokeep/remote/util"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	log "github.com/sirupsen/logrus"
+)
+
+// Memory is a database kept entirely in memory, for embedding the server where there's no database to
+// connect to and for fast tests. It keeps the same rules as the other databases: deleted accounts and keys
+// are only marked as deleted, unique values are enforced, and rows can't point at rows that don't exist.
+// Everything in it is lost when it is closed.
+type Memory struct {
+	mu       sync.Mutex
+	tables   *tables
+	config   *util.Config
+	validate *validator.Validate
+}
+
+// tables holds the rows of every table, each in the order it was added.
+type tables struct {
+	settings       map[string]string
+	accounts       []*accountRow
+	keys           []*keyRow
+	reads          []*readRow
+	readSet        map[readUnique]bool
+	notifications  []*notificationRow
+	sessions       []*sessionRow
+	webhooks       []*webhookRow
+	deliveries     []*deliveryRow
+	alertRules     []*alertRuleRow
+	passwordResets []*resetRow
+	resetRequests  map[string]*resetRequestRow
+	twoFactors     []*twoFactorRow
+	recoveryCodes  []*recoveryCodeRow
+	loginFailures  map[string]*loginFailureRow
+	organizations  []*organizationRow
+	members        []*memberRow
+	roles          map[string]string
+	migrations     []types.MigrationRecord
+	// Identifiers are never reused, like an auto incremented column.
+	lastID map[string]int64
+}
+
+// nextID Returns the next identifier for a table.
+func (t *tables) nextID(table string) int64 {
+	t.lastID[table]++
+	return t.lastID[table]
+}
+
+// getTables Used as a general way to get the tables, the lock must be held.
+func (m *Memory) getTables() (*tables, error) {
+	if m.tables == nil {
+		return nil, errors.New("database not setup")
+	}
+	return m.tables, nil
+}
+
+// Setup Creates the tables if they don't exist yet and adds the admin account if there are no accounts.
+func (m *Memory) Setup(config *util.Config) error {
+	if config == nil {
+		return fmt.Errorf("no valid config supplied")
+	}
+	// Set up Validator.
+	m.validate = validator.New()
+	log.Info("Setting up database.")
+	m.mu.Lock()
+	m.config = config
+	if m.tables == nil {
+		m.createTables()
+	}
+	m.mu.Unlock()
+
+	// Check if there's an account created.
+	accounts, err := m.GetAccounts()
+	if err != nil {
+		return fmt.Errorf("error checking for account: %v", err)
+	}
+	if len(accounts) < 1 {
+		if config.AdminName == "" || config.AdminEmail == "" || config.AdminPass == "" {
+			log.Warn("Admin account doesn't exist and proper credentials have not been supplied, one can be added with the account create command.")
+			return nil
+		}
+		log.Info("Creating admin user.")
+		acc := types.Account{
+			Name:     config.AdminName,
+			Email:    config.AdminEmail,
+			Password: config.AdminPass,
+			Type:     types.RoleAdmin,
+		}
+		err = m.validate.Struct(acc)
+		if err != nil {
+			return fmt.Errorf("error validating base admin account on setup: %v", err)
+		}
+		acc.Password, err = auth.HashPassword(config.AdminPass)
+		if err != nil {
+			return fmt.Errorf("error hashing admin account password on setup: %v", err)
+		}
+		_, err = m.AddAccount(acc)
+		if err != nil {
+			return fmt.Errorf("error adding admin account on setup: %v", err)
+		}
+	}
+	return nil
+}
+
+// createTables Starts every table empty other than the default roles, the lock must be held.
+func (m *Memory) createTables() {
+	log.Info("Creating database tables.")
+	m.tables = &tables{
+		settings:      make(map[string]string),
+		readSet:       make(map[readUnique]bool),
+		resetRequests: make(map[string]*resetRequestRow),
+		loginFailures: make(map[string]*loginFailureRow),
+		roles:         make(map[string]string),
+		lastID:        make(map[string]int64),
+	}
+	// Every database starts with the default roles.
+	for _, role := range types.DefaultRoles() {
+		m.tables.roles[role.Name] = role.PermissionsValue()
+	}
+	m.tables.settings["version"] = strconv.Itoa(database.CurrentVersion)
+	m.tables.migrations = append(m.tables.migrations, types.MigrationRecord{
+		Identifier: m.tables.nextID("schema_migration"),
+		Version:    database.CurrentVersion,
+		Name:       "create tables",
+		Direction:  types.MigrationUp,
+		AppliedAt:  currentTime(),
+	})
+}
+
+func (m *Memory) SetSetting(name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	t.settings[name] = value
+	return nil
+}
+
+// GetSetting Returns the value of a setting, or an empty string if it hasn't been set.
+func (m *Memory) GetSetting(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return "", err
+	}
+	return t.settings[name], nil
+}
+
+// Close Closes database, throwing away everything in it.
+func (m *Memory) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tables = nil
+}
+
+// currentTime Returns the current time the way times are stored, in UTC to the second.
+func currentTime() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// countRows Returns how many rows match.
+func countRows[T any](rows []*T, match func(row *T) bool) int64 {
+	var count int64
+	for _, row := range rows {
+		if match(row) {
+			count++
+		}
+	}
+	return count
+}
+
+// deleteRows Returns the rows that don't match.
+func deleteRows[T any](rows []*T, match func(row *T) bool) []*T {
+	out := rows[:0]
+	for _, row := range rows {
+		if !match(row) {
+			out = append(out, row)
+		}
+	}
+	// Clear out the rest so removed rows can be collected.
+	clear(rows[len(out):])
+	return out
+}
+
+// copyTime Returns a copy of a stored time so it can't be changed from outside the database.
+func copyTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	out := *value
+	return &out
+}
+
+// copyID Returns a copy of a stored identifier so it can't be changed from outside the database.
+func copyID(value *int64) *int64 {
+	if value == nil {
+		return nil
+	}
+	out := *value
+	return &out
+}
@@ -0,0 +1,181 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/auth"
+	"chronokeep/remote/auth/authtest"
+	"chronokeep/remote/database"
+	"chronokeep/remote/util"
+	"strconv"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestMain(m *testing.M) {
+	authtest.Main(m)
+}
+
+func testHashPassword(pass string) string {
+	hash, _ := auth.HashPassword(pass)
+	return hash
+}
+
+func setupTests(t *testing.T) (*Memory, func(t *testing.T), error) {
+	t.Log("Setting up testing database variables.")
+	o := Memory{
+		config:   getTestConfig(),
+		validate: validator.New(),
+	}
+	t.Log("Initializing database.")
+	o.createTables()
+	t.Log("Database initialized.")
+	return &o, func(t *testing.T) {
+		t.Log("Deleting old database.")
+		o.Close()
+		t.Log("Database successfully deleted.")
+	}, nil
+}
+
+func TestSetupAndGet(t *testing.T) {
+	t.Log("Setting up testing database variables.")
+	o := &Memory{}
+	config := getTestConfig()
+	t.Log("Initializing database.")
+	err := o.Setup(config)
+	defer o.Close()
+	if err != nil {
+		t.Fatalf("Error initializing database. %v", err)
+	}
+	t.Log("Database initialized.")
+	version, err := o.GetSetting("version")
+	if err != nil || version != strconv.Itoa(database.CurrentVersion) {
+		t.Errorf("Expected version %v, found '%v' (%v).", database.CurrentVersion, version, err)
+	}
+	accounts, err := o.GetAccounts()
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("Expected the admin account to be added, found %+v (%v).", accounts, err)
+	}
+	if accounts[0].Email != config.AdminEmail || accounts[0].Name != config.AdminName || accounts[0].Type != "admin" {
+		t.Errorf("Expected the admin account from the config, found %+v.", accounts[0])
+	}
+	roles, err := o.GetRoles()
+	if err != nil || len(roles) != 3 {
+		t.Errorf("Expected the default roles, found %+v (%v).", roles, err)
+	}
+	// Setting up again keeps what's already there.
+	err = o.SetSetting("test_setting", "kept")
+	if err != nil {
+		t.Fatalf("Error setting setting: %v", err)
+	}
+	err = o.Setup(config)
+	if err != nil {
+		t.Fatalf("Error setting up database again: %v", err)
+	}
+	value, _ := o.GetSetting("test_setting")
+	accounts, _ = o.GetAccounts()
+	if value != "kept" || len(accounts) != 1 {
+		t.Errorf("Expected setup to keep the database, found setting '%v' and accounts %+v.", value, accounts)
+	}
+	// Closing throws everything away.
+	o.Close()
+	_, err = o.GetAccounts()
+	if err == nil {
+		t.Error("Expected error getting accounts from a closed database.")
+	}
+}
+
+func TestSetupWithoutAdmin(t *testing.T) {
+	o := &Memory{}
+	config := getTestConfig()
+	config.AdminEmail = ""
+	config.AdminName = ""
+	config.AdminPass = ""
+	// Without credentials the database is still set up, the admin gets added with the account create command.
+	err := o.Setup(config)
+	defer o.Close()
+	if err != nil {
+		t.Fatalf("Error initializing database without admin credentials. %v", err)
+	}
+	accounts, err := o.GetAccounts()
+	if err != nil || len(accounts) != 0 {
+		t.Errorf("Expected no accounts to be added, found %+v (%v).", accounts, err)
+	}
+}
+
+func TestSetting(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	value, err := db.GetSetting("test_setting")
+	if err != nil {
+		t.Fatalf("Error getting setting: %v", err)
+	}
+	if value != "" {
+		t.Errorf("Expected setting that hasn't been set to be empty, found '%v'.", value)
+	}
+	for _, expected := range []string{"first", "second"} {
+		err = db.SetSetting("test_setting", expected)
+		if err != nil {
+			t.Fatalf("Error setting setting: %v", err)
+		}
+		value, err = db.GetSetting("test_setting")
+		if err != nil {
+			t.Fatalf("Error getting setting: %v", err)
+		}
+		if value != expected {
+			t.Errorf("Expected setting to be '%v', found '%v'.", expected, value)
+		}
+	}
+}
+
+func TestNoDatabase(t *testing.T) {
+	db := Memory{}
+	err := db.Setup(nil)
+	if err == nil {
+		t.Fatal("Expected error in Setup.")
+	}
+	// There's nothing to connect to, so only a missing config keeps it from being set up.
+	db = Memory{}
+	err = db.Setup(&util.Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error in Setup: %v", err)
+	}
+	db = Memory{}
+	err = db.SetSetting("", "")
+	if err == nil {
+		t.Fatal("Expected error setting setting.")
+	}
+	_, err = db.GetSetting("")
+	if err == nil {
+		t.Fatal("Expected error getting setting.")
+	}
+}
+
+func getTestConfig() *util.Config {
+	return &util.Config{
+		DBDriver:   "memory",
+		AdminEmail: "admin@test.com",
+		AdminName:  "tester number 1",
+		AdminPass:  "password",
+	}
+}
@@ -0,0 +1,357 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type keyRow struct {
+	id            int64
+	accountID     int64
+	orgID         *int64
+	name          string
+	prefix        string
+	value         string
+	keyType       string
+	allowedHosts  string
+	validUntil    *time.Time
+	oldValue      *string
+	oldValidUntil *time.Time
+	deleted       bool
+	deletedAt     int64
+}
+
+// toKey Returns the key the way it is read from the database.
+func (k *keyRow) toKey() types.Key {
+	out := types.Key{
+		AccountIdentifier: k.accountID,
+		Name:              k.name,
+		Prefix:            k.prefix,
+		Hash:              k.value,
+		Type:              k.keyType,
+		ValidUntil:        copyTime(k.validUntil),
+		Organization:      copyID(k.orgID),
+	}
+	out.SetAllowedHosts(k.allowedHosts)
+	return out
+}
+
+// matches Reports whether the hash is the key's value, or its previous value while the grace period it
+// was rotated with lasts.
+func (k *keyRow) matches(hash string, now time.Time) bool {
+	if k.value == hash {
+		return true
+	}
+	return k.oldValue != nil && *k.oldValue == hash && k.oldValidUntil != nil && k.oldValidUntil.After(now)
+}
+
+// inOrganization Reports whether the key belongs to the organization, or to none if org is nil.
+func (k *keyRow) inOrganization(org *int64) bool {
+	if k.orgID == nil || org == nil {
+		return k.orgID == nil && org == nil
+	}
+	return *k.orgID == *org
+}
+
+// activeKeys Returns the keys that haven't been deleted and match.
+func (t *tables) activeKeys(match func(k *keyRow) bool) []types.Key {
+	var outKeys []types.Key
+	for _, k := range t.keys {
+		if !k.deleted && match(k) {
+			outKeys = append(outKeys, k.toKey())
+		}
+	}
+	return outKeys
+}
+
+// activeKey Returns the key that hasn't been deleted with the given hash.
+func (t *tables) activeKey(hash string) *keyRow {
+	for _, k := range t.keys {
+		if !k.deleted && k.value == hash {
+			return k
+		}
+	}
+	return nil
+}
+
+// checkKeyUnique Returns an error if a key other than the one given already has the value, previous value,
+// or the name on the account.
+func (t *tables) checkKeyUnique(self *keyRow, accountID int64, name, value string, oldValue *string) error {
+	for _, k := range t.keys {
+		if k == self {
+			continue
+		}
+		if k.value == value {
+			return errors.New("key value already in use")
+		}
+		if oldValue != nil && k.oldValue != nil && *k.oldValue == *oldValue {
+			return errors.New("previous key value already in use")
+		}
+		if k.accountID == accountID && k.name == name {
+			return fmt.Errorf("key name %v already in use", name)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetAccountKeys(email string) ([]types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	a := t.accountByEmail(email)
+	if a == nil {
+		return nil, nil
+	}
+	return t.activeKeys(func(k *keyRow) bool {
+		return k.accountID == a.id
+	}), nil
+}
+
+func (m *Memory) GetAccountKeysByKey(key string) ([]types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	hash := types.HashKey(key)
+	accounts := make(map[int64]bool)
+	for _, k := range t.keys {
+		if k.value == hash {
+			accounts[k.accountID] = true
+		}
+	}
+	return t.activeKeys(func(k *keyRow) bool {
+		return accounts[k.accountID]
+	}), nil
+}
+
+// GetMemberKeys Gets the keys an account can see, its own and those of every organization it is a member of.
+func (m *Memory) GetMemberKeys(account int64) ([]types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	orgs := make(map[int64]bool)
+	for _, o := range t.members {
+		if o.accountID == account {
+			orgs[o.orgID] = true
+		}
+	}
+	return t.activeKeys(func(k *keyRow) bool {
+		return k.accountID == account || (k.orgID != nil && orgs[*k.orgID])
+	}), nil
+}
+
+// GetOrganizationKeys Gets the keys belonging to an organization.
+func (m *Memory) GetOrganizationKeys(org int64) ([]types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	return t.activeKeys(func(k *keyRow) bool {
+		return k.orgID != nil && *k.orgID == org
+	}), nil
+}
+
+func (m *Memory) GetKey(key string) (*types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	k := t.activeKey(types.HashKey(key))
+	if k == nil {
+		return nil, nil
+	}
+	outKey := k.toKey()
+	return &outKey, nil
+}
+
+func (m *Memory) AddKey(key types.Key) (*types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if t.accountByID(key.AccountIdentifier) == nil {
+		return nil, errors.New("unable to add key: account not found")
+	}
+	hash := types.HashKey(key.Value)
+	err = t.checkKeyUnique(nil, key.AccountIdentifier, key.Name, hash, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to add key: %v", err)
+	}
+	t.keys = append(t.keys, &keyRow{
+		id:           t.nextID("api_key"),
+		accountID:    key.AccountIdentifier,
+		orgID:        copyID(key.Organization),
+		name:         key.Name,
+		prefix:       types.KeyPrefix(key.Value),
+		value:        hash,
+		keyType:      key.Type,
+		allowedHosts: key.AllowedHostsValue(),
+		validUntil:   copyTime(key.ValidUntil),
+	})
+	return &types.Key{
+		AccountIdentifier: key.AccountIdentifier,
+		Organization:      key.Organization,
+		Name:              key.Name,
+		Value:             key.Value,
+		Prefix:            types.KeyPrefix(key.Value),
+		Hash:              hash,
+		Type:              key.Type,
+		AllowedHosts:      key.AllowedHosts,
+		ValidUntil:        key.ValidUntil,
+	}, nil
+}
+
+func (m *Memory) DeleteKey(key types.Key) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	k := t.activeKey(key.ValueHash())
+	if k == nil {
+		return fmt.Errorf("error deleting key, rows affected: %v", 0)
+	}
+	k.deleted = true
+	k.deletedAt = time.Now().UnixMilli()
+	return nil
+}
+
+func (m *Memory) UpdateKey(key types.Key) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	k := t.activeKey(key.ValueHash())
+	if k == nil {
+		return fmt.Errorf("error updating key, rows affected: %v", 0)
+	}
+	err = t.checkKeyUnique(k, k.accountID, key.Name, k.value, nil)
+	if err != nil {
+		return fmt.Errorf("error updating key: %v", err)
+	}
+	k.name = key.Name
+	k.keyType = key.Type
+	k.allowedHosts = key.AllowedHostsValue()
+	k.validUntil = copyTime(key.ValidUntil)
+	return nil
+}
+
+// RotateKey Replaces the value of a key with a new one. Reads and notifications stay attached to the key.
+// If graceUntil is set the old value keeps working until then, otherwise it stops working immediately.
+func (m *Memory) RotateKey(key, newValue string, graceUntil *time.Time) (*types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var oldHash *string
+	if graceUntil != nil {
+		hash := types.HashKey(key)
+		oldHash = &hash
+	}
+	k := t.activeKey(types.HashKey(key))
+	if k == nil {
+		return nil, fmt.Errorf("error rotating key, rows affected: %v", 0)
+	}
+	err = t.checkKeyUnique(k, k.accountID, k.name, types.HashKey(newValue), oldHash)
+	if err != nil {
+		return nil, fmt.Errorf("error rotating key: %v", err)
+	}
+	k.prefix = types.KeyPrefix(newValue)
+	k.value = types.HashKey(newValue)
+	k.oldValue = oldHash
+	k.oldValidUntil = copyTime(graceUntil)
+	rotated := k.toKey()
+	// This is the only time the new value is known, so hand it back.
+	rotated.Value = newValue
+	return &rotated, nil
+}
+
+// GetKeysExpiredBetween Gets the keys that stopped being valid after from and at or before to.
+func (m *Memory) GetKeysExpiredBetween(from, to time.Time) ([]types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	return t.activeKeys(func(k *keyRow) bool {
+		return k.validUntil != nil && k.validUntil.After(from) && !k.validUntil.After(to)
+	}), nil
+}
+
+// GetDeletedKeys Gets the deleted keys kept under an account, whether or not the account is deleted.
+func (m *Memory) GetDeletedKeys(account int64) ([]types.Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outKeys []types.Key
+	for _, k := range t.keys {
+		if k.deleted && k.accountID == account {
+			key := k.toKey()
+			key.SetDeletedAt(k.deletedAt)
+			outKeys = append(outKeys, key)
+		}
+	}
+	return outKeys, nil
+}
+
+// RestoreKey Brings a deleted key back. Keys are identified by their name since deleted keys have no value
+// anyone should still be using.
+func (m *Memory) RestoreKey(account int64, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	for _, k := range t.keys {
+		if k.deleted && k.accountID == account && k.name == name {
+			k.deleted = false
+			k.deletedAt = 0
+			return nil
+		}
+	}
+	return fmt.Errorf("error restoring key, rows affected: %v", 0)
+}
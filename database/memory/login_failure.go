@@ -0,0 +1,97 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"time"
+)
+
+type loginFailureRow struct {
+	count        int
+	last         int64
+	blockedUntil int64
+}
+
+// toLoginFailures Returns the failures the way they are read from the database.
+func (l *loginFailureRow) toLoginFailures(ip string) *types.LoginFailures {
+	outFailures := &types.LoginFailures{
+		IP:          ip,
+		Count:       l.count,
+		LastFailure: time.Unix(l.last, 0),
+	}
+	if l.blockedUntil != 0 {
+		until := time.Unix(l.blockedUntil, 0)
+		outFailures.BlockedUntil = &until
+	}
+	return outFailures
+}
+
+// GetLoginFailures Gets the failed logins from an IP address. Returns nil if there are none left to remember.
+func (m *Memory) GetLoginFailures(ip string) (*types.LoginFailures, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	failure, ok := t.loginFailures[ip]
+	if !ok {
+		return nil, nil
+	}
+	outFailures := failure.toLoginFailures(ip)
+	// Failures that have outlasted the lockout are forgotten, even if they haven't been cleared out yet.
+	window := m.config.IPLockoutDuration()
+	if window > 0 && outFailures.LastFailure.Add(window).Before(time.Now()) && !outFailures.Blocked() {
+		return nil, nil
+	}
+	return outFailures, nil
+}
+
+// AddLoginFailure Counts a failed login from an IP address, blocking logins from it once the configured
+// number of failures is reached. Returns the updated failures.
+func (m *Memory) AddLoginFailure(ip string) (*types.LoginFailures, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	window := m.config.IPLockoutDuration()
+	if window > 0 {
+		// Clear out the failures every address has outlasted so they don't keep piling up.
+		for failureIP, failure := range t.loginFailures {
+			if failure.last < now.Add(-window).Unix() && failure.blockedUntil < now.Unix() {
+				delete(t.loginFailures, failureIP)
+			}
+		}
+	}
+	failure, ok := t.loginFailures[ip]
+	if !ok {
+		failure = &loginFailureRow{}
+		t.loginFailures[ip] = failure
+	}
+	failure.count++
+	if m.config.IPMaxFailures > 0 && failure.count >= m.config.IPMaxFailures && failure.blockedUntil < now.Unix() {
+		failure.blockedUntil = now.Add(window).Unix()
+	}
+	failure.last = now.Unix()
+	return failure.toLoginFailures(ip), nil
+}
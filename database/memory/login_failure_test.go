@@ -0,0 +1,100 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginFailures(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	db.config.IPMaxFailures = 3
+	db.config.IPLockoutMinutes = 15
+	defer func() {
+		db.config.IPMaxFailures = 0
+		db.config.IPLockoutMinutes = 0
+	}()
+	failures, err := db.GetLoginFailures("10.0.0.1")
+	if err != nil || failures != nil {
+		t.Fatalf("Expected no login failures, found %+v (%v).", failures, err)
+	}
+	for i := 1; i < 3; i++ {
+		failures, err = db.AddLoginFailure("10.0.0.1")
+		if err != nil {
+			t.Fatalf("(%v) Error adding login failure: %v", i, err)
+		}
+		if failures.Count != i || failures.Blocked() {
+			t.Errorf("(%v) Expected %v unblocked failures, found %+v.", i, i, failures)
+		}
+	}
+	failures, err = db.AddLoginFailure("10.0.0.1")
+	if err != nil {
+		t.Fatalf("Error adding login failure: %v", err)
+	}
+	if failures.Count != 3 || !failures.Blocked() {
+		t.Errorf("Expected address to be blocked after 3 failures, found %+v.", failures)
+	}
+	failures, err = db.GetLoginFailures("10.0.0.1")
+	if err != nil || failures == nil || !failures.Blocked() {
+		t.Fatalf("Expected blocked login failures, found %+v (%v).", failures, err)
+	}
+	if failures.BlockedUntil.Before(time.Now().Add(14*time.Minute)) || failures.BlockedUntil.After(time.Now().Add(15*time.Minute)) {
+		t.Errorf("Expected address to be blocked for 15 minutes, blocked until %v.", failures.BlockedUntil)
+	}
+	// Other addresses are counted separately.
+	failures, err = db.AddLoginFailure("10.0.0.2")
+	if err != nil || failures.Count != 1 || failures.Blocked() {
+		t.Errorf("Expected a single unblocked failure, found %+v (%v).", failures, err)
+	}
+	// Failures are forgotten once the lockout has passed.
+	old := time.Now().Add(-16 * time.Minute).Unix()
+	for _, failure := range db.tables.loginFailures {
+		failure.last = old
+		failure.blockedUntil = old
+	}
+	failures, err = db.GetLoginFailures("10.0.0.1")
+	if err != nil || failures != nil {
+		t.Errorf("Expected old login failures to be forgotten, found %+v (%v).", failures, err)
+	}
+	failures, err = db.AddLoginFailure("10.0.0.1")
+	if err != nil || failures.Count != 1 || failures.Blocked() {
+		t.Errorf("Expected failures to start over, found %+v (%v).", failures, err)
+	}
+	failures, err = db.GetLoginFailures("10.0.0.2")
+	if err != nil || failures != nil {
+		t.Errorf("Expected old login failures to be cleared out, found %+v (%v).", failures, err)
+	}
+}
+
+func TestNoDatabaseLoginFailures(t *testing.T) {
+	db := Memory{}
+	_, err := db.GetLoginFailures("")
+	if err == nil {
+		t.Fatal("Expected error getting login failures.")
+	}
+	_, err = db.AddLoginFailure("")
+	if err == nil {
+		t.Fatal("Expected error adding login failure.")
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"fmt"
+	"slices"
+)
+
+// Migrate The memory database has no schema to change, so it's always at the current version.
+func (m *Memory) Migrate(target int, dryRun bool) (*types.MigrationPlan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if target != database.CurrentVersion {
+		return nil, fmt.Errorf("memory database can't be moved from version %d to %d", database.CurrentVersion, target)
+	}
+	return &types.MigrationPlan{
+		From:  database.CurrentVersion,
+		To:    database.CurrentVersion,
+		Steps: make([]types.MigrationStep, 0),
+	}, nil
+}
+
+// GetMigrationHistory Returns every migration run against the database, oldest first.
+func (m *Memory) GetMigrationHistory() ([]types.MigrationRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(t.migrations), nil
+}
@@ -0,0 +1,67 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	history, err := db.GetMigrationHistory()
+	if err != nil {
+		t.Fatalf("error getting migration history: %v", err)
+	}
+	if len(history) != 1 || history[0].Version != database.CurrentVersion || history[0].Direction != types.MigrationUp {
+		t.Fatalf("Expected table creation in migration history, found %+v.", history)
+	}
+	for _, dryRun := range []bool{true, false} {
+		plan, err := db.Migrate(database.CurrentVersion, dryRun)
+		if err != nil {
+			t.Fatalf("error migrating to current version: %v", err)
+		}
+		if plan.From != database.CurrentVersion || plan.To != database.CurrentVersion || len(plan.Steps) != 0 {
+			t.Errorf("Expected no steps when already at the current version, found %+v.", plan)
+		}
+	}
+	// There's no schema to move to any other version.
+	_, err = db.Migrate(7, true)
+	if err == nil {
+		t.Error("Expected error migrating to version 7.")
+	}
+	history, err = db.GetMigrationHistory()
+	if err != nil || len(history) != 1 {
+		t.Errorf("Expected migration history to be unchanged, found %+v (%v).", history, err)
+	}
+	db.Close()
+	_, err = db.Migrate(database.CurrentVersion, false)
+	if err == nil {
+		t.Error("Expected error migrating a closed database.")
+	}
+	_, err = db.GetMigrationHistory()
+	if err == nil {
+		t.Error("Expected error getting migration history from a closed database.")
+	}
+}
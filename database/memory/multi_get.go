@@ -0,0 +1,64 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"time"
+)
+
+// GetKeyAndAccount Gets an account and key based upon the key value.
+func (m *Memory) GetKeyAndAccount(key string) (*types.MultiKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	// Only the hash of a key value is stored, so that is what has to match.
+	hash := types.HashKey(key)
+	for _, k := range t.keys {
+		if k.deleted || (k.value != hash && (k.oldValue == nil || *k.oldValue != hash)) {
+			continue
+		}
+		a := t.activeAccount(func(a *accountRow) bool { return a.id == k.accountID })
+		if a == nil {
+			continue
+		}
+		// A rotated key's previous value only works until the grace period given when it was rotated ends.
+		if k.value != hash && (k.oldValidUntil == nil || k.oldValidUntil.Before(time.Now())) {
+			return nil, nil
+		}
+		outKey := k.toKey()
+		outAccount := a.toAccount()
+		return &types.MultiKey{
+			Key: &outKey,
+			Account: &types.Account{
+				Identifier:  outAccount.Identifier,
+				Name:        outAccount.Name,
+				Email:       outAccount.Email,
+				Type:        outAccount.Type,
+				Locked:      outAccount.Locked,
+				LockReason:  outAccount.LockReason,
+				LockedUntil: outAccount.LockedUntil,
+			},
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,186 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type notificationRow struct {
+	id               int64
+	keyID            int64
+	notificationType string
+	when             int64
+	createdAt        time.Time
+	acknowledgedAt   *time.Time
+}
+
+func (m *Memory) GetNotification(account int64, org *int64, reader_name string) (*types.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	keys := t.readerKeys(account, org, reader_name)
+	// Only the latest notification for the reader counts, and only if it was sent in the last five minutes.
+	var latest *notificationRow
+	for _, n := range t.notifications {
+		if keys[n.keyID] != nil && (latest == nil || n.when > latest.when) {
+			latest = n
+		}
+	}
+	if latest == nil || latest.when <= time.Now().Add(time.Minute*-5).Unix() {
+		return nil, nil
+	}
+	return &types.Notification{
+		Identifier: latest.id,
+		Type:       latest.notificationType,
+		When:       time.Unix(latest.when, 0),
+	}, nil
+}
+
+func (m *Memory) SaveNotification(notification *types.RequestNotification, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return false, err
+	}
+	if !types.ValidNotificationType(notification.Type) {
+		return false, fmt.Errorf("%v is not a valid type", notification.Type)
+	}
+	when, err := time.Parse(time.RFC3339, notification.When)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse time value: %v", err)
+	}
+	hash := types.HashKey(key)
+	createdAt := currentTime()
+	var found, rows int64
+	for _, k := range t.keys {
+		if !k.matches(hash, time.Now()) {
+			continue
+		}
+		found++
+		// A key only keeps one notification for any point in time, the rest are ignored.
+		exists := slices.ContainsFunc(t.notifications, func(n *notificationRow) bool {
+			return n.keyID == k.id && n.when == when.Unix()
+		})
+		if exists {
+			continue
+		}
+		t.notifications = append(t.notifications, &notificationRow{
+			id:               t.nextID("notification"),
+			keyID:            k.id,
+			notificationType: notification.Type,
+			when:             when.Unix(),
+			createdAt:        createdAt,
+		})
+		rows++
+	}
+	if found < 1 {
+		return false, errors.New("insert appears to be unsuccessful")
+	}
+	return rows > 0, nil
+}
+
+// GetNotificationHistory Gets notifications saved between from and to (inclusive), newest first.
+// An empty reader_name returns notifications for all of the account's readers.
+func (m *Memory) GetNotificationHistory(account int64, org *int64, reader_name string, from, to int64, unacknowledged bool, after *types.NotificationCursor, limit int) ([]types.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	keys := make(map[int64]*keyRow)
+	for _, k := range t.keys {
+		if k.accountID == account && k.inOrganization(org) && (reader_name == "" || k.name == reader_name) {
+			keys[k.id] = k
+		}
+	}
+	var outNotes []types.Notification
+	for _, n := range t.notifications {
+		k, ok := keys[n.keyID]
+		if !ok || n.when < from || n.when > to {
+			continue
+		}
+		if unacknowledged && n.acknowledgedAt != nil {
+			continue
+		}
+		if after != nil && (n.when > after.When || (n.when == after.When && n.id >= after.Identifier)) {
+			continue
+		}
+		outNotes = append(outNotes, types.Notification{
+			Identifier:   n.id,
+			Reader:       k.name,
+			Type:         n.notificationType,
+			When:         time.Unix(n.when, 0),
+			Acknowledged: copyTime(n.acknowledgedAt),
+		})
+	}
+	slices.SortFunc(outNotes, func(a, b types.Notification) int {
+		if c := cmp.Compare(b.When.Unix(), a.When.Unix()); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Identifier, a.Identifier)
+	})
+	if len(outNotes) > limit {
+		outNotes = outNotes[:limit]
+	}
+	return outNotes, nil
+}
+
+// AcknowledgeNotifications Acknowledges the account's notifications with the given ids, returning how many
+// were acknowledged. Notifications that were already acknowledged keep their original time.
+func (m *Memory) AcknowledgeNotifications(account int64, org *int64, notifications []int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	if len(notifications) < 1 {
+		return 0, nil
+	}
+	keys := make(map[int64]bool)
+	for _, k := range t.keys {
+		if k.accountID == account && k.inOrganization(org) {
+			keys[k.id] = true
+		}
+	}
+	acknowledgedAt := currentTime()
+	var rows int64
+	for _, n := range t.notifications {
+		if n.acknowledgedAt == nil && keys[n.keyID] && slices.Contains(notifications, n.id) {
+			at := acknowledgedAt
+			n.acknowledgedAt = &at
+			rows++
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,492 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"testing"
+	"time"
+)
+
+func setupNotificationTests() {
+	if len(accounts) < 1 {
+		accounts = []types.Account{
+			{
+				Name:     "John Smith",
+				Email:    "j@test.com",
+				Type:     "admin",
+				Password: testHashPassword("password"),
+			},
+			{
+				Name:     "Rose MacDonald",
+				Email:    "rose2004@test.com",
+				Type:     "paid",
+				Password: testHashPassword("password"),
+			},
+			{
+				Name:     "Tia Johnson",
+				Email:    "tiatheway@test.com",
+				Type:     "free",
+				Password: testHashPassword("password"),
+			},
+		}
+	}
+}
+
+func TestSaveNotification(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("Error setting up test. %v", err)
+	}
+	defer finalize(t)
+	setupNotificationTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	times := []time.Time{
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2016, 4, 1, 4, 11, 5, 0, time.Local),
+	}
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "default",
+			Name:              "reader1",
+			ValidUntil:        &times[0],
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-KH789A-00123B",
+			Type:              "delete",
+			Name:              "reader2",
+			ValidUntil:        &times[1],
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	when := time.Now()
+	notifications := []types.RequestNotification{
+		{
+			Type: "invalid_type",
+			When: when.UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "UPS_DISCONNECTED",
+			When: "invalid date",
+		},
+		{
+			Type: "UPS_DISCONNECTED",
+			When: when.Add(time.Second * -9).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "UPS_CONNECTED",
+			When: when.Add(time.Second * -8).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "UPS_ON_BATTERY",
+			When: when.Add(time.Second * -7).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "UPS_LOW_BATTERY",
+			When: when.Add(time.Second * -6).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "UPS_ONLINE",
+			When: when.Add(time.Second * -5).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "SHUTTING_DOWN",
+			When: when.Add(time.Second * -4).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "RESTARTING",
+			When: when.Add(time.Second * -3).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "HIGH_TEMP",
+			When: when.Add(time.Second * -2).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "MAX_TEMP",
+			When: when.Add(time.Second * -1).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "MAX_TEMP",
+			When: when.Add(time.Second * -9).UTC().Format(time.RFC3339),
+		},
+	}
+	_, err = db.SaveNotification(&notifications[0], keys[0].Value)
+	if err == nil {
+		t.Fatalf("expected error saving notification with invalid type but no error found")
+	}
+	_, err = db.SaveNotification(&notifications[1], keys[0].Value)
+	if err == nil {
+		t.Fatalf("expected error saving notification with invalid date but no error found")
+	}
+	_, err = db.SaveNotification(&notifications[2], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	_, err = db.SaveNotification(&notifications[3], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	_, err = db.SaveNotification(&notifications[4], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	_, err = db.SaveNotification(&notifications[5], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	_, err = db.SaveNotification(&notifications[6], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	_, err = db.SaveNotification(&notifications[7], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	_, err = db.SaveNotification(&notifications[8], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	_, err = db.SaveNotification(&notifications[9], keys[0].Value)
+	if err != nil {
+		t.Fatalf("error found saving notification: %v", err)
+	}
+	saved, err := db.SaveNotification(&notifications[10], keys[0].Value)
+	if err != nil || !saved {
+		t.Fatalf("error found saving notification: %v (saved %v)", err, saved)
+	}
+	// A notification for a time the key already has one for is ignored.
+	saved, err = db.SaveNotification(&notifications[11], keys[0].Value)
+	if err != nil || saved {
+		t.Fatalf("expected notification with duplicate when value to be ignored, found %v (saved %v)", err, saved)
+	}
+	_, err = db.SaveNotification(&notifications[2], "unknown-key")
+	if err == nil {
+		t.Fatalf("expected error when adding notification with unknown key but no error was found")
+	}
+	/*err = db.SaveNotification(&notifications[2], "invalid key")
+	if err != nil {
+		t.Fatalf("expected error when adding notification with invalid key but no error was found")
+	}*/
+}
+
+func TestGetNotification(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("Error setting up test. %v", err)
+	}
+	defer finalize(t)
+	setupNotificationTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	times := []time.Time{
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2016, 4, 1, 4, 11, 5, 0, time.Local),
+	}
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "default",
+			Name:              "reader1",
+			ValidUntil:        &times[0],
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-KH789A-00123B",
+			Type:              "delete",
+			Name:              "reader2",
+			ValidUntil:        &times[1],
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	when := time.Now()
+	notifications := []types.RequestNotification{
+		{
+			Type: "UPS_DISCONNECTED",
+			When: when.UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "UPS_DISCONNECTED",
+			When: when.Add(time.Minute * -6).UTC().Format(time.RFC3339),
+		},
+		{
+			Type: "UPS_ON_BATTERY",
+			When: when.Add(time.Second * -10).UTC().Format(time.RFC3339),
+		},
+	}
+	// No notifications saved.
+	note, err := db.GetNotification(account1.Identifier, nil, keys[0].Name)
+	if err != nil {
+		t.Fatalf("error when trying to get notification: %v", err)
+	}
+	if note != nil {
+		t.Fatalf("found notification when none was expected: %v", note)
+	}
+	_, _ = db.SaveNotification(&notifications[0], keys[0].Value)
+	_, _ = db.SaveNotification(&notifications[1], keys[1].Value)
+	_, _ = db.SaveNotification(&notifications[2], keys[0].Value)
+	// Saved notification, within time period
+	note, err = db.GetNotification(account1.Identifier, nil, keys[0].Name)
+	if err != nil {
+		t.Fatalf("error when trying to get notification: %v", err)
+	}
+	if note == nil {
+		t.Fatalf("expected a notification but didn't find anything")
+	}
+	if note.Type != notifications[0].Type {
+		t.Fatalf("expected to find %v for the notification type, found %v", notifications[0].Type, note.Type)
+	}
+	// Notification too long ago
+	note, err = db.GetNotification(account2.Identifier, nil, keys[1].Name)
+	if err != nil {
+		t.Fatalf("error when trying to get notification: %v", err)
+	}
+	if note != nil {
+		t.Fatalf("found notification when none was expected: %v", note)
+	}
+	// Invalid key
+	note, err = db.GetNotification(account1.Identifier, nil, "invalid key")
+	if err != nil {
+		t.Fatalf("error when trying to get notification: %v", err)
+	}
+	if note != nil {
+		t.Fatalf("found notification when none was expected: %v", note)
+	}
+}
+
+func TestGetNotificationHistory(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("Error setting up test. %v", err)
+	}
+	defer finalize(t)
+	setupNotificationTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-KH789A-00123B",
+			Type:              "delete",
+			Name:              "reader3",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	db.AddKey(keys[2])
+	when := time.Now().Truncate(time.Second)
+	// Older than the five minutes GetNotification looks at.
+	for i, note := range []struct {
+		key  string
+		kind string
+		when time.Time
+	}{
+		{keys[0].Value, "UPS_ON_BATTERY", when.Add(time.Hour * -3)},
+		{keys[0].Value, "UPS_ONLINE", when.Add(time.Hour * -2)},
+		{keys[1].Value, "HIGH_TEMP", when.Add(time.Hour * -1)},
+		{keys[0].Value, "UPS_LOW_BATTERY", when.Add(time.Minute * -30)},
+		{keys[2].Value, "MAX_TEMP", when.Add(time.Minute * -10)},
+	} {
+		_, err = db.SaveNotification(&types.RequestNotification{
+			Type: note.kind,
+			When: note.when.UTC().Format(time.RFC3339),
+		}, note.key)
+		if err != nil {
+			t.Fatalf("(%v) error saving notification: %v", i, err)
+		}
+	}
+	// All readers on the account, newest first.
+	notes, err := db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 4 {
+		t.Fatalf("Expected %v notifications, found %v.", 4, len(notes))
+	}
+	expected := []string{"UPS_LOW_BATTERY", "HIGH_TEMP", "UPS_ONLINE", "UPS_ON_BATTERY"}
+	for i, note := range notes {
+		if note.Type != expected[i] {
+			t.Errorf("Expected notification %v to be %v, found %v.", i, expected[i], note.Type)
+		}
+		if note.Acknowledged != nil {
+			t.Errorf("Expected notification %v to not be acknowledged, found %v.", i, *note.Acknowledged)
+		}
+	}
+	if notes[1].Reader != keys[1].Name || notes[0].Reader != keys[0].Name {
+		t.Errorf("Expected reader names to be set, found %v and %v.", notes[0].Reader, notes[1].Reader)
+	}
+	// Single reader.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, keys[0].Name, 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 3 {
+		t.Errorf("Expected %v notifications, found %v.", 3, len(notes))
+	}
+	// Time range.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "", when.Add(time.Hour*-2).Unix(), when.Add(time.Hour*-1).Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Type != "HIGH_TEMP" || notes[1].Type != "UPS_ONLINE" {
+		t.Errorf("Expected HIGH_TEMP and UPS_ONLINE notifications, found %+v.", notes)
+	}
+	// Paging.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 3)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 3 {
+		t.Fatalf("Expected %v notifications, found %v.", 3, len(notes))
+	}
+	after := notes[1].Cursor()
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, &after, 3)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Type != "UPS_ONLINE" || notes[1].Type != "UPS_ON_BATTERY" {
+		t.Errorf("Expected UPS_ONLINE and UPS_ON_BATTERY notifications, found %+v.", notes)
+	}
+	_, err = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 0)
+	if err == nil {
+		t.Error("Expected error getting notification history with no limit.")
+	}
+	// Other account.
+	notes, err = db.GetNotificationHistory(account2.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Type != "MAX_TEMP" {
+		t.Errorf("Expected a MAX_TEMP notification, found %+v.", notes)
+	}
+	// Unknown reader.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "invalid reader", 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("Expected no notifications, found %+v.", notes)
+	}
+}
+
+func TestAcknowledgeNotifications(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("Error setting up test. %v", err)
+	}
+	defer finalize(t)
+	setupNotificationTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-KH789A-00123B",
+			Type:              "delete",
+			Name:              "reader2",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	when := time.Now().Truncate(time.Second)
+	for i := 1; i <= 3; i++ {
+		db.SaveNotification(&types.RequestNotification{
+			Type: "HIGH_TEMP",
+			When: when.Add(time.Minute * time.Duration(-i)).UTC().Format(time.RFC3339),
+		}, keys[0].Value)
+	}
+	db.SaveNotification(&types.RequestNotification{
+		Type: "MAX_TEMP",
+		When: when.UTC().Format(time.RFC3339),
+	}, keys[1].Value)
+	notes, _ := db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(notes) != 3 {
+		t.Fatalf("Expected %v notifications, found %v.", 3, len(notes))
+	}
+	others, _ := db.GetNotificationHistory(account2.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(others) != 1 {
+		t.Fatalf("Expected %v notifications, found %v.", 1, len(others))
+	}
+	// Notifications belonging to another account aren't acknowledged.
+	count, err := db.AcknowledgeNotifications(account1.Identifier, nil, []int64{notes[0].Identifier, others[0].Identifier})
+	if err != nil {
+		t.Fatalf("error acknowledging notifications: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected %v notifications acknowledged, found %v.", 1, count)
+	}
+	others, _ = db.GetNotificationHistory(account2.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(others) != 1 || others[0].Acknowledged != nil {
+		t.Errorf("Expected other account's notification to not be acknowledged, found %+v.", others)
+	}
+	notes, _ = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(notes) != 3 || notes[0].Acknowledged == nil || notes[1].Acknowledged != nil {
+		t.Fatalf("Expected only the first notification to be acknowledged, found %+v.", notes)
+	}
+	acknowledged := *notes[0].Acknowledged
+	unacked, _ := db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), true, nil, 100)
+	if len(unacked) != 2 {
+		t.Errorf("Expected %v unacknowledged notifications, found %v.", 2, len(unacked))
+	}
+	// Acknowledging again keeps the original time.
+	time.Sleep(time.Second)
+	count, err = db.AcknowledgeNotifications(account1.Identifier, nil, []int64{notes[0].Identifier, notes[1].Identifier, notes[2].Identifier})
+	if err != nil {
+		t.Fatalf("error acknowledging notifications: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected %v notifications acknowledged, found %v.", 2, count)
+	}
+	notes, _ = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(notes) != 3 || notes[0].Acknowledged == nil || !notes[0].Acknowledged.Equal(acknowledged) {
+		t.Errorf("Expected acknowledged time %v to be kept, found %+v.", acknowledged, notes)
+	}
+	unacked, _ = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), true, nil, 100)
+	if len(unacked) != 0 {
+		t.Errorf("Expected no unacknowledged notifications, found %v.", len(unacked))
+	}
+	count, err = db.AcknowledgeNotifications(account1.Identifier, nil, nil)
+	if err != nil || count != 0 {
+		t.Errorf("Expected nothing to be acknowledged, found %v (%v).", count, err)
+	}
+}
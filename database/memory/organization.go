@@ -0,0 +1,278 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type organizationRow struct {
+	id        int64
+	accountID int64
+	name      string
+	createdAt time.Time
+}
+
+type memberRow struct {
+	orgID     int64
+	accountID int64
+	role      string
+	createdAt time.Time
+}
+
+func (o *organizationRow) toOrganization() types.Organization {
+	return types.Organization{
+		Identifier:        o.id,
+		AccountIdentifier: o.accountID,
+		Name:              o.name,
+		CreatedAt:         o.createdAt,
+	}
+}
+
+// organization Returns an organization by its identifier.
+func (t *tables) organization(id int64) *organizationRow {
+	for _, o := range t.organizations {
+		if o.id == id {
+			return o
+		}
+	}
+	return nil
+}
+
+// AddOrganization Adds an organization, making the account that created it its first owner.
+func (m *Memory) AddOrganization(org types.Organization) (*types.Organization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if t.accountByID(org.AccountIdentifier) == nil {
+		return nil, errors.New("unable to add organization: account not found")
+	}
+	// An account can only create one organization.
+	for _, o := range t.organizations {
+		if o.accountID == org.AccountIdentifier {
+			return nil, errors.New("unable to add organization: account already has an organization")
+		}
+	}
+	createdAt := currentTime()
+	org.Identifier = t.nextID("organization")
+	org.CreatedAt = createdAt
+	t.organizations = append(t.organizations, &organizationRow{
+		id:        org.Identifier,
+		accountID: org.AccountIdentifier,
+		name:      org.Name,
+		createdAt: createdAt,
+	})
+	t.members = append(t.members, &memberRow{
+		orgID:     org.Identifier,
+		accountID: org.AccountIdentifier,
+		role:      types.OrgRoleOwner,
+		createdAt: createdAt,
+	})
+	return &org, nil
+}
+
+// GetOrganization Gets an organization. Returns nil if it doesn't exist.
+func (m *Memory) GetOrganization(id int64) (*types.Organization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	o := t.organization(id)
+	if o == nil {
+		return nil, nil
+	}
+	outOrg := o.toOrganization()
+	return &outOrg, nil
+}
+
+// UpdateOrganization Updates the name of an organization.
+func (m *Memory) UpdateOrganization(org types.Organization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	o := t.organization(org.Identifier)
+	if o == nil {
+		return fmt.Errorf("error updating organization, rows affected: %v", 0)
+	}
+	o.name = org.Name
+	return nil
+}
+
+// DeleteOrganization Deletes an organization and its memberships. Its keys and webhooks go back to
+// being those of the account that created it.
+func (m *Memory) DeleteOrganization(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	if t.organization(id) == nil {
+		return fmt.Errorf("error deleting organization, rows affected: %v", 0)
+	}
+	for _, k := range t.keys {
+		if k.orgID != nil && *k.orgID == id {
+			k.orgID = nil
+		}
+	}
+	for _, w := range t.webhooks {
+		if w.orgID != nil && *w.orgID == id {
+			w.orgID = nil
+		}
+	}
+	t.members = deleteRows(t.members, func(o *memberRow) bool {
+		return o.orgID == id
+	})
+	t.organizations = deleteRows(t.organizations, func(o *organizationRow) bool {
+		return o.id == id
+	})
+	return nil
+}
+
+// GetMembership Gets the role an account has in an organization. Returns nil if it isn't a member.
+func (m *Memory) GetMembership(org, account int64) (*types.Membership, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range t.members {
+		if member.orgID == org && member.accountID == account {
+			if o := t.organization(org); o != nil {
+				return &types.Membership{
+					Organization: o.toOrganization(),
+					Role:         member.role,
+				}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// GetAccountMemberships Gets every organization an account is a member of, with the role it has in each.
+func (m *Memory) GetAccountMemberships(account int64) ([]types.Membership, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outMemberships []types.Membership
+	for _, member := range t.members {
+		if member.accountID != account {
+			continue
+		}
+		if o := t.organization(member.orgID); o != nil {
+			outMemberships = append(outMemberships, types.Membership{
+				Organization: o.toOrganization(),
+				Role:         member.role,
+			})
+		}
+	}
+	slices.SortFunc(outMemberships, func(a, b types.Membership) int {
+		return cmp.Compare(a.Organization.Identifier, b.Organization.Identifier)
+	})
+	return outMemberships, nil
+}
+
+// GetOrganizationMembers Gets the members of an organization. Deleted accounts are left out.
+func (m *Memory) GetOrganizationMembers(org int64) ([]types.OrganizationMember, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outMembers []types.OrganizationMember
+	for _, member := range t.members {
+		if member.orgID != org {
+			continue
+		}
+		a := t.activeAccount(func(a *accountRow) bool { return a.id == member.accountID })
+		if a == nil {
+			continue
+		}
+		outMembers = append(outMembers, types.OrganizationMember{
+			OrganizationIdentifier: member.orgID,
+			AccountIdentifier:      member.accountID,
+			Name:                   a.name,
+			Email:                  a.email,
+			Role:                   member.role,
+		})
+	}
+	slices.SortFunc(outMembers, func(a, b types.OrganizationMember) int {
+		return cmp.Compare(a.Email, b.Email)
+	})
+	return outMembers, nil
+}
+
+// SetOrganizationMember Adds an account to an organization, or changes its role if it is already a member.
+func (m *Memory) SetOrganizationMember(member types.OrganizationMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	if t.organization(member.OrganizationIdentifier) == nil || t.accountByID(member.AccountIdentifier) == nil {
+		return errors.New("unable to add organization member: organization or account not found")
+	}
+	t.members = deleteRows(t.members, func(o *memberRow) bool {
+		return o.orgID == member.OrganizationIdentifier && o.accountID == member.AccountIdentifier
+	})
+	t.members = append(t.members, &memberRow{
+		orgID:     member.OrganizationIdentifier,
+		accountID: member.AccountIdentifier,
+		role:      member.Role,
+		createdAt: currentTime(),
+	})
+	return nil
+}
+
+// RemoveOrganizationMember Removes an account from an organization.
+func (m *Memory) RemoveOrganizationMember(org, account int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	match := func(o *memberRow) bool {
+		return o.orgID == org && o.accountID == account
+	}
+	if rows := countRows(t.members, match); rows != 1 {
+		return fmt.Errorf("error removing organization member, rows affected: %v", rows)
+	}
+	t.members = deleteRows(t.members, match)
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"errors"
+	"time"
+)
+
+type resetRow struct {
+	accountID int64
+	token     string
+	expiresAt int64
+}
+
+type resetRequestRow struct {
+	count       int
+	windowStart int64
+}
+
+// AddPasswordReset Stores a password reset token for an account, replacing any it already had.
+func (m *Memory) AddPasswordReset(reset types.PasswordReset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	if t.accountByID(reset.AccountIdentifier) == nil {
+		return errors.New("unable to add password reset: account not found")
+	}
+	token := types.HashKey(reset.Token)
+	for _, r := range t.passwordResets {
+		if r.token == token && r.accountID != reset.AccountIdentifier {
+			return errors.New("unable to add password reset: token already in use")
+		}
+	}
+	t.passwordResets = deleteRows(t.passwordResets, func(r *resetRow) bool {
+		return r.accountID == reset.AccountIdentifier
+	})
+	t.passwordResets = append(t.passwordResets, &resetRow{
+		accountID: reset.AccountIdentifier,
+		token:     token,
+		expiresAt: reset.ExpiresAt.Unix(),
+	})
+	return nil
+}
+
+// AddPasswordResetRequest Counts a password reset request against the key, starting the count over once the
+// window has passed since the first request it holds. Counts every key has outlasted are cleared out. Returns
+// the number of requests counted in the current window, this one included.
+func (m *Memory) AddPasswordResetRequest(key string, window time.Duration, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	for requestKey, request := range t.resetRequests {
+		if request.windowStart <= now.Add(-window).Unix() {
+			delete(t.resetRequests, requestKey)
+		}
+	}
+	request, ok := t.resetRequests[key]
+	if !ok {
+		request = &resetRequestRow{windowStart: now.Unix()}
+		t.resetRequests[key] = request
+	}
+	request.count++
+	return request.count, nil
+}
+
+// ResetPassword Uses a password reset token to set a new password on the account it was issued for.
+// The account is unlocked and every reset token and session it has is removed. Returns
+// database.ErrInvalidResetToken if the token is unknown, has already been used, expired before now,
+// or belongs to an account that has since been deleted.
+func (m *Memory) ResetPassword(token, newPassword string, now time.Time) (*types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	hash := types.HashKey(token)
+	var reset *resetRow
+	for _, r := range t.passwordResets {
+		if r.token == hash && r.expiresAt > now.Unix() {
+			reset = r
+			break
+		}
+	}
+	if reset == nil {
+		return nil, database.ErrInvalidResetToken
+	}
+	a := t.activeAccount(func(a *accountRow) bool { return a.id == reset.accountID })
+	if a == nil {
+		return nil, database.ErrInvalidResetToken
+	}
+	// Every token the account has goes, including the one used, so it can only be used once.
+	t.passwordResets = deleteRows(t.passwordResets, func(r *resetRow) bool {
+		return r.accountID == reset.accountID
+	})
+	t.deleteAccountSessions(a.id)
+	a.password = newPassword
+	a.unlock()
+	return a.toAccount(), nil
+}
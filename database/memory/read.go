@@ -0,0 +1,310 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"cmp"
+	"errors"
+	"slices"
+	"time"
+)
+
+type readRow struct {
+	id        int64
+	keyID     int64
+	read      types.Read
+	createdAt time.Time
+}
+
+// readUnique holds the values no two reads can share.
+type readUnique struct {
+	keyID        int64
+	identifier   string
+	seconds      int64
+	milliseconds int
+	identType    string
+}
+
+func (r *readRow) unique() readUnique {
+	return readUnique{
+		keyID:        r.keyID,
+		identifier:   r.read.Identifier,
+		seconds:      r.read.Seconds,
+		milliseconds: r.read.Milliseconds,
+		identType:    r.read.IdentType,
+	}
+}
+
+// deleteReads Deletes the reads that match, returning how many were deleted.
+func (t *tables) deleteReads(match func(r *readRow) bool) int64 {
+	count := countRows(t.reads, match)
+	if count > 0 {
+		t.reads = deleteRows(t.reads, func(r *readRow) bool {
+			if match(r) {
+				delete(t.readSet, r.unique())
+				return true
+			}
+			return false
+		})
+	}
+	return count
+}
+
+// readerKeys Returns the keys, deleted or not, an account has for a reader in the organization given.
+func (t *tables) readerKeys(account int64, org *int64, reader_name string) map[int64]*keyRow {
+	keys := make(map[int64]*keyRow)
+	for _, k := range t.keys {
+		if k.accountID == account && k.inOrganization(org) && k.name == reader_name {
+			keys[k.id] = k
+		}
+	}
+	return keys
+}
+
+// readerReads Returns the reads for a reader with seconds between from and to, in the order they were added.
+func (t *tables) readerReads(account int64, org *int64, reader_name string, from, to int64) []types.Read {
+	toVal := to
+	if to < from {
+		toVal = from + 360
+	}
+	keys := t.readerKeys(account, org, reader_name)
+	var outReads []types.Read
+	for _, r := range t.reads {
+		k, ok := keys[r.keyID]
+		if !ok || r.read.Seconds < from || r.read.Seconds > toVal {
+			continue
+		}
+		read := r.read
+		read.Key = k.prefix
+		outReads = append(outReads, read)
+	}
+	return outReads
+}
+
+func (m *Memory) GetReads(account int64, org *int64, reader_name string, from, to int64) ([]types.Read, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	return t.readerReads(account, org, reader_name, from, to), nil
+}
+
+// compareReads Orders reads by (seconds, milliseconds, identifier, ident_type).
+func compareReads(a, b types.ReadCursor) int {
+	switch {
+	case a.Seconds != b.Seconds:
+		return cmp.Compare(a.Seconds, b.Seconds)
+	case a.Milliseconds != b.Milliseconds:
+		return cmp.Compare(a.Milliseconds, b.Milliseconds)
+	case a.Identifier != b.Identifier:
+		return cmp.Compare(a.Identifier, b.Identifier)
+	}
+	return cmp.Compare(a.IdentType, b.IdentType)
+}
+
+// GetReadsPage Gets up to limit reads ordered by (seconds, milliseconds, identifier, ident_type),
+// starting after the position marked by the cursor if one is given.
+func (m *Memory) GetReadsPage(account int64, org *int64, reader_name string, from, to int64, after *types.ReadCursor, limit int) ([]types.Read, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	reads := t.readerReads(account, org, reader_name, from, to)
+	if after != nil {
+		reads = slices.DeleteFunc(reads, func(r types.Read) bool {
+			return compareReads(r.Cursor(), *after) <= 0
+		})
+	}
+	slices.SortFunc(reads, func(a, b types.Read) int {
+		return compareReads(a.Cursor(), b.Cursor())
+	})
+	if len(reads) > limit {
+		reads = reads[:limit]
+	}
+	return reads, nil
+}
+
+// GetReadsAfter Gets up to limit reads added after the read with the given id, in the order they were added.
+func (m *Memory) GetReadsAfter(account int64, org *int64, reader_name string, after int64, limit int) ([]types.Read, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	keys := t.readerKeys(account, org, reader_name)
+	var outReads []types.Read
+	for _, r := range t.reads {
+		k, ok := keys[r.keyID]
+		if !ok || r.id <= after {
+			continue
+		}
+		read := r.read
+		read.Key = k.prefix
+		read.ID = r.id
+		outReads = append(outReads, read)
+		if len(outReads) == limit {
+			break
+		}
+	}
+	return outReads, nil
+}
+
+// AddReads Adds reads to the database and returns the reads that were inserted, in order.
+// Reads that already exist are skipped and not returned.
+func (m *Memory) AddReads(key string, reads []types.Read) ([]types.Read, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	hash := types.HashKey(key)
+	var keyID int64
+	found := false
+	for _, k := range t.keys {
+		if k.matches(hash, time.Now()) {
+			keyID = k.id
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.New("unable to find key for reads: key not found")
+	}
+	createdAt := currentTime()
+	var outReads []types.Read
+	for _, read := range reads {
+		row := &readRow{
+			keyID:     keyID,
+			read:      read,
+			createdAt: createdAt,
+		}
+		row.read.Key = ""
+		// Duplicates are ignored and aren't reported as added.
+		if t.readSet[row.unique()] {
+			continue
+		}
+		t.readSet[row.unique()] = true
+		row.id = t.nextID("a_read")
+		t.reads = append(t.reads, row)
+		read.ID = row.id
+		outReads = append(outReads, read)
+	}
+	return outReads, nil
+}
+
+func (m *Memory) DeleteReaderReads(account int64, org *int64, reader_name string, from, to int64) (int64, error) {
+	if to < from {
+		return 0, errors.New("second input variable must be greater than first")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	keys := t.readerKeys(account, org, reader_name)
+	return t.deleteReads(func(r *readRow) bool {
+		return keys[r.keyID] != nil && r.read.Seconds >= from && r.read.Seconds <= to
+	}), nil
+}
+
+func (m *Memory) DeleteKeyReads(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	hash := types.HashKey(key)
+	keys := make(map[int64]bool)
+	for _, k := range t.keys {
+		if k.value == hash {
+			keys[k.id] = true
+		}
+	}
+	return t.deleteReads(func(r *readRow) bool {
+		return keys[r.keyID]
+	}), nil
+}
+
+func (m *Memory) DeleteReaderReadsBefore(account int64, org *int64, reader_name string, to int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	keys := t.readerKeys(account, org, reader_name)
+	return t.deleteReads(func(r *readRow) bool {
+		return keys[r.keyID] != nil && r.read.Seconds <= to
+	}), nil
+}
+
+func (m *Memory) DeleteReaderReadsBetween(account int64, org *int64, reader_name string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	keys := t.readerKeys(account, org, reader_name)
+	return t.deleteReads(func(r *readRow) bool {
+		return keys[r.keyID] != nil
+	}), nil
+}
+
+// DeleteAccountTypeReadsBefore Deletes reads uploaded (not read) before the given unix time for
+// every account of the given type. Used to enforce read retention.
+func (m *Memory) DeleteAccountTypeReadsBefore(account_type string, before int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	accounts := make(map[int64]bool)
+	for _, a := range t.accounts {
+		if a.accountType == account_type {
+			accounts[a.id] = true
+		}
+	}
+	keys := make(map[int64]bool)
+	for _, k := range t.keys {
+		if accounts[k.accountID] {
+			keys[k.id] = true
+		}
+	}
+	cutoff := time.Unix(before, 0)
+	return t.deleteReads(func(r *readRow) bool {
+		return keys[r.keyID] && r.createdAt.Before(cutoff)
+	}), nil
+}
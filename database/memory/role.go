@@ -0,0 +1,90 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// GetRoles Gets every role.
+func (m *Memory) GetRoles() ([]types.Role, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outRoles []types.Role
+	for name, permissions := range t.roles {
+		role := types.Role{Name: name}
+		role.SetPermissions(permissions)
+		outRoles = append(outRoles, role)
+	}
+	slices.SortFunc(outRoles, func(a, b types.Role) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return outRoles, nil
+}
+
+// GetRole Gets a role by name. Returns nil if it doesn't exist.
+func (m *Memory) GetRole(name string) (*types.Role, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	permissions, ok := t.roles[name]
+	if !ok {
+		return nil, nil
+	}
+	outRole := types.Role{Name: name}
+	outRole.SetPermissions(permissions)
+	return &outRole, nil
+}
+
+// SaveRole Adds a role, or replaces the permissions of the role with the same name.
+func (m *Memory) SaveRole(role types.Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	t.roles[role.Name] = role.PermissionsValue()
+	return nil
+}
+
+// DeleteRole Deletes a role.
+func (m *Memory) DeleteRole(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	if _, ok := t.roles[name]; !ok {
+		return fmt.Errorf("error deleting role, rows affected: %v", 0)
+	}
+	delete(t.roles, name)
+	return nil
+}
@@ -0,0 +1,243 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type sessionRow struct {
+	id           int64
+	accountID    int64
+	token        string
+	refreshToken string
+	userAgent    string
+	ip           string
+	createdAt    time.Time
+	lastUsed     time.Time
+}
+
+func (s *sessionRow) toSession() types.Session {
+	return types.Session{
+		Identifier:        s.id,
+		AccountIdentifier: s.accountID,
+		UserAgent:         s.userAgent,
+		IP:                s.ip,
+		CreatedAt:         s.createdAt,
+		LastUsed:          s.lastUsed,
+	}
+}
+
+// checkSessionUnique Returns an error if a session other than the one given already has either token.
+func (t *tables) checkSessionUnique(self *sessionRow, token, refreshToken string) error {
+	for _, s := range t.sessions {
+		if s != self && (s.token == token || s.refreshToken == refreshToken) {
+			return errors.New("session token already in use")
+		}
+	}
+	return nil
+}
+
+// session Returns the session that matches.
+func (t *tables) session(match func(s *sessionRow) bool) *sessionRow {
+	for _, s := range t.sessions {
+		if match(s) {
+			return s
+		}
+	}
+	return nil
+}
+
+// deleteAccountSessions Ends every session belonging to an account.
+func (t *tables) deleteAccountSessions(account int64) {
+	t.sessions = deleteRows(t.sessions, func(s *sessionRow) bool {
+		return s.accountID == account
+	})
+}
+
+// AddSession Adds a session for an account, storing the hashes of its tokens.
+func (m *Memory) AddSession(session types.Session) (*types.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if t.accountByID(session.AccountIdentifier) == nil {
+		return nil, errors.New("unable to add session: account not found")
+	}
+	token, refreshToken := types.HashToken(session.Token), types.HashToken(session.RefreshToken)
+	err = t.checkSessionUnique(nil, token, refreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("unable to add session: %v", err)
+	}
+	createdAt := currentTime()
+	session.Identifier = t.nextID("account_session")
+	session.CreatedAt = createdAt
+	session.LastUsed = createdAt
+	t.sessions = append(t.sessions, &sessionRow{
+		id:           session.Identifier,
+		accountID:    session.AccountIdentifier,
+		token:        token,
+		refreshToken: refreshToken,
+		userAgent:    session.UserAgent,
+		ip:           session.IP,
+		createdAt:    createdAt,
+		lastUsed:     createdAt,
+	})
+	return &session, nil
+}
+
+// GetSession Gets the session a token belongs to.
+func (m *Memory) GetSession(token string) (*types.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	hash := types.HashToken(token)
+	s := t.session(func(s *sessionRow) bool { return s.token == hash })
+	if s == nil {
+		return nil, nil
+	}
+	outSession := s.toSession()
+	return &outSession, nil
+}
+
+// GetSessionByRefreshToken Gets the session a refresh token belongs to.
+func (m *Memory) GetSessionByRefreshToken(refreshToken string) (*types.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	hash := types.HashToken(refreshToken)
+	s := t.session(func(s *sessionRow) bool { return s.refreshToken == hash })
+	if s == nil {
+		return nil, nil
+	}
+	outSession := s.toSession()
+	return &outSession, nil
+}
+
+// GetAccountSessions Gets all sessions for an account, most recently used first.
+func (m *Memory) GetAccountSessions(account int64) ([]types.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outSessions []types.Session
+	for _, s := range t.sessions {
+		if s.accountID == account {
+			outSessions = append(outSessions, s.toSession())
+		}
+	}
+	slices.SortFunc(outSessions, func(a, b types.Session) int {
+		if c := b.LastUsed.Compare(a.LastUsed); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Identifier, a.Identifier)
+	})
+	return outSessions, nil
+}
+
+// UpdateSession Replaces the tokens of a session and records where it was last used from.
+func (m *Memory) UpdateSession(session types.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	s := t.session(func(s *sessionRow) bool { return s.id == session.Identifier })
+	if s == nil {
+		return fmt.Errorf("error updating session, rows affected: %v", 0)
+	}
+	token, refreshToken := types.HashToken(session.Token), types.HashToken(session.RefreshToken)
+	err = t.checkSessionUnique(s, token, refreshToken)
+	if err != nil {
+		return fmt.Errorf("error updating session: %v", err)
+	}
+	s.token = token
+	s.refreshToken = refreshToken
+	s.userAgent = session.UserAgent
+	s.ip = session.IP
+	s.lastUsed = currentTime()
+	return nil
+}
+
+// UseSession Marks a session as used now.
+func (m *Memory) UseSession(session int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	s := t.session(func(s *sessionRow) bool { return s.id == session })
+	if s == nil {
+		return fmt.Errorf("error updating session, rows affected: %v", 0)
+	}
+	s.lastUsed = currentTime()
+	return nil
+}
+
+// DeleteSession Ends a single session belonging to an account.
+func (m *Memory) DeleteSession(account, session int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	match := func(s *sessionRow) bool {
+		return s.accountID == account && s.id == session
+	}
+	if rows := countRows(t.sessions, match); rows != 1 {
+		return fmt.Errorf("error deleting session, rows affected: %v", rows)
+	}
+	t.sessions = deleteRows(t.sessions, match)
+	return nil
+}
+
+// DeleteAccountSessions Ends every session belonging to an account other than the ones to keep.
+// Returns the number of sessions ended.
+func (m *Memory) DeleteAccountSessions(account int64, keep ...int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return 0, err
+	}
+	match := func(s *sessionRow) bool {
+		return s.accountID == account && !slices.Contains(keep, s.id)
+	}
+	rows := countRows(t.sessions, match)
+	t.sessions = deleteRows(t.sessions, match)
+	return rows, nil
+}
@@ -0,0 +1,205 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"errors"
+	"fmt"
+)
+
+type twoFactorRow struct {
+	accountID int64
+	secret    string
+	enabled   bool
+	lastStep  int64
+}
+
+type recoveryCodeRow struct {
+	accountID int64
+	hash      string
+}
+
+// twoFactor Returns the two-factor setup for an account.
+func (t *tables) twoFactor(account int64) *twoFactorRow {
+	for _, f := range t.twoFactors {
+		if f.accountID == account {
+			return f
+		}
+	}
+	return nil
+}
+
+// deleteTwoFactor Removes the two-factor setup and recovery codes from an account.
+func (t *tables) deleteTwoFactor(account int64) {
+	t.recoveryCodes = deleteRows(t.recoveryCodes, func(c *recoveryCodeRow) bool {
+		return c.accountID == account
+	})
+	t.twoFactors = deleteRows(t.twoFactors, func(f *twoFactorRow) bool {
+		return f.accountID == account
+	})
+}
+
+// replaceRecoveryCodes Replaces the recovery codes for an account. Nothing is changed if any code is repeated.
+func (t *tables) replaceRecoveryCodes(account int64, recoveryCodes []string) error {
+	if t.accountByID(account) == nil {
+		return errors.New("unable to add recovery code: account not found")
+	}
+	hashes := make(map[string]bool)
+	for _, code := range recoveryCodes {
+		hash := types.HashKey(code)
+		if hashes[hash] {
+			return errors.New("unable to add recovery code: code repeated")
+		}
+		hashes[hash] = true
+	}
+	t.recoveryCodes = deleteRows(t.recoveryCodes, func(c *recoveryCodeRow) bool {
+		return c.accountID == account
+	})
+	for _, code := range recoveryCodes {
+		t.recoveryCodes = append(t.recoveryCodes, &recoveryCodeRow{
+			accountID: account,
+			hash:      types.HashKey(code),
+		})
+	}
+	return nil
+}
+
+// GetTwoFactor Gets the two-factor setup for an account. Returns nil if it doesn't have one.
+func (m *Memory) GetTwoFactor(account int64) (*types.TwoFactor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	f := t.twoFactor(account)
+	if f == nil {
+		return nil, nil
+	}
+	return &types.TwoFactor{
+		AccountIdentifier: f.accountID,
+		Secret:            f.secret,
+		Enabled:           f.enabled,
+		LastStep:          f.lastStep,
+		RecoveryCodes: int(countRows(t.recoveryCodes, func(c *recoveryCodeRow) bool {
+			return c.accountID == account
+		})),
+	}, nil
+}
+
+// AddTwoFactor Stores a new two-factor setup for an account, replacing any setup and recovery codes it already had.
+func (m *Memory) AddTwoFactor(twoFactor types.TwoFactor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	if t.accountByID(twoFactor.AccountIdentifier) == nil {
+		return errors.New("unable to add two factor: account not found")
+	}
+	t.deleteTwoFactor(twoFactor.AccountIdentifier)
+	t.twoFactors = append(t.twoFactors, &twoFactorRow{
+		accountID: twoFactor.AccountIdentifier,
+		secret:    twoFactor.Secret,
+		enabled:   twoFactor.Enabled,
+		lastStep:  twoFactor.LastStep,
+	})
+	return nil
+}
+
+// EnableTwoFactor Turns on the two-factor setup for an account once a code for the given step
+// has been verified, replacing its recovery codes.
+func (m *Memory) EnableTwoFactor(account, step int64, recoveryCodes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	f := t.twoFactor(account)
+	if f == nil || f.enabled {
+		return fmt.Errorf("error enabling two factor, rows affected: %v", 0)
+	}
+	err = t.replaceRecoveryCodes(account, recoveryCodes)
+	if err != nil {
+		return err
+	}
+	f.enabled = true
+	f.lastStep = step
+	return nil
+}
+
+// SetRecoveryCodes Replaces the recovery codes for an account.
+func (m *Memory) SetRecoveryCodes(account int64, recoveryCodes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	return t.replaceRecoveryCodes(account, recoveryCodes)
+}
+
+// UseTwoFactorStep Records the step of a TOTP code used on an account. Returns false if a code from
+// the same or a later step has already been used, so each code only works once.
+func (m *Memory) UseTwoFactorStep(account, step int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return false, err
+	}
+	f := t.twoFactor(account)
+	if f == nil || !f.enabled || f.lastStep >= step {
+		return false, nil
+	}
+	f.lastStep = step
+	return true, nil
+}
+
+// UseRecoveryCode Removes a recovery code from an account. Returns false if the account doesn't have the code.
+func (m *Memory) UseRecoveryCode(account int64, code string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return false, err
+	}
+	hash := types.HashKey(code)
+	match := func(c *recoveryCodeRow) bool {
+		return c.accountID == account && c.hash == hash
+	}
+	rows := countRows(t.recoveryCodes, match)
+	t.recoveryCodes = deleteRows(t.recoveryCodes, match)
+	return rows == 1, nil
+}
+
+// DeleteTwoFactor Removes the two-factor setup and recovery codes from an account.
+func (m *Memory) DeleteTwoFactor(account int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	t.deleteTwoFactor(account)
+	return nil
+}
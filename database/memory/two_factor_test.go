@@ -0,0 +1,167 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"testing"
+)
+
+func TestTwoFactor(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	twoFactor, err := db.GetTwoFactor(account1.Identifier)
+	if err != nil || twoFactor != nil {
+		t.Fatalf("Expected no two factor, found %+v (%v).", twoFactor, err)
+	}
+	err = db.AddTwoFactor(types.TwoFactor{
+		AccountIdentifier: account1.Identifier,
+		Secret:            "secret1",
+	})
+	if err != nil {
+		t.Fatalf("Error adding two factor: %v", err)
+	}
+	// Codes can't be used until two factor is enabled.
+	used, err := db.UseTwoFactorStep(account1.Identifier, 10)
+	if err != nil || used {
+		t.Errorf("Expected step to be unusable before enabling, found %v (%v).", used, err)
+	}
+	// A new setup replaces the old one.
+	err = db.AddTwoFactor(types.TwoFactor{
+		AccountIdentifier: account1.Identifier,
+		Secret:            "secret2",
+	})
+	if err != nil {
+		t.Fatalf("Error adding two factor: %v", err)
+	}
+	twoFactor, err = db.GetTwoFactor(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting two factor: %v", err)
+	}
+	if twoFactor == nil || twoFactor.Secret != "secret2" || twoFactor.Enabled || twoFactor.RecoveryCodes != 0 {
+		t.Fatalf("Expected pending two factor with secret %v, found %+v.", "secret2", twoFactor)
+	}
+	err = db.EnableTwoFactor(account1.Identifier, 10, []string{"code1", "code2", "code3"})
+	if err != nil {
+		t.Fatalf("Error enabling two factor: %v", err)
+	}
+	err = db.EnableTwoFactor(account1.Identifier, 11, []string{"code4"})
+	if err == nil {
+		t.Error("Expected error enabling two factor twice.")
+	}
+	err = db.EnableTwoFactor(account2.Identifier, 10, []string{"code1"})
+	if err == nil {
+		t.Error("Expected error enabling two factor that wasn't added.")
+	}
+	twoFactor, err = db.GetTwoFactor(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting two factor: %v", err)
+	}
+	if !twoFactor.Enabled || twoFactor.LastStep != 10 || twoFactor.RecoveryCodes != 3 {
+		t.Errorf("Expected enabled two factor at step %v with %v recovery codes, found %+v.", 10, 3, twoFactor)
+	}
+	// Steps can only be used once and never go backwards.
+	used, err = db.UseTwoFactorStep(account1.Identifier, 10)
+	if err != nil || used {
+		t.Errorf("Expected used step to be unusable, found %v (%v).", used, err)
+	}
+	used, err = db.UseTwoFactorStep(account1.Identifier, 12)
+	if err != nil || !used {
+		t.Errorf("Expected new step to be usable, found %v (%v).", used, err)
+	}
+	used, err = db.UseTwoFactorStep(account1.Identifier, 11)
+	if err != nil || used {
+		t.Errorf("Expected earlier step to be unusable, found %v (%v).", used, err)
+	}
+	// Recovery codes can only be used once and only on their own account.
+	used, err = db.UseRecoveryCode(account2.Identifier, "code1")
+	if err != nil || used {
+		t.Errorf("Expected recovery code to be unusable on another account, found %v (%v).", used, err)
+	}
+	used, err = db.UseRecoveryCode(account1.Identifier, "code1")
+	if err != nil || !used {
+		t.Errorf("Expected recovery code to be usable, found %v (%v).", used, err)
+	}
+	used, err = db.UseRecoveryCode(account1.Identifier, "code1")
+	if err != nil || used {
+		t.Errorf("Expected used recovery code to be unusable, found %v (%v).", used, err)
+	}
+	twoFactor, _ = db.GetTwoFactor(account1.Identifier)
+	if twoFactor.RecoveryCodes != 2 {
+		t.Errorf("Expected %v recovery codes, found %v.", 2, twoFactor.RecoveryCodes)
+	}
+	err = db.SetRecoveryCodes(account1.Identifier, []string{"code5"})
+	if err != nil {
+		t.Fatalf("Error setting recovery codes: %v", err)
+	}
+	used, err = db.UseRecoveryCode(account1.Identifier, "code2")
+	if err != nil || used {
+		t.Errorf("Expected replaced recovery code to be unusable, found %v (%v).", used, err)
+	}
+	used, err = db.UseRecoveryCode(account1.Identifier, "code5")
+	if err != nil || !used {
+		t.Errorf("Expected new recovery code to be usable, found %v (%v).", used, err)
+	}
+	err = db.DeleteTwoFactor(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting two factor: %v", err)
+	}
+	twoFactor, err = db.GetTwoFactor(account1.Identifier)
+	if err != nil || twoFactor != nil {
+		t.Errorf("Expected no two factor after delete, found %+v (%v).", twoFactor, err)
+	}
+}
+
+func TestNoDatabaseTwoFactor(t *testing.T) {
+	db := Memory{}
+	_, err := db.GetTwoFactor(0)
+	if err == nil {
+		t.Fatal("Expected error getting two factor.")
+	}
+	err = db.AddTwoFactor(types.TwoFactor{})
+	if err == nil {
+		t.Fatal("Expected error adding two factor.")
+	}
+	err = db.EnableTwoFactor(0, 0, nil)
+	if err == nil {
+		t.Fatal("Expected error enabling two factor.")
+	}
+	err = db.SetRecoveryCodes(0, nil)
+	if err == nil {
+		t.Fatal("Expected error setting recovery codes.")
+	}
+	_, err = db.UseTwoFactorStep(0, 0)
+	if err == nil {
+		t.Fatal("Expected error using two factor step.")
+	}
+	_, err = db.UseRecoveryCode(0, "")
+	if err == nil {
+		t.Fatal("Expected error using recovery code.")
+	}
+	err = db.DeleteTwoFactor(0)
+	if err == nil {
+		t.Fatal("Expected error deleting two factor.")
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"chronokeep/remote/types"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type webhookRow struct {
+	id        int64
+	accountID int64
+	orgID     *int64
+	url       string
+	secret    string
+	events    string
+	createdAt time.Time
+}
+
+type deliveryRow struct {
+	id           int64
+	webhookID    int64
+	event        string
+	payload      string
+	status       string
+	attempts     int
+	nextAttempt  int64
+	responseCode int
+	errorMessage string
+	claimedUntil int64
+	createdAt    time.Time
+	deliveredAt  *time.Time
+}
+
+// webhook Returns a webhook by its identifier.
+func (t *tables) webhook(id int64) *webhookRow {
+	for _, w := range t.webhooks {
+		if w.id == id {
+			return w
+		}
+	}
+	return nil
+}
+
+// AddWebhook Adds a webhook to an account.
+func (m *Memory) AddWebhook(webhook types.Webhook) (*types.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	if t.accountByID(webhook.AccountIdentifier) == nil {
+		return nil, errors.New("unable to add webhook: account not found")
+	}
+	webhook.Identifier = t.nextID("webhook")
+	webhook.CreatedAt = currentTime()
+	t.webhooks = append(t.webhooks, &webhookRow{
+		id:        webhook.Identifier,
+		accountID: webhook.AccountIdentifier,
+		orgID:     copyID(webhook.Organization),
+		url:       webhook.URL,
+		secret:    webhook.Secret,
+		events:    webhook.EventsValue(),
+		createdAt: webhook.CreatedAt,
+	})
+	return &webhook, nil
+}
+
+// GetAccountWebhooks Gets all webhooks for an account, secrets included.
+func (m *Memory) GetAccountWebhooks(account int64) ([]types.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	var outWebhooks []types.Webhook
+	for _, w := range t.webhooks {
+		if w.accountID != account {
+			continue
+		}
+		webhook := types.Webhook{
+			Identifier:        w.id,
+			AccountIdentifier: w.accountID,
+			Organization:      copyID(w.orgID),
+			URL:               w.url,
+			Secret:            w.secret,
+			CreatedAt:         w.createdAt,
+		}
+		webhook.SetEvents(w.events)
+		outWebhooks = append(outWebhooks, webhook)
+	}
+	return outWebhooks, nil
+}
+
+// DeleteWebhook Deletes a webhook belonging to an account along with its delivery log.
+func (m *Memory) DeleteWebhook(account, webhook int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	w := t.webhook(webhook)
+	if w == nil || w.accountID != account {
+		return fmt.Errorf("error deleting webhook, rows affected: %v", 0)
+	}
+	t.deliveries = deleteRows(t.deliveries, func(d *deliveryRow) bool {
+		return d.webhookID == webhook
+	})
+	t.webhooks = deleteRows(t.webhooks, func(o *webhookRow) bool {
+		return o == w
+	})
+	return nil
+}
+
+// AddWebhookDeliveries Queues deliveries to be sent.
+func (m *Memory) AddWebhookDeliveries(deliveries []types.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	// Every delivery is checked first so none are added if one of them can't be.
+	for _, delivery := range deliveries {
+		if t.webhook(delivery.WebhookIdentifier) == nil {
+			return errors.New("error adding webhook delivery: webhook not found")
+		}
+	}
+	createdAt := currentTime()
+	for _, delivery := range deliveries {
+		t.deliveries = append(t.deliveries, &deliveryRow{
+			id:          t.nextID("webhook_delivery"),
+			webhookID:   delivery.WebhookIdentifier,
+			event:       delivery.Event,
+			payload:     delivery.Payload,
+			status:      types.DeliveryPending,
+			nextAttempt: delivery.NextAttempt.Unix(),
+			createdAt:   createdAt,
+		})
+	}
+	return nil
+}
+
+// webhookDeliveries Returns the deliveries that match along with the url and secret of their webhook.
+func (t *tables) webhookDeliveries(match func(d *deliveryRow, w *webhookRow) bool) []types.WebhookDelivery {
+	var outDeliveries []types.WebhookDelivery
+	for _, d := range t.deliveries {
+		w := t.webhook(d.webhookID)
+		if w == nil || !match(d, w) {
+			continue
+		}
+		outDeliveries = append(outDeliveries, types.WebhookDelivery{
+			Identifier:        d.id,
+			WebhookIdentifier: d.webhookID,
+			URL:               w.url,
+			Secret:            w.secret,
+			Event:             d.event,
+			Payload:           d.payload,
+			Status:            d.status,
+			Attempts:          d.attempts,
+			NextAttempt:       time.Unix(d.nextAttempt, 0),
+			ResponseCode:      d.responseCode,
+			Error:             d.errorMessage,
+			CreatedAt:         d.createdAt,
+			DeliveredAt:       copyTime(d.deliveredAt),
+		})
+	}
+	return outDeliveries
+}
+
+// limitRows Returns up to limit rows. Like a query's limit, a negative limit returns every row.
+func limitRows[T any](rows []T, limit int) []T {
+	if limit >= 0 && len(rows) > limit {
+		return rows[:limit]
+	}
+	return rows
+}
+
+// ClaimWebhookDeliveries Claims up to limit deliveries due to be sent at or before now, oldest first, so no
+// other instance sends them before until. Deliveries claimed by another instance are skipped until their claim
+// runs out. Recording the result of an attempt releases the claim.
+func (m *Memory) ClaimWebhookDeliveries(now, until time.Time, limit int) ([]types.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	outDeliveries := t.webhookDeliveries(func(d *deliveryRow, w *webhookRow) bool {
+		return d.status == types.DeliveryPending && d.nextAttempt <= now.Unix() && d.claimedUntil <= now.Unix()
+	})
+	slices.SortFunc(outDeliveries, func(a, b types.WebhookDelivery) int {
+		if c := cmp.Compare(a.NextAttempt.Unix(), b.NextAttempt.Unix()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Identifier, b.Identifier)
+	})
+	outDeliveries = limitRows(outDeliveries, limit)
+	for _, delivery := range outDeliveries {
+		for _, d := range t.deliveries {
+			if d.id == delivery.Identifier {
+				d.claimedUntil = until.Unix()
+			}
+		}
+	}
+	return outDeliveries, nil
+}
+
+// GetWebhookDeliveries Gets the delivery log for a webhook belonging to an account, newest first.
+func (m *Memory) GetWebhookDeliveries(account, webhook int64, limit int) ([]types.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return nil, err
+	}
+	outDeliveries := t.webhookDeliveries(func(d *deliveryRow, w *webhookRow) bool {
+		return w.accountID == account && d.webhookID == webhook
+	})
+	slices.Reverse(outDeliveries)
+	return limitRows(outDeliveries, limit), nil
+}
+
+// UpdateWebhookDelivery Records the result of an attempt to send a delivery.
+func (m *Memory) UpdateWebhookDelivery(delivery types.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.getTables()
+	if err != nil {
+		return err
+	}
+	for _, d := range t.deliveries {
+		if d.id == delivery.Identifier {
+			d.status = delivery.Status
+			d.attempts = delivery.Attempts
+			d.nextAttempt = delivery.NextAttempt.Unix()
+			d.responseCode = delivery.ResponseCode
+			d.errorMessage = delivery.Error
+			d.deliveredAt = copyTime(delivery.DeliveredAt)
+			d.claimedUntil = 0
+			return nil
+		}
+	}
+	return fmt.Errorf("error updating webhook delivery, rows affected: %v", 0)
+}
@@ -0,0 +1,112 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"chronokeep/remote/types"
+)
+
+// Migration is a single change to a database's schema. Up moves the schema from the version before it
+// to Version and Down moves it back again. A migration without a Down can't be reversed.
+type Migration[T any] struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, tx T) error
+	Down    func(ctx context.Context, tx T) error
+}
+
+// PlanMigrations Returns the steps needed to move a schema from one version to another. Upgrades run
+// migrations oldest first and downgrades reverse them newest first.
+func PlanMigrations[T any](migrations []Migration[T], from, to int) (*types.MigrationPlan, error) {
+	byVersion := make(map[int]Migration[T])
+	latest := 1
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+		latest = max(latest, migration.Version)
+	}
+	if to < 1 || to > latest {
+		return nil, fmt.Errorf("unknown database version %d, versions run from 1 to %d", to, latest)
+	}
+	if from < 1 || from > latest {
+		return nil, fmt.Errorf("database is at unknown version %d, versions run from 1 to %d", from, latest)
+	}
+	plan := &types.MigrationPlan{
+		From:  from,
+		To:    to,
+		Steps: make([]types.MigrationStep, 0),
+	}
+	for version := from + 1; version <= to; version++ {
+		migration, ok := byVersion[version]
+		if !ok || migration.Up == nil {
+			return nil, fmt.Errorf("no migration to version %d", version)
+		}
+		plan.Steps = append(plan.Steps, types.MigrationStep{
+			Version:   version,
+			Name:      migration.Name,
+			Direction: types.MigrationUp,
+		})
+	}
+	for version := from; version > to; version-- {
+		migration, ok := byVersion[version]
+		if !ok || migration.Down == nil {
+			return nil, fmt.Errorf("migration to version %d (%s) can't be reversed", version, migration.Name)
+		}
+		plan.Steps = append(plan.Steps, types.MigrationStep{
+			Version:   version,
+			Name:      migration.Name,
+			Direction: types.MigrationDown,
+		})
+	}
+	return plan, nil
+}
+
+// RunMigrations Runs each step of a plan with the given transaction. Record is called after every step
+// so it can be added to the migration history.
+func RunMigrations[T any](
+	ctx context.Context,
+	tx T,
+	migrations []Migration[T],
+	plan *types.MigrationPlan,
+	record func(step types.MigrationStep) error,
+) error {
+	byVersion := make(map[int]Migration[T])
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+	for _, step := range plan.Steps {
+		migration := byVersion[step.Version]
+		run := migration.Up
+		if step.Direction == types.MigrationDown {
+			run = migration.Down
+		}
+		if run == nil {
+			return fmt.Errorf("no %s migration for version %d", step.Direction, step.Version)
+		}
+		if err := run(ctx, tx); err != nil {
+			return fmt.Errorf("error running %s migration for version %d (%s): %v", step.Direction, step.Version, step.Name, err)
+		}
+		if err := record(step); err != nil {
+			return fmt.Errorf("error recording migration for version %d: %v", step.Version, err)
+		}
+	}
+	return nil
+}
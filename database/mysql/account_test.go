@@ -20,7 +20,10 @@ package mysql
 
 import (
 	"chronokeep/remote/auth"
+	"chronokeep/remote/database"
 	"chronokeep/remote/types"
+	"chronokeep/remote/util"
+	"errors"
 	"testing"
 	"time"
 )
@@ -491,6 +494,256 @@ func TestGetDeletedAccount(t *testing.T) {
 	}
 }
 
+func TestRestoreAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	keys := []types.Key{
+		{
+			AccountIdentifier: nAccount.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: nAccount.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	// Keys deleted before the account stay deleted when it's restored.
+	err = db.DeleteKey(keys[0])
+	if err != nil {
+		t.Fatalf("Error deleting key: %v", err)
+	}
+	time.Sleep(time.Millisecond * 5)
+	err = db.DeleteAccount(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting account: %v", err)
+	}
+	deleted, err := db.GetDeletedAccounts()
+	if err != nil {
+		t.Fatalf("Error getting deleted accounts: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Email != nAccount.Email || deleted[0].DeletedAt == nil {
+		t.Errorf("Expected deleted account with its deletion time, found %+v.", deleted)
+	}
+	// A deleted account keeps its email, so no other account can take it before it's restored.
+	_, err = db.AddAccount(types.Account{
+		Name:     "Jane Smith",
+		Email:    nAccount.Email,
+		Type:     types.RoleFree,
+		Password: accounts[1].Password,
+	})
+	if err == nil {
+		t.Error("Expected error adding an account with a deleted account's email.")
+	}
+	oAccount, err := db.AddAccount(accounts[1])
+	if err != nil {
+		t.Fatalf("Error adding account: %v", err)
+	}
+	err = db.ChangeEmail(oAccount.Email, nAccount.Email)
+	if err == nil {
+		t.Error("Expected error changing an email to a deleted account's email.")
+	}
+	err = db.RestoreAccount(nAccount.Identifier)
+	if err != nil {
+		t.Fatalf("Error restoring account: %v", err)
+	}
+	account, _ := db.GetAccount(nAccount.Email)
+	if account == nil || account.DeletedAt != nil {
+		t.Errorf("Expected account to be restored, found %+v.", account)
+	}
+	restoredKeys, _ := db.GetAccountKeys(nAccount.Email)
+	if len(restoredKeys) != 1 || restoredKeys[0].Name != "reader2" {
+		t.Errorf("Expected the key deleted with the account to be restored, found %+v.", restoredKeys)
+	}
+	deletedKeys, _ := db.GetDeletedKeys(nAccount.Identifier)
+	if len(deletedKeys) != 1 || deletedKeys[0].Name != "reader1" || deletedKeys[0].DeletedAt == nil {
+		t.Errorf("Expected the key deleted before the account to stay deleted, found %+v.", deletedKeys)
+	}
+	deleted, _ = db.GetDeletedAccounts()
+	if len(deleted) != 0 {
+		t.Errorf("Expected no deleted accounts, found %+v.", deleted)
+	}
+	err = db.RestoreAccount(nAccount.Identifier)
+	if err == nil {
+		t.Error("Expected error restoring an account that isn't deleted.")
+	}
+}
+
+func TestPurgeAccount(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-K2389A-33123B",
+			Type:              "write",
+			Name:              "reader3",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	db.AddKey(keys[2])
+	db.DeleteKey(keys[1])
+	now := time.Now().Unix()
+	reads := []types.Read{
+		{
+			Identifier:   "1",
+			Seconds:      now,
+			Milliseconds: 100,
+			IdentType:    "chip",
+			Type:         "reader",
+			Antenna:      1,
+			Reader:       "test",
+			RSSI:         "-50",
+		},
+		{
+			Identifier:   "2",
+			Seconds:      now + 10,
+			Milliseconds: 200,
+			IdentType:    "chip",
+			Type:         "reader",
+			Antenna:      1,
+			Reader:       "test",
+			RSSI:         "-50",
+		},
+	}
+	db.AddReads(keys[0].Value, reads)
+	db.AddReads(keys[2].Value, reads[0:1])
+	db.SaveNotification(&types.RequestNotification{
+		Type: "UPS_CONNECTED",
+		When: time.Now().UTC().Format(time.RFC3339),
+	}, keys[0].Value)
+	db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token1", RefreshToken: "refresh1"})
+	db.AddSession(types.Session{AccountIdentifier: account2.Identifier, Token: "token2", RefreshToken: "refresh2"})
+	webhook, _ := db.AddWebhook(types.Webhook{
+		AccountIdentifier: account1.Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret1",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	db.AddWebhookDeliveries([]types.WebhookDelivery{
+		{
+			WebhookIdentifier: webhook.Identifier,
+			Event:             types.WebhookReadsAdded,
+			Payload:           "{}",
+			NextAttempt:       time.Now(),
+		},
+	})
+	org, _ := db.AddOrganization(types.Organization{
+		AccountIdentifier: account2.Identifier,
+		Name:              "Timing Company",
+	})
+	db.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      account1.Identifier,
+		Role:                   types.OrgRoleViewer,
+	})
+	_, err = db.PurgeAccount(account2.Identifier, true)
+	if !errors.Is(err, database.ErrOwnsOrganization) {
+		t.Errorf("Expected error purging an account that owns an organization, found %v.", err)
+	}
+	_, err = db.PurgeAccount(account2.Identifier, false)
+	if !errors.Is(err, database.ErrOwnsOrganization) {
+		t.Errorf("Expected error purging an account that owns an organization, found %v.", err)
+	}
+	account, _ := db.GetAccount(account2.Email)
+	if account == nil {
+		t.Fatal("Expected an account that owns an organization to remain.")
+	}
+	expected := types.PurgeCounts{
+		Accounts:          1,
+		Keys:              2,
+		Reads:             2,
+		Notifications:     1,
+		Sessions:          1,
+		Webhooks:          1,
+		WebhookDeliveries: 1,
+		Memberships:       1,
+	}
+	counts, err := db.PurgeAccount(account1.Identifier, true)
+	if err != nil {
+		t.Fatalf("Error on purge account dry run: %v", err)
+	}
+	if *counts != expected {
+		t.Errorf("Expected dry run counts %+v, found %+v.", expected, *counts)
+	}
+	account, _ = db.GetAccount(account1.Email)
+	if account == nil {
+		t.Fatal("Expected account to remain after a dry run.")
+	}
+	found, _ := db.GetReads(account1.Identifier, nil, keys[0].Name, now, now+100)
+	if len(found) != 2 {
+		t.Errorf("Expected %v reads to remain after a dry run, found %v.", 2, len(found))
+	}
+	counts, err = db.PurgeAccount(account1.Identifier, false)
+	if err != nil {
+		t.Fatalf("Error purging account: %v", err)
+	}
+	if *counts != expected {
+		t.Errorf("Expected purge counts %+v, found %+v.", expected, *counts)
+	}
+	account, _ = db.GetAccount(account1.Email)
+	if account != nil {
+		t.Errorf("Expected account to be purged, found %+v.", *account)
+	}
+	account, _ = db.GetDeletedAccount(account1.Email)
+	if account != nil {
+		t.Errorf("Expected no deleted account after purge, found %+v.", *account)
+	}
+	key, _ := db.GetKey(keys[0].Value)
+	if key != nil {
+		t.Errorf("Expected key to be purged, found %+v.", *key)
+	}
+	found, _ = db.GetReads(account1.Identifier, nil, keys[0].Name, now, now+100)
+	if len(found) != 0 {
+		t.Errorf("Expected reads to be purged, found %v.", len(found))
+	}
+	found, _ = db.GetReads(account2.Identifier, nil, keys[2].Name, now, now+100)
+	if len(found) != 1 {
+		t.Errorf("Expected %v reads for another account to remain, found %v.", 1, len(found))
+	}
+	sessions, _ := db.GetAccountSessions(account2.Identifier)
+	if len(sessions) != 1 {
+		t.Errorf("Expected %v sessions for another account to remain, found %v.", 1, len(sessions))
+	}
+	members, _ := db.GetOrganizationMembers(org.Identifier)
+	if len(members) != 1 || members[0].AccountIdentifier != account2.Identifier {
+		t.Errorf("Expected only the owner to remain in the organization, found %+v.", members)
+	}
+	_, err = db.PurgeAccount(account1.Identifier, true)
+	if err == nil {
+		t.Error("Expected error purging an account that doesn't exist.")
+	}
+}
+
 func TestChangePassword(t *testing.T) {
 	db, finalize, err := setupTests(t)
 	if err != nil {
@@ -512,20 +765,18 @@ func TestChangePassword(t *testing.T) {
 	if err != nil {
 		t.Errorf("password doesn't match: %v", err)
 	}
-	nAccount.Token = "testToken1"
-	nAccount.RefreshToken = "testToken2"
-	_ = db.UpdateTokens(*nAccount)
-	nAccount, _ = db.GetAccount(nAccount.Email)
-	if nAccount.Token == "" || nAccount.RefreshToken == "" {
-		t.Error("Expected tokens to be set.")
+	_, _ = db.AddSession(types.Session{AccountIdentifier: nAccount.Identifier, Token: "testToken1", RefreshToken: "testToken2"})
+	sessions, _ := db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 1 {
+		t.Error("Expected a session to be set.")
 	}
 	err = db.ChangePassword(nAccount.Email, hashPass, true)
 	if err != nil {
 		t.Fatalf("error changing password: %v", err)
 	}
-	nAccount, _ = db.GetAccount(nAccount.Email)
-	if nAccount.Token != "" || nAccount.RefreshToken != "" {
-		t.Errorf("Expected tokens not to be set. Found Token %v and Refresh Token %v.", nAccount.Token, nAccount.RefreshToken)
+	sessions, _ = db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 0 {
+		t.Errorf("Expected sessions to be removed. Found %v.", sessions)
 	}
 }
 
@@ -538,12 +789,10 @@ func TestChangeEmail(t *testing.T) {
 	setupAccountTests()
 	nAccount, _ := db.AddAccount(accounts[0])
 	newEmail := "new_email2020@test.com"
-	nAccount.Token = "testToken1"
-	nAccount.RefreshToken = "testToken2"
-	_ = db.UpdateTokens(*nAccount)
-	nAccount, _ = db.GetAccount(nAccount.Email)
-	if nAccount.Token == "" || nAccount.RefreshToken == "" {
-		t.Error("Expected tokens to be set.")
+	_, _ = db.AddSession(types.Session{AccountIdentifier: nAccount.Identifier, Token: "testToken1", RefreshToken: "testToken2"})
+	sessions, _ := db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 1 {
+		t.Error("Expected a session to be set.")
 	}
 	err = db.ChangeEmail(nAccount.Email, newEmail)
 	if err != nil {
@@ -556,8 +805,8 @@ func TestChangeEmail(t *testing.T) {
 	nAccount, _ = db.GetAccount(newEmail)
 	if nAccount == nil {
 		t.Error("account with new email not found")
-	} else if nAccount.Token != "" || nAccount.RefreshToken != "" {
-		t.Errorf("Expected tokens not to be set. Found Token %v and Refresh Token %v.", nAccount.Token, nAccount.RefreshToken)
+	} else if sessions, _ = db.GetAccountSessions(nAccount.Identifier); len(sessions) != 0 {
+		t.Errorf("Expected sessions to be removed. Found %v.", sessions)
 	}
 }
 
@@ -569,14 +818,12 @@ func TestInvalidPassword(t *testing.T) {
 	defer finalize(t)
 	setupAccountTests()
 	nAccount, _ := db.AddAccount(accounts[0])
-	nAccount.Token = "testToken1"
-	nAccount.RefreshToken = "testToken2"
-	_ = db.UpdateTokens(*nAccount)
-	nAccount, _ = db.GetAccount(nAccount.Email)
-	var dAccount *types.Account
-	if nAccount.Token == "" || nAccount.RefreshToken == "" {
-		t.Error("Expected tokens to be set.")
+	_, _ = db.AddSession(types.Session{AccountIdentifier: nAccount.Identifier, Token: "testToken1", RefreshToken: "testToken2"})
+	sessions, _ := db.GetAccountSessions(nAccount.Identifier)
+	if len(sessions) != 1 {
+		t.Error("Expected a session to be set.")
 	}
+	var dAccount *types.Account
 	for i := 1; i <= MaxLoginAttempts+3; i++ {
 		err = db.InvalidPassword(*nAccount)
 		if err != nil {
@@ -585,19 +832,99 @@ func TestInvalidPassword(t *testing.T) {
 		dAccount, _ = db.GetAccount(nAccount.Email)
 		if dAccount.WrongPassAttempts > MaxLoginAttempts && dAccount.Locked == false {
 			t.Errorf("account is not locked after (%v) invalid password attempts; should be after (%v)", i, MaxLoginAttempts+1)
-			if dAccount.Token != "" || dAccount.RefreshToken != "" {
-				t.Errorf("Expected tokens not to be set. Found Token %v and Refresh Token %v.", dAccount.Token, dAccount.RefreshToken)
+			if sessions, _ = db.GetAccountSessions(dAccount.Identifier); len(sessions) != 0 {
+				t.Errorf("Expected sessions to be removed. Found %v.", sessions)
 			}
 		} else if dAccount.WrongPassAttempts <= MaxLoginAttempts && dAccount.Locked == true {
 			t.Errorf("account is locked after (%v) invalid password attempts; should be (%v)", i, MaxLoginAttempts+1)
-			if dAccount.Token == "" || dAccount.RefreshToken == "" {
-				t.Error("Expected tokens to be set.")
+			if sessions, _ = db.GetAccountSessions(dAccount.Identifier); len(sessions) != 1 {
+				t.Error("Expected a session to be set.")
 			}
 		}
 		if dAccount.WrongPassAttempts != i {
 			t.Errorf("wrong password attempts set to %v, should be %v", dAccount.WrongPassAttempts, i)
 		}
 	}
+	if sessions, _ = db.GetAccountSessions(nAccount.Identifier); len(sessions) != 0 {
+		t.Errorf("Expected sessions to be removed once the account was locked. Found %v.", sessions)
+	}
+	// Without the backoff policy the lock lasts until the account is unlocked.
+	if dAccount.LockReason != types.LockReasonWrongPassword || dAccount.LockedUntil != nil {
+		t.Errorf("Expected a lock without an expiry, found %+v.", dAccount)
+	}
+}
+
+func TestInvalidPasswordBackoff(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	db.config.LockoutPolicy = util.LockoutBackoff
+	db.config.LockoutMinutes = 5
+	db.config.LockoutMaxMinutes = 15
+	defer func() {
+		db.config.LockoutPolicy = ""
+		db.config.LockoutMinutes = 0
+		db.config.LockoutMaxMinutes = 0
+	}()
+	setupAccountTests()
+	nAccount, _ := db.AddAccount(accounts[0])
+	con, _ := db.GetDB()
+	// Each lock lasts twice as long as the one before it, up to the maximum.
+	for lock, minutes := range []int{5, 10, 15, 15} {
+		for i := 1; i <= MaxLoginAttempts+1; i++ {
+			err = db.InvalidPassword(*nAccount)
+			if err != nil {
+				t.Fatalf("(%v) error telling the database about an invalid password: %v", i, err)
+			}
+		}
+		dAccount, _ := db.GetAccount(nAccount.Email)
+		if !dAccount.Locked || dAccount.LockReason != types.LockReasonWrongPassword || dAccount.LockedUntil == nil {
+			t.Fatalf("(%v) Expected account to be locked until a set time, found %+v.", lock, dAccount)
+		}
+		expected := time.Now().Add(time.Duration(minutes) * time.Minute)
+		if dAccount.LockedUntil.Before(expected.Add(-time.Minute)) || dAccount.LockedUntil.After(expected) {
+			t.Errorf("(%v) Expected account to be locked for %v minutes, locked until %v.", lock, minutes, dAccount.LockedUntil)
+		}
+		if dAccount.WrongPassAttempts != 0 || dAccount.LockCount != lock+1 {
+			t.Errorf("(%v) Expected wrong passwords to start over and the lock to be counted, found %+v.", lock, dAccount)
+		}
+		err = db.ValidPassword(*dAccount)
+		if err == nil {
+			t.Errorf("(%v) Expected an error on valid password attempt for locked account.", lock)
+		}
+		// Expire the lock.
+		_, err = con.Exec("UPDATE account SET account_locked_until=? WHERE account_id=?;", time.Now().Add(-time.Second).Unix(), nAccount.Identifier)
+		if err != nil {
+			t.Fatalf("Error expiring lock: %v", err)
+		}
+		dAccount, _ = db.GetAccount(nAccount.Email)
+		if dAccount.Locked || dAccount.LockReason != "" || dAccount.LockedUntil != nil {
+			t.Errorf("(%v) Expected account to be unlocked once the lock expired, found %+v.", lock, dAccount)
+		}
+	}
+	err = db.ValidPassword(*nAccount)
+	if err != nil {
+		t.Fatalf("Valid password threw an error: %v", err)
+	}
+	dAccount, _ := db.GetAccount(nAccount.Email)
+	if dAccount.Locked || dAccount.LockCount != 0 {
+		t.Errorf("Expected a valid password to reset the lock count, found %+v.", dAccount)
+	}
+	// Unexpired locks can still be lifted by an admin.
+	for i := 1; i <= MaxLoginAttempts+1; i++ {
+		db.InvalidPassword(*nAccount)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	err = db.UnlockAccount(*dAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error on unlock account: %v", err)
+	}
+	dAccount, _ = db.GetAccount(nAccount.Email)
+	if dAccount.Locked || dAccount.LockedUntil != nil || dAccount.LockCount != 0 {
+		t.Errorf("Expected account to be unlocked, found %+v.", dAccount)
+	}
 }
 
 func TestGetAccountByKey(t *testing.T) {
@@ -688,30 +1015,6 @@ func TestGetAccountByID(t *testing.T) {
 	}
 }
 
-func TestUpdateTokens(t *testing.T) {
-	db, finalize, err := setupTests(t)
-	if err != nil {
-		t.Fatalf("setup error: %v", err)
-	}
-	defer finalize(t)
-	setupAccountTests()
-	oAccount := accounts[0]
-	nAccount, _ := db.AddAccount(oAccount)
-	nAccount.Token = "testtoken1"
-	nAccount.RefreshToken = "refreshtoken1"
-	err = db.UpdateTokens(*nAccount)
-	if err != nil {
-		t.Fatalf("Error updating tokens: %v", err)
-	}
-	dAccount, _ := db.GetAccount(nAccount.Email)
-	if dAccount.Token != nAccount.Token {
-		t.Errorf("Expected token %v, found %v.", nAccount.Token, dAccount.Token)
-	}
-	if dAccount.RefreshToken != nAccount.RefreshToken {
-		t.Errorf("Expected refresh token %v, found %v.", nAccount.RefreshToken, dAccount.RefreshToken)
-	}
-}
-
 func TestValidPassword(t *testing.T) {
 	db, finalize, err := setupTests(t)
 	if err != nil {
@@ -830,6 +1133,18 @@ func TestBadDatabaseAccount(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Expected error getting deleted account.")
 	}
+	_, err = db.GetDeletedAccounts()
+	if err == nil {
+		t.Fatalf("Expected error getting deleted accounts.")
+	}
+	err = db.RestoreAccount(0)
+	if err == nil {
+		t.Fatalf("Expected error restoring account.")
+	}
+	_, err = db.PurgeAccount(0, false)
+	if err == nil {
+		t.Fatalf("Expected error purging account.")
+	}
 	err = db.UpdateAccount(types.Account{})
 	if err == nil {
 		t.Fatalf("Expected error updating account.")
@@ -838,10 +1153,6 @@ func TestBadDatabaseAccount(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Expected error changing password.")
 	}
-	err = db.UpdateTokens(types.Account{})
-	if err == nil {
-		t.Fatalf("Expected error updating tokens.")
-	}
 	err = db.ChangeEmail("", "")
 	if err == nil {
 		t.Fatalf("Expected error changing email.")
@@ -899,6 +1210,18 @@ func TestNoDatabaseAccount(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Expected error getting deleted account.")
 	}
+	_, err = db.GetDeletedAccounts()
+	if err == nil {
+		t.Fatalf("Expected error getting deleted accounts.")
+	}
+	err = db.RestoreAccount(0)
+	if err == nil {
+		t.Fatalf("Expected error restoring account.")
+	}
+	_, err = db.PurgeAccount(0, false)
+	if err == nil {
+		t.Fatalf("Expected error purging account.")
+	}
 	err = db.UpdateAccount(types.Account{})
 	if err == nil {
 		t.Fatalf("Expected error updating account.")
@@ -907,10 +1230,6 @@ func TestNoDatabaseAccount(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Expected error changing password.")
 	}
-	err = db.UpdateTokens(types.Account{})
-	if err == nil {
-		t.Fatalf("Expected error updating tokens.")
-	}
 	err = db.ChangeEmail("", "")
 	if err == nil {
 		t.Fatalf("Expected error changing email.")
@@ -99,13 +99,26 @@ func (m *MySQL) Setup(config *util.Config) error {
 		return fmt.Errorf("error connecting to database; %v", err)
 	}
 
+	// Other instances may be starting against the same database, only one of them should create or update it.
+	unlock, err := m.lockMigrations()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = m.createMigrationTable()
+	if err != nil {
+		return err
+	}
+
 	dbVersion := m.checkVersion()
 
 	if dbVersion < 1 {
 		return m.createTables()
 	} else if dbVersion < database.CurrentVersion {
 		log.Info(fmt.Sprintf("Updating database from verison %v to %v", dbVersion, database.CurrentVersion))
-		return m.updateTables(dbVersion, database.CurrentVersion)
+		_, err = m.migrate(database.CurrentVersion, false)
+		return err
 	}
 
 	// Check if there's an account created.
@@ -114,15 +127,16 @@ func (m *MySQL) Setup(config *util.Config) error {
 		return fmt.Errorf("error checking for account: %v", err)
 	}
 	if len(accounts) < 1 {
-		log.Info("Creating admin user.")
 		if config.AdminName == "" || config.AdminEmail == "" || config.AdminPass == "" {
-			return errors.New("admin account doesn't exist and proper credentions have not been supplied")
+			log.Warn("Admin account doesn't exist and proper credentials have not been supplied, one can be added with the account create command.")
+			return nil
 		}
+		log.Info("Creating admin user.")
 		acc := types.Account{
 			Name:     config.AdminName,
 			Email:    config.AdminEmail,
 			Password: config.AdminPass,
-			Type:     "admin",
+			Type:     types.RoleAdmin,
 		}
 		err = m.validate.Struct(acc)
 		if err != nil {
@@ -149,7 +163,7 @@ func (m *MySQL) dropTables() error {
 	defer cancelfunc()
 	_, err = db.ExecContext(
 		ctx,
-		"DROP TABLE notification, a_read, api_key, settings, account;",
+		"DROP TABLE account_role, org_member, organization, login_failure, recovery_code, two_factor, password_reset_request, password_reset, alert_rule, webhook_delivery, webhook, notification, a_read, api_key, account_session, settings, account, schema_migration;",
 	)
 	if err != nil {
 		return fmt.Errorf("error dropping tables: %v", err)
@@ -176,6 +190,33 @@ func (m *MySQL) SetSetting(name, value string) error {
 	return nil
 }
 
+// GetSetting Returns the value of a setting, or an empty string if it hasn't been set.
+func (m *MySQL) GetSetting(name string) (string, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT value FROM settings WHERE name=?;",
+		name,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving settings value: %v", err)
+	}
+	defer res.Close()
+	var value string
+	if res.Next() {
+		err := res.Scan(&value)
+		if err != nil {
+			return "", fmt.Errorf("error getting settings value: %v", err)
+		}
+	}
+	return value, nil
+}
+
 type myQuery struct {
 	name  string
 	query string
@@ -204,11 +245,13 @@ func (m *MySQL) createTables() error {
 				"account_type VARCHAR(20) NOT NULL, " +
 				"account_wrong_pass INT NOT NULL DEFAULT 0, " +
 				"account_locked BOOL DEFAULT FALSE, " +
-				"account_token VARCHAR(1000) NOT NULL DEFAULT '', " +
-				"account_refresh_token VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"account_lock_reason VARCHAR(100) NOT NULL DEFAULT '', " +
+				"account_locked_until BIGINT NOT NULL DEFAULT 0, " +
+				"account_lock_count INT NOT NULL DEFAULT 0, " +
 				"account_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
 				"account_updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP," +
 				"account_deleted BOOL DEFAULT FALSE, " +
+				"account_deleted_at BIGINT NOT NULL DEFAULT 0, " +
 				"UNIQUE(account_email), " +
 				"PRIMARY KEY (account_id)" +
 				");",
@@ -217,24 +260,34 @@ func (m *MySQL) createTables() error {
 		{
 			name: "KeyTable",
 			query: "CREATE TABLE IF NOT EXISTS api_key(" +
+				"key_id BIGINT NOT NULL AUTO_INCREMENT, " +
 				"account_id BIGINT NOT NULL, " +
+				"org_id BIGINT DEFAULT NULL, " +
 				"key_name VARCHAR(100) NOT NULL," +
+				"key_prefix VARCHAR(20) NOT NULL DEFAULT '', " +
 				"key_value VARCHAR(100) NOT NULL, " +
 				"key_type VARCHAR(20) NOT NULL, " +
+				"allowed_hosts VARCHAR(1000) NOT NULL DEFAULT '', " +
 				"valid_until DATETIME DEFAULT NULL, " +
+				"old_key_value VARCHAR(100) DEFAULT NULL, " +
+				"old_key_valid_until DATETIME DEFAULT NULL, " +
 				"key_deleted BOOL DEFAULT FALSE, " +
+				"key_deleted_at BIGINT NOT NULL DEFAULT 0, " +
 				"key_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
 				"key_updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, " +
 				"UNIQUE(key_value), " +
+				"UNIQUE(old_key_value), " +
 				"UNIQUE(account_id, key_name)," +
-				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (key_id)" +
 				");",
 		},
 		// READ TABLE
 		{
 			name: "ReadTable",
 			query: "CREATE TABLE IF NOT EXISTS a_read(" +
-				"key_value VARCHAR(100) NOT NULL, " +
+				"read_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"key_id BIGINT NOT NULL, " +
 				"identifier VARCHAR(100) NOT NULL, " +
 				"seconds BIGINT NOT NULL DEFAULT 0, " +
 				"milliseconds INT NOT NULL DEFAULT 0, " +
@@ -244,8 +297,9 @@ func (m *MySQL) createTables() error {
 				"reader VARCHAR(50) NOT NULL DEFAULT '', " +
 				"rssi VARCHAR(10) NOT NULL DEFAULT '', " +
 				"read_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
-				"UNIQUE(key_value, identifier, seconds, milliseconds, ident_type), " +
-				"FOREIGN KEY (key_value) REFERENCES api_key(key_value)" +
+				"UNIQUE(key_id, identifier, seconds, milliseconds, ident_type), " +
+				"FOREIGN KEY (key_id) REFERENCES api_key(key_id), " +
+				"PRIMARY KEY (read_id)" +
 				");",
 		},
 		// NOTIFICATIONS TABLE
@@ -253,15 +307,193 @@ func (m *MySQL) createTables() error {
 			name: "NotificationsTable",
 			query: "CREATE TABLE IF NOT EXISTS notification(" +
 				"notification_id BIGINT NOT NULL AUTO_INCREMENT, " +
-				"key_value VARCHAR(100) NOT NULL, " +
+				"key_id BIGINT NOT NULL, " +
 				"notification_type VARCHAR(100) NOT NULL, " +
 				"notification_when BIGINT NOT NULL, " +
 				"notification_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
-				"UNIQUE(key_value, notification_when), " +
-				"FOREIGN KEY (key_value) REFERENCES api_key(key_value), " +
+				"notification_acknowledged_at DATETIME DEFAULT NULL, " +
+				"UNIQUE(key_id, notification_when), " +
+				"FOREIGN KEY (key_id) REFERENCES api_key(key_id), " +
 				"PRIMARY KEY (notification_id)" +
 				");",
 		},
+		// SESSION TABLE
+		{
+			name: "SessionTable",
+			query: "CREATE TABLE IF NOT EXISTS account_session(" +
+				"session_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"account_id BIGINT NOT NULL, " +
+				"session_token VARCHAR(100) NOT NULL, " +
+				"session_refresh_token VARCHAR(100) NOT NULL, " +
+				"session_user_agent VARCHAR(500) NOT NULL DEFAULT '', " +
+				"session_ip VARCHAR(100) NOT NULL DEFAULT '', " +
+				"session_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"session_last_used DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(session_token), " +
+				"UNIQUE(session_refresh_token), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (session_id)" +
+				");",
+		},
+		// WEBHOOK TABLE
+		{
+			name: "WebhookTable",
+			query: "CREATE TABLE IF NOT EXISTS webhook(" +
+				"webhook_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"account_id BIGINT NOT NULL, " +
+				"org_id BIGINT DEFAULT NULL, " +
+				"webhook_url VARCHAR(500) NOT NULL, " +
+				"webhook_secret VARCHAR(100) NOT NULL, " +
+				"webhook_events VARCHAR(200) NOT NULL DEFAULT '', " +
+				"webhook_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (webhook_id)" +
+				");",
+		},
+		// WEBHOOK DELIVERY TABLE
+		{
+			name: "WebhookDeliveryTable",
+			query: "CREATE TABLE IF NOT EXISTS webhook_delivery(" +
+				"delivery_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"webhook_id BIGINT NOT NULL, " +
+				"delivery_event VARCHAR(50) NOT NULL, " +
+				"delivery_payload MEDIUMTEXT NOT NULL, " +
+				"delivery_status VARCHAR(20) NOT NULL DEFAULT 'pending', " +
+				"delivery_attempts INT NOT NULL DEFAULT 0, " +
+				"delivery_next_attempt BIGINT NOT NULL, " +
+				"delivery_claimed_until BIGINT NOT NULL DEFAULT 0, " +
+				"delivery_response_code INT NOT NULL DEFAULT 0, " +
+				"delivery_error VARCHAR(500) NOT NULL DEFAULT '', " +
+				"delivery_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"delivery_delivered_at DATETIME DEFAULT NULL, " +
+				"FOREIGN KEY (webhook_id) REFERENCES webhook(webhook_id) ON DELETE CASCADE, " +
+				"PRIMARY KEY (delivery_id)" +
+				");",
+		},
+		// WEBHOOK DELIVERY INDEX
+		{
+			name:  "WebhookDeliveryIndex",
+			query: "CREATE INDEX idx_delivery_pending ON webhook_delivery(delivery_status, delivery_next_attempt);",
+		},
+		// ALERT RULE TABLE
+		{
+			name: "AlertRuleTable",
+			query: "CREATE TABLE IF NOT EXISTS alert_rule(" +
+				"rule_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"account_id BIGINT NOT NULL, " +
+				"rule_types VARCHAR(500) NOT NULL, " +
+				"rule_readers VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"rule_recipients VARCHAR(1000) NOT NULL, " +
+				"rule_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (rule_id)" +
+				");",
+		},
+		// PASSWORD RESET TABLE
+		{
+			name: "PasswordResetTable",
+			query: "CREATE TABLE IF NOT EXISTS password_reset(" +
+				"reset_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"account_id BIGINT NOT NULL, " +
+				"reset_token VARCHAR(100) NOT NULL, " +
+				"reset_expires_at BIGINT NOT NULL, " +
+				"reset_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(reset_token), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (reset_id)" +
+				");",
+		},
+		// PASSWORD RESET REQUEST TABLE
+		{
+			name: "PasswordResetRequestTable",
+			query: "CREATE TABLE IF NOT EXISTS password_reset_request(" +
+				"request_key VARCHAR(100) NOT NULL, " +
+				"request_count INT NOT NULL DEFAULT 0, " +
+				"request_window_start BIGINT NOT NULL DEFAULT 0, " +
+				"PRIMARY KEY (request_key)" +
+				");",
+		},
+		// TWO FACTOR TABLE
+		{
+			name: "TwoFactorTable",
+			query: "CREATE TABLE IF NOT EXISTS two_factor(" +
+				"account_id BIGINT NOT NULL, " +
+				"tf_secret VARCHAR(100) NOT NULL, " +
+				"tf_enabled BOOL DEFAULT FALSE, " +
+				"tf_last_step BIGINT NOT NULL DEFAULT 0, " +
+				"tf_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (account_id)" +
+				");",
+		},
+		// RECOVERY CODE TABLE
+		{
+			name: "RecoveryCodeTable",
+			query: "CREATE TABLE IF NOT EXISTS recovery_code(" +
+				"code_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"account_id BIGINT NOT NULL, " +
+				"code_hash VARCHAR(100) NOT NULL, " +
+				"UNIQUE(account_id, code_hash), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (code_id)" +
+				");",
+		},
+		// LOGIN FAILURE TABLE
+		{
+			name: "LoginFailureTable",
+			query: "CREATE TABLE IF NOT EXISTS login_failure(" +
+				"failure_ip VARCHAR(100) NOT NULL, " +
+				"failure_count INT NOT NULL DEFAULT 0, " +
+				"failure_last BIGINT NOT NULL DEFAULT 0, " +
+				"failure_blocked_until BIGINT NOT NULL DEFAULT 0, " +
+				"PRIMARY KEY (failure_ip)" +
+				");",
+		},
+		// ORGANIZATION TABLE
+		{
+			name: "OrganizationTable",
+			query: "CREATE TABLE IF NOT EXISTS organization(" +
+				"org_id BIGINT NOT NULL AUTO_INCREMENT, " +
+				"account_id BIGINT NOT NULL, " +
+				"org_name VARCHAR(100) NOT NULL, " +
+				"org_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(account_id), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (org_id)" +
+				");",
+		},
+		// ORGANIZATION MEMBER TABLE
+		{
+			name: "OrganizationMemberTable",
+			query: "CREATE TABLE IF NOT EXISTS org_member(" +
+				"org_id BIGINT NOT NULL, " +
+				"account_id BIGINT NOT NULL, " +
+				"member_role VARCHAR(20) NOT NULL, " +
+				"member_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (org_id) REFERENCES organization(org_id), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (org_id, account_id)" +
+				");",
+		},
+		// ROLE TABLE
+		{
+			name: "RoleTable",
+			query: "CREATE TABLE IF NOT EXISTS account_role(" +
+				"role_name VARCHAR(20) NOT NULL, " +
+				"role_permissions VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"PRIMARY KEY (role_name)" +
+				");",
+		},
+		// MIGRATION TABLE
+		{
+			name:  "MigrationTable",
+			query: migrationTableQuery,
+		},
+		// READ TIME INDEX
+		{
+			name:  "ReadTimeIndex",
+			query: "CREATE INDEX idx_read_time ON a_read(key_id, seconds, milliseconds, identifier, ident_type);",
+		},
 	}
 
 	if m.db == nil {
@@ -282,6 +514,32 @@ func (m *MySQL) createTables() error {
 			return fmt.Errorf("error executing %s query: %v", single.name, err)
 		}
 	}
+	// Every database starts with the default roles.
+	for _, role := range types.DefaultRoles() {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO account_role(role_name, role_permissions) VALUES (?, ?);",
+			role.Name,
+			role.PermissionsValue(),
+		)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error adding %s role: %v", role.Name, err)
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO schema_migration(migration_version, migration_name, migration_direction, "+
+			"migration_applied_at) VALUES (?, ?, ?, ?);",
+		database.CurrentVersion,
+		"create tables",
+		types.MigrationUp,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error recording table creation: %v", err)
+	}
 	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
@@ -314,54 +572,6 @@ func (m *MySQL) checkVersion() int {
 	return -1
 }
 
-func (m *MySQL) updateTables(oldVersion, newVersion int) error {
-	if m.db == nil {
-		return fmt.Errorf("database not set up")
-	}
-	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancelfunc()
-	tx, err := m.db.Begin()
-	if err != nil {
-		return fmt.Errorf("unable to start transaction: %v", err)
-	}
-	// Update from version 1 to 2
-	if oldVersion < 2 && newVersion >= 2 {
-		log.Debug("Updating to database version 2.")
-		_, err := tx.ExecContext(
-			ctx,
-			"CREATE TABLE IF NOT EXISTS notification("+
-				"notification_id BIGINT NOT NULL AUTO_INCREMENT, "+
-				"key_value VARCHAR(100) NOT NULL, "+
-				"notification_type VARCHAR(100) NOT NULL, "+
-				"notification_when BIGINT NOT NULL, "+
-				"notification_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
-				"UNIQUE(key_value, notification_when), "+
-				"FOREIGN KEY (key_value) REFERENCES api_key(key_value), "+
-				"PRIMARY KEY (notification_id)"+
-				");",
-		)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("error updating from verison %d to %d: %v", oldVersion, newVersion, err)
-		}
-	}
-	_, err = tx.ExecContext(
-		ctx,
-		"UPDATE settings SET value=? WHERE name='version';",
-		strconv.Itoa(newVersion),
-	)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error updating from version %d to %d: %v", oldVersion, newVersion, err)
-	}
-	err = tx.Commit()
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error committing transaction: %v", err)
-	}
-	return nil
-}
-
 func (m *MySQL) updateDB(newdb *sql.DB) {
 	m.db = newdb
 }
@@ -369,4 +579,3 @@ func (m *MySQL) updateDB(newdb *sql.DB) {
 func (m *MySQL) Close() {
 	m.db.Close()
 }
-
@@ -23,10 +23,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
-func (m *MySQL) GetNotification(account int64, reader_name string) (*types.Notification, error) {
+func (m *MySQL) GetNotification(account int64, org *int64, reader_name string) (*types.Notification, error) {
 	db, err := m.GetDB()
 	if err != nil {
 		return nil, err
@@ -36,10 +37,11 @@ func (m *MySQL) GetNotification(account int64, reader_name string) (*types.Notif
 	res, err := db.QueryContext(
 		ctx,
 		"SELECT notification_id, notification_type, notification_when "+
-			"FROM (SELECT MAX(notification_when) AS max_when, key_value AS kv FROM notification GROUP BY key_value) AS b JOIN notification AS n ON b.max_when=n.notification_when AND b.kv=n.key_value "+
+			"FROM (SELECT MAX(notification_when) AS max_when, key_id AS kid FROM notification GROUP BY key_id) AS b JOIN notification AS n ON b.max_when=n.notification_when AND b.kid=n.key_id "+
 			"NATURAL JOIN api_key AS a "+
-			"WHERE a.account_id=? AND a.key_name=? AND n.notification_when>?;",
+			"WHERE a.account_id=? AND a.org_id <=> ? AND a.key_name=? AND n.notification_when>?;",
 		account,
+		org,
 		reader_name,
 		time.Now().Add(time.Minute*-5).Unix(),
 	)
@@ -65,10 +67,10 @@ func (m *MySQL) GetNotification(account int64, reader_name string) (*types.Notif
 	return &out, nil
 }
 
-func (m *MySQL) SaveNotification(notification *types.RequestNotification, key string) error {
+func (m *MySQL) SaveNotification(notification *types.RequestNotification, key string) (bool, error) {
 	db, err := m.GetDB()
 	if err != nil {
-		return err
+		return false, err
 	}
 	valid := false
 	switch notification.Type {
@@ -76,31 +78,141 @@ func (m *MySQL) SaveNotification(notification *types.RequestNotification, key st
 		valid = true
 	}
 	if !valid {
-		return fmt.Errorf("%v is not a valid type", notification.Type)
+		return false, fmt.Errorf("%v is not a valid type", notification.Type)
 	}
 	when, err := time.Parse(time.RFC3339, notification.When)
 	if err != nil {
-		return fmt.Errorf("unable to parse time value: %v", err)
+		return false, fmt.Errorf("unable to parse time value: %v", err)
 	}
 	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"INSERT IGNORE INTO notification(notification_type, notification_when, key_value) VALUES(?, ?, ?);",
+		"INSERT IGNORE INTO notification(notification_type, notification_when, key_id) SELECT ?, ?, key_id "+
+			"FROM api_key WHERE key_value=? OR (old_key_value=? AND old_key_valid_until>?);",
 		notification.Type,
 		when.Unix(),
-		key,
+		types.HashKey(key),
+		types.HashKey(key),
+		time.Now(),
 	)
 	if err != nil {
-		return fmt.Errorf("unable to add notification: %v", err)
+		return false, fmt.Errorf("unable to add notification: %v", err)
 	}
 	rows, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking rows affected: %v", err)
+		return false, fmt.Errorf("error checking rows affected: %v", err)
 	}
-	if rows < 1 {
-		return errors.New("insert appears to be unsuccessful")
+	if rows > 0 {
+		return true, nil
 	}
-	return nil
+	// Nothing is added when the key already has a notification for that time, which is ignored, or when
+	// there's no such key.
+	var found int
+	err = db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM api_key WHERE key_value=? OR (old_key_value=? AND old_key_valid_until>?);",
+		types.HashKey(key),
+		types.HashKey(key),
+		time.Now(),
+	).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("error checking for key: %v", err)
+	}
+	if found < 1 {
+		return false, errors.New("insert appears to be unsuccessful")
+	}
+	return false, nil
+}
+
+// GetNotificationHistory Gets notifications saved between from and to (inclusive), newest first.
+// An empty reader_name returns notifications for all of the account's readers.
+func (m *MySQL) GetNotificationHistory(account int64, org *int64, reader_name string, from, to int64, unacknowledged bool, after *types.NotificationCursor, limit int) ([]types.Notification, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	query := "SELECT n.notification_id, a.key_name, n.notification_type, n.notification_when, n.notification_acknowledged_at " +
+		"FROM notification AS n JOIN api_key AS a ON n.key_id=a.key_id WHERE a.account_id=? AND a.org_id <=> ? AND " +
+		"n.notification_when>=? AND n.notification_when<=? "
+	args := []interface{}{account, org, from, to}
+	if reader_name != "" {
+		query += "AND a.key_name=? "
+		args = append(args, reader_name)
+	}
+	if unacknowledged {
+		query += "AND n.notification_acknowledged_at IS NULL "
+	}
+	if after != nil {
+		query += "AND (n.notification_when, n.notification_id)<(?, ?) "
+		args = append(args, after.When, after.Identifier)
+	}
+	query += fmt.Sprintf("ORDER BY n.notification_when DESC, n.notification_id DESC LIMIT %d;", limit)
+	res, err := db.QueryContext(
+		ctx,
+		query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving notifications: %v", err)
+	}
+	defer res.Close()
+	var outNotes []types.Notification
+	for res.Next() {
+		var note types.Notification
+		var when int64
+		err := res.Scan(
+			&note.Identifier,
+			&note.Reader,
+			&note.Type,
+			&when,
+			&note.Acknowledged,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting notification: %v", err)
+		}
+		note.When = time.Unix(when, 0)
+		outNotes = append(outNotes, note)
+	}
+	return outNotes, nil
 }
 
+// AcknowledgeNotifications Acknowledges the account's notifications with the given ids, returning how many
+// were acknowledged. Notifications that were already acknowledged keep their original time.
+func (m *MySQL) AcknowledgeNotifications(account int64, org *int64, notifications []int64) (int64, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	if len(notifications) < 1 {
+		return 0, nil
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	args := []interface{}{time.Now().UTC().Truncate(time.Second), account, org}
+	placeholders := make([]string, len(notifications))
+	for i, id := range notifications {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE notification SET notification_acknowledged_at=? WHERE notification_acknowledged_at IS NULL AND "+
+			"key_id IN (SELECT key_id FROM api_key WHERE account_id=? AND org_id <=> ?) AND "+
+			"notification_id IN ("+strings.Join(placeholders, ", ")+");",
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error acknowledging notifications: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking rows affected: %v", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,520 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package mysql
+
+import (
+	"chronokeep/remote/types"
+	"testing"
+	"time"
+)
+
+func TestAddOrganization(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	added, err := db.AddOrganization(types.Organization{
+		AccountIdentifier: account1.Identifier,
+		Name:              "Timing Company",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	if added.Identifier == 0 || added.CreatedAt.IsZero() {
+		t.Errorf("Expected organization to have an id and created time set, found %+v.", *added)
+	}
+	org, err := db.GetOrganization(added.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting organization: %v", err)
+	}
+	if org == nil || org.Name != "Timing Company" || org.AccountIdentifier != account1.Identifier {
+		t.Errorf("Expected organization %+v, found %+v.", *added, org)
+	}
+	// The account that created the organization is its first owner.
+	membership, err := db.GetMembership(added.Identifier, account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting membership: %v", err)
+	}
+	if membership == nil || membership.Role != types.OrgRoleOwner || membership.Organization.Name != "Timing Company" {
+		t.Errorf("Expected owner membership, found %+v.", membership)
+	}
+	// An account can only create one organization.
+	_, err = db.AddOrganization(types.Organization{
+		AccountIdentifier: account1.Identifier,
+		Name:              "Second Company",
+	})
+	if err == nil {
+		t.Error("Expected error adding a second organization for an account.")
+	}
+	org, err = db.GetOrganization(added.Identifier + 100)
+	if err != nil {
+		t.Fatalf("Error getting organization: %v", err)
+	}
+	if org != nil {
+		t.Errorf("Expected no organization, found %+v.", *org)
+	}
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	org, _ := db.AddOrganization(types.Organization{
+		AccountIdentifier: account1.Identifier,
+		Name:              "Timing Company",
+	})
+	org.Name = "Renamed Company"
+	err = db.UpdateOrganization(*org)
+	if err != nil {
+		t.Fatalf("Error updating organization: %v", err)
+	}
+	updated, _ := db.GetOrganization(org.Identifier)
+	if updated == nil || updated.Name != "Renamed Company" {
+		t.Errorf("Expected organization name to be %v, found %+v.", "Renamed Company", updated)
+	}
+	org.Identifier += 100
+	err = db.UpdateOrganization(*org)
+	if err == nil {
+		t.Error("Expected error updating an organization that doesn't exist.")
+	}
+}
+
+func TestOrganizationMembers(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	account3, _ := db.AddAccount(accounts[2])
+	org, _ := db.AddOrganization(types.Organization{
+		AccountIdentifier: account1.Identifier,
+		Name:              "Timing Company",
+	})
+	err = db.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      account2.Identifier,
+		Role:                   types.OrgRoleViewer,
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization member: %v", err)
+	}
+	err = db.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      account3.Identifier,
+		Role:                   types.OrgRoleViewer,
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization member: %v", err)
+	}
+	// Setting a member that already exists changes their role.
+	err = db.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      account2.Identifier,
+		Role:                   types.OrgRoleManager,
+	})
+	if err != nil {
+		t.Fatalf("Error changing organization member role: %v", err)
+	}
+	members, err := db.GetOrganizationMembers(org.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting organization members: %v", err)
+	}
+	if len(members) != 3 {
+		t.Fatalf("Expected %v members, found %v.", 3, len(members))
+	}
+	roles := make(map[string]string)
+	for _, member := range members {
+		roles[member.Email] = member.Role
+	}
+	if roles[account1.Email] != types.OrgRoleOwner || roles[account2.Email] != types.OrgRoleManager ||
+		roles[account3.Email] != types.OrgRoleViewer {
+		t.Errorf("Unexpected member roles: %v", roles)
+	}
+	memberships, err := db.GetAccountMemberships(account2.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting memberships: %v", err)
+	}
+	if len(memberships) != 1 || memberships[0].Organization.Identifier != org.Identifier || memberships[0].Role != types.OrgRoleManager {
+		t.Errorf("Expected manager membership of organization %v, found %+v.", org.Identifier, memberships)
+	}
+	err = db.RemoveOrganizationMember(org.Identifier, account3.Identifier)
+	if err != nil {
+		t.Fatalf("Error removing organization member: %v", err)
+	}
+	membership, _ := db.GetMembership(org.Identifier, account3.Identifier)
+	if membership != nil {
+		t.Errorf("Expected no membership after removal, found %+v.", *membership)
+	}
+	err = db.RemoveOrganizationMember(org.Identifier, account3.Identifier)
+	if err == nil {
+		t.Error("Expected error removing a member twice.")
+	}
+	// Deleted accounts aren't listed as members.
+	db.DeleteAccount(account2.Identifier)
+	members, _ = db.GetOrganizationMembers(org.Identifier)
+	if len(members) != 1 {
+		t.Errorf("Expected %v members, found %v.", 1, len(members))
+	}
+}
+
+func TestOrganizationKeys(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	account3, _ := db.AddAccount(accounts[2])
+	org, _ := db.AddOrganization(types.Organization{
+		AccountIdentifier: account1.Identifier,
+		Name:              "Timing Company",
+	})
+	db.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      account2.Identifier,
+		Role:                   types.OrgRoleViewer,
+	})
+	orgKey, err := db.AddKey(types.Key{
+		AccountIdentifier: account1.Identifier,
+		Organization:      &org.Identifier,
+		Name:              "finish",
+		Value:             "org-key-value-1",
+		Type:              "write",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization key: %v", err)
+	}
+	if orgKey.Organization == nil || *orgKey.Organization != org.Identifier {
+		t.Errorf("Expected key to belong to organization %v, found %v.", org.Identifier, orgKey.Organization)
+	}
+	db.AddKey(types.Key{
+		AccountIdentifier: account1.Identifier,
+		Name:              "personal",
+		Value:             "personal-key-value-1",
+		Type:              "write",
+	})
+	db.AddKey(types.Key{
+		AccountIdentifier: account2.Identifier,
+		Name:              "start",
+		Value:             "personal-key-value-2",
+		Type:              "write",
+	})
+	db.AddKey(types.Key{
+		AccountIdentifier: account3.Identifier,
+		Name:              "other",
+		Value:             "personal-key-value-3",
+		Type:              "write",
+	})
+	keys, err := db.GetOrganizationKeys(org.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting organization keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Name != "finish" || keys[0].Organization == nil || *keys[0].Organization != org.Identifier {
+		t.Errorf("Expected only the organization key, found %+v.", keys)
+	}
+	// Members see their own keys and those of their organizations.
+	keys, err = db.GetMemberKeys(account2.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting member keys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected %v keys, found %v.", 2, len(keys))
+	}
+	keys, _ = db.GetMemberKeys(account1.Identifier)
+	if len(keys) != 2 {
+		t.Errorf("Expected %v keys, found %v.", 2, len(keys))
+	}
+	keys, _ = db.GetMemberKeys(account3.Identifier)
+	if len(keys) != 1 {
+		t.Errorf("Expected %v keys, found %v.", 1, len(keys))
+	}
+	key, _ := db.GetKey("org-key-value-1")
+	if key == nil || key.Organization == nil || *key.Organization != org.Identifier {
+		t.Errorf("Expected key to belong to organization %v, found %+v.", org.Identifier, key)
+	}
+	mkey, _ := db.GetKeyAndAccount("org-key-value-1")
+	if mkey == nil || mkey.Key.Organization == nil || *mkey.Key.Organization != org.Identifier || mkey.Account.Identifier != account1.Identifier {
+		t.Errorf("Expected key to belong to organization %v under account %v, found %+v.", org.Identifier, account1.Identifier, mkey)
+	}
+	key, _ = db.GetKey("personal-key-value-1")
+	if key == nil || key.Organization != nil {
+		t.Errorf("Expected key without an organization, found %+v.", key)
+	}
+}
+
+func TestOrganizationReadScope(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	org, _ := db.AddOrganization(types.Organization{
+		AccountIdentifier: account1.Identifier,
+		Name:              "Timing Company",
+	})
+	db.AddKey(types.Key{
+		AccountIdentifier: account1.Identifier,
+		Organization:      &org.Identifier,
+		Name:              "finish",
+		Value:             "org-key-value-1",
+		Type:              "write",
+	})
+	db.AddKey(types.Key{
+		AccountIdentifier: account1.Identifier,
+		Name:              "personal",
+		Value:             "personal-key-value-1",
+		Type:              "write",
+	})
+	read := types.Read{
+		Identifier:   "1",
+		Seconds:      100,
+		Milliseconds: 0,
+		IdentType:    "chip",
+		Type:         "reader",
+	}
+	db.AddReads("org-key-value-1", []types.Read{read})
+	db.AddReads("personal-key-value-1", []types.Read{read})
+	db.SaveNotification(&types.RequestNotification{
+		Type: "UPS_CONNECTED",
+		When: time.Now().UTC().Format(time.RFC3339),
+	}, "personal-key-value-1")
+	// Organization keys are kept under the account, but only reach the organization's readers.
+	reads, err := db.GetReads(account1.Identifier, &org.Identifier, "personal", 0, 1000)
+	if err != nil {
+		t.Fatalf("Error getting reads: %v", err)
+	}
+	if len(reads) != 0 {
+		t.Errorf("Expected no reads for a reader outside of the organization, found %+v.", reads)
+	}
+	reads, _ = db.GetReadsPage(account1.Identifier, &org.Identifier, "personal", 0, 1000, nil, 10)
+	if len(reads) != 0 {
+		t.Errorf("Expected no reads for a reader outside of the organization, found %+v.", reads)
+	}
+	reads, _ = db.GetReads(account1.Identifier, &org.Identifier, "finish", 0, 1000)
+	if len(reads) != 1 {
+		t.Errorf("Expected %v reads for the organization's reader, found %v.", 1, len(reads))
+	}
+	reads, _ = db.GetReads(account1.Identifier, nil, "finish", 0, 1000)
+	if len(reads) != 0 {
+		t.Errorf("Expected no reads for the organization's reader outside of it, found %+v.", reads)
+	}
+	note, err := db.GetNotification(account1.Identifier, &org.Identifier, "personal")
+	if err != nil {
+		t.Fatalf("Error getting notification: %v", err)
+	}
+	if note != nil {
+		t.Errorf("Expected no notification for a reader outside of the organization, found %+v.", *note)
+	}
+	notes, err := db.GetNotificationHistory(account1.Identifier, &org.Identifier, "", 0, time.Now().Add(time.Hour).Unix(), false, nil, 10)
+	if err != nil {
+		t.Fatalf("Error getting notification history: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("Expected no notifications for readers outside of the organization, found %+v.", notes)
+	}
+	notes, _ = db.GetNotificationHistory(account1.Identifier, nil, "", 0, time.Now().Add(time.Hour).Unix(), false, nil, 10)
+	if len(notes) != 1 {
+		t.Fatalf("Expected %v notifications, found %v.", 1, len(notes))
+	}
+	count, err := db.AcknowledgeNotifications(account1.Identifier, &org.Identifier, []int64{notes[0].Identifier})
+	if err != nil {
+		t.Fatalf("Error acknowledging notifications: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no notifications outside of the organization to be acknowledged, found %v.", count)
+	}
+	count, _ = db.DeleteReaderReads(account1.Identifier, &org.Identifier, "personal", 0, 1000)
+	if count != 0 {
+		t.Errorf("Expected no reads outside of the organization to be deleted, found %v.", count)
+	}
+	count, _ = db.DeleteReaderReadsBefore(account1.Identifier, &org.Identifier, "personal", 1000)
+	if count != 0 {
+		t.Errorf("Expected no reads outside of the organization to be deleted, found %v.", count)
+	}
+	count, _ = db.DeleteReaderReadsBetween(account1.Identifier, &org.Identifier, "personal")
+	if count != 0 {
+		t.Errorf("Expected no reads outside of the organization to be deleted, found %v.", count)
+	}
+	reads, _ = db.GetReads(account1.Identifier, nil, "personal", 0, 1000)
+	if len(reads) != 1 {
+		t.Errorf("Expected %v reads outside of the organization to remain, found %v.", 1, len(reads))
+	}
+}
+
+func TestDeleteOrganization(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	org, _ := db.AddOrganization(types.Organization{
+		AccountIdentifier: account1.Identifier,
+		Name:              "Timing Company",
+	})
+	db.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      account2.Identifier,
+		Role:                   types.OrgRoleManager,
+	})
+	db.AddKey(types.Key{
+		AccountIdentifier: account1.Identifier,
+		Organization:      &org.Identifier,
+		Name:              "finish",
+		Value:             "org-key-value-1",
+		Type:              "write",
+	})
+	err = db.DeleteOrganization(org.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting organization: %v", err)
+	}
+	deleted, _ := db.GetOrganization(org.Identifier)
+	if deleted != nil {
+		t.Errorf("Expected organization to be deleted, found %+v.", *deleted)
+	}
+	memberships, _ := db.GetAccountMemberships(account2.Identifier)
+	if len(memberships) != 0 {
+		t.Errorf("Expected %v memberships, found %v.", 0, len(memberships))
+	}
+	// The organization's keys go back to the account that created it.
+	key, _ := db.GetKey("org-key-value-1")
+	if key == nil || key.Organization != nil || key.AccountIdentifier != account1.Identifier {
+		t.Errorf("Expected key to be kept without an organization, found %+v.", key)
+	}
+	keys, _ := db.GetMemberKeys(account2.Identifier)
+	if len(keys) != 0 {
+		t.Errorf("Expected %v keys, found %v.", 0, len(keys))
+	}
+	err = db.DeleteOrganization(org.Identifier)
+	if err == nil {
+		t.Error("Expected error deleting organization twice.")
+	}
+}
+
+func TestBadDatabaseOrganization(t *testing.T) {
+	db := badTestSetup(t)
+	_, err := db.AddOrganization(types.Organization{})
+	if err == nil {
+		t.Fatal("Expected error adding organization.")
+	}
+	_, err = db.GetOrganization(0)
+	if err == nil {
+		t.Fatal("Expected error getting organization.")
+	}
+	err = db.UpdateOrganization(types.Organization{})
+	if err == nil {
+		t.Fatal("Expected error updating organization.")
+	}
+	err = db.DeleteOrganization(0)
+	if err == nil {
+		t.Fatal("Expected error deleting organization.")
+	}
+	_, err = db.GetMembership(0, 0)
+	if err == nil {
+		t.Fatal("Expected error getting membership.")
+	}
+	_, err = db.GetAccountMemberships(0)
+	if err == nil {
+		t.Fatal("Expected error getting memberships.")
+	}
+	_, err = db.GetOrganizationMembers(0)
+	if err == nil {
+		t.Fatal("Expected error getting organization members.")
+	}
+	err = db.SetOrganizationMember(types.OrganizationMember{})
+	if err == nil {
+		t.Fatal("Expected error setting organization member.")
+	}
+	err = db.RemoveOrganizationMember(0, 0)
+	if err == nil {
+		t.Fatal("Expected error removing organization member.")
+	}
+	_, err = db.GetOrganizationKeys(0)
+	if err == nil {
+		t.Fatal("Expected error getting organization keys.")
+	}
+	_, err = db.GetMemberKeys(0)
+	if err == nil {
+		t.Fatal("Expected error getting member keys.")
+	}
+}
+
+func TestNoDatabaseOrganization(t *testing.T) {
+	db := MySQL{}
+	_, err := db.AddOrganization(types.Organization{})
+	if err == nil {
+		t.Fatal("Expected error adding organization.")
+	}
+	_, err = db.GetOrganization(0)
+	if err == nil {
+		t.Fatal("Expected error getting organization.")
+	}
+	err = db.UpdateOrganization(types.Organization{})
+	if err == nil {
+		t.Fatal("Expected error updating organization.")
+	}
+	err = db.DeleteOrganization(0)
+	if err == nil {
+		t.Fatal("Expected error deleting organization.")
+	}
+	_, err = db.GetMembership(0, 0)
+	if err == nil {
+		t.Fatal("Expected error getting membership.")
+	}
+	_, err = db.GetAccountMemberships(0)
+	if err == nil {
+		t.Fatal("Expected error getting memberships.")
+	}
+	_, err = db.GetOrganizationMembers(0)
+	if err == nil {
+		t.Fatal("Expected error getting organization members.")
+	}
+	err = db.SetOrganizationMember(types.OrganizationMember{})
+	if err == nil {
+		t.Fatal("Expected error setting organization member.")
+	}
+	err = db.RemoveOrganizationMember(0, 0)
+	if err == nil {
+		t.Fatal("Expected error removing organization member.")
+	}
+	_, err = db.GetOrganizationKeys(0)
+	if err == nil {
+		t.Fatal("Expected error getting organization keys.")
+	}
+	_, err = db.GetMemberKeys(0)
+	if err == nil {
+		t.Fatal("Expected error getting member keys.")
+	}
+}
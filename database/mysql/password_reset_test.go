@@ -0,0 +1,191 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package mysql
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPasswordReset(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	for i := 0; i <= database.MaxLoginAttempts; i++ {
+		db.InvalidPassword(*account1)
+	}
+	db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token1", RefreshToken: "refresh1"})
+	db.AddSession(types.Session{AccountIdentifier: account2.Identifier, Token: "token2", RefreshToken: "refresh2"})
+	now := time.Now()
+	err = db.AddPasswordReset(types.PasswordReset{
+		AccountIdentifier: account1.Identifier,
+		Token:             "reset1",
+		ExpiresAt:         now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Error adding password reset: %v", err)
+	}
+	// A new token replaces the old one.
+	err = db.AddPasswordReset(types.PasswordReset{
+		AccountIdentifier: account1.Identifier,
+		Token:             "reset2",
+		ExpiresAt:         now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Error adding password reset: %v", err)
+	}
+	err = db.AddPasswordReset(types.PasswordReset{
+		AccountIdentifier: account2.Identifier,
+		Token:             "reset3",
+		ExpiresAt:         now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Error adding password reset: %v", err)
+	}
+	account, err := db.ResetPassword("reset1", "newpassword", now)
+	if !errors.Is(err, database.ErrInvalidResetToken) || account != nil {
+		t.Errorf("Expected replaced token to be unusable, found %+v (%v).", account, err)
+	}
+	account, err = db.ResetPassword("unknown", "newpassword", now)
+	if !errors.Is(err, database.ErrInvalidResetToken) || account != nil {
+		t.Errorf("Expected unknown token to be unusable, found %+v (%v).", account, err)
+	}
+	account, err = db.ResetPassword("reset2", "newpassword", now.Add(time.Hour))
+	if !errors.Is(err, database.ErrInvalidResetToken) || account != nil {
+		t.Errorf("Expected expired token to be unusable, found %+v (%v).", account, err)
+	}
+	account, err = db.ResetPassword("reset2", "newpassword", now)
+	if err != nil {
+		t.Fatalf("Error resetting password: %v", err)
+	}
+	if account == nil || account.Identifier != account1.Identifier {
+		t.Fatalf("Expected password to be reset for account %v, found %+v.", account1.Identifier, account)
+	}
+	if account.Password != "newpassword" || account.Locked || account.WrongPassAttempts != 0 {
+		t.Errorf("Expected new password on an unlocked account, found %+v.", account)
+	}
+	sessions, _ := db.GetAccountSessions(account1.Identifier)
+	if len(sessions) != 0 {
+		t.Errorf("Expected %v sessions, found %v.", 0, len(sessions))
+	}
+	sessions, _ = db.GetAccountSessions(account2.Identifier)
+	if len(sessions) != 1 {
+		t.Errorf("Expected %v sessions, found %v.", 1, len(sessions))
+	}
+	// Tokens can only be used once.
+	account, err = db.ResetPassword("reset2", "otherpassword", now)
+	if !errors.Is(err, database.ErrInvalidResetToken) || account != nil {
+		t.Errorf("Expected used token to be unusable, found %+v (%v).", account, err)
+	}
+	// Other accounts keep their tokens.
+	account, err = db.ResetPassword("reset3", "otherpassword", now)
+	if err != nil || account == nil || account.Identifier != account2.Identifier {
+		t.Errorf("Expected password to be reset for account %v, found %+v (%v).", account2.Identifier, account, err)
+	}
+	// Tokens for deleted accounts can't be used.
+	err = db.AddPasswordReset(types.PasswordReset{
+		AccountIdentifier: account2.Identifier,
+		Token:             "reset4",
+		ExpiresAt:         now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Error adding password reset: %v", err)
+	}
+	err = db.DeleteAccount(account2.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting account: %v", err)
+	}
+	account, err = db.ResetPassword("reset4", "otherpassword", now)
+	if !errors.Is(err, database.ErrInvalidResetToken) || account != nil {
+		t.Errorf("Expected token for deleted account to be unusable, found %+v (%v).", account, err)
+	}
+}
+
+func TestPasswordResetRequest(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		count, err := db.AddPasswordResetRequest("address:192.0.2.10", time.Hour, now)
+		if err != nil {
+			t.Fatalf("Error adding password reset request: %v", err)
+		}
+		if count != i {
+			t.Errorf("Expected %v password reset requests, found %v.", i, count)
+		}
+	}
+	// Keys are counted separately.
+	count, err := db.AddPasswordResetRequest("account:1", time.Hour, now)
+	if err != nil || count != 1 {
+		t.Errorf("Expected %v password reset requests, found %v (%v).", 1, count, err)
+	}
+	// Counts start over once the window has passed.
+	count, err = db.AddPasswordResetRequest("address:192.0.2.10", time.Hour, now.Add(time.Hour))
+	if err != nil || count != 1 {
+		t.Errorf("Expected %v password reset requests, found %v (%v).", 1, count, err)
+	}
+	// Requests aren't failed logins.
+	failures, err := db.GetLoginFailures("192.0.2.10")
+	if err != nil || failures != nil {
+		t.Errorf("Expected no login failures, found %+v (%v).", failures, err)
+	}
+}
+
+func TestBadDatabasePasswordReset(t *testing.T) {
+	db := badTestSetup(t)
+	err := db.AddPasswordReset(types.PasswordReset{})
+	if err == nil {
+		t.Fatal("Expected error adding password reset.")
+	}
+	_, err = db.ResetPassword("", "", time.Now())
+	if err == nil {
+		t.Fatal("Expected error resetting password.")
+	}
+	_, err = db.AddPasswordResetRequest("", time.Hour, time.Now())
+	if err == nil {
+		t.Fatal("Expected error adding password reset request.")
+	}
+}
+
+func TestNoDatabasePasswordReset(t *testing.T) {
+	db := MySQL{}
+	err := db.AddPasswordReset(types.PasswordReset{})
+	if err == nil {
+		t.Fatal("Expected error adding password reset.")
+	}
+	_, err = db.ResetPassword("", "", time.Now())
+	if err == nil {
+		t.Fatal("Expected error resetting password.")
+	}
+	_, err = db.AddPasswordResetRequest("", time.Hour, time.Now())
+	if err == nil {
+		t.Fatal("Expected error adding password reset request.")
+	}
+}
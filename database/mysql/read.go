@@ -26,7 +26,7 @@ import (
 	"time"
 )
 
-func (m *MySQL) GetReads(account int64, reader_name string, from, to int64) ([]types.Read, error) {
+func (m *MySQL) GetReads(account int64, org *int64, reader_name string, from, to int64) ([]types.Read, error) {
 	db, err := m.GetDB()
 	if err != nil {
 		return nil, err
@@ -39,10 +39,11 @@ func (m *MySQL) GetReads(account int64, reader_name string, from, to int64) ([]t
 	}
 	res, err := db.QueryContext(
 		ctx,
-		"SELECT key_value, identifier, seconds, milliseconds, ident_type, type, antenna, "+
+		"SELECT key_prefix, identifier, seconds, milliseconds, ident_type, type, antenna, "+
 			"reader, rssi FROM a_read NATURAL JOIN api_key WHERE account_id=? AND "+
-			"key_name=? AND seconds>=? AND seconds<=?;",
+			"org_id <=> ? AND key_name=? AND seconds>=? AND seconds<=?;",
 		account,
+		org,
 		reader_name,
 		from,
 		toVal,
@@ -73,6 +74,113 @@ func (m *MySQL) GetReads(account int64, reader_name string, from, to int64) ([]t
 	return outReads, nil
 }
 
+// GetReadsPage Gets up to limit reads ordered by (seconds, milliseconds, identifier, ident_type),
+// starting after the position marked by the cursor if one is given.
+func (m *MySQL) GetReadsPage(account int64, org *int64, reader_name string, from, to int64, after *types.ReadCursor, limit int) ([]types.Read, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	toVal := to
+	if to < from {
+		toVal = from + 360
+	}
+	query := "SELECT key_prefix, identifier, seconds, milliseconds, ident_type, type, antenna, " +
+		"reader, rssi FROM a_read NATURAL JOIN api_key WHERE account_id=? AND " +
+		"org_id <=> ? AND key_name=? AND seconds>=? AND seconds<=? "
+	args := []interface{}{account, org, reader_name, from, toVal}
+	if after != nil {
+		query += "AND (seconds, milliseconds, identifier, ident_type)>(?, ?, ?, ?) "
+		args = append(args, after.Seconds, after.Milliseconds, after.Identifier, after.IdentType)
+	}
+	query += fmt.Sprintf("ORDER BY seconds, milliseconds, identifier, ident_type LIMIT %d;", limit)
+	res, err := db.QueryContext(
+		ctx,
+		query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving reads: %v", err)
+	}
+	defer res.Close()
+	var outReads []types.Read
+	for res.Next() {
+		var read types.Read
+		err := res.Scan(
+			&read.Key,
+			&read.Identifier,
+			&read.Seconds,
+			&read.Milliseconds,
+			&read.IdentType,
+			&read.Type,
+			&read.Antenna,
+			&read.Reader,
+			&read.RSSI,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting read: %v", err)
+		}
+		outReads = append(outReads, read)
+	}
+	return outReads, nil
+}
+
+// GetReadsAfter Gets up to limit reads added after the read with the given id, in the order they were added.
+func (m *MySQL) GetReadsAfter(account int64, org *int64, reader_name string, after int64, limit int) ([]types.Read, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT read_id, key_prefix, identifier, seconds, milliseconds, ident_type, type, antenna, "+
+			"reader, rssi FROM a_read NATURAL JOIN api_key WHERE account_id=? AND "+
+			"org_id <=> ? AND key_name=? AND read_id>? ORDER BY read_id LIMIT ?;",
+		account,
+		org,
+		reader_name,
+		after,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving reads: %v", err)
+	}
+	defer res.Close()
+	var outReads []types.Read
+	for res.Next() {
+		var read types.Read
+		err := res.Scan(
+			&read.ID,
+			&read.Key,
+			&read.Identifier,
+			&read.Seconds,
+			&read.Milliseconds,
+			&read.IdentType,
+			&read.Type,
+			&read.Antenna,
+			&read.Reader,
+			&read.RSSI,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting read: %v", err)
+		}
+		outReads = append(outReads, read)
+	}
+	return outReads, nil
+}
+
+// AddReads Adds reads to the database and returns the reads that were inserted, in order.
+// Reads that already exist are skipped and not returned.
 func (m *MySQL) AddReads(key string, reads []types.Read) ([]types.Read, error) {
 	db, err := m.GetDB()
 	if err != nil {
@@ -84,10 +192,22 @@ func (m *MySQL) AddReads(key string, reads []types.Read) ([]types.Read, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to begin transaction: %v", err)
 	}
+	var keyID int64
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT key_id FROM api_key WHERE key_value=? OR (old_key_value=? AND old_key_valid_until>?);",
+		types.HashKey(key),
+		types.HashKey(key),
+		time.Now(),
+	).Scan(&keyID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("unable to find key for reads: %v", err)
+	}
 	stmt, err := tx.PrepareContext(
 		ctx,
 		"INSERT IGNORE INTO a_read("+
-			"key_value, "+
+			"key_id, "+
 			"identifier, "+
 			"seconds, "+
 			"milliseconds, "+
@@ -109,14 +229,15 @@ func (m *MySQL) AddReads(key string, reads []types.Read) ([]types.Read, error) {
 			");",
 	)
 	if err != nil {
+		tx.Rollback()
 		return nil, fmt.Errorf("unable to prepare statement for read add: %v", err)
 	}
 	defer stmt.Close()
 	var outReads []types.Read
 	for _, read := range reads {
-		_, err := stmt.ExecContext(
+		res, err := stmt.ExecContext(
 			ctx,
-			key,
+			keyID,
 			read.Identifier,
 			read.Seconds,
 			read.Milliseconds,
@@ -130,17 +251,30 @@ func (m *MySQL) AddReads(key string, reads []types.Read) ([]types.Read, error) {
 			tx.Rollback()
 			return outReads, fmt.Errorf("error adding reads to database: %v", err)
 		}
-		outReads = append(outReads, read)
+		rows, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("unable to determine rows affected by read add: %v", err)
+		}
+		// Duplicates are ignored by the insert and aren't reported as added.
+		if rows > 0 {
+			read.ID, err = res.LastInsertId()
+			if err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("unable to determine id of added read: %v", err)
+			}
+			outReads = append(outReads, read)
+		}
 	}
 	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("unable to commit transaction: %v", err)
 	}
-	return reads, nil
+	return outReads, nil
 }
 
-func (m *MySQL) DeleteReaderReads(account int64, reader_name string, from, to int64) (int64, error) {
+func (m *MySQL) DeleteReaderReads(account int64, org *int64, reader_name string, from, to int64) (int64, error) {
 	if to < from {
 		return 0, errors.New("second input variable must be greater than first")
 	}
@@ -153,11 +287,12 @@ func (m *MySQL) DeleteReaderReads(account int64, reader_name string, from, to in
 	res, err := db.ExecContext(
 		ctx,
 		"DELETE r FROM a_read r WHERE r.seconds>=? AND r.seconds<=? AND EXISTS "+
-			"(SELECT * FROM api_key a WHERE r.key_value=a.key_value AND "+
-			"a.account_id=? AND a.key_name=?);",
+			"(SELECT * FROM api_key a WHERE r.key_id=a.key_id AND "+
+			"a.account_id=? AND a.org_id <=> ? AND a.key_name=?);",
 		from,
 		to,
 		account,
+		org,
 		reader_name,
 	)
 	if err != nil {
@@ -179,8 +314,8 @@ func (m *MySQL) DeleteKeyReads(key string) (int64, error) {
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"DELETE FROM a_read WHERE key_value=?;",
-		key,
+		"DELETE FROM a_read WHERE key_id IN (SELECT key_id FROM api_key WHERE key_value=?);",
+		types.HashKey(key),
 	)
 	if err != nil {
 		return 0, fmt.Errorf("unable to delete reads: %v", err)
@@ -192,7 +327,7 @@ func (m *MySQL) DeleteKeyReads(key string) (int64, error) {
 	return rows, nil
 }
 
-func (m *MySQL) DeleteReaderReadsBefore(account int64, reader_name string, to int64) (int64, error) {
+func (m *MySQL) DeleteReaderReadsBefore(account int64, org *int64, reader_name string, to int64) (int64, error) {
 	db, err := m.GetDB()
 	if err != nil {
 		return 0, err
@@ -202,9 +337,10 @@ func (m *MySQL) DeleteReaderReadsBefore(account int64, reader_name string, to in
 	res, err := db.ExecContext(
 		ctx,
 		"DELETE r FROM a_read r WHERE r.seconds<=? AND EXISTS (SELECT * FROM api_key a WHERE "+
-			"r.key_value=a.key_value AND a.account_id=? AND a.key_name=?);",
+			"r.key_id=a.key_id AND a.account_id=? AND a.org_id <=> ? AND a.key_name=?);",
 		to,
 		account,
+		org,
 		reader_name,
 	)
 	if err != nil {
@@ -217,7 +353,7 @@ func (m *MySQL) DeleteReaderReadsBefore(account int64, reader_name string, to in
 	return rows, nil
 }
 
-func (m *MySQL) DeleteReaderReadsBetween(account int64, reader_name string) (int64, error) {
+func (m *MySQL) DeleteReaderReadsBetween(account int64, org *int64, reader_name string) (int64, error) {
 	db, err := m.GetDB()
 	if err != nil {
 		return 0, err
@@ -227,8 +363,9 @@ func (m *MySQL) DeleteReaderReadsBetween(account int64, reader_name string) (int
 	res, err := db.ExecContext(
 		ctx,
 		"DELETE r FROM a_read r WHERE EXISTS (SELECT * FROM api_key a WHERE "+
-			"r.key_value=a.key_value AND a.account_id=? AND a.key_name=?);",
+			"r.key_id=a.key_id AND a.account_id=? AND a.org_id <=> ? AND a.key_name=?);",
 		account,
+		org,
 		reader_name,
 	)
 	if err != nil {
@@ -241,3 +378,28 @@ func (m *MySQL) DeleteReaderReadsBetween(account int64, reader_name string) (int
 	return rows, nil
 }
 
+// DeleteAccountTypeReadsBefore Deletes reads uploaded (not read) before the given unix time for
+// every account of the given type. Used to enforce read retention.
+func (m *MySQL) DeleteAccountTypeReadsBefore(account_type string, before int64) (int64, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE r FROM a_read r WHERE r.read_created_at<FROM_UNIXTIME(?) AND EXISTS (SELECT * FROM "+
+			"api_key a NATURAL JOIN account c WHERE r.key_id=a.key_id AND c.account_type=?);",
+		before,
+		account_type,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete reads: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to determine rows affected by delete: %v", err)
+	}
+	return rows, nil
+}
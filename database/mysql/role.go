@@ -0,0 +1,153 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package mysql
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetRoles Gets every role.
+func (m *MySQL) GetRoles() ([]types.Role, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT role_name, role_permissions FROM account_role ORDER BY role_name;",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving roles: %v", err)
+	}
+	defer res.Close()
+	var outRoles []types.Role
+	for res.Next() {
+		var role types.Role
+		var permissions string
+		err := res.Scan(
+			&role.Name,
+			&permissions,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting role: %v", err)
+		}
+		role.SetPermissions(permissions)
+		outRoles = append(outRoles, role)
+	}
+	return outRoles, nil
+}
+
+// GetRole Gets a role by name. Returns nil if it doesn't exist.
+func (m *MySQL) GetRole(name string) (*types.Role, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT role_name, role_permissions FROM account_role WHERE role_name=?;",
+		name,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %v", err)
+	}
+	defer res.Close()
+	if !res.Next() {
+		return nil, nil
+	}
+	var outRole types.Role
+	var permissions string
+	err = res.Scan(
+		&outRole.Name,
+		&permissions,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting role: %v", err)
+	}
+	outRole.SetPermissions(permissions)
+	return &outRole, nil
+}
+
+// SaveRole Adds a role, or replaces the permissions of the role with the same name.
+func (m *MySQL) SaveRole(role types.Role) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to save role: %v", err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM account_role WHERE role_name=?;", role.Name)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error removing old role: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO account_role(role_name, role_permissions) VALUES (?, ?);",
+		role.Name,
+		role.PermissionsValue(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error saving role: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// DeleteRole Deletes a role.
+func (m *MySQL) DeleteRole(name string) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE FROM account_role WHERE role_name=?;",
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting role: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error deleting role, rows affected: %v", rows)
+	}
+	return nil
+}
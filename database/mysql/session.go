@@ -0,0 +1,256 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package mysql
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddSession Adds a session for an account, storing the hashes of its tokens.
+func (m *MySQL) AddSession(session types.Session) (*types.Session, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	now := time.Now().UTC().Truncate(time.Second)
+	res, err := db.ExecContext(
+		ctx,
+		"INSERT INTO account_session(account_id, session_token, session_refresh_token, session_user_agent, "+
+			"session_ip, session_created_at, session_last_used) VALUES (?, ?, ?, ?, ?, ?, ?);",
+		session.AccountIdentifier,
+		types.HashToken(session.Token),
+		types.HashToken(session.RefreshToken),
+		session.UserAgent,
+		session.IP,
+		now,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to add session: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine ID for session: %v", err)
+	}
+	session.Identifier = id
+	session.CreatedAt = now
+	session.LastUsed = now
+	return &session, nil
+}
+
+func (m *MySQL) getSessions(query string, args ...interface{}) ([]types.Session, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT session_id, account_id, session_user_agent, session_ip, session_created_at, session_last_used "+
+			"FROM account_session "+query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving sessions: %v", err)
+	}
+	defer res.Close()
+	var outSessions []types.Session
+	for res.Next() {
+		var session types.Session
+		err := res.Scan(
+			&session.Identifier,
+			&session.AccountIdentifier,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting session: %v", err)
+		}
+		outSessions = append(outSessions, session)
+	}
+	return outSessions, nil
+}
+
+// GetSession Gets the session a token belongs to.
+func (m *MySQL) GetSession(token string) (*types.Session, error) {
+	sessions, err := m.getSessions("WHERE session_token=?;", types.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) < 1 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
+
+// GetSessionByRefreshToken Gets the session a refresh token belongs to.
+func (m *MySQL) GetSessionByRefreshToken(refreshToken string) (*types.Session, error) {
+	sessions, err := m.getSessions("WHERE session_refresh_token=?;", types.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) < 1 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
+
+// GetAccountSessions Gets all sessions for an account, most recently used first.
+func (m *MySQL) GetAccountSessions(account int64) ([]types.Session, error) {
+	return m.getSessions("WHERE account_id=? ORDER BY session_last_used DESC, session_id DESC;", account)
+}
+
+// UpdateSession Replaces the tokens of a session and records where it was last used from.
+func (m *MySQL) UpdateSession(session types.Session) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE account_session SET session_token=?, session_refresh_token=?, session_user_agent=?, "+
+			"session_ip=?, session_last_used=? WHERE session_id=?;",
+		types.HashToken(session.Token),
+		types.HashToken(session.RefreshToken),
+		session.UserAgent,
+		session.IP,
+		time.Now().UTC().Truncate(time.Second),
+		session.Identifier,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating session: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error updating session, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// UseSession Marks a session as used now.
+func (m *MySQL) UseSession(session int64) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE account_session SET session_last_used=? WHERE session_id=?;",
+		time.Now().UTC().Truncate(time.Second),
+		session,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating session: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error updating session, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// DeleteSession Ends a single session belonging to an account.
+func (m *MySQL) DeleteSession(account, session int64) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE FROM account_session WHERE account_id=? AND session_id=?;",
+		account,
+		session,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting session: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error deleting session, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// DeleteAccountSessions Ends every session belonging to an account other than the ones to keep.
+// Returns the number of sessions ended.
+func (m *MySQL) DeleteAccountSessions(account int64, keep ...int64) (int64, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	query := "DELETE FROM account_session WHERE account_id=?"
+	args := []interface{}{account}
+	for _, session := range keep {
+		query += " AND session_id<>?"
+		args = append(args, session)
+	}
+	res, err := db.ExecContext(ctx, query+";", args...)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting sessions: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking rows affected: %v", err)
+	}
+	return rows, nil
+}
+
+// deleteEmailSessions Ends every session belonging to the account with the given email.
+func (m *MySQL) deleteEmailSessions(email string) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	_, err = db.ExecContext(
+		ctx,
+		"DELETE FROM account_session WHERE account_id IN (SELECT account_id FROM account WHERE account_email=?);",
+		email,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting sessions: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package mysql
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetTwoFactor Gets the two-factor setup for an account. Returns nil if it doesn't have one.
+func (m *MySQL) GetTwoFactor(account int64) (*types.TwoFactor, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT account_id, tf_secret, tf_enabled, tf_last_step, "+
+			"(SELECT COUNT(*) FROM recovery_code r WHERE r.account_id=t.account_id) "+
+			"FROM two_factor t WHERE account_id=?;",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving two factor: %v", err)
+	}
+	defer res.Close()
+	if !res.Next() {
+		return nil, nil
+	}
+	var outTwoFactor types.TwoFactor
+	err = res.Scan(
+		&outTwoFactor.AccountIdentifier,
+		&outTwoFactor.Secret,
+		&outTwoFactor.Enabled,
+		&outTwoFactor.LastStep,
+		&outTwoFactor.RecoveryCodes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting two factor: %v", err)
+	}
+	return &outTwoFactor, nil
+}
+
+// AddTwoFactor Stores a new two-factor setup for an account, replacing any setup and recovery codes it already had.
+func (m *MySQL) AddTwoFactor(twoFactor types.TwoFactor) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to add two factor: %v", err)
+	}
+	for _, query := range []string{
+		"DELETE FROM recovery_code WHERE account_id=?;",
+		"DELETE FROM two_factor WHERE account_id=?;",
+	} {
+		_, err = tx.ExecContext(ctx, query, twoFactor.AccountIdentifier)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error deleting old two factor: %v", err)
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO two_factor(account_id, tf_secret, tf_enabled, tf_last_step) VALUES (?, ?, ?, ?);",
+		twoFactor.AccountIdentifier,
+		twoFactor.Secret,
+		twoFactor.Enabled,
+		twoFactor.LastStep,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("unable to add two factor: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// EnableTwoFactor Turns on the two-factor setup for an account once a code for the given step
+// has been verified, replacing its recovery codes.
+func (m *MySQL) EnableTwoFactor(account, step int64, recoveryCodes []string) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to enable two factor: %v", err)
+	}
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE two_factor SET tf_enabled=TRUE, tf_last_step=? WHERE account_id=? AND tf_enabled=FALSE;",
+		step,
+		account,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error enabling two factor: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error checking rows affected on enable two factor: %v", err)
+	}
+	if rows != 1 {
+		tx.Rollback()
+		return fmt.Errorf("error enabling two factor, rows affected: %v", rows)
+	}
+	err = m.replaceRecoveryCodes(ctx, tx, account, recoveryCodes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// SetRecoveryCodes Replaces the recovery codes for an account.
+func (m *MySQL) SetRecoveryCodes(account int64, recoveryCodes []string) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to set recovery codes: %v", err)
+	}
+	err = m.replaceRecoveryCodes(ctx, tx, account, recoveryCodes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+func (m *MySQL) replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, account int64, recoveryCodes []string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM recovery_code WHERE account_id=?;",
+		account,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting old recovery codes: %v", err)
+	}
+	for _, code := range recoveryCodes {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO recovery_code(account_id, code_hash) VALUES (?, ?);",
+			account,
+			types.HashKey(code),
+		)
+		if err != nil {
+			return fmt.Errorf("unable to add recovery code: %v", err)
+		}
+	}
+	return nil
+}
+
+// UseTwoFactorStep Records the step of a TOTP code used on an account. Returns false if a code from
+// the same or a later step has already been used, so each code only works once.
+func (m *MySQL) UseTwoFactorStep(account, step int64) (bool, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return false, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE two_factor SET tf_last_step=? WHERE account_id=? AND tf_enabled=TRUE AND tf_last_step<?;",
+		step,
+		account,
+		step,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error using two factor step: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error checking rows affected on two factor step: %v", err)
+	}
+	return rows == 1, nil
+}
+
+// UseRecoveryCode Removes a recovery code from an account. Returns false if the account doesn't have the code.
+func (m *MySQL) UseRecoveryCode(account int64, code string) (bool, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return false, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE FROM recovery_code WHERE account_id=? AND code_hash=?;",
+		account,
+		types.HashKey(code),
+	)
+	if err != nil {
+		return false, fmt.Errorf("error using recovery code: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error checking rows affected on recovery code: %v", err)
+	}
+	return rows == 1, nil
+}
+
+// DeleteTwoFactor Removes the two-factor setup and recovery codes from an account.
+func (m *MySQL) DeleteTwoFactor(account int64) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to delete two factor: %v", err)
+	}
+	for _, query := range []string{
+		"DELETE FROM recovery_code WHERE account_id=?;",
+		"DELETE FROM two_factor WHERE account_id=?;",
+	} {
+		_, err = tx.ExecContext(ctx, query, account)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error deleting two factor: %v", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
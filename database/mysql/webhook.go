@@ -0,0 +1,317 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package mysql
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AddWebhook Adds a webhook to an account.
+func (m *MySQL) AddWebhook(webhook types.Webhook) (*types.Webhook, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	now := time.Now().UTC().Truncate(time.Second)
+	res, err := db.ExecContext(
+		ctx,
+		"INSERT INTO webhook(account_id, org_id, webhook_url, webhook_secret, webhook_events, webhook_created_at) VALUES (?, ?, ?, ?, ?, ?);",
+		webhook.AccountIdentifier,
+		webhook.Organization,
+		webhook.URL,
+		webhook.Secret,
+		webhook.EventsValue(),
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to add webhook: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine ID for webhook: %v", err)
+	}
+	webhook.Identifier = id
+	webhook.CreatedAt = now
+	return &webhook, nil
+}
+
+// GetAccountWebhooks Gets all webhooks for an account, secrets included.
+func (m *MySQL) GetAccountWebhooks(account int64) ([]types.Webhook, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT webhook_id, account_id, org_id, webhook_url, webhook_secret, webhook_events, webhook_created_at "+
+			"FROM webhook WHERE account_id=? ORDER BY webhook_id;",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving webhooks: %v", err)
+	}
+	defer res.Close()
+	var outWebhooks []types.Webhook
+	for res.Next() {
+		var webhook types.Webhook
+		var events string
+		err := res.Scan(
+			&webhook.Identifier,
+			&webhook.AccountIdentifier,
+			&webhook.Organization,
+			&webhook.URL,
+			&webhook.Secret,
+			&events,
+			&webhook.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting webhook: %v", err)
+		}
+		webhook.SetEvents(events)
+		outWebhooks = append(outWebhooks, webhook)
+	}
+	return outWebhooks, nil
+}
+
+// DeleteWebhook Deletes a webhook belonging to an account along with its delivery log.
+func (m *MySQL) DeleteWebhook(account, webhook int64) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE FROM webhook WHERE account_id=? AND webhook_id=?;",
+		account,
+		webhook,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error deleting webhook, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// AddWebhookDeliveries Queues deliveries to be sent.
+func (m *MySQL) AddWebhookDeliveries(deliveries []types.WebhookDelivery) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to add webhook deliveries: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, delivery := range deliveries {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO webhook_delivery(webhook_id, delivery_event, delivery_payload, delivery_status, "+
+				"delivery_next_attempt, delivery_created_at) VALUES (?, ?, ?, ?, ?, ?);",
+			delivery.WebhookIdentifier,
+			delivery.Event,
+			delivery.Payload,
+			types.DeliveryPending,
+			delivery.NextAttempt.Unix(),
+			now,
+		)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error adding webhook delivery: %v", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+func (m *MySQL) getWebhookDeliveries(query string, args ...interface{}) ([]types.WebhookDelivery, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT d.delivery_id, d.webhook_id, w.webhook_url, w.webhook_secret, d.delivery_event, d.delivery_payload, "+
+			"d.delivery_status, d.delivery_attempts, d.delivery_next_attempt, d.delivery_response_code, d.delivery_error, "+
+			"d.delivery_created_at, d.delivery_delivered_at FROM webhook_delivery d JOIN webhook w ON d.webhook_id=w.webhook_id "+
+			query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving webhook deliveries: %v", err)
+	}
+	defer res.Close()
+	var outDeliveries []types.WebhookDelivery
+	for res.Next() {
+		var delivery types.WebhookDelivery
+		var nextAttempt int64
+		err := res.Scan(
+			&delivery.Identifier,
+			&delivery.WebhookIdentifier,
+			&delivery.URL,
+			&delivery.Secret,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&nextAttempt,
+			&delivery.ResponseCode,
+			&delivery.Error,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting webhook delivery: %v", err)
+		}
+		delivery.NextAttempt = time.Unix(nextAttempt, 0)
+		outDeliveries = append(outDeliveries, delivery)
+	}
+	return outDeliveries, nil
+}
+
+// ClaimWebhookDeliveries Claims up to limit deliveries due to be sent at or before now, oldest first, so no
+// other instance sends them before until. Deliveries claimed by another instance are skipped until their claim
+// runs out. Recording the result of an attempt releases the claim.
+func (m *MySQL) ClaimWebhookDeliveries(now, until time.Time, limit int) ([]types.WebhookDelivery, error) {
+	db, err := m.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	// Rows another instance is claiming are skipped rather than waited on.
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("unable to begin transaction to claim webhook deliveries: %v", err)
+	}
+	res, err := tx.QueryContext(
+		ctx,
+		"SELECT delivery_id FROM webhook_delivery WHERE delivery_status=? AND delivery_next_attempt<=? AND "+
+			"delivery_claimed_until<=? ORDER BY delivery_next_attempt, delivery_id LIMIT ? FOR UPDATE SKIP LOCKED;",
+		types.DeliveryPending,
+		now.Unix(),
+		now.Unix(),
+		limit,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error retrieving pending webhook deliveries: %v", err)
+	}
+	var ids []interface{}
+	for res.Next() {
+		var id int64
+		if err := res.Scan(&id); err != nil {
+			res.Close()
+			tx.Rollback()
+			return nil, fmt.Errorf("error getting pending webhook delivery: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	res.Close()
+	if len(ids) < 1 {
+		tx.Rollback()
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE webhook_delivery SET delivery_claimed_until=? WHERE delivery_id IN ("+placeholders+");",
+		append([]interface{}{until.Unix()}, ids...)...,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error claiming webhook deliveries: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return m.getWebhookDeliveries(
+		"WHERE d.delivery_id IN ("+placeholders+") ORDER BY d.delivery_next_attempt, d.delivery_id;",
+		ids...,
+	)
+}
+
+// GetWebhookDeliveries Gets the delivery log for a webhook belonging to an account, newest first.
+func (m *MySQL) GetWebhookDeliveries(account, webhook int64, limit int) ([]types.WebhookDelivery, error) {
+	return m.getWebhookDeliveries(
+		"WHERE w.account_id=? AND d.webhook_id=? ORDER BY d.delivery_id DESC LIMIT ?;",
+		account,
+		webhook,
+		limit,
+	)
+}
+
+// UpdateWebhookDelivery Records the result of an attempt to send a delivery.
+func (m *MySQL) UpdateWebhookDelivery(delivery types.WebhookDelivery) error {
+	db, err := m.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE webhook_delivery SET delivery_status=?, delivery_attempts=?, delivery_next_attempt=?, "+
+			"delivery_response_code=?, delivery_error=?, delivery_delivered_at=?, delivery_claimed_until=0 WHERE delivery_id=?;",
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttempt.Unix(),
+		delivery.ResponseCode,
+		delivery.Error,
+		delivery.DeliveredAt,
+		delivery.Identifier,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error updating webhook delivery, rows affected: %v", rows)
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package postgres
 
 import (
+	"chronokeep/remote/database"
 	"chronokeep/remote/types"
 	"context"
 	"errors"
@@ -26,6 +27,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 const (
@@ -43,19 +45,19 @@ func (p *Postgres) getAccountInternal(email, key *string, id *int64) (*types.Acc
 	if email != nil {
 		res, err = db.Query(
 			ctx,
-			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_token, account_refresh_token FROM account WHERE account_deleted=FALSE AND account_email=$1;",
+			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account WHERE account_deleted=FALSE AND account_email=$1;",
 			email,
 		)
 	} else if key != nil {
 		res, err = db.Query(
 			ctx,
-			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_token, account_refresh_token FROM account NATURAL JOIN api_key WHERE account_deleted=FALSE AND key_deleted=FALSE AND key_value=$1;",
-			key,
+			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account NATURAL JOIN api_key WHERE account_deleted=FALSE AND key_deleted=FALSE AND key_value=$1;",
+			types.HashKey(*key),
 		)
 	} else if id != nil {
 		res, err = db.Query(
 			ctx,
-			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_token, account_refresh_token FROM account WHERE account_deleted=FALSE AND account_id=$1;",
+			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account WHERE account_deleted=FALSE AND account_id=$1;",
 			id,
 		)
 	} else {
@@ -67,6 +69,7 @@ func (p *Postgres) getAccountInternal(email, key *string, id *int64) (*types.Acc
 	defer res.Close()
 	var outAccount types.Account
 	if res.Next() {
+		var lockedUntil int64
 		err := res.Scan(
 			&outAccount.Identifier,
 			&outAccount.Name,
@@ -75,12 +78,14 @@ func (p *Postgres) getAccountInternal(email, key *string, id *int64) (*types.Acc
 			&outAccount.Password,
 			&outAccount.Locked,
 			&outAccount.WrongPassAttempts,
-			&outAccount.Token,
-			&outAccount.RefreshToken,
+			&outAccount.LockReason,
+			&lockedUntil,
+			&outAccount.LockCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting account information: %v", err)
 		}
+		outAccount.SetLockedUntil(lockedUntil)
 	} else {
 		return nil, nil
 	}
@@ -112,7 +117,7 @@ func (p *Postgres) GetAccounts() ([]types.Account, error) {
 	defer cancelfunc()
 	res, err := db.Query(
 		ctx,
-		"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_token, account_refresh_token FROM account WHERE account_deleted=FALSE;",
+		"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account WHERE account_deleted=FALSE;",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving accounts: %v", err)
@@ -121,6 +126,7 @@ func (p *Postgres) GetAccounts() ([]types.Account, error) {
 	var outAccounts []types.Account
 	for res.Next() {
 		var account types.Account
+		var lockedUntil int64
 		err := res.Scan(
 			&account.Identifier,
 			&account.Name,
@@ -129,12 +135,14 @@ func (p *Postgres) GetAccounts() ([]types.Account, error) {
 			&account.Password,
 			&account.Locked,
 			&account.WrongPassAttempts,
-			&account.Token,
-			&account.RefreshToken,
+			&account.LockReason,
+			&lockedUntil,
+			&account.LockCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting account information: %v", err)
 		}
+		account.SetLockedUntil(lockedUntil)
 		outAccounts = append(outAccounts, account)
 	}
 	return outAccounts, nil
@@ -173,7 +181,7 @@ func (p *Postgres) AddAccount(account types.Account) (*types.Account, error) {
 }
 
 // DeleteAccount Deletes an account from view, does not permanently delete from database.
-// This does not delete events associated with this account, but does set keys to deleted.
+// This does not delete events associated with this account, but does set keys to deleted and ends its sessions.
 func (p *Postgres) DeleteAccount(id int64) error {
 	db, err := p.GetDB()
 	if err != nil {
@@ -181,9 +189,11 @@ func (p *Postgres) DeleteAccount(id int64) error {
 	}
 	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancelfunc()
+	deletedAt := time.Now().UnixMilli()
 	res, err := db.Exec(
 		ctx,
-		"UPDATE account SET account_deleted=TRUE WHERE account_id=$1",
+		"UPDATE account SET account_deleted=TRUE, account_deleted_at=$1 WHERE account_id=$2",
+		deletedAt,
 		id,
 	)
 	if err != nil {
@@ -194,12 +204,21 @@ func (p *Postgres) DeleteAccount(id int64) error {
 	}
 	_, err = db.Exec(
 		ctx,
-		"UPDATE api_key SET key_deleted=TRUE WHERE account_id=$1",
+		"UPDATE api_key SET key_deleted=TRUE, key_deleted_at=$1 WHERE key_deleted=FALSE AND account_id=$2",
+		deletedAt,
 		id,
 	)
 	if err != nil {
 		return fmt.Errorf("error deleting keys attached to account: %v", err)
 	}
+	_, err = db.Exec(
+		ctx,
+		"DELETE FROM account_session WHERE account_id=$1",
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting sessions attached to account: %v", err)
+	}
 	return nil
 }
 
@@ -213,7 +232,7 @@ func (p *Postgres) ResurrectAccount(email string) error {
 	defer cancelfunc()
 	res, err := db.Exec(
 		ctx,
-		"UPDATE account SET account_deleted=FALSE WHERE account_email=$1",
+		"UPDATE account SET account_deleted=FALSE, account_deleted_at=0 WHERE account_email=$1",
 		email,
 	)
 	if err != nil {
@@ -235,7 +254,7 @@ func (p *Postgres) GetDeletedAccount(email string) (*types.Account, error) {
 	defer cancelfunc()
 	res, err := db.Query(
 		ctx,
-		"SELECT account_id, account_name, account_email, account_type FROM account WHERE account_deleted=TRUE AND account_email=$1;",
+		"SELECT account_id, account_name, account_email, account_type, account_deleted_at FROM account WHERE account_deleted=TRUE AND account_email=$1;",
 		email,
 	)
 	if err != nil {
@@ -244,15 +263,18 @@ func (p *Postgres) GetDeletedAccount(email string) (*types.Account, error) {
 	defer res.Close()
 	var outAccount types.Account
 	if res.Next() {
+		var deletedAt int64
 		err := res.Scan(
 			&outAccount.Identifier,
 			&outAccount.Name,
 			&outAccount.Email,
 			&outAccount.Type,
+			&deletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting account information: %v", err)
 		}
+		outAccount.SetDeletedAt(deletedAt)
 	} else {
 		return nil, nil
 	}
@@ -292,9 +314,6 @@ func (p *Postgres) ChangePassword(email, newPassword string, logout ...bool) err
 	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancelfunc()
 	stmt := "UPDATE account SET account_password=$1 WHERE account_email=$2;"
-	if len(logout) > 0 && logout[0] {
-		stmt = "UPDATE account SET account_password=$1, account_token='', account_refresh_token='' WHERE account_email=$2;"
-	}
 	res, err := db.Exec(
 		ctx,
 		stmt,
@@ -307,29 +326,9 @@ func (p *Postgres) ChangePassword(email, newPassword string, logout ...bool) err
 	if res.RowsAffected() != 1 {
 		return fmt.Errorf("error changing password, rows affected: %v", res.RowsAffected())
 	}
-	return nil
-}
-
-// UpdateTokens Updates a user's tokens.
-func (p *Postgres) UpdateTokens(account types.Account) error {
-	db, err := p.GetDB()
-	if err != nil {
-		return err
-	}
-	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancelfunc()
-	res, err := db.Exec(
-		ctx,
-		"UPDATE account SET account_token=$1, account_refresh_token=$2 WHERE account_email=$3;",
-		account.Token,
-		account.RefreshToken,
-		account.Email,
-	)
-	if err != nil {
-		return fmt.Errorf("error updating tokens: %v", err)
-	}
-	if res.RowsAffected() != 1 {
-		return fmt.Errorf("error updating tokens, rows affected: %v", res.RowsAffected())
+	// Changing someone else's password logs them out everywhere.
+	if len(logout) > 0 && logout[0] {
+		return p.deleteEmailSessions(email)
 	}
 	return nil
 }
@@ -344,7 +343,7 @@ func (p *Postgres) ChangeEmail(oldEmail, newEmail string) error {
 	defer cancelfunc()
 	res, err := db.Exec(
 		ctx,
-		"UPDATE account SET account_email=$1, account_token='', account_refresh_token='' WHERE account_email=$2;",
+		"UPDATE account SET account_email=$1 WHERE account_email=$2;",
 		newEmail,
 		oldEmail,
 	)
@@ -354,10 +353,11 @@ func (p *Postgres) ChangeEmail(oldEmail, newEmail string) error {
 	if res.RowsAffected() != 1 {
 		return fmt.Errorf("error changing email, rows affected: %v", res.RowsAffected())
 	}
-	return nil
+	return p.deleteEmailSessions(newEmail)
 }
 
-// InvalidPassword Increments/locks an account due to an invalid password.
+// InvalidPassword Increments/locks an account due to an invalid password. Under the backoff lockout policy
+// the lock expires on its own, lasting twice as long each time the account is locked before a valid password.
 func (p *Postgres) InvalidPassword(account types.Account) error {
 	db, err := p.GetDB()
 	if err != nil {
@@ -369,18 +369,39 @@ func (p *Postgres) InvalidPassword(account types.Account) error {
 	if err != nil {
 		return fmt.Errorf("error trying to retrieve account: %v", err)
 	}
-	locked := false
-	if pAcc.WrongPassAttempts >= MaxLoginAttempts {
+	if pAcc == nil {
+		return errors.New("account not found")
+	}
+	locked := pAcc.Locked
+	var lockedUntil int64
+	if pAcc.LockedUntil != nil {
+		lockedUntil = pAcc.LockedUntil.Unix()
+	}
+	wrongPass := pAcc.WrongPassAttempts + 1
+	lockCount := pAcc.LockCount
+	if !locked && pAcc.WrongPassAttempts >= MaxLoginAttempts {
 		locked = true
+		lockCount++
+		if duration := p.config.LockoutDuration(pAcc.LockCount); duration > 0 {
+			lockedUntil = time.Now().Add(duration).Unix()
+			// Wrong passwords are counted again from zero once the lock expires.
+			wrongPass = 0
+		}
 	}
-	stmt := "UPDATE account SET account_locked=$1, account_wrong_pass=account_wrong_pass + 1 WHERE account_email=$2;"
+	lockReason := ""
 	if locked {
-		stmt = "UPDATE account SET account_locked=$1, account_wrong_pass=account_wrong_pass + 1, account_token='', account_refresh_token='' WHERE account_email=$2;"
+		lockReason = types.LockReasonWrongPassword
 	}
+	stmt := "UPDATE account SET account_locked=$1, account_lock_reason=$2, account_locked_until=$3, account_lock_count=$4, " +
+		"account_wrong_pass=$5 WHERE account_email=$6;"
 	res, err := db.Exec(
 		ctx,
 		stmt,
 		locked,
+		lockReason,
+		lockedUntil,
+		lockCount,
+		wrongPass,
 		account.Email,
 	)
 	if err != nil {
@@ -389,6 +410,10 @@ func (p *Postgres) InvalidPassword(account types.Account) error {
 	if res.RowsAffected() != 1 {
 		return fmt.Errorf("error updating invalid password information, rows affected: %v", res.RowsAffected())
 	}
+	// Locking an account logs it out everywhere.
+	if locked {
+		return p.deleteEmailSessions(account.Email)
+	}
 	return nil
 }
 
@@ -409,7 +434,7 @@ func (p *Postgres) ValidPassword(account types.Account) error {
 	defer cancelfunc()
 	_, err = db.Exec(
 		ctx,
-		"UPDATE account SET account_wrong_pass=0 WHERE account_email=$1;",
+		"UPDATE account SET account_wrong_pass=0, account_locked=FALSE, account_lock_reason='', account_locked_until=0, account_lock_count=0 WHERE account_email=$1;",
 		account.Email,
 	)
 	if err != nil {
@@ -431,7 +456,7 @@ func (p *Postgres) UnlockAccount(account types.Account) error {
 	}
 	res, err := db.Exec(
 		ctx,
-		"UPDATE account SET account_wrong_pass=0, account_locked=FALSE WHERE account_email=$1;",
+		"UPDATE account SET account_wrong_pass=0, account_locked=FALSE, account_lock_reason='', account_locked_until=0, account_lock_count=0 WHERE account_email=$1;",
 		account.Email,
 	)
 	if err != nil {
@@ -443,3 +468,169 @@ func (p *Postgres) UnlockAccount(account types.Account) error {
 	return nil
 }
 
+// GetDeletedAccounts Gets every account that has been deleted.
+func (p *Postgres) GetDeletedAccounts() ([]types.Account, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT account_id, account_name, account_email, account_type, account_deleted_at FROM account WHERE account_deleted=TRUE;",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving deleted accounts: %v", err)
+	}
+	defer res.Close()
+	var outAccounts []types.Account
+	for res.Next() {
+		var account types.Account
+		var deletedAt int64
+		err := res.Scan(
+			&account.Identifier,
+			&account.Name,
+			&account.Email,
+			&account.Type,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting account information: %v", err)
+		}
+		account.SetDeletedAt(deletedAt)
+		outAccounts = append(outAccounts, account)
+	}
+	return outAccounts, nil
+}
+
+// RestoreAccount Brings a deleted account back along with the keys deleted with it, those having been given the
+// same deletion time. Keys that were deleted before the account stay deleted.
+func (p *Postgres) RestoreAccount(id int64) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %v", err)
+	}
+	var deletedAt int64
+	err = tx.QueryRow(
+		ctx,
+		"SELECT account_deleted_at FROM account WHERE account_deleted=TRUE AND account_id=$1;",
+		id,
+	).Scan(&deletedAt)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error retrieving deleted account: %v", err)
+	}
+	_, err = tx.Exec(
+		ctx,
+		"UPDATE api_key SET key_deleted=FALSE, key_deleted_at=0 WHERE key_deleted=TRUE AND account_id=$1 AND key_deleted_at=$2;",
+		id,
+		deletedAt,
+	)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error restoring keys attached to account: %v", err)
+	}
+	_, err = tx.Exec(
+		ctx,
+		"UPDATE account SET account_deleted=FALSE, account_deleted_at=0 WHERE account_id=$1;",
+		id,
+	)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error restoring account: %v", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// PurgeAccount Permanently removes an account along with its keys, reads, notifications, sessions and everything
+// else attached to it. With dryRun set nothing is removed and the counts are of the rows that would have been.
+// Accounts that own an organization aren't purged, the organization has to be deleted first.
+func (p *Postgres) PurgeAccount(id int64, dryRun bool) (*types.PurgeCounts, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start transaction: %v", err)
+	}
+	var owned int64
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM organization WHERE account_id=$1;", id).Scan(&owned)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error checking owned organizations: %v", err)
+	}
+	if owned > 0 {
+		tx.Rollback(ctx)
+		return nil, database.ErrOwnsOrganization
+	}
+	counts := &types.PurgeCounts{}
+	// Ordered so that no row is removed while another still references it.
+	steps := []struct {
+		count *int64
+		table string
+		where string
+	}{
+		{&counts.Reads, "read", "key_id IN (SELECT key_id FROM api_key WHERE account_id=$1)"},
+		{&counts.Notifications, "notification", "key_id IN (SELECT key_id FROM api_key WHERE account_id=$1)"},
+		{&counts.WebhookDeliveries, "webhook_delivery", "webhook_id IN (SELECT webhook_id FROM webhook WHERE account_id=$1)"},
+		{&counts.Webhooks, "webhook", "account_id=$1"},
+		{&counts.AlertRules, "alert_rule", "account_id=$1"},
+		{&counts.PasswordResets, "password_reset", "account_id=$1"},
+		{&counts.RecoveryCodes, "recovery_code", "account_id=$1"},
+		{&counts.TwoFactor, "two_factor", "account_id=$1"},
+		{&counts.Sessions, "account_session", "account_id=$1"},
+		{&counts.Memberships, "org_member", "account_id=$1"},
+		{&counts.Keys, "api_key", "account_id=$1"},
+		{&counts.Accounts, "account", "account_id=$1"},
+	}
+	for _, step := range steps {
+		if dryRun {
+			err = tx.QueryRow(
+				ctx,
+				"SELECT COUNT(*) FROM "+step.table+" WHERE "+step.where+";",
+				id,
+			).Scan(step.count)
+		} else {
+			var res pgconn.CommandTag
+			res, err = tx.Exec(
+				ctx,
+				"DELETE FROM "+step.table+" WHERE "+step.where+";",
+				id,
+			)
+			*step.count = res.RowsAffected()
+		}
+		if err != nil {
+			tx.Rollback(ctx)
+			return nil, fmt.Errorf("error purging from %s: %v", step.table, err)
+		}
+	}
+	if counts.Accounts != 1 {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error purging account, rows affected: %v", counts.Accounts)
+	}
+	if dryRun {
+		tx.Rollback(ctx)
+		return counts, nil
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return counts, nil
+}
@@ -20,7 +20,9 @@ package postgres
 
 import (
 	"chronokeep/remote/auth"
+	"chronokeep/remote/auth/authtest"
 	"chronokeep/remote/database"
+	"chronokeep/remote/types"
 	"chronokeep/remote/util"
 	"context"
 	"errors"
@@ -30,6 +32,10 @@ import (
 	"time"
 )
 
+func TestMain(m *testing.M) {
+	authtest.Main(m)
+}
+
 const (
 	dbName     = "remote_test"
 	dbHost     = "database.lan"
@@ -78,7 +84,7 @@ func setupTests(t *testing.T) (*Postgres, func(t *testing.T), error) {
 		}
 		// Otherwise check if our database is out of date and update if necessary.
 	} else if dbVersion < database.CurrentVersion {
-		err = o.updateTables(dbVersion, database.CurrentVersion)
+		_, err = o.Migrate(database.CurrentVersion, false)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -237,6 +243,34 @@ func TestSetupAndGet(t *testing.T) {
 	}
 }
 
+func TestSetting(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	value, err := db.GetSetting("test_setting")
+	if err != nil {
+		t.Fatalf("Error getting setting: %v", err)
+	}
+	if value != "" {
+		t.Errorf("Expected setting that hasn't been set to be empty, found '%v'.", value)
+	}
+	for _, expected := range []string{"first", "second"} {
+		err = db.SetSetting("test_setting", expected)
+		if err != nil {
+			t.Fatalf("Error setting setting: %v", err)
+		}
+		value, err = db.GetSetting("test_setting")
+		if err != nil {
+			t.Fatalf("Error getting setting: %v", err)
+		}
+		if value != expected {
+			t.Errorf("Expected setting to be '%v', found '%v'.", expected, value)
+		}
+	}
+}
+
 func TestCheckVersion(t *testing.T) {
 	db, finalize, err := setupTests(t)
 	if err != nil {
@@ -267,7 +301,7 @@ func TestUpgrade(t *testing.T) {
 		t.Fatalf("Version set to '%v' expected '1'.", version)
 	}
 	// Verify version 2
-	err = db.updateTables(version, 2)
+	_, err = db.Migrate(2, false)
 	if err != nil {
 		t.Fatalf("error updating database from %d to %d: %v", version, 2, err)
 	}
@@ -275,6 +309,372 @@ func TestUpgrade(t *testing.T) {
 	if version != 2 {
 		t.Fatalf("Version set to %v expected 2.", version)
 	}
+	// Verify version 3
+	_, err = db.Migrate(3, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 3, err)
+	}
+	version = db.checkVersion()
+	if version != 3 {
+		t.Fatalf("Version set to %v expected 3.", version)
+	}
+	// Verify version 4
+	_, err = db.Migrate(4, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 4, err)
+	}
+	version = db.checkVersion()
+	if version != 4 {
+		t.Fatalf("Version set to %v expected 4.", version)
+	}
+	// Add a key with a read and a notification to make sure they stay attached to the key.
+	for _, query := range []string{
+		"INSERT INTO account(account_name, account_email, account_password, account_type) " +
+			"VALUES ('John Smith', 'j@test.com', 'password', 'admin');",
+		"INSERT INTO api_key(account_id, key_name, key_value, key_type) " +
+			"SELECT account_id, 'reader1', 'upgrade-key', 'write' FROM account WHERE account_email='j@test.com';",
+		"INSERT INTO read(key_value, identifier, seconds) VALUES ('upgrade-key', '1001', 100);",
+		"INSERT INTO notification(key_value, notification_type, notification_when) VALUES ('upgrade-key', 'UPS_ONLINE', 100);",
+	} {
+		_, err = db.db.Exec(context.Background(), query)
+		if err != nil {
+			t.Fatalf("error adding values before update: %v", err)
+		}
+	}
+	// Verify version 5
+	_, err = db.Migrate(5, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 5, err)
+	}
+	version = db.checkVersion()
+	if version != 5 {
+		t.Fatalf("Version set to %v expected 5.", version)
+	}
+	// Verify version 6
+	_, err = db.Migrate(6, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 6, err)
+	}
+	version = db.checkVersion()
+	if version != 6 {
+		t.Fatalf("Version set to %v expected 6.", version)
+	}
+	var storedValue string
+	err = db.db.QueryRow(context.Background(), "SELECT key_value FROM api_key;").Scan(&storedValue)
+	if err != nil {
+		t.Fatalf("error getting stored key value after update: %v", err)
+	}
+	if storedValue != types.HashKey("upgrade-key") {
+		t.Errorf("Expected key value to be replaced by its hash, found %v.", storedValue)
+	}
+	// Reading accounts and keys needs the columns added in versions 13 and 14, until then the key is looked up directly.
+	mkey := &types.MultiKey{Key: &types.Key{}, Account: &types.Account{Email: "j@test.com"}}
+	err = db.db.QueryRow(context.Background(), "SELECT account_id, key_prefix FROM api_key;").Scan(&mkey.Account.Identifier, &mkey.Key.Prefix)
+	if err != nil {
+		t.Fatalf("error getting key after update: %v", err)
+	}
+	if mkey.Key.Prefix != types.KeyPrefix("upgrade-key") {
+		t.Errorf("Expected key prefix %v, found %v.", types.KeyPrefix("upgrade-key"), mkey.Key.Prefix)
+	}
+	// Reads are scoped by the organization column added in version 14, so they're looked up directly as well.
+	reads := make([]types.Read, 1)
+	err = db.db.QueryRow(context.Background(), "SELECT read_id, identifier, key_prefix FROM read NATURAL JOIN api_key WHERE key_name='reader1';").Scan(&reads[0].ID, &reads[0].Identifier, &reads[0].Key)
+	if err != nil {
+		t.Fatalf("error getting reads after update: %v", err)
+	}
+	if reads[0].ID != 1 || reads[0].Identifier != "1001" || reads[0].Key != mkey.Key.Prefix {
+		t.Errorf("Expected read to be kept, found %+v.", reads)
+	}
+	// Tokens stored on the account before sessions existed are dropped.
+	_, err = db.db.Exec(context.Background(), "UPDATE account SET account_token='old-token', account_refresh_token='old-refresh';")
+	if err != nil {
+		t.Fatalf("error adding values before update: %v", err)
+	}
+	// Verify version 7
+	_, err = db.Migrate(7, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 7, err)
+	}
+	version = db.checkVersion()
+	if version != 7 {
+		t.Fatalf("Version set to %v expected 7.", version)
+	}
+	account := mkey.Account
+	session, err := db.GetSession("old-token")
+	if err != nil {
+		t.Fatalf("error getting session after update: %v", err)
+	}
+	if session != nil {
+		t.Errorf("Expected old token to not be kept, found %+v.", *session)
+	}
+	_, err = db.AddSession(types.Session{AccountIdentifier: account.Identifier, Token: "new-token", RefreshToken: "new-refresh"})
+	if err != nil {
+		t.Fatalf("error adding session after update: %v", err)
+	}
+	session, _ = db.GetSession("new-token")
+	if session == nil || session.AccountIdentifier != account.Identifier {
+		t.Errorf("Expected session for account %v, found %+v.", account.Identifier, session)
+	}
+	// Verify version 8
+	_, err = db.Migrate(8, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 8, err)
+	}
+	version = db.checkVersion()
+	if version != 8 {
+		t.Fatalf("Version set to %v expected 8.", version)
+	}
+	var unacknowledged int
+	err = db.db.QueryRow(context.Background(), "SELECT COUNT(*) FROM notification WHERE notification_type='UPS_ONLINE' AND notification_acknowledged_at IS NULL;").Scan(&unacknowledged)
+	if err != nil {
+		t.Fatalf("error getting notifications after update: %v", err)
+	}
+	if unacknowledged != 1 {
+		t.Fatalf("Expected an unacknowledged notification to be kept, found %v.", unacknowledged)
+	}
+	// Verify version 9
+	_, err = db.Migrate(9, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 9, err)
+	}
+	version = db.checkVersion()
+	if version != 9 {
+		t.Fatalf("Version set to %v expected 9.", version)
+	}
+	deliveries, err := db.ClaimWebhookDeliveries(time.Now(), time.Now().Add(time.Minute), 10)
+	if err != nil || len(deliveries) != 0 {
+		t.Errorf("Expected no webhook deliveries after update, found %v (%v).", deliveries, err)
+	}
+	// Verify version 10
+	_, err = db.Migrate(10, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 10, err)
+	}
+	version = db.checkVersion()
+	if version != 10 {
+		t.Fatalf("Version set to %v expected 10.", version)
+	}
+	_, err = db.AddAlertRule(types.AlertRule{
+		AccountIdentifier: account.Identifier,
+		Types:             []string{"MAX_TEMP"},
+		Recipients:        []string{"alerts@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("error adding alert rule after update: %v", err)
+	}
+	rules, err := db.GetAccountAlertRules(account.Identifier)
+	if err != nil || len(rules) != 1 {
+		t.Errorf("Expected an alert rule after update, found %v (%v).", rules, err)
+	}
+	// Verify version 11
+	_, err = db.Migrate(11, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 11, err)
+	}
+	version = db.checkVersion()
+	if version != 11 {
+		t.Fatalf("Version set to %v expected 11.", version)
+	}
+	err = db.AddPasswordReset(types.PasswordReset{
+		AccountIdentifier: account.Identifier,
+		Token:             "reset",
+		ExpiresAt:         time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("error adding password reset after update: %v", err)
+	}
+	// Verify version 12
+	_, err = db.Migrate(12, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 12, err)
+	}
+	version = db.checkVersion()
+	if version != 12 {
+		t.Fatalf("Version set to %v expected 12.", version)
+	}
+	err = db.AddTwoFactor(types.TwoFactor{
+		AccountIdentifier: account.Identifier,
+		Secret:            "secret",
+	})
+	if err != nil {
+		t.Fatalf("error adding two factor after update: %v", err)
+	}
+	err = db.EnableTwoFactor(account.Identifier, 1, []string{"recovery"})
+	if err != nil {
+		t.Fatalf("error enabling two factor after update: %v", err)
+	}
+	twoFactor, err := db.GetTwoFactor(account.Identifier)
+	if err != nil || twoFactor == nil || !twoFactor.Enabled || twoFactor.RecoveryCodes != 1 {
+		t.Errorf("Expected enabled two factor with a recovery code after update, found %+v (%v).", twoFactor, err)
+	}
+	// Verify version 13
+	_, err = db.Migrate(13, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 13, err)
+	}
+	version = db.checkVersion()
+	if version != 13 {
+		t.Fatalf("Version set to %v expected 13.", version)
+	}
+	reset, err := db.ResetPassword("reset", "newpassword", time.Now())
+	if err != nil || reset == nil {
+		t.Errorf("Expected to reset password after update, found %v (%v).", reset, err)
+	}
+	err = db.InvalidPassword(*account)
+	if err != nil {
+		t.Fatalf("error setting invalid password after update: %v", err)
+	}
+	account, err = db.GetAccount(account.Email)
+	if err != nil || account == nil || account.WrongPassAttempts != 1 || account.Locked {
+		t.Fatalf("Expected a wrong password after update, found %+v (%v).", account, err)
+	}
+	failures, err := db.AddLoginFailure("10.0.0.1")
+	if err != nil || failures.Count != 1 {
+		t.Errorf("Expected a login failure after update, found %+v (%v).", failures, err)
+	}
+	// Verify version 14
+	_, err = db.Migrate(14, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 14, err)
+	}
+	version = db.checkVersion()
+	if version != 14 {
+		t.Fatalf("Version set to %v expected 14.", version)
+	}
+	_, err = db.RotateKey("upgrade-key", "rotated-key", nil)
+	if err != nil {
+		t.Fatalf("error rotating key after update: %v", err)
+	}
+	reads, _ = db.GetReads(account.Identifier, nil, "reader1", 0, 1000)
+	if len(reads) != 1 {
+		t.Errorf("Expected read to be kept after rotating key, found %+v.", reads)
+	}
+	mkey, err = db.GetKeyAndAccount("rotated-key")
+	if err != nil || mkey == nil || mkey.Account.Identifier != account.Identifier || mkey.Key.Organization != nil {
+		t.Fatalf("Expected to get key and account after update, found %+v (%v).", mkey, err)
+	}
+	org, err := db.AddOrganization(types.Organization{AccountIdentifier: account.Identifier, Name: "Timing Company"})
+	if err != nil {
+		t.Fatalf("error adding organization after update: %v", err)
+	}
+	_, err = db.AddKey(types.Key{
+		AccountIdentifier: account.Identifier,
+		Organization:      &org.Identifier,
+		Name:              "reader2",
+		Value:             "organization-key",
+		Type:              "write",
+	})
+	if err != nil {
+		t.Fatalf("error adding organization key after update: %v", err)
+	}
+	keys, err := db.GetOrganizationKeys(org.Identifier)
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Expected an organization key after update, found %+v (%v).", keys, err)
+	}
+	reads, _ = db.GetReads(account.Identifier, &org.Identifier, "reader1", 0, 1000)
+	if len(reads) != 0 {
+		t.Errorf("Expected no reads for a reader outside of the organization, found %+v.", reads)
+	}
+	notes, err := db.GetNotificationHistory(account.Identifier, nil, "", 0, 1000, true, nil, 10)
+	if err != nil {
+		t.Fatalf("error getting notification history after update: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Type != "UPS_ONLINE" || notes[0].Acknowledged != nil {
+		t.Fatalf("Expected an unacknowledged notification to be kept, found %+v.", notes)
+	}
+	count, err := db.AcknowledgeNotifications(account.Identifier, nil, []int64{notes[0].Identifier})
+	if err != nil || count != 1 {
+		t.Errorf("Expected to acknowledge notification after update, found %v (%v).", count, err)
+	}
+	// Verify version 15
+	_, err = db.Migrate(15, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 15, err)
+	}
+	version = db.checkVersion()
+	if version != 15 {
+		t.Fatalf("Version set to %v expected 15.", version)
+	}
+	role, err := db.GetRole(account.Type)
+	if err != nil || role == nil || !role.Can(types.PermKeysCreate) {
+		t.Errorf("Expected the account's role to exist after update, found %+v (%v).", role, err)
+	}
+	roles, err := db.GetRoles()
+	if err != nil || len(roles) != len(types.DefaultRoles()) {
+		t.Errorf("Expected the default roles after update, found %+v (%v).", roles, err)
+	}
+	// Verify version 16
+	_, err = db.Migrate(16, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 16, err)
+	}
+	version = db.checkVersion()
+	if version != 16 {
+		t.Fatalf("Version set to %v expected 16.", version)
+	}
+	err = db.DeleteAccount(account.Identifier)
+	if err != nil {
+		t.Fatalf("error deleting account after update: %v", err)
+	}
+	err = db.RestoreAccount(account.Identifier)
+	if err != nil {
+		t.Fatalf("error restoring account after update: %v", err)
+	}
+	keys, err = db.GetOrganizationKeys(org.Identifier)
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Expected organization key to be restored after update, found %+v (%v).", keys, err)
+	}
+	// Verify version 17
+	_, err = db.Migrate(17, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 17, err)
+	}
+	version = db.checkVersion()
+	if version != 17 {
+		t.Fatalf("Version set to %v expected 17.", version)
+	}
+	webhook, err := db.AddWebhook(types.Webhook{
+		AccountIdentifier: account.Identifier,
+		Organization:      &org.Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	if err != nil {
+		t.Fatalf("error adding webhook after update: %v", err)
+	}
+	hooks, err := db.GetAccountWebhooks(account.Identifier)
+	if err != nil || len(hooks) != 1 || !hooks[0].InOrganization(&org.Identifier) {
+		t.Errorf("Expected organization webhook after update, found %+v (%v).", hooks, err)
+	}
+	err = db.AddWebhookDeliveries([]types.WebhookDelivery{
+		{
+			WebhookIdentifier: webhook.Identifier,
+			Event:             types.WebhookReadsAdded,
+			Payload:           "{}",
+			NextAttempt:       time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("error adding webhook delivery after update: %v", err)
+	}
+	deliveries, err = db.ClaimWebhookDeliveries(time.Now(), time.Now().Add(time.Minute), 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Errorf("Expected a pending webhook delivery after update, found %v (%v).", deliveries, err)
+	}
+	// Verify version 18
+	_, err = db.Migrate(18, false)
+	if err != nil {
+		t.Fatalf("error updating database from %d to %d: %v", version, 18, err)
+	}
+	version = db.checkVersion()
+	if version != 18 {
+		t.Fatalf("Version set to %v expected 18.", version)
+	}
+	requests, err := db.AddPasswordResetRequest("address:192.0.2.10", time.Hour, time.Now())
+	if err != nil || requests != 1 {
+		t.Errorf("Expected a password reset request after update, found %v (%v).", requests, err)
+	}
 	// Check for error on drop tables as well. Because we can.
 	err = db.dropTables()
 	if err != nil {
@@ -282,6 +682,110 @@ func TestUpgrade(t *testing.T) {
 	}
 }
 
+func TestMigrate(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	history, err := db.GetMigrationHistory()
+	if err != nil {
+		t.Fatalf("error getting migration history: %v", err)
+	}
+	if len(history) != 1 || history[0].Version != database.CurrentVersion || history[0].Direction != types.MigrationUp {
+		t.Fatalf("Expected table creation in migration history, found %+v.", history)
+	}
+	account, err := db.AddAccount(types.Account{
+		Name:     "John Smith",
+		Email:    "j@test.com",
+		Type:     types.RoleFree,
+		Password: testHashPassword("password"),
+	})
+	if err != nil {
+		t.Fatalf("error adding account: %v", err)
+	}
+	// A dry run plans the migrations without running any of them.
+	plan, err := db.Migrate(7, true)
+	if err != nil {
+		t.Fatalf("error planning migration: %v", err)
+	}
+	if plan.From != database.CurrentVersion || plan.To != 7 || len(plan.Steps) != database.CurrentVersion-7 {
+		t.Fatalf("Expected %d steps from %d to 7, found %+v.", database.CurrentVersion-7, database.CurrentVersion, plan)
+	}
+	for i, step := range plan.Steps {
+		if step.Version != database.CurrentVersion-i || step.Direction != types.MigrationDown || step.Name == "" {
+			t.Errorf("Expected step %d to reverse version %d, found %+v.", i, database.CurrentVersion-i, step)
+		}
+	}
+	version := db.checkVersion()
+	if version != database.CurrentVersion {
+		t.Fatalf("Version set to %v after dry run expected %v.", version, database.CurrentVersion)
+	}
+	// Migrations before version 6 can't be reversed, and versions past the last migration don't exist.
+	_, err = db.Migrate(5, true)
+	if err == nil {
+		t.Error("Expected error planning migration to version 5.")
+	}
+	_, err = db.Migrate(database.CurrentVersion+1, true)
+	if err == nil {
+		t.Errorf("Expected error planning migration to version %d.", database.CurrentVersion+1)
+	}
+	// Down to version 7 and back up again.
+	plan, err = db.Migrate(7, false)
+	if err != nil {
+		t.Fatalf("error migrating to version 7: %v", err)
+	}
+	version = db.checkVersion()
+	if version != 7 {
+		t.Fatalf("Version set to %v expected 7.", version)
+	}
+	_, err = db.db.Exec(context.Background(), "SELECT role_name FROM account_role;")
+	if err == nil {
+		t.Error("Expected role table to be gone at version 7.")
+	}
+	plan, err = db.Migrate(database.CurrentVersion, false)
+	if err != nil {
+		t.Fatalf("error migrating to version %d: %v", database.CurrentVersion, err)
+	}
+	if len(plan.Steps) != database.CurrentVersion-7 || plan.Steps[0].Version != 8 || plan.Steps[0].Direction != types.MigrationUp {
+		t.Errorf("Expected steps up from version 8, found %+v.", plan.Steps)
+	}
+	version = db.checkVersion()
+	if version != database.CurrentVersion {
+		t.Fatalf("Version set to %v expected %v.", version, database.CurrentVersion)
+	}
+	roles, err := db.GetRoles()
+	if err != nil || len(roles) != len(types.DefaultRoles()) {
+		t.Errorf("Expected the default roles after migrating back up, found %+v (%v).", roles, err)
+	}
+	found, err := db.GetAccount(account.Email)
+	if err != nil || found == nil || found.Identifier != account.Identifier {
+		t.Errorf("Expected account to survive migrations, found %+v (%v).", found, err)
+	}
+	// Nothing left to do.
+	plan, err = db.Migrate(database.CurrentVersion, false)
+	if err != nil {
+		t.Fatalf("error migrating to current version: %v", err)
+	}
+	if len(plan.Steps) != 0 {
+		t.Errorf("Expected no steps when already at the current version, found %+v.", plan.Steps)
+	}
+	history, err = db.GetMigrationHistory()
+	if err != nil {
+		t.Fatalf("error getting migration history: %v", err)
+	}
+	if len(history) != 1+2*(database.CurrentVersion-7) {
+		t.Fatalf("Expected %d migration records, found %+v.", 1+2*(database.CurrentVersion-7), history)
+	}
+	if history[1].Version != database.CurrentVersion || history[1].Direction != types.MigrationDown {
+		t.Errorf("Expected first migration to reverse version %d, found %+v.", database.CurrentVersion, history[1])
+	}
+	last := history[len(history)-1]
+	if last.Version != database.CurrentVersion || last.Direction != types.MigrationUp || last.AppliedAt.IsZero() {
+		t.Errorf("Expected last migration to run version %d, found %+v.", database.CurrentVersion, last)
+	}
+}
+
 func TestBadDatabase(t *testing.T) {
 	db := &Postgres{}
 	_, err := db.GetDatabase(&util.Config{})
@@ -307,6 +811,10 @@ func TestBadDatabase(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error setting setting.")
 	}
+	_, err = db.GetSetting("")
+	if err == nil {
+		t.Fatal("Expected error getting setting.")
+	}
 	err = db.createTables()
 	if err == nil {
 		t.Fatal("Expected error creating tables.")
@@ -315,7 +823,7 @@ func TestBadDatabase(t *testing.T) {
 	if v != -1 {
 		t.Fatal("Expected error getting database.")
 	}
-	err = db.updateTables(0, 0)
+	_, err = db.Migrate(0, false)
 	if err == nil {
 		t.Fatal("Expected error updating tables.")
 	}
@@ -346,6 +854,10 @@ func TestNoDatabase(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error setting setting.")
 	}
+	_, err = db.GetSetting("")
+	if err == nil {
+		t.Fatal("Expected error getting setting.")
+	}
 	err = db.createTables()
 	if err == nil {
 		t.Fatal("Expected error creating tables.")
@@ -354,10 +866,14 @@ func TestNoDatabase(t *testing.T) {
 	if v != -1 {
 		t.Fatal("Expected error getting database.")
 	}
-	err = db.updateTables(0, 0)
+	_, err = db.Migrate(0, false)
 	if err == nil {
 		t.Fatal("Expected error updating tables.")
 	}
+	_, err = db.GetMigrationHistory()
+	if err == nil {
+		t.Fatal("Expected error getting migration history.")
+	}
 }
 
 func getTestConfig() *util.Config {
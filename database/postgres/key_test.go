@@ -386,6 +386,51 @@ func TestDeleteKey(t *testing.T) {
 	}
 }
 
+func TestRestoreKey(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupKeyTests()
+	account1, _ := db.AddAccount(accounts[0])
+	keys[0].AccountIdentifier = account1.Identifier
+	keys[1].AccountIdentifier = account1.Identifier
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	deletedKeys, err := db.GetDeletedKeys(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting deleted keys: %v", err)
+	}
+	if len(deletedKeys) != 0 {
+		t.Errorf("Expected no deleted keys, found %+v.", deletedKeys)
+	}
+	db.DeleteKey(keys[0])
+	deletedKeys, err = db.GetDeletedKeys(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting deleted keys: %v", err)
+	}
+	if len(deletedKeys) != 1 || deletedKeys[0].Name != keys[0].Name || deletedKeys[0].DeletedAt == nil {
+		t.Errorf("Expected deleted key %v, found %+v.", keys[0].Name, deletedKeys)
+	}
+	err = db.RestoreKey(account1.Identifier, keys[0].Name)
+	if err != nil {
+		t.Fatalf("Error restoring key: %v", err)
+	}
+	k, _ := db.GetKey(keys[0].Value)
+	if k == nil || k.DeletedAt != nil {
+		t.Errorf("Expected key to be restored, found %+v.", k)
+	}
+	err = db.RestoreKey(account1.Identifier, keys[1].Name)
+	if err == nil {
+		t.Error("Expected error restoring a key that isn't deleted.")
+	}
+	err = db.RestoreKey(account1.Identifier, "unknown")
+	if err == nil {
+		t.Error("Expected error restoring an unknown key.")
+	}
+}
+
 func TestUpdateKey(t *testing.T) {
 	db, finalize, err := setupTests(t)
 	if err != nil {
@@ -429,6 +474,286 @@ func TestUpdateKey(t *testing.T) {
 	}
 }
 
+func TestKeyAllowedHosts(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupKeyTests()
+	account1, _ := db.AddAccount(accounts[0])
+	key := keys[1]
+	key.AccountIdentifier = account1.Identifier
+	key.AllowedHosts = []string{"10.0.0.0/8", "192.168.1.15", "reader.example.com"}
+	added, err := db.AddKey(key)
+	if err != nil {
+		t.Fatalf("Error adding key: %v", err)
+	}
+	if !added.Equal(&key) {
+		t.Errorf("Expected key %+v, found %+v.", key, *added)
+	}
+	found, _ := db.GetKey(key.Value)
+	if found == nil || !found.Equal(&key) {
+		t.Errorf("Expected key %+v, found %+v.", key, found)
+	}
+	mkey, _ := db.GetKeyAndAccount(key.Value)
+	if mkey == nil || !mkey.Key.Equal(&key) {
+		t.Errorf("Expected key %+v, found %+v.", key, mkey)
+	}
+	key.AllowedHosts = nil
+	err = db.UpdateKey(key)
+	if err != nil {
+		t.Fatalf("Error updating key: %v", err)
+	}
+	found, _ = db.GetKey(key.Value)
+	if found == nil || len(found.AllowedHosts) != 0 {
+		t.Errorf("Expected no allowed hosts, found %+v.", found)
+	}
+}
+
+func TestKeyHashed(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupKeyTests()
+	account1, _ := db.AddAccount(accounts[0])
+	key := keys[0]
+	key.AccountIdentifier = account1.Identifier
+	added, err := db.AddKey(key)
+	if err != nil {
+		t.Fatalf("Error adding key: %v", err)
+	}
+	if added.Value != key.Value || added.Prefix != types.KeyPrefix(key.Value) {
+		t.Errorf("Expected added key to have value %v and prefix %v, found %+v.", key.Value, types.KeyPrefix(key.Value), *added)
+	}
+	k, err := db.GetAccountKeys(account1.Email)
+	if err != nil {
+		t.Fatalf("Error getting account keys: %v", err)
+	}
+	if len(k) != 1 {
+		t.Fatalf("Expected %v keys found for account but found %v keys.", 1, len(k))
+	}
+	if k[0].Value != "" {
+		t.Errorf("Expected key value to not be returned, found %v.", k[0].Value)
+	}
+	if k[0].Prefix != types.KeyPrefix(key.Value) || k[0].Hash != types.HashKey(key.Value) {
+		t.Errorf("Expected key prefix %v and hash %v, found %+v.", types.KeyPrefix(key.Value), types.HashKey(key.Value), k[0])
+	}
+	found, _ := db.GetKey(key.Value)
+	if found == nil || found.Value != "" || !found.Equal(&key) {
+		t.Errorf("Expected key %+v, found %+v.", key, found)
+	}
+	found, _ = db.GetKey(types.HashKey(key.Value))
+	if found != nil {
+		t.Errorf("Expected hash to not work as a key value, found %+v.", found)
+	}
+	mkey, _ := db.GetKeyAndAccount(types.HashKey(key.Value))
+	if mkey != nil {
+		t.Errorf("Expected hash to not work as a key value, found %+v.", mkey)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupKeyTests()
+	account1, _ := db.AddAccount(accounts[0])
+	key := keys[1]
+	key.AccountIdentifier = account1.Identifier
+	db.AddKey(key)
+	db.AddReads(key.Value, []types.Read{
+		{
+			Identifier: "1001",
+			Seconds:    100,
+			IdentType:  "chip",
+			Type:       "reader",
+		},
+	})
+	_, err = db.SaveNotification(&types.RequestNotification{
+		Type: "UPS_ONLINE",
+		When: time.Now().UTC().Format(time.RFC3339),
+	}, key.Value)
+	if err != nil {
+		t.Fatalf("Error saving notification: %v", err)
+	}
+	// Rotate with a grace period, both values should work.
+	graceUntil := time.Now().Add(time.Hour)
+	rotated, err := db.RotateKey(key.Value, "rotated-key-value-1", &graceUntil)
+	if err != nil {
+		t.Fatalf("Error rotating key: %v", err)
+	}
+	if rotated == nil || rotated.Value != "rotated-key-value-1" || rotated.Name != key.Name {
+		t.Fatalf("Expected rotated key with new value, found %+v.", rotated)
+	}
+	mkey, err := db.GetKeyAndAccount(key.Value)
+	if err != nil {
+		t.Fatalf("Error getting key by old value: %v", err)
+	}
+	if mkey == nil || mkey.Key.Hash != rotated.Hash {
+		t.Errorf("Expected old value to find rotated key, found %+v.", mkey)
+	}
+	mkey, _ = db.GetKeyAndAccount(rotated.Value)
+	if mkey == nil || mkey.Key.Hash != rotated.Hash {
+		t.Errorf("Expected new value to find rotated key, found %+v.", mkey)
+	}
+	found, _ := db.GetKey(key.Value)
+	if found != nil {
+		t.Errorf("Expected old value to no longer be the key's value, found %+v.", found)
+	}
+	// Reads and notifications stay attached to the key.
+	reads, _ := db.GetReads(account1.Identifier, nil, key.Name, 0, 1000)
+	if len(reads) != 1 || reads[0].Key != rotated.Prefix {
+		t.Errorf("Expected read to stay attached to the key, found %+v.", reads)
+	}
+	db.AddReads(rotated.Value, []types.Read{
+		{
+			Identifier: "1002",
+			Seconds:    200,
+			IdentType:  "chip",
+			Type:       "reader",
+		},
+	})
+	_, err = db.AddReads(key.Value, []types.Read{
+		{
+			Identifier: "1003",
+			Seconds:    300,
+			IdentType:  "chip",
+			Type:       "reader",
+		},
+	})
+	if err != nil {
+		t.Errorf("Error adding reads with old value during grace period: %v", err)
+	}
+	reads, _ = db.GetReads(account1.Identifier, nil, key.Name, 0, 1000)
+	if len(reads) != 3 {
+		t.Errorf("Expected %v reads, found %v.", 3, len(reads))
+	}
+	note, _ := db.GetNotification(account1.Identifier, nil, key.Name)
+	if note == nil || note.Type != "UPS_ONLINE" {
+		t.Errorf("Expected notification to stay attached to the key, found %+v.", note)
+	}
+	// Rotate without a grace period, only the newest value should work.
+	rotated2, err := db.RotateKey(rotated.Value, "rotated-key-value-2", nil)
+	if err != nil {
+		t.Fatalf("Error rotating key: %v", err)
+	}
+	for _, value := range []string{key.Value, rotated.Value} {
+		mkey, _ = db.GetKeyAndAccount(value)
+		if mkey != nil {
+			t.Errorf("Expected old value %v to stop working, found %+v.", value, mkey)
+		}
+	}
+	mkey, _ = db.GetKeyAndAccount(rotated2.Value)
+	if mkey == nil {
+		t.Error("Expected newest value to work.")
+	}
+	// A grace period that has already ended doesn't keep the old value working.
+	graceUntil = time.Now().Add(time.Hour * -1)
+	rotated3, err := db.RotateKey(rotated2.Value, "rotated-key-value-3", &graceUntil)
+	if err != nil {
+		t.Fatalf("Error rotating key: %v", err)
+	}
+	mkey, _ = db.GetKeyAndAccount(rotated2.Value)
+	if mkey != nil {
+		t.Errorf("Expected old value to stop working after grace period, found %+v.", mkey)
+	}
+	_, err = db.AddReads(rotated2.Value, []types.Read{
+		{
+			Identifier: "1004",
+			Seconds:    400,
+			IdentType:  "chip",
+			Type:       "reader",
+		},
+	})
+	if err == nil {
+		t.Error("Expected error adding reads with old value after grace period.")
+	}
+	_, err = db.SaveNotification(&types.RequestNotification{
+		Type: "UPS_ONLINE",
+		When: time.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+	}, rotated2.Value)
+	if err == nil {
+		t.Error("Expected error saving notification with old value after grace period.")
+	}
+	reads, _ = db.GetReads(account1.Identifier, nil, rotated3.Name, 0, 1000)
+	if len(reads) != 3 {
+		t.Errorf("Expected %v reads, found %v.", 3, len(reads))
+	}
+	_, err = db.RotateKey("unknown-key-value", "rotated-key-value-4", nil)
+	if err == nil {
+		t.Error("Expected error rotating unknown key.")
+	}
+}
+
+func TestGetKeysExpiredBetween(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupKeyTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys[0].AccountIdentifier = account1.Identifier
+	keys[1].AccountIdentifier = account1.Identifier
+	keys[2].AccountIdentifier = account2.Identifier
+	keys[3].AccountIdentifier = account2.Identifier
+	// Other tests update these, so make sure they have the expiry times this test expects.
+	keys[0].ValidUntil = &times[0]
+	keys[1].ValidUntil = &times[1]
+	keys[2].ValidUntil = &times[2]
+	keys[3].ValidUntil = nil
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	db.AddKey(keys[2])
+	db.AddKey(keys[3])
+	now := time.Now()
+	expired, err := db.GetKeysExpiredBetween(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), now)
+	if err != nil {
+		t.Fatalf("Error getting expired keys: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Name != keys[2].Name || expired[0].AccountIdentifier != account2.Identifier {
+		t.Errorf("Expected key %v to have expired, found %+v.", keys[2].Name, expired)
+	}
+	expired, err = db.GetKeysExpiredBetween(time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC), now)
+	if err != nil {
+		t.Fatalf("Error getting expired keys: %v", err)
+	}
+	if len(expired) != 2 {
+		t.Errorf("Expected %v expired keys, found %v.", 2, len(expired))
+	}
+	// Keys without an expiry never show up, keys that haven't expired yet only once their time passes.
+	expired, err = db.GetKeysExpiredBetween(time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC), now.Add(time.Hour*24))
+	if err != nil {
+		t.Fatalf("Error getting expired keys: %v", err)
+	}
+	if len(expired) != 3 {
+		t.Errorf("Expected %v expired keys, found %v.", 3, len(expired))
+	}
+	// The start of the range isn't included.
+	expired, err = db.GetKeysExpiredBetween(times[2], now)
+	if err != nil {
+		t.Fatalf("Error getting expired keys: %v", err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("Expected %v expired keys, found %v.", 0, len(expired))
+	}
+	// Deleted keys aren't reported.
+	db.DeleteKey(keys[2])
+	expired, err = db.GetKeysExpiredBetween(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), now)
+	if err != nil {
+		t.Fatalf("Error getting expired keys: %v", err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("Expected %v expired keys, found %v.", 0, len(expired))
+	}
+}
+
 func TestBadDatabaseKey(t *testing.T) {
 	db := badTestSetup(t)
 	_, err := db.GetAccountKeys("")
@@ -451,6 +776,22 @@ func TestBadDatabaseKey(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error updating key.")
 	}
+	_, err = db.RotateKey("", "", nil)
+	if err == nil {
+		t.Fatal("Expected error rotating key.")
+	}
+	_, err = db.GetKeysExpiredBetween(time.Now(), time.Now())
+	if err == nil {
+		t.Fatal("Expected error getting expired keys.")
+	}
+	_, err = db.GetDeletedKeys(0)
+	if err == nil {
+		t.Fatal("Expected error getting deleted keys.")
+	}
+	err = db.RestoreKey(0, "")
+	if err == nil {
+		t.Fatal("Expected error restoring key.")
+	}
 }
 
 func TestNoDatabaseKey(t *testing.T) {
@@ -475,5 +816,21 @@ func TestNoDatabaseKey(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error updating key.")
 	}
+	_, err = db.RotateKey("", "", nil)
+	if err == nil {
+		t.Fatal("Expected error rotating key.")
+	}
+	_, err = db.GetKeysExpiredBetween(time.Now(), time.Now())
+	if err == nil {
+		t.Fatal("Expected error getting expired keys.")
+	}
+	_, err = db.GetDeletedKeys(0)
+	if err == nil {
+		t.Fatal("Expected error getting deleted keys.")
+	}
+	err = db.RestoreKey(0, "")
+	if err == nil {
+		t.Fatal("Expected error restoring key.")
+	}
 }
 
@@ -0,0 +1,155 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetLoginFailures Gets the failed logins from an IP address. Returns nil if there are none left to remember.
+func (p *Postgres) GetLoginFailures(ip string) (*types.LoginFailures, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT failure_ip, failure_count, failure_last, failure_blocked_until FROM login_failure WHERE failure_ip=$1;",
+		ip,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving login failures: %v", err)
+	}
+	defer res.Close()
+	if !res.Next() {
+		return nil, nil
+	}
+	var outFailures types.LoginFailures
+	var last, blockedUntil int64
+	err = res.Scan(
+		&outFailures.IP,
+		&outFailures.Count,
+		&last,
+		&blockedUntil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting login failures: %v", err)
+	}
+	outFailures.LastFailure = time.Unix(last, 0)
+	if blockedUntil != 0 {
+		until := time.Unix(blockedUntil, 0)
+		outFailures.BlockedUntil = &until
+	}
+	// Failures that have outlasted the lockout are forgotten, even if they haven't been cleared out yet.
+	window := p.config.IPLockoutDuration()
+	if window > 0 && outFailures.LastFailure.Add(window).Before(time.Now()) && !outFailures.Blocked() {
+		return nil, nil
+	}
+	return &outFailures, nil
+}
+
+// AddLoginFailure Counts a failed login from an IP address, blocking logins from it once the configured
+// number of failures is reached. Returns the updated failures.
+func (p *Postgres) AddLoginFailure(ip string) (*types.LoginFailures, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to begin transaction to add login failure: %v", err)
+	}
+	now := time.Now()
+	window := p.config.IPLockoutDuration()
+	if window > 0 {
+		// Clear out the failures every address has outlasted so the table doesn't keep growing.
+		_, err = tx.Exec(
+			ctx,
+			"DELETE FROM login_failure WHERE failure_last<$1 AND failure_blocked_until<$2;",
+			now.Add(-window).Unix(),
+			now.Unix(),
+		)
+		if err != nil {
+			tx.Rollback(ctx)
+			return nil, fmt.Errorf("error clearing old login failures: %v", err)
+		}
+	}
+	res, err := tx.Query(
+		ctx,
+		"SELECT failure_count, failure_blocked_until FROM login_failure WHERE failure_ip=$1;",
+		ip,
+	)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error retrieving login failures: %v", err)
+	}
+	var count int
+	var blockedUntil int64
+	if res.Next() {
+		err = res.Scan(&count, &blockedUntil)
+	}
+	res.Close()
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error getting login failures: %v", err)
+	}
+	count++
+	if p.config.IPMaxFailures > 0 && count >= p.config.IPMaxFailures && blockedUntil < now.Unix() {
+		blockedUntil = now.Add(window).Unix()
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM login_failure WHERE failure_ip=$1;", ip)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error deleting old login failures: %v", err)
+	}
+	_, err = tx.Exec(
+		ctx,
+		"INSERT INTO login_failure(failure_ip, failure_count, failure_last, failure_blocked_until) VALUES ($1, $2, $3, $4);",
+		ip,
+		count,
+		now.Unix(),
+		blockedUntil,
+	)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("unable to add login failure: %v", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	outFailures := types.LoginFailures{
+		IP:          ip,
+		Count:       count,
+		LastFailure: time.Unix(now.Unix(), 0),
+	}
+	if blockedUntil != 0 {
+		until := time.Unix(blockedUntil, 0)
+		outFailures.BlockedUntil = &until
+	}
+	return &outFailures, nil
+}
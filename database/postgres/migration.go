@@ -0,0 +1,618 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+// migrations Every change made to the schema since version 1, oldest first. Versions 5 and 6 rebuilt and
+// hashed the key table in place so nothing before version 6 can be reversed.
+var migrations = []database.Migration[pgx.Tx]{
+	{
+		Version: 2,
+		Name:    "add notifications",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS notification(" +
+				"notification_id BIGSERIAL NOT NULL, " +
+				"key_value VARCHAR(100) NOT NULL, " +
+				"notification_type VARCHAR(100) NOT NULL, " +
+				"notification_when BIGINT NOT NULL, " +
+				"notification_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(key_value, notification_when), " +
+				"FOREIGN KEY (key_value) REFERENCES api_key(key_value), " +
+				"PRIMARY KEY (notification_id)" +
+				");",
+		),
+	},
+	{
+		Version: 3,
+		Name:    "add read ids and time index",
+		// Reads are given an id in the order they were added so streams can resume from the last one sent.
+		Up: execQueries(
+			"ALTER TABLE read ADD COLUMN read_id BIGSERIAL NOT NULL PRIMARY KEY;",
+			"CREATE INDEX IF NOT EXISTS idx_read_time ON read(key_value, seconds, milliseconds, identifier, ident_type);",
+		),
+	},
+	{
+		Version: 4,
+		Name:    "add key allowed hosts",
+		Up: execQueries(
+			"ALTER TABLE api_key ADD COLUMN allowed_hosts VARCHAR(1000) NOT NULL DEFAULT '';",
+		),
+	},
+	{
+		Version: 5,
+		Name:    "add key ids",
+		// Reads and notifications were tied to the key value, which meant replacing a key orphaned
+		// everything uploaded with it. Give keys an id and point reads and notifications at that instead.
+		Up: execQueries(
+			"ALTER TABLE api_key ADD COLUMN key_id BIGSERIAL NOT NULL PRIMARY KEY, "+
+				"ADD COLUMN old_key_value VARCHAR(100) DEFAULT NULL, "+
+				"ADD COLUMN old_key_valid_until TIMESTAMPTZ DEFAULT NULL, ADD UNIQUE(old_key_value);",
+			"ALTER TABLE read ADD COLUMN key_id BIGINT;",
+			"UPDATE read r SET key_id=a.key_id FROM api_key a WHERE r.key_value=a.key_value;",
+			"ALTER TABLE read ALTER COLUMN key_id SET NOT NULL, DROP COLUMN key_value, "+
+				"ADD UNIQUE(key_id, identifier, seconds, milliseconds, ident_type), "+
+				"ADD FOREIGN KEY (key_id) REFERENCES api_key(key_id);",
+			"ALTER TABLE notification ADD COLUMN key_id BIGINT;",
+			"UPDATE notification n SET key_id=a.key_id FROM api_key a WHERE n.key_value=a.key_value;",
+			"ALTER TABLE notification ALTER COLUMN key_id SET NOT NULL, DROP COLUMN key_value, "+
+				"ADD UNIQUE(key_id, notification_when), "+
+				"ADD FOREIGN KEY (key_id) REFERENCES api_key(key_id);",
+			"CREATE INDEX IF NOT EXISTS idx_read_time ON read(key_id, seconds, milliseconds, identifier, ident_type);",
+		),
+	},
+	{
+		Version: 6,
+		Name:    "hash key values",
+		Up:      hashKeyValues,
+	},
+	{
+		Version: 7,
+		Name:    "add sessions",
+		// Tokens move from the account table to their own table so an account can have
+		// more than one session. Existing tokens are dropped, so everyone has to log in again.
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS account_session("+
+				"session_id BIGSERIAL NOT NULL, "+
+				"account_id BIGINT NOT NULL, "+
+				"session_token VARCHAR(100) NOT NULL, "+
+				"session_refresh_token VARCHAR(100) NOT NULL, "+
+				"session_user_agent VARCHAR(500) NOT NULL DEFAULT '', "+
+				"session_ip VARCHAR(100) NOT NULL DEFAULT '', "+
+				"session_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, "+
+				"session_last_used TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, "+
+				"UNIQUE(session_token), "+
+				"UNIQUE(session_refresh_token), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), "+
+				"PRIMARY KEY (session_id)"+
+				");",
+			"ALTER TABLE account DROP COLUMN account_token, DROP COLUMN account_refresh_token;",
+		),
+		// Sessions can't be turned back into tokens, so everyone has to log in again going this way too.
+		Down: execQueries(
+			"ALTER TABLE account ADD COLUMN account_token VARCHAR(1000) NOT NULL DEFAULT '', ADD COLUMN account_refresh_token VARCHAR(1000) NOT NULL DEFAULT '';",
+			"DROP TABLE account_session;",
+		),
+	},
+	{
+		Version: 8,
+		Name:    "add notification acknowledgement",
+		Up: execQueries(
+			"ALTER TABLE notification ADD COLUMN notification_acknowledged_at TIMESTAMPTZ DEFAULT NULL;",
+		),
+		Down: execQueries(
+			"ALTER TABLE notification DROP COLUMN notification_acknowledged_at;",
+		),
+	},
+	{
+		Version: 9,
+		Name:    "add webhooks",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS webhook("+
+				"webhook_id BIGSERIAL NOT NULL, "+
+				"account_id BIGINT NOT NULL, "+
+				"webhook_url VARCHAR(500) NOT NULL, "+
+				"webhook_secret VARCHAR(100) NOT NULL, "+
+				"webhook_events VARCHAR(200) NOT NULL DEFAULT '', "+
+				"webhook_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), "+
+				"PRIMARY KEY (webhook_id)"+
+				");",
+			"CREATE TABLE IF NOT EXISTS webhook_delivery("+
+				"delivery_id BIGSERIAL NOT NULL, "+
+				"webhook_id BIGINT NOT NULL, "+
+				"delivery_event VARCHAR(50) NOT NULL, "+
+				"delivery_payload TEXT NOT NULL, "+
+				"delivery_status VARCHAR(20) NOT NULL DEFAULT 'pending', "+
+				"delivery_attempts INT NOT NULL DEFAULT 0, "+
+				"delivery_next_attempt BIGINT NOT NULL, "+
+				"delivery_claimed_until BIGINT NOT NULL DEFAULT 0, "+
+				"delivery_response_code INT NOT NULL DEFAULT 0, "+
+				"delivery_error VARCHAR(500) NOT NULL DEFAULT '', "+
+				"delivery_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, "+
+				"delivery_delivered_at TIMESTAMPTZ DEFAULT NULL, "+
+				"FOREIGN KEY (webhook_id) REFERENCES webhook(webhook_id) ON DELETE CASCADE, "+
+				"PRIMARY KEY (delivery_id)"+
+				");",
+			"CREATE INDEX IF NOT EXISTS idx_delivery_pending ON webhook_delivery(delivery_status, delivery_next_attempt);",
+		),
+		Down: execQueries(
+			"DROP TABLE webhook_delivery;",
+			"DROP TABLE webhook;",
+		),
+	},
+	{
+		Version: 10,
+		Name:    "add alert rules",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS alert_rule(" +
+				"rule_id BIGSERIAL NOT NULL, " +
+				"account_id BIGINT NOT NULL, " +
+				"rule_types VARCHAR(500) NOT NULL, " +
+				"rule_readers VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"rule_recipients VARCHAR(1000) NOT NULL, " +
+				"rule_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (rule_id)" +
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE alert_rule;",
+		),
+	},
+	{
+		Version: 11,
+		Name:    "add password resets",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS password_reset(" +
+				"reset_id BIGSERIAL NOT NULL, " +
+				"account_id BIGINT NOT NULL, " +
+				"reset_token VARCHAR(100) NOT NULL, " +
+				"reset_expires_at BIGINT NOT NULL, " +
+				"reset_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(reset_token), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (reset_id)" +
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE password_reset;",
+		),
+	},
+	{
+		Version: 12,
+		Name:    "add two-factor",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS two_factor("+
+				"account_id BIGINT NOT NULL, "+
+				"tf_secret VARCHAR(100) NOT NULL, "+
+				"tf_enabled BOOL DEFAULT FALSE, "+
+				"tf_last_step BIGINT NOT NULL DEFAULT 0, "+
+				"tf_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), "+
+				"PRIMARY KEY (account_id)"+
+				");",
+			"CREATE TABLE IF NOT EXISTS recovery_code("+
+				"code_id BIGSERIAL NOT NULL, "+
+				"account_id BIGINT NOT NULL, "+
+				"code_hash VARCHAR(100) NOT NULL, "+
+				"UNIQUE(account_id, code_hash), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), "+
+				"PRIMARY KEY (code_id)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE recovery_code;",
+			"DROP TABLE two_factor;",
+		),
+	},
+	{
+		Version: 13,
+		Name:    "add account lockouts",
+		Up: execQueries(
+			"ALTER TABLE account ADD COLUMN account_lock_reason VARCHAR(100) NOT NULL DEFAULT '', "+
+				"ADD COLUMN account_locked_until BIGINT NOT NULL DEFAULT 0, "+
+				"ADD COLUMN account_lock_count INT NOT NULL DEFAULT 0;",
+			"CREATE TABLE IF NOT EXISTS login_failure("+
+				"failure_ip VARCHAR(100) NOT NULL, "+
+				"failure_count INT NOT NULL DEFAULT 0, "+
+				"failure_last BIGINT NOT NULL DEFAULT 0, "+
+				"failure_blocked_until BIGINT NOT NULL DEFAULT 0, "+
+				"PRIMARY KEY (failure_ip)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE login_failure;",
+			"ALTER TABLE account DROP COLUMN account_lock_reason, DROP COLUMN account_locked_until, DROP COLUMN account_lock_count;",
+		),
+	},
+	{
+		Version: 14,
+		Name:    "add organizations",
+		Up: execQueries(
+			"ALTER TABLE api_key ADD COLUMN org_id BIGINT DEFAULT NULL;",
+			"CREATE TABLE IF NOT EXISTS organization("+
+				"org_id BIGSERIAL NOT NULL, "+
+				"account_id BIGINT NOT NULL, "+
+				"org_name VARCHAR(100) NOT NULL, "+
+				"org_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, "+
+				"UNIQUE(account_id), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), "+
+				"PRIMARY KEY (org_id)"+
+				");",
+			"CREATE TABLE IF NOT EXISTS org_member("+
+				"org_id BIGINT NOT NULL, "+
+				"account_id BIGINT NOT NULL, "+
+				"member_role VARCHAR(20) NOT NULL, "+
+				"member_created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, "+
+				"FOREIGN KEY (org_id) REFERENCES organization(org_id), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), "+
+				"PRIMARY KEY (org_id, account_id)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE org_member;",
+			"DROP TABLE organization;",
+			"ALTER TABLE api_key DROP COLUMN org_id;",
+		),
+	},
+	{
+		Version: 15,
+		Name:    "add roles",
+		Up:      addRoles,
+		Down: execQueries(
+			"DROP TABLE account_role;",
+		),
+	},
+	{
+		Version: 16,
+		Name:    "add deletion times",
+		Up: execQueries(
+			"ALTER TABLE account ADD COLUMN account_deleted_at BIGINT NOT NULL DEFAULT 0;",
+			"ALTER TABLE api_key ADD COLUMN key_deleted_at BIGINT NOT NULL DEFAULT 0;",
+		),
+		Down: execQueries(
+			"ALTER TABLE api_key DROP COLUMN key_deleted_at;",
+			"ALTER TABLE account DROP COLUMN account_deleted_at;",
+		),
+	},
+	{
+		Version: 17,
+		Name:    "add webhook organizations",
+		Up: execQueries(
+			"ALTER TABLE webhook ADD COLUMN org_id BIGINT DEFAULT NULL;",
+		),
+		Down: execQueries(
+			"ALTER TABLE webhook DROP COLUMN org_id;",
+		),
+	},
+	{
+		Version: 18,
+		Name:    "add password reset requests",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS password_reset_request("+
+				"request_key VARCHAR(100) NOT NULL, "+
+				"request_count INT NOT NULL DEFAULT 0, "+
+				"request_window_start BIGINT NOT NULL DEFAULT 0, "+
+				"PRIMARY KEY (request_key)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE password_reset_request;",
+		),
+	},
+}
+
+// execQueries Returns a migration step that runs each query in order.
+func execQueries(queries ...string) func(ctx context.Context, tx pgx.Tx) error {
+	return func(ctx context.Context, tx pgx.Tx) error {
+		for _, query := range queries {
+			_, err := tx.Exec(ctx, query)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// hashKeyValues Key values were stored in plain text. Keep the start of each value in the clear so keys
+// can still be told apart and replace the values with their hashes.
+func hashKeyValues(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(
+		ctx,
+		"ALTER TABLE api_key ADD COLUMN key_prefix VARCHAR(20) NOT NULL DEFAULT '';",
+	)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Query(ctx, "SELECT key_id, key_value, old_key_value FROM api_key;")
+	if err != nil {
+		return err
+	}
+	type plainKey struct {
+		id       int64
+		value    string
+		oldValue *string
+	}
+	var plainKeys []plainKey
+	for res.Next() {
+		var key plainKey
+		err := res.Scan(&key.id, &key.value, &key.oldValue)
+		if err != nil {
+			res.Close()
+			return err
+		}
+		plainKeys = append(plainKeys, key)
+	}
+	res.Close()
+	for _, key := range plainKeys {
+		var oldHash *string
+		if key.oldValue != nil {
+			hash := types.HashKey(*key.oldValue)
+			oldHash = &hash
+		}
+		_, err := tx.Exec(
+			ctx,
+			"UPDATE api_key SET key_prefix=$1, key_value=$2, old_key_value=$3 WHERE key_id=$4;",
+			types.KeyPrefix(key.value),
+			types.HashKey(key.value),
+			oldHash,
+			key.id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRoles Account types become roles, starting with the defaults.
+func addRoles(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(
+		ctx,
+		"CREATE TABLE IF NOT EXISTS account_role("+
+			"role_name VARCHAR(20) NOT NULL, "+
+			"role_permissions VARCHAR(1000) NOT NULL DEFAULT '', "+
+			"PRIMARY KEY (role_name)"+
+			");",
+	)
+	if err != nil {
+		return err
+	}
+	for _, role := range types.DefaultRoles() {
+		_, err := tx.Exec(
+			ctx,
+			"INSERT INTO account_role(role_name, role_permissions) VALUES ($1, $2);",
+			role.Name,
+			role.PermissionsValue(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const migrationTableQuery = "CREATE TABLE IF NOT EXISTS schema_migration(" +
+	"migration_id BIGSERIAL NOT NULL, " +
+	"migration_version INT NOT NULL, " +
+	"migration_name VARCHAR(100) NOT NULL, " +
+	"migration_direction VARCHAR(10) NOT NULL, " +
+	"migration_applied_at BIGINT NOT NULL, " +
+	"PRIMARY KEY (migration_id)" +
+	");"
+
+// createMigrationTable Adds the migration history to databases created before it was kept.
+func (p *Postgres) createMigrationTable() error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	_, err = db.Exec(ctx, migrationTableQuery)
+	if err != nil {
+		return fmt.Errorf("error creating migration table: %v", err)
+	}
+	return nil
+}
+
+// lockMigrations Takes the advisory lock that keeps instances from changing the schema at the same time.
+// The lock belongs to the connection that took it, so the connection is held until the returned function
+// releases them both.
+func (p *Postgres) lockMigrations() (func(), error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), database.MigrationTimeout)
+	defer cancelfunc()
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get connection for migration lock: %v", err)
+	}
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock(hashtext($1));", database.MigrationLockName)
+	if err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("error taking migration lock: %v", err)
+	}
+	return func() {
+		ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancelfunc()
+		_, err := conn.Exec(ctx, "SELECT pg_advisory_unlock(hashtext($1));", database.MigrationLockName)
+		if err != nil {
+			// Closing the connection is the only other way to let go of the lock.
+			log.Error(fmt.Sprintf("Error releasing migration lock: %v", err))
+			conn.Hijack().Close(ctx)
+			return
+		}
+		conn.Release()
+	}, nil
+}
+
+// Bootstrap Creates the tables of an empty database at the current version without adding an admin account,
+// so it can be migrated. Databases that already have tables are left as they are.
+func (p *Postgres) Bootstrap() error {
+	unlock, err := p.lockMigrations()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = p.createMigrationTable()
+	if err != nil {
+		return err
+	}
+	if p.checkVersion() < 1 {
+		return p.createTables()
+	}
+	return nil
+}
+
+// Migrate Moves the database to the target version, running migrations up or reversing them as needed.
+func (p *Postgres) Migrate(target int, dryRun bool) (*types.MigrationPlan, error) {
+	unlock, err := p.lockMigrations()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return p.migrate(target, dryRun)
+}
+
+// migrate Does the work for Migrate, expecting the caller to hold the migration lock.
+func (p *Postgres) migrate(target int, dryRun bool) (*types.MigrationPlan, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), database.MigrationTimeout)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start transaction: %v", err)
+	}
+	var version string
+	err = tx.QueryRow(
+		ctx,
+		"SELECT value FROM settings WHERE name='version';",
+	).Scan(&version)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error checking database version: %v", err)
+	}
+	from, err := strconv.Atoi(version)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error checking database version: %v", err)
+	}
+	plan, err := database.PlanMigrations(migrations, from, target)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	if dryRun {
+		tx.Rollback(ctx)
+		return plan, nil
+	}
+	_, err = tx.Exec(ctx, migrationTableQuery)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error creating migration table: %v", err)
+	}
+	err = database.RunMigrations(ctx, tx, migrations, plan, func(step types.MigrationStep) error {
+		log.Info(fmt.Sprintf("Migrated database %s through version %d (%s).", step.Direction, step.Version, step.Name))
+		_, err := tx.Exec(
+			ctx,
+			"INSERT INTO schema_migration(migration_version, migration_name, migration_direction, "+
+				"migration_applied_at) VALUES ($1, $2, $3, $4);",
+			step.Version,
+			step.Name,
+			step.Direction,
+			time.Now().Unix(),
+		)
+		return err
+	})
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error migrating from version %d to %d: %v", from, target, err)
+	}
+	_, err = tx.Exec(
+		ctx,
+		"UPDATE settings SET value=$1 WHERE name='version';",
+		strconv.Itoa(target),
+	)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error migrating from version %d to %d: %v", from, target, err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return plan, nil
+}
+
+// GetMigrationHistory Returns every migration run against the database, oldest first.
+func (p *Postgres) GetMigrationHistory() ([]types.MigrationRecord, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT migration_id, migration_version, migration_name, migration_direction, migration_applied_at "+
+			"FROM schema_migration ORDER BY migration_id;",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving migration history: %v", err)
+	}
+	defer res.Close()
+	outRecords := make([]types.MigrationRecord, 0)
+	for res.Next() {
+		var record types.MigrationRecord
+		var appliedAt int64
+		err := res.Scan(
+			&record.Identifier,
+			&record.Version,
+			&record.Name,
+			&record.Direction,
+			&appliedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting migration record: %v", err)
+		}
+		record.AppliedAt = time.Unix(appliedAt, 0).UTC()
+		outRecords = append(outRecords, record)
+	}
+	return outRecords, nil
+}
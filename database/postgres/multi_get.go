@@ -31,15 +31,17 @@ func (p *Postgres) GetKeyAndAccount(key string) (*types.MultiKey, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Only the hash of a key value is stored, so that is what has to match.
+	hash := types.HashKey(key)
 	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancelfunc()
 	res, err := db.Query(
 		ctx,
 		"SELECT "+
-			"account_id, account_name, account_email, account_type, account_locked, "+
-			"key_value, key_type, key_name, valid_until "+
-			"FROM account NATURAL JOIN api_key WHERE account_deleted=FALSE AND key_deleted=FALSE AND key_value=$1",
-		key,
+			"account_id, account_name, account_email, account_type, account_locked, account_lock_reason, account_locked_until, "+
+			"key_prefix, key_value, key_type, key_name, allowed_hosts, valid_until, old_key_valid_until, org_id "+
+			"FROM account NATURAL JOIN api_key WHERE account_deleted=FALSE AND key_deleted=FALSE AND (key_value=$1 OR old_key_value=$1)",
+		hash,
 	)
 	if err != nil {
 		res.Close()
@@ -47,6 +49,9 @@ func (p *Postgres) GetKeyAndAccount(key string) (*types.MultiKey, error) {
 	}
 	defer res.Close()
 	if res.Next() {
+		var allowedHosts string
+		var oldValidUntil *time.Time
+		var lockedUntil int64
 		outVal := types.MultiKey{
 			Key:     &types.Key{},
 			Account: &types.Account{},
@@ -57,15 +62,27 @@ func (p *Postgres) GetKeyAndAccount(key string) (*types.MultiKey, error) {
 			&outVal.Account.Email,
 			&outVal.Account.Type,
 			&outVal.Account.Locked,
-			&outVal.Key.Value,
+			&outVal.Account.LockReason,
+			&lockedUntil,
+			&outVal.Key.Prefix,
+			&outVal.Key.Hash,
 			&outVal.Key.Type,
 			&outVal.Key.Name,
+			&allowedHosts,
 			&outVal.Key.ValidUntil,
+			&oldValidUntil,
+			&outVal.Key.Organization,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting values for account and event: %v", err)
 		}
+		outVal.Account.SetLockedUntil(lockedUntil)
 		outVal.Key.AccountIdentifier = outVal.Account.Identifier
+		outVal.Key.SetAllowedHosts(allowedHosts)
+		// A rotated key's previous value only works until the grace period given when it was rotated ends.
+		if outVal.Key.Hash != hash && (oldValidUntil == nil || oldValidUntil.Before(time.Now())) {
+			return nil, nil
+		}
 		return &outVal, nil
 	}
 	return nil, nil
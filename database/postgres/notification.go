@@ -26,7 +26,7 @@ import (
 	"time"
 )
 
-func (p *Postgres) GetNotification(account int64, reader_name string) (*types.Notification, error) {
+func (p *Postgres) GetNotification(account int64, org *int64, reader_name string) (*types.Notification, error) {
 	db, err := p.GetDB()
 	if err != nil {
 		return nil, err
@@ -36,10 +36,11 @@ func (p *Postgres) GetNotification(account int64, reader_name string) (*types.No
 	res, err := db.Query(
 		ctx,
 		"SELECT notification_id, notification_type, notification_when "+
-			"FROM (SELECT key_value AS kv, MAX(notification_when) AS max_when FROM notification GROUP BY key_value) AS b INNER JOIN notification AS n ON b.max_when=n.notification_when AND b.kv=n.key_value "+
+			"FROM (SELECT key_id AS kid, MAX(notification_when) AS max_when FROM notification GROUP BY key_id) AS b INNER JOIN notification AS n ON b.max_when=n.notification_when AND b.kid=n.key_id "+
 			"NATURAL JOIN api_key AS a "+
-			"WHERE a.account_id=$1 AND a.key_name=$2 AND n.notification_when>$3;",
+			"WHERE a.account_id=$1 AND a.org_id IS NOT DISTINCT FROM $2 AND a.key_name=$3 AND n.notification_when>$4;",
 		account,
+		org,
 		reader_name,
 		time.Now().Add(time.Minute*-5).Unix(),
 	)
@@ -65,10 +66,10 @@ func (p *Postgres) GetNotification(account int64, reader_name string) (*types.No
 	return &out, nil
 }
 
-func (p *Postgres) SaveNotification(notification *types.RequestNotification, key string) error {
+func (p *Postgres) SaveNotification(notification *types.RequestNotification, key string) (bool, error) {
 	db, err := p.GetDB()
 	if err != nil {
-		return err
+		return false, err
 	}
 	valid := false
 	switch notification.Type {
@@ -76,27 +77,127 @@ func (p *Postgres) SaveNotification(notification *types.RequestNotification, key
 		valid = true
 	}
 	if !valid {
-		return fmt.Errorf("%v is not a valid type", notification.Type)
+		return false, fmt.Errorf("%v is not a valid type", notification.Type)
 	}
 	when, err := time.Parse(time.RFC3339, notification.When)
 	if err != nil {
-		return fmt.Errorf("unable to parse time value: %v", err)
+		return false, fmt.Errorf("unable to parse time value: %v", err)
 	}
 	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancelfunc()
 	res, err := db.Exec(
 		ctx,
-		"INSERT INTO notification(notification_type, notification_when, key_value) VALUES($1, $2, $3) ON CONFLICT DO NOTHING;",
+		"INSERT INTO notification(notification_type, notification_when, key_id) SELECT $1::VARCHAR, $2::BIGINT, key_id "+
+			"FROM api_key WHERE key_value=$3 OR (old_key_value=$3 AND old_key_valid_until>$4) ON CONFLICT DO NOTHING;",
 		notification.Type,
 		when.Unix(),
-		key,
+		types.HashKey(key),
+		time.Now(),
 	)
 	if err != nil {
-		return fmt.Errorf("unable to add notification: %v", err)
+		return false, fmt.Errorf("unable to add notification: %v", err)
 	}
-	if res.RowsAffected() < 1 {
-		return errors.New("insert appears to be unsuccessful")
+	if res.RowsAffected() > 0 {
+		return true, nil
 	}
-	return nil
+	// Nothing is added when the key already has a notification for that time, which is ignored, or when
+	// there's no such key.
+	var found int
+	err = db.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM api_key WHERE key_value=$1 OR (old_key_value=$1 AND old_key_valid_until>$2);",
+		types.HashKey(key),
+		time.Now(),
+	).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("error checking for key: %v", err)
+	}
+	if found < 1 {
+		return false, errors.New("insert appears to be unsuccessful")
+	}
+	return false, nil
 }
 
+// GetNotificationHistory Gets notifications saved between from and to (inclusive), newest first.
+// An empty reader_name returns notifications for all of the account's readers.
+func (p *Postgres) GetNotificationHistory(account int64, org *int64, reader_name string, from, to int64, unacknowledged bool, after *types.NotificationCursor, limit int) ([]types.Notification, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	query := "SELECT n.notification_id, a.key_name, n.notification_type, n.notification_when, n.notification_acknowledged_at " +
+		"FROM notification AS n JOIN api_key AS a ON n.key_id=a.key_id WHERE a.account_id=$1 AND " +
+		"a.org_id IS NOT DISTINCT FROM $2 AND n.notification_when>=$3 AND n.notification_when<=$4 "
+	args := []interface{}{account, org, from, to}
+	if reader_name != "" {
+		args = append(args, reader_name)
+		query += fmt.Sprintf("AND a.key_name=$%d ", len(args))
+	}
+	if unacknowledged {
+		query += "AND n.notification_acknowledged_at IS NULL "
+	}
+	if after != nil {
+		args = append(args, after.When, after.Identifier)
+		query += fmt.Sprintf("AND (n.notification_when, n.notification_id)<($%d, $%d) ", len(args)-1, len(args))
+	}
+	query += fmt.Sprintf("ORDER BY n.notification_when DESC, n.notification_id DESC LIMIT %d;", limit)
+	res, err := db.Query(
+		ctx,
+		query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving notifications: %v", err)
+	}
+	defer res.Close()
+	var outNotes []types.Notification
+	for res.Next() {
+		var note types.Notification
+		var when int64
+		err := res.Scan(
+			&note.Identifier,
+			&note.Reader,
+			&note.Type,
+			&when,
+			&note.Acknowledged,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting notification: %v", err)
+		}
+		note.When = time.Unix(when, 0)
+		outNotes = append(outNotes, note)
+	}
+	return outNotes, nil
+}
+
+// AcknowledgeNotifications Acknowledges the account's notifications with the given ids, returning how many
+// were acknowledged. Notifications that were already acknowledged keep their original time.
+func (p *Postgres) AcknowledgeNotifications(account int64, org *int64, notifications []int64) (int64, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	if len(notifications) < 1 {
+		return 0, nil
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"UPDATE notification SET notification_acknowledged_at=$1 WHERE notification_acknowledged_at IS NULL AND "+
+			"key_id IN (SELECT key_id FROM api_key WHERE account_id=$2 AND org_id IS NOT DISTINCT FROM $3) AND notification_id=ANY($4);",
+		time.Now().UTC().Truncate(time.Second),
+		account,
+		org,
+		notifications,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error acknowledging notifications: %v", err)
+	}
+	return res.RowsAffected(), nil
+}
@@ -24,9 +24,11 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
-func (p *Postgres) GetReads(account int64, reader_name string, from, to int64) ([]types.Read, error) {
+func (p *Postgres) GetReads(account int64, org *int64, reader_name string, from, to int64) ([]types.Read, error) {
 	db, err := p.GetDB()
 	if err != nil {
 		return nil, err
@@ -39,10 +41,11 @@ func (p *Postgres) GetReads(account int64, reader_name string, from, to int64) (
 	}
 	res, err := db.Query(
 		ctx,
-		"SELECT key_value, identifier, seconds, milliseconds, ident_type, type, antenna,"+
+		"SELECT key_prefix, identifier, seconds, milliseconds, ident_type, type, antenna,"+
 			" reader, rssi FROM read NATURAL JOIN api_key WHERE account_id=$1 AND "+
-			"key_name=$2 AND seconds>=$3 AND seconds<=$4;",
+			"org_id IS NOT DISTINCT FROM $2 AND key_name=$3 AND seconds>=$4 AND seconds<=$5;",
 		account,
+		org,
 		reader_name,
 		from,
 		toVal,
@@ -73,6 +76,113 @@ func (p *Postgres) GetReads(account int64, reader_name string, from, to int64) (
 	return outReads, nil
 }
 
+// GetReadsPage Gets up to limit reads ordered by (seconds, milliseconds, identifier, ident_type),
+// starting after the position marked by the cursor if one is given.
+func (p *Postgres) GetReadsPage(account int64, org *int64, reader_name string, from, to int64, after *types.ReadCursor, limit int) ([]types.Read, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	toVal := to
+	if to < from {
+		toVal = from + 360
+	}
+	query := "SELECT key_prefix, identifier, seconds, milliseconds, ident_type, type, antenna, " +
+		"reader, rssi FROM read NATURAL JOIN api_key WHERE account_id=$1 AND " +
+		"org_id IS NOT DISTINCT FROM $2 AND key_name=$3 AND seconds>=$4 AND seconds<=$5 "
+	args := []interface{}{account, org, reader_name, from, toVal}
+	if after != nil {
+		query += "AND (seconds, milliseconds, identifier, ident_type)>($6, $7, $8, $9) "
+		args = append(args, after.Seconds, after.Milliseconds, after.Identifier, after.IdentType)
+	}
+	query += fmt.Sprintf("ORDER BY seconds, milliseconds, identifier, ident_type LIMIT %d;", limit)
+	res, err := db.Query(
+		ctx,
+		query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving reads: %v", err)
+	}
+	defer res.Close()
+	var outReads []types.Read
+	for res.Next() {
+		var read types.Read
+		err := res.Scan(
+			&read.Key,
+			&read.Identifier,
+			&read.Seconds,
+			&read.Milliseconds,
+			&read.IdentType,
+			&read.Type,
+			&read.Antenna,
+			&read.Reader,
+			&read.RSSI,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting read: %v", err)
+		}
+		outReads = append(outReads, read)
+	}
+	return outReads, nil
+}
+
+// GetReadsAfter Gets up to limit reads added after the read with the given id, in the order they were added.
+func (p *Postgres) GetReadsAfter(account int64, org *int64, reader_name string, after int64, limit int) ([]types.Read, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT read_id, key_prefix, identifier, seconds, milliseconds, ident_type, type, antenna, "+
+			"reader, rssi FROM read NATURAL JOIN api_key WHERE account_id=$1 AND "+
+			"org_id IS NOT DISTINCT FROM $2 AND key_name=$3 AND read_id>$4 ORDER BY read_id LIMIT $5;",
+		account,
+		org,
+		reader_name,
+		after,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving reads: %v", err)
+	}
+	defer res.Close()
+	var outReads []types.Read
+	for res.Next() {
+		var read types.Read
+		err := res.Scan(
+			&read.ID,
+			&read.Key,
+			&read.Identifier,
+			&read.Seconds,
+			&read.Milliseconds,
+			&read.IdentType,
+			&read.Type,
+			&read.Antenna,
+			&read.Reader,
+			&read.RSSI,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting read: %v", err)
+		}
+		outReads = append(outReads, read)
+	}
+	return outReads, nil
+}
+
+// AddReads Adds reads to the database and returns the reads that were inserted, in order.
+// Reads that already exist are skipped and not returned.
 func (p *Postgres) AddReads(key string, reads []types.Read) ([]types.Read, error) {
 	db, err := p.GetDB()
 	if err != nil {
@@ -84,11 +194,23 @@ func (p *Postgres) AddReads(key string, reads []types.Read) ([]types.Read, error
 	if err != nil {
 		return nil, fmt.Errorf("unable to begin transaction to add reads: %v", err)
 	}
+	var keyID int64
+	err = tx.QueryRow(
+		ctx,
+		"SELECT key_id FROM api_key WHERE key_value=$1 OR (old_key_value=$1 AND old_key_valid_until>$2);",
+		types.HashKey(key),
+		time.Now(),
+	).Scan(&keyID)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("unable to find key for reads: %v", err)
+	}
+	var outReads []types.Read
 	for _, read := range reads {
-		_, err := tx.Exec(
+		err := tx.QueryRow(
 			ctx,
 			"INSERT INTO read("+
-				"key_value, "+
+				"key_id, "+
 				"identifier, "+
 				"seconds, "+
 				"milliseconds, "+
@@ -108,8 +230,9 @@ func (p *Postgres) AddReads(key string, reads []types.Read) ([]types.Read, error
 				"$8, "+
 				"$9 "+
 				") "+
-				"ON CONFLICT(key_value, identifier, seconds, milliseconds, ident_type) DO NOTHING;",
-			key,
+				"ON CONFLICT(key_id, identifier, seconds, milliseconds, ident_type) DO NOTHING "+
+				"RETURNING read_id;",
+			keyID,
 			read.Identifier,
 			read.Seconds,
 			read.Milliseconds,
@@ -118,15 +241,25 @@ func (p *Postgres) AddReads(key string, reads []types.Read) ([]types.Read, error
 			read.Antenna,
 			read.Reader,
 			read.RSSI,
-		)
+		).Scan(&read.ID)
+		// Duplicates are ignored by the insert, return no id, and aren't reported as added.
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
 		if err != nil {
-			return nil, err
+			tx.Rollback(ctx)
+			return nil, fmt.Errorf("error adding reads to database: %v", err)
 		}
+		outReads = append(outReads, read)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to commit transaction: %v", err)
 	}
-	return reads, tx.Commit(ctx)
+	return outReads, nil
 }
 
-func (p *Postgres) DeleteReaderReads(account int64, reader_name string, from, to int64) (int64, error) {
+func (p *Postgres) DeleteReaderReads(account int64, org *int64, reader_name string, from, to int64) (int64, error) {
 	if to < from {
 		return 0, errors.New("second input variable must be greater than first")
 	}
@@ -139,11 +272,12 @@ func (p *Postgres) DeleteReaderReads(account int64, reader_name string, from, to
 	res, err := db.Exec(
 		ctx,
 		"DELETE FROM read r WHERE seconds>=$1 AND seconds<=$2 AND EXISTS (SELECT * "+
-			"FROM api_key a WHERE a.key_value=r.key_value AND a.account_id=$3 AND "+
-			"a.key_name=$4);",
+			"FROM api_key a WHERE a.key_id=r.key_id AND a.account_id=$3 AND "+
+			"a.org_id IS NOT DISTINCT FROM $4 AND a.key_name=$5);",
 		from,
 		to,
 		account,
+		org,
 		reader_name,
 	)
 	if err != nil {
@@ -161,8 +295,8 @@ func (p *Postgres) DeleteKeyReads(key string) (int64, error) {
 	defer cancelfunc()
 	res, err := db.Exec(
 		ctx,
-		"DELETE FROM read WHERE key_value=$1;",
-		key,
+		"DELETE FROM read WHERE key_id IN (SELECT key_id FROM api_key WHERE key_value=$1);",
+		types.HashKey(key),
 	)
 	if err != nil {
 		return 0, fmt.Errorf("unable to delete reads: %v", err)
@@ -170,7 +304,7 @@ func (p *Postgres) DeleteKeyReads(key string) (int64, error) {
 	return res.RowsAffected(), nil
 }
 
-func (p *Postgres) DeleteReaderReadsBefore(account int64, reader_name string, to int64) (int64, error) {
+func (p *Postgres) DeleteReaderReadsBefore(account int64, org *int64, reader_name string, to int64) (int64, error) {
 	db, err := p.GetDB()
 	if err != nil {
 		return 0, err
@@ -180,9 +314,10 @@ func (p *Postgres) DeleteReaderReadsBefore(account int64, reader_name string, to
 	res, err := db.Exec(
 		ctx,
 		"DELETE FROM read r WHERE seconds<=$1 AND EXISTS (SELECT * FROM api_key a WHERE "+
-			"a.key_value=r.key_value AND a.account_id=$2 AND a.key_name=$3);",
+			"a.key_id=r.key_id AND a.account_id=$2 AND a.org_id IS NOT DISTINCT FROM $3 AND a.key_name=$4);",
 		to,
 		account,
+		org,
 		reader_name,
 	)
 	if err != nil {
@@ -191,7 +326,7 @@ func (p *Postgres) DeleteReaderReadsBefore(account int64, reader_name string, to
 	return res.RowsAffected(), nil
 }
 
-func (p *Postgres) DeleteReaderReadsBetween(account int64, reader_name string) (int64, error) {
+func (p *Postgres) DeleteReaderReadsBetween(account int64, org *int64, reader_name string) (int64, error) {
 	db, err := p.GetDB()
 	if err != nil {
 		return 0, err
@@ -201,8 +336,9 @@ func (p *Postgres) DeleteReaderReadsBetween(account int64, reader_name string) (
 	res, err := db.Exec(
 		ctx,
 		"DELETE FROM read r WHERE EXISTS (SELECT * FROM api_key a WHERE "+
-			"a.key_value=r.key_value AND a.account_id=$1 AND a.key_name=$2);",
+			"a.key_id=r.key_id AND a.account_id=$1 AND a.org_id IS NOT DISTINCT FROM $2 AND a.key_name=$3);",
 		account,
+		org,
 		reader_name,
 	)
 	if err != nil {
@@ -211,3 +347,24 @@ func (p *Postgres) DeleteReaderReadsBetween(account int64, reader_name string) (
 	return res.RowsAffected(), nil
 }
 
+// DeleteAccountTypeReadsBefore Deletes reads uploaded (not read) before the given unix time for
+// every account of the given type. Used to enforce read retention.
+func (p *Postgres) DeleteAccountTypeReadsBefore(account_type string, before int64) (int64, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"DELETE FROM read r WHERE r.read_created_at<to_timestamp($1) AND EXISTS (SELECT * FROM "+
+			"api_key a NATURAL JOIN account c WHERE r.key_id=a.key_id AND c.account_type=$2);",
+		before,
+		account_type,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete reads: %v", err)
+	}
+	return res.RowsAffected(), nil
+}
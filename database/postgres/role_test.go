@@ -0,0 +1,187 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"chronokeep/remote/types"
+	"testing"
+)
+
+func TestGetRoles(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	roles, err := db.GetRoles()
+	if err != nil {
+		t.Fatalf("Error getting roles: %v", err)
+	}
+	if len(roles) != len(types.DefaultRoles()) {
+		t.Fatalf("Expected %v roles, found %+v.", len(types.DefaultRoles()), roles)
+	}
+	for _, def := range types.DefaultRoles() {
+		role, err := db.GetRole(def.Name)
+		if err != nil {
+			t.Fatalf("Error getting role: %v", err)
+		}
+		if role == nil || role.Name != def.Name || len(role.Permissions) != len(def.Permissions) {
+			t.Errorf("Expected role %+v, found %+v.", def, role)
+		}
+	}
+	role, err := db.GetRole(types.RoleAdmin)
+	if err != nil {
+		t.Fatalf("Error getting role: %v", err)
+	}
+	for _, permission := range types.Permissions() {
+		if !role.Can(permission) {
+			t.Errorf("Expected admin role to have permission %v.", permission)
+		}
+	}
+	role, err = db.GetRole("unknown")
+	if err != nil {
+		t.Fatalf("Error getting role: %v", err)
+	}
+	if role != nil {
+		t.Errorf("Expected no role, found %+v.", *role)
+	}
+}
+
+func TestSaveRole(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	err = db.SaveRole(types.Role{
+		Name:        "support",
+		Permissions: []string{types.PermAccountsView, types.PermAccountsUnlock, types.PermAccountsView},
+	})
+	if err != nil {
+		t.Fatalf("Error saving role: %v", err)
+	}
+	role, err := db.GetRole("support")
+	if err != nil {
+		t.Fatalf("Error getting role: %v", err)
+	}
+	if role == nil || len(role.Permissions) != 2 || !role.Can(types.PermAccountsView) || !role.Can(types.PermAccountsUnlock) {
+		t.Errorf("Expected support role with two permissions, found %+v.", role)
+	}
+	// Saving a role with the same name replaces its permissions.
+	err = db.SaveRole(types.Role{
+		Name:        "support",
+		Permissions: []string{types.PermRetentionView},
+	})
+	if err != nil {
+		t.Fatalf("Error saving role: %v", err)
+	}
+	role, err = db.GetRole("support")
+	if err != nil {
+		t.Fatalf("Error getting role: %v", err)
+	}
+	if role == nil || len(role.Permissions) != 1 || !role.Can(types.PermRetentionView) {
+		t.Errorf("Expected support role with one permission, found %+v.", role)
+	}
+	// A role can have no permissions at all.
+	err = db.SaveRole(types.Role{Name: "support"})
+	if err != nil {
+		t.Fatalf("Error saving role: %v", err)
+	}
+	role, err = db.GetRole("support")
+	if err != nil {
+		t.Fatalf("Error getting role: %v", err)
+	}
+	if role == nil || len(role.Permissions) != 0 {
+		t.Errorf("Expected support role without permissions, found %+v.", role)
+	}
+	roles, err := db.GetRoles()
+	if err != nil {
+		t.Fatalf("Error getting roles: %v", err)
+	}
+	if len(roles) != len(types.DefaultRoles())+1 {
+		t.Errorf("Expected %v roles, found %+v.", len(types.DefaultRoles())+1, roles)
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	err = db.SaveRole(types.Role{Name: "support", Permissions: []string{types.PermAccountsView}})
+	if err != nil {
+		t.Fatalf("Error saving role: %v", err)
+	}
+	err = db.DeleteRole("support")
+	if err != nil {
+		t.Fatalf("Error deleting role: %v", err)
+	}
+	role, err := db.GetRole("support")
+	if err != nil {
+		t.Fatalf("Error getting role: %v", err)
+	}
+	if role != nil {
+		t.Errorf("Expected role to be deleted, found %+v.", *role)
+	}
+	err = db.DeleteRole("support")
+	if err == nil {
+		t.Error("Expected error deleting role twice.")
+	}
+}
+
+func TestBadDatabaseRole(t *testing.T) {
+	db := badTestSetup(t)
+	_, err := db.GetRoles()
+	if err == nil {
+		t.Fatal("Expected error getting roles.")
+	}
+	_, err = db.GetRole("")
+	if err == nil {
+		t.Fatal("Expected error getting role.")
+	}
+	err = db.SaveRole(types.Role{})
+	if err == nil {
+		t.Fatal("Expected error saving role.")
+	}
+	err = db.DeleteRole("")
+	if err == nil {
+		t.Fatal("Expected error deleting role.")
+	}
+}
+
+func TestNoDatabaseRole(t *testing.T) {
+	db := Postgres{}
+	_, err := db.GetRoles()
+	if err == nil {
+		t.Fatal("Expected error getting roles.")
+	}
+	_, err = db.GetRole("")
+	if err == nil {
+		t.Fatal("Expected error getting role.")
+	}
+	err = db.SaveRole(types.Role{})
+	if err == nil {
+		t.Fatal("Expected error saving role.")
+	}
+	err = db.DeleteRole("")
+	if err == nil {
+		t.Fatal("Expected error deleting role.")
+	}
+}
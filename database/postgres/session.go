@@ -0,0 +1,240 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddSession Adds a session for an account, storing the hashes of its tokens.
+func (p *Postgres) AddSession(session types.Session) (*types.Session, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	now := time.Now().UTC().Truncate(time.Second)
+	var id int64
+	err = db.QueryRow(
+		ctx,
+		"INSERT INTO account_session(account_id, session_token, session_refresh_token, session_user_agent, "+
+			"session_ip, session_created_at, session_last_used) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING (session_id);",
+		session.AccountIdentifier,
+		types.HashToken(session.Token),
+		types.HashToken(session.RefreshToken),
+		session.UserAgent,
+		session.IP,
+		now,
+		now,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to add session: %v", err)
+	}
+	session.Identifier = id
+	session.CreatedAt = now
+	session.LastUsed = now
+	return &session, nil
+}
+
+func (p *Postgres) getSessions(query string, args ...interface{}) ([]types.Session, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT session_id, account_id, session_user_agent, session_ip, session_created_at, session_last_used "+
+			"FROM account_session "+query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving sessions: %v", err)
+	}
+	defer res.Close()
+	var outSessions []types.Session
+	for res.Next() {
+		var session types.Session
+		err := res.Scan(
+			&session.Identifier,
+			&session.AccountIdentifier,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting session: %v", err)
+		}
+		outSessions = append(outSessions, session)
+	}
+	return outSessions, nil
+}
+
+// GetSession Gets the session a token belongs to.
+func (p *Postgres) GetSession(token string) (*types.Session, error) {
+	sessions, err := p.getSessions("WHERE session_token=$1;", types.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) < 1 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
+
+// GetSessionByRefreshToken Gets the session a refresh token belongs to.
+func (p *Postgres) GetSessionByRefreshToken(refreshToken string) (*types.Session, error) {
+	sessions, err := p.getSessions("WHERE session_refresh_token=$1;", types.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) < 1 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
+
+// GetAccountSessions Gets all sessions for an account, most recently used first.
+func (p *Postgres) GetAccountSessions(account int64) ([]types.Session, error) {
+	return p.getSessions("WHERE account_id=$1 ORDER BY session_last_used DESC, session_id DESC;", account)
+}
+
+// UpdateSession Replaces the tokens of a session and records where it was last used from.
+func (p *Postgres) UpdateSession(session types.Session) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"UPDATE account_session SET session_token=$1, session_refresh_token=$2, session_user_agent=$3, "+
+			"session_ip=$4, session_last_used=$5 WHERE session_id=$6;",
+		types.HashToken(session.Token),
+		types.HashToken(session.RefreshToken),
+		session.UserAgent,
+		session.IP,
+		time.Now().UTC().Truncate(time.Second),
+		session.Identifier,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating session: %v", err)
+	}
+	rows := res.RowsAffected()
+	if rows != 1 {
+		return fmt.Errorf("error updating session, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// UseSession Marks a session as used now.
+func (p *Postgres) UseSession(session int64) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"UPDATE account_session SET session_last_used=$1 WHERE session_id=$2;",
+		time.Now().UTC().Truncate(time.Second),
+		session,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating session: %v", err)
+	}
+	rows := res.RowsAffected()
+	if rows != 1 {
+		return fmt.Errorf("error updating session, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// DeleteSession Ends a single session belonging to an account.
+func (p *Postgres) DeleteSession(account, session int64) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"DELETE FROM account_session WHERE account_id=$1 AND session_id=$2;",
+		account,
+		session,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting session: %v", err)
+	}
+	rows := res.RowsAffected()
+	if rows != 1 {
+		return fmt.Errorf("error deleting session, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// DeleteAccountSessions Ends every session belonging to an account other than the ones to keep.
+// Returns the number of sessions ended.
+func (p *Postgres) DeleteAccountSessions(account int64, keep ...int64) (int64, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	query := "DELETE FROM account_session WHERE account_id=$1"
+	args := []interface{}{account}
+	for _, session := range keep {
+		args = append(args, session)
+		query += fmt.Sprintf(" AND session_id<>$%d", len(args))
+	}
+	res, err := db.Exec(ctx, query+";", args...)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting sessions: %v", err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// deleteEmailSessions Ends every session belonging to the account with the given email.
+func (p *Postgres) deleteEmailSessions(email string) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	_, err = db.Exec(
+		ctx,
+		"DELETE FROM account_session WHERE account_id IN (SELECT account_id FROM account WHERE account_email=$1);",
+		email,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting sessions: %v", err)
+	}
+	return nil
+}
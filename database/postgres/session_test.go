@@ -0,0 +1,344 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"chronokeep/remote/types"
+	"testing"
+)
+
+func TestAddSession(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	session := types.Session{
+		AccountIdentifier: account1.Identifier,
+		Token:             "testtoken1",
+		RefreshToken:      "refreshtoken1",
+		UserAgent:         "test-agent/1.0",
+		IP:                "192.168.1.10",
+	}
+	added, err := db.AddSession(session)
+	if err != nil {
+		t.Fatalf("Error adding session: %v", err)
+	}
+	if added.Identifier == 0 || added.CreatedAt.IsZero() || added.LastUsed.IsZero() {
+		t.Errorf("Expected session to have an id and times set, found %+v.", *added)
+	}
+	found, err := db.GetSession(session.Token)
+	if err != nil {
+		t.Fatalf("Error getting session: %v", err)
+	}
+	if found == nil {
+		t.Fatal("Expected to find session by token.")
+	}
+	if found.Identifier != added.Identifier || found.AccountIdentifier != account1.Identifier {
+		t.Errorf("Expected session %+v, found %+v.", *added, *found)
+	}
+	if found.UserAgent != session.UserAgent || found.IP != session.IP {
+		t.Errorf("Expected user agent %v and ip %v, found %v and %v.", session.UserAgent, session.IP, found.UserAgent, found.IP)
+	}
+	if found.Token != "" || found.RefreshToken != "" {
+		t.Errorf("Expected tokens to not be returned, found %+v.", *found)
+	}
+	found, err = db.GetSessionByRefreshToken(session.RefreshToken)
+	if err != nil {
+		t.Fatalf("Error getting session: %v", err)
+	}
+	if found == nil || found.Identifier != added.Identifier {
+		t.Errorf("Expected to find session %v by refresh token, found %+v.", added.Identifier, found)
+	}
+	// Tokens are stored hashed and can't be swapped for each other.
+	found, _ = db.GetSession(types.HashToken(session.Token))
+	if found != nil {
+		t.Errorf("Expected hash to not work as a token, found %+v.", *found)
+	}
+	found, _ = db.GetSession(session.RefreshToken)
+	if found != nil {
+		t.Errorf("Expected refresh token to not work as a token, found %+v.", *found)
+	}
+	// Duplicate tokens aren't allowed.
+	_, err = db.AddSession(session)
+	if err == nil {
+		t.Error("Expected error adding session with a duplicate token.")
+	}
+}
+
+func TestGetAccountSessions(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token1", RefreshToken: "refresh1"})
+	db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token2", RefreshToken: "refresh2"})
+	db.AddSession(types.Session{AccountIdentifier: account2.Identifier, Token: "token3", RefreshToken: "refresh3"})
+	sessions, err := db.GetAccountSessions(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("Expected %v sessions, found %v.", 2, len(sessions))
+	}
+	for _, session := range sessions {
+		if session.AccountIdentifier != account1.Identifier {
+			t.Errorf("Expected session for account %v, found %+v.", account1.Identifier, session)
+		}
+	}
+	sessions, err = db.GetAccountSessions(account2.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting sessions: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Errorf("Expected %v sessions, found %v.", 1, len(sessions))
+	}
+	sessions, err = db.GetAccountSessions(account2.Identifier + 100)
+	if err != nil {
+		t.Fatalf("Error getting sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("Expected %v sessions, found %v.", 0, len(sessions))
+	}
+}
+
+func TestUpdateSession(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	added, err := db.AddSession(types.Session{
+		AccountIdentifier: account1.Identifier,
+		Token:             "token1",
+		RefreshToken:      "refresh1",
+		UserAgent:         "agent1",
+		IP:                "10.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("Error adding session: %v", err)
+	}
+	added.Token = "token2"
+	added.RefreshToken = "refresh2"
+	added.UserAgent = "agent2"
+	added.IP = "10.0.0.2"
+	err = db.UpdateSession(*added)
+	if err != nil {
+		t.Fatalf("Error updating session: %v", err)
+	}
+	found, _ := db.GetSession("token1")
+	if found != nil {
+		t.Errorf("Expected old token to no longer work, found %+v.", *found)
+	}
+	found, _ = db.GetSessionByRefreshToken("refresh1")
+	if found != nil {
+		t.Errorf("Expected old refresh token to no longer work, found %+v.", *found)
+	}
+	found, _ = db.GetSession("token2")
+	if found == nil {
+		t.Fatal("Expected to find session by new token.")
+	}
+	if found.Identifier != added.Identifier || found.UserAgent != "agent2" || found.IP != "10.0.0.2" {
+		t.Errorf("Expected session %+v, found %+v.", *added, *found)
+	}
+	if !found.CreatedAt.Equal(added.CreatedAt) {
+		t.Errorf("Expected created time %v to be kept, found %v.", added.CreatedAt, found.CreatedAt)
+	}
+	found, _ = db.GetSessionByRefreshToken("refresh2")
+	if found == nil || found.Identifier != added.Identifier {
+		t.Errorf("Expected to find session %v by new refresh token, found %+v.", added.Identifier, found)
+	}
+	err = db.UseSession(added.Identifier)
+	if err != nil {
+		t.Errorf("Error using session: %v", err)
+	}
+	err = db.UseSession(added.Identifier + 100)
+	if err == nil {
+		t.Error("Expected error using unknown session.")
+	}
+	added.Identifier = added.Identifier + 100
+	err = db.UpdateSession(*added)
+	if err == nil {
+		t.Error("Expected error updating unknown session.")
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	session1, _ := db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token1", RefreshToken: "refresh1"})
+	session2, _ := db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token2", RefreshToken: "refresh2"})
+	// Sessions can only be deleted by the account they belong to.
+	err = db.DeleteSession(account2.Identifier, session1.Identifier)
+	if err == nil {
+		t.Error("Expected error deleting session belonging to another account.")
+	}
+	err = db.DeleteSession(account1.Identifier, session1.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting session: %v", err)
+	}
+	found, _ := db.GetSession("token1")
+	if found != nil {
+		t.Errorf("Expected deleted session to not be found, found %+v.", *found)
+	}
+	found, _ = db.GetSession("token2")
+	if found == nil || found.Identifier != session2.Identifier {
+		t.Errorf("Expected other session to be kept, found %+v.", found)
+	}
+	err = db.DeleteSession(account1.Identifier, session1.Identifier)
+	if err == nil {
+		t.Error("Expected error deleting session twice.")
+	}
+}
+
+func TestDeleteAccountSessions(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	session1, _ := db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token1", RefreshToken: "refresh1"})
+	db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token2", RefreshToken: "refresh2"})
+	db.AddSession(types.Session{AccountIdentifier: account1.Identifier, Token: "token3", RefreshToken: "refresh3"})
+	db.AddSession(types.Session{AccountIdentifier: account2.Identifier, Token: "token4", RefreshToken: "refresh4"})
+	count, err := db.DeleteAccountSessions(account1.Identifier, session1.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting sessions: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected %v sessions to be deleted, found %v.", 2, count)
+	}
+	sessions, _ := db.GetAccountSessions(account1.Identifier)
+	if len(sessions) != 1 || sessions[0].Identifier != session1.Identifier {
+		t.Errorf("Expected only session %v to be kept, found %+v.", session1.Identifier, sessions)
+	}
+	count, err = db.DeleteAccountSessions(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting sessions: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected %v sessions to be deleted, found %v.", 1, count)
+	}
+	sessions, _ = db.GetAccountSessions(account1.Identifier)
+	if len(sessions) != 0 {
+		t.Errorf("Expected no sessions, found %+v.", sessions)
+	}
+	sessions, _ = db.GetAccountSessions(account2.Identifier)
+	if len(sessions) != 1 {
+		t.Errorf("Expected other account's sessions to be kept, found %+v.", sessions)
+	}
+	// Deleting an account ends its sessions.
+	err = db.DeleteAccount(account2.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting account: %v", err)
+	}
+	sessions, _ = db.GetAccountSessions(account2.Identifier)
+	if len(sessions) != 0 {
+		t.Errorf("Expected deleted account to have no sessions, found %+v.", sessions)
+	}
+}
+
+func TestBadDatabaseSession(t *testing.T) {
+	db := badTestSetup(t)
+	_, err := db.AddSession(types.Session{})
+	if err == nil {
+		t.Fatal("Expected error adding session.")
+	}
+	_, err = db.GetSession("")
+	if err == nil {
+		t.Fatal("Expected error getting session.")
+	}
+	_, err = db.GetSessionByRefreshToken("")
+	if err == nil {
+		t.Fatal("Expected error getting session by refresh token.")
+	}
+	_, err = db.GetAccountSessions(0)
+	if err == nil {
+		t.Fatal("Expected error getting account sessions.")
+	}
+	err = db.UpdateSession(types.Session{})
+	if err == nil {
+		t.Fatal("Expected error updating session.")
+	}
+	err = db.UseSession(0)
+	if err == nil {
+		t.Fatal("Expected error using session.")
+	}
+	err = db.DeleteSession(0, 0)
+	if err == nil {
+		t.Fatal("Expected error deleting session.")
+	}
+	_, err = db.DeleteAccountSessions(0)
+	if err == nil {
+		t.Fatal("Expected error deleting account sessions.")
+	}
+}
+
+func TestNoDatabaseSession(t *testing.T) {
+	db := Postgres{}
+	_, err := db.AddSession(types.Session{})
+	if err == nil {
+		t.Fatal("Expected error adding session.")
+	}
+	_, err = db.GetSession("")
+	if err == nil {
+		t.Fatal("Expected error getting session.")
+	}
+	_, err = db.GetSessionByRefreshToken("")
+	if err == nil {
+		t.Fatal("Expected error getting session by refresh token.")
+	}
+	_, err = db.GetAccountSessions(0)
+	if err == nil {
+		t.Fatal("Expected error getting account sessions.")
+	}
+	err = db.UpdateSession(types.Session{})
+	if err == nil {
+		t.Fatal("Expected error updating session.")
+	}
+	err = db.UseSession(0)
+	if err == nil {
+		t.Fatal("Expected error using session.")
+	}
+	err = db.DeleteSession(0, 0)
+	if err == nil {
+		t.Fatal("Expected error deleting session.")
+	}
+	_, err = db.DeleteAccountSessions(0)
+	if err == nil {
+		t.Fatal("Expected error deleting account sessions.")
+	}
+}
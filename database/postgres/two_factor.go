@@ -0,0 +1,269 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetTwoFactor Gets the two-factor setup for an account. Returns nil if it doesn't have one.
+func (p *Postgres) GetTwoFactor(account int64) (*types.TwoFactor, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT account_id, tf_secret, tf_enabled, tf_last_step, "+
+			"(SELECT COUNT(*) FROM recovery_code r WHERE r.account_id=t.account_id) "+
+			"FROM two_factor t WHERE account_id=$1;",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving two factor: %v", err)
+	}
+	defer res.Close()
+	if !res.Next() {
+		return nil, nil
+	}
+	var outTwoFactor types.TwoFactor
+	err = res.Scan(
+		&outTwoFactor.AccountIdentifier,
+		&outTwoFactor.Secret,
+		&outTwoFactor.Enabled,
+		&outTwoFactor.LastStep,
+		&outTwoFactor.RecoveryCodes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting two factor: %v", err)
+	}
+	return &outTwoFactor, nil
+}
+
+// AddTwoFactor Stores a new two-factor setup for an account, replacing any setup and recovery codes it already had.
+func (p *Postgres) AddTwoFactor(twoFactor types.TwoFactor) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to add two factor: %v", err)
+	}
+	for _, query := range []string{
+		"DELETE FROM recovery_code WHERE account_id=$1;",
+		"DELETE FROM two_factor WHERE account_id=$1;",
+	} {
+		_, err = tx.Exec(ctx, query, twoFactor.AccountIdentifier)
+		if err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("error deleting old two factor: %v", err)
+		}
+	}
+	_, err = tx.Exec(
+		ctx,
+		"INSERT INTO two_factor(account_id, tf_secret, tf_enabled, tf_last_step) VALUES ($1, $2, $3, $4);",
+		twoFactor.AccountIdentifier,
+		twoFactor.Secret,
+		twoFactor.Enabled,
+		twoFactor.LastStep,
+	)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("unable to add two factor: %v", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// EnableTwoFactor Turns on the two-factor setup for an account once a code for the given step
+// has been verified, replacing its recovery codes.
+func (p *Postgres) EnableTwoFactor(account, step int64, recoveryCodes []string) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to enable two factor: %v", err)
+	}
+	res, err := tx.Exec(
+		ctx,
+		"UPDATE two_factor SET tf_enabled=TRUE, tf_last_step=$1 WHERE account_id=$2 AND tf_enabled=FALSE;",
+		step,
+		account,
+	)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error enabling two factor: %v", err)
+	}
+	rows := res.RowsAffected()
+	if rows != 1 {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error enabling two factor, rows affected: %v", rows)
+	}
+	err = p.replaceRecoveryCodes(ctx, tx, account, recoveryCodes)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// SetRecoveryCodes Replaces the recovery codes for an account.
+func (p *Postgres) SetRecoveryCodes(account int64, recoveryCodes []string) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to set recovery codes: %v", err)
+	}
+	err = p.replaceRecoveryCodes(ctx, tx, account, recoveryCodes)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+func (p *Postgres) replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, account int64, recoveryCodes []string) error {
+	_, err := tx.Exec(
+		ctx,
+		"DELETE FROM recovery_code WHERE account_id=$1;",
+		account,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting old recovery codes: %v", err)
+	}
+	for _, code := range recoveryCodes {
+		_, err = tx.Exec(
+			ctx,
+			"INSERT INTO recovery_code(account_id, code_hash) VALUES ($1, $2);",
+			account,
+			types.HashKey(code),
+		)
+		if err != nil {
+			return fmt.Errorf("unable to add recovery code: %v", err)
+		}
+	}
+	return nil
+}
+
+// UseTwoFactorStep Records the step of a TOTP code used on an account. Returns false if a code from
+// the same or a later step has already been used, so each code only works once.
+func (p *Postgres) UseTwoFactorStep(account, step int64) (bool, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return false, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"UPDATE two_factor SET tf_last_step=$1 WHERE account_id=$2 AND tf_enabled=TRUE AND tf_last_step<$3;",
+		step,
+		account,
+		step,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error using two factor step: %v", err)
+	}
+	rows := res.RowsAffected()
+	return rows == 1, nil
+}
+
+// UseRecoveryCode Removes a recovery code from an account. Returns false if the account doesn't have the code.
+func (p *Postgres) UseRecoveryCode(account int64, code string) (bool, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return false, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"DELETE FROM recovery_code WHERE account_id=$1 AND code_hash=$2;",
+		account,
+		types.HashKey(code),
+	)
+	if err != nil {
+		return false, fmt.Errorf("error using recovery code: %v", err)
+	}
+	rows := res.RowsAffected()
+	return rows == 1, nil
+}
+
+// DeleteTwoFactor Removes the two-factor setup and recovery codes from an account.
+func (p *Postgres) DeleteTwoFactor(account int64) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to delete two factor: %v", err)
+	}
+	for _, query := range []string{
+		"DELETE FROM recovery_code WHERE account_id=$1;",
+		"DELETE FROM two_factor WHERE account_id=$1;",
+	} {
+		_, err = tx.Exec(ctx, query, account)
+		if err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("error deleting two factor: %v", err)
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
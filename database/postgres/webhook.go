@@ -0,0 +1,290 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddWebhook Adds a webhook to an account.
+func (p *Postgres) AddWebhook(webhook types.Webhook) (*types.Webhook, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	now := time.Now().UTC().Truncate(time.Second)
+	var id int64
+	err = db.QueryRow(
+		ctx,
+		"INSERT INTO webhook(account_id, org_id, webhook_url, webhook_secret, webhook_events, webhook_created_at) "+
+			"VALUES ($1, $2, $3, $4, $5, $6) RETURNING (webhook_id);",
+		webhook.AccountIdentifier,
+		webhook.Organization,
+		webhook.URL,
+		webhook.Secret,
+		webhook.EventsValue(),
+		now,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to add webhook: %v", err)
+	}
+	webhook.Identifier = id
+	webhook.CreatedAt = now
+	return &webhook, nil
+}
+
+// GetAccountWebhooks Gets all webhooks for an account, secrets included.
+func (p *Postgres) GetAccountWebhooks(account int64) ([]types.Webhook, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT webhook_id, account_id, org_id, webhook_url, webhook_secret, webhook_events, webhook_created_at "+
+			"FROM webhook WHERE account_id=$1 ORDER BY webhook_id;",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving webhooks: %v", err)
+	}
+	defer res.Close()
+	var outWebhooks []types.Webhook
+	for res.Next() {
+		var webhook types.Webhook
+		var events string
+		err := res.Scan(
+			&webhook.Identifier,
+			&webhook.AccountIdentifier,
+			&webhook.Organization,
+			&webhook.URL,
+			&webhook.Secret,
+			&events,
+			&webhook.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting webhook: %v", err)
+		}
+		webhook.SetEvents(events)
+		outWebhooks = append(outWebhooks, webhook)
+	}
+	return outWebhooks, nil
+}
+
+// DeleteWebhook Deletes a webhook belonging to an account along with its delivery log.
+func (p *Postgres) DeleteWebhook(account, webhook int64) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"DELETE FROM webhook WHERE account_id=$1 AND webhook_id=$2;",
+		account,
+		webhook,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %v", err)
+	}
+	rows := res.RowsAffected()
+	if rows != 1 {
+		return fmt.Errorf("error deleting webhook, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// AddWebhookDeliveries Queues deliveries to be sent.
+func (p *Postgres) AddWebhookDeliveries(deliveries []types.WebhookDelivery) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to add webhook deliveries: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, delivery := range deliveries {
+		_, err := tx.Exec(
+			ctx,
+			"INSERT INTO webhook_delivery(webhook_id, delivery_event, delivery_payload, delivery_status, "+
+				"delivery_next_attempt, delivery_created_at) VALUES ($1, $2, $3, $4, $5, $6);",
+			delivery.WebhookIdentifier,
+			delivery.Event,
+			delivery.Payload,
+			types.DeliveryPending,
+			delivery.NextAttempt.Unix(),
+			now,
+		)
+		if err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("error adding webhook delivery: %v", err)
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+func (p *Postgres) getWebhookDeliveries(query string, args ...interface{}) ([]types.WebhookDelivery, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Query(
+		ctx,
+		"SELECT d.delivery_id, d.webhook_id, w.webhook_url, w.webhook_secret, d.delivery_event, d.delivery_payload, "+
+			"d.delivery_status, d.delivery_attempts, d.delivery_next_attempt, d.delivery_response_code, d.delivery_error, "+
+			"d.delivery_created_at, d.delivery_delivered_at FROM webhook_delivery d JOIN webhook w ON d.webhook_id=w.webhook_id "+
+			query,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving webhook deliveries: %v", err)
+	}
+	defer res.Close()
+	var outDeliveries []types.WebhookDelivery
+	for res.Next() {
+		var delivery types.WebhookDelivery
+		var nextAttempt int64
+		err := res.Scan(
+			&delivery.Identifier,
+			&delivery.WebhookIdentifier,
+			&delivery.URL,
+			&delivery.Secret,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&nextAttempt,
+			&delivery.ResponseCode,
+			&delivery.Error,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting webhook delivery: %v", err)
+		}
+		delivery.NextAttempt = time.Unix(nextAttempt, 0)
+		outDeliveries = append(outDeliveries, delivery)
+	}
+	return outDeliveries, nil
+}
+
+// ClaimWebhookDeliveries Claims up to limit deliveries due to be sent at or before now, oldest first, so no
+// other instance sends them before until. Deliveries claimed by another instance are skipped until their claim
+// runs out. Recording the result of an attempt releases the claim.
+func (p *Postgres) ClaimWebhookDeliveries(now, until time.Time, limit int) ([]types.WebhookDelivery, error) {
+	db, err := p.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	// Rows another instance is claiming are skipped rather than waited on.
+	res, err := db.Query(
+		ctx,
+		"UPDATE webhook_delivery SET delivery_claimed_until=$1 WHERE delivery_id IN (SELECT delivery_id FROM "+
+			"webhook_delivery WHERE delivery_status=$2 AND delivery_next_attempt<=$3 AND delivery_claimed_until<=$3 "+
+			"ORDER BY delivery_next_attempt, delivery_id LIMIT $4 FOR UPDATE SKIP LOCKED) RETURNING delivery_id;",
+		until.Unix(),
+		types.DeliveryPending,
+		now.Unix(),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %v", err)
+	}
+	var ids []int64
+	for res.Next() {
+		var id int64
+		if err := res.Scan(&id); err != nil {
+			res.Close()
+			return nil, fmt.Errorf("error getting claimed webhook delivery: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	res.Close()
+	if err := res.Err(); err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %v", err)
+	}
+	if len(ids) < 1 {
+		return nil, nil
+	}
+	return p.getWebhookDeliveries(
+		"WHERE d.delivery_id=ANY($1) ORDER BY d.delivery_next_attempt, d.delivery_id;",
+		ids,
+	)
+}
+
+// GetWebhookDeliveries Gets the delivery log for a webhook belonging to an account, newest first.
+func (p *Postgres) GetWebhookDeliveries(account, webhook int64, limit int) ([]types.WebhookDelivery, error) {
+	return p.getWebhookDeliveries(
+		"WHERE w.account_id=$1 AND d.webhook_id=$2 ORDER BY d.delivery_id DESC LIMIT $3;",
+		account,
+		webhook,
+		limit,
+	)
+}
+
+// UpdateWebhookDelivery Records the result of an attempt to send a delivery.
+func (p *Postgres) UpdateWebhookDelivery(delivery types.WebhookDelivery) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.Exec(
+		ctx,
+		"UPDATE webhook_delivery SET delivery_status=$1, delivery_attempts=$2, delivery_next_attempt=$3, "+
+			"delivery_response_code=$4, delivery_error=$5, delivery_delivered_at=$6, delivery_claimed_until=0 WHERE delivery_id=$7;",
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttempt.Unix(),
+		delivery.ResponseCode,
+		delivery.Error,
+		delivery.DeliveredAt,
+		delivery.Identifier,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery: %v", err)
+	}
+	rows := res.RowsAffected()
+	if rows != 1 {
+		return fmt.Errorf("error updating webhook delivery, rows affected: %v", rows)
+	}
+	return nil
+}
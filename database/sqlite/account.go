@@ -40,7 +40,7 @@ func (s *SQLite) getAccountInternal(email, key *string, id *int64) (*types.Accou
 		res, err = db.QueryContext(
 			ctx,
 			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, "+
-				"account_wrong_pass, account_token, account_refresh_token FROM account WHERE account_deleted=FALSE "+
+				"account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account WHERE account_deleted=FALSE "+
 				"AND account_email=?;",
 			email,
 		)
@@ -48,15 +48,15 @@ func (s *SQLite) getAccountInternal(email, key *string, id *int64) (*types.Accou
 		res, err = db.QueryContext(
 			ctx,
 			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, "+
-				"account_wrong_pass, account_token, account_refresh_token FROM account NATURAL JOIN api_key WHERE "+
+				"account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account NATURAL JOIN api_key WHERE "+
 				"account_deleted=FALSE AND key_deleted=FALSE AND key_value=?;",
-			key,
+			types.HashKey(*key),
 		)
 	} else if id != nil {
 		res, err = db.QueryContext(
 			ctx,
 			"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, "+
-				"account_wrong_pass, account_token, account_refresh_token FROM account WHERE account_deleted=FALSE "+
+				"account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account WHERE account_deleted=FALSE "+
 				"AND account_id=?;",
 			id,
 		)
@@ -69,6 +69,7 @@ func (s *SQLite) getAccountInternal(email, key *string, id *int64) (*types.Accou
 	defer res.Close()
 	var outAccount types.Account
 	if res.Next() {
+		var lockedUntil int64
 		err := res.Scan(
 			&outAccount.Identifier,
 			&outAccount.Name,
@@ -77,12 +78,14 @@ func (s *SQLite) getAccountInternal(email, key *string, id *int64) (*types.Accou
 			&outAccount.Password,
 			&outAccount.Locked,
 			&outAccount.WrongPassAttempts,
-			&outAccount.Token,
-			&outAccount.RefreshToken,
+			&outAccount.LockReason,
+			&lockedUntil,
+			&outAccount.LockCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting account information: %v", err)
 		}
+		outAccount.SetLockedUntil(lockedUntil)
 	} else {
 		return nil, nil
 	}
@@ -115,7 +118,7 @@ func (s *SQLite) GetAccounts() ([]types.Account, error) {
 	res, err := db.QueryContext(
 		ctx,
 		"SELECT account_id, account_name, account_email, account_type, account_password, account_locked, "+
-			"account_wrong_pass, account_token, account_refresh_token FROM account WHERE account_deleted=FALSE;",
+			"account_wrong_pass, account_lock_reason, account_locked_until, account_lock_count FROM account WHERE account_deleted=FALSE;",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving accounts: %v", err)
@@ -124,6 +127,7 @@ func (s *SQLite) GetAccounts() ([]types.Account, error) {
 	var outAccounts []types.Account
 	for res.Next() {
 		var account types.Account
+		var lockedUntil int64
 		err := res.Scan(
 			&account.Identifier,
 			&account.Name,
@@ -132,12 +136,14 @@ func (s *SQLite) GetAccounts() ([]types.Account, error) {
 			&account.Password,
 			&account.Locked,
 			&account.WrongPassAttempts,
-			&account.Token,
-			&account.RefreshToken,
+			&account.LockReason,
+			&lockedUntil,
+			&account.LockCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting account information: %v", err)
 		}
+		account.SetLockedUntil(lockedUntil)
 		outAccounts = append(outAccounts, account)
 	}
 	return outAccounts, nil
@@ -179,7 +185,7 @@ func (s *SQLite) AddAccount(account types.Account) (*types.Account, error) {
 }
 
 // DeleteAccount Deletes an account from view, does not permanently delete from database.
-// This does not delete events associated with this account, but does set keys to deleted.
+// This does not delete events associated with this account, but does set keys to deleted and ends its sessions.
 func (s *SQLite) DeleteAccount(id int64) error {
 	db, err := s.GetDB()
 	if err != nil {
@@ -191,9 +197,11 @@ func (s *SQLite) DeleteAccount(id int64) error {
 	if err != nil {
 		return fmt.Errorf("unable to start transaction: %v", err)
 	}
+	deletedAt := time.Now().UnixMilli()
 	res, err := tx.ExecContext(
 		ctx,
-		"UPDATE account SET account_deleted=TRUE WHERE account_id=?",
+		"UPDATE account SET account_deleted=TRUE, account_deleted_at=? WHERE account_id=?",
+		deletedAt,
 		id,
 	)
 	if err != nil {
@@ -211,13 +219,23 @@ func (s *SQLite) DeleteAccount(id int64) error {
 	}
 	_, err = tx.ExecContext(
 		ctx,
-		"UPDATE api_key SET key_deleted=TRUE WHERE account_id=?",
+		"UPDATE api_key SET key_deleted=TRUE, key_deleted_at=? WHERE key_deleted=FALSE AND account_id=?",
+		deletedAt,
 		id,
 	)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("error deleting keys attached to account: %v", err)
 	}
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM account_session WHERE account_id=?",
+		id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error deleting sessions attached to account: %v", err)
+	}
 	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
@@ -236,7 +254,7 @@ func (s *SQLite) ResurrectAccount(email string) error {
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"UPDATE account SET account_deleted=FALSE WHERE account_email=?",
+		"UPDATE account SET account_deleted=FALSE, account_deleted_at=0 WHERE account_email=?",
 		email,
 	)
 	if err != nil {
@@ -262,7 +280,7 @@ func (s *SQLite) GetDeletedAccount(email string) (*types.Account, error) {
 	defer cancelfunc()
 	res, err := db.QueryContext(
 		ctx,
-		"SELECT account_id, account_name, account_email, account_type FROM account WHERE account_deleted=TRUE AND account_email=?;",
+		"SELECT account_id, account_name, account_email, account_type, account_deleted_at FROM account WHERE account_deleted=TRUE AND account_email=?;",
 		email,
 	)
 	if err != nil {
@@ -271,15 +289,18 @@ func (s *SQLite) GetDeletedAccount(email string) (*types.Account, error) {
 	defer res.Close()
 	var outAccount types.Account
 	if res.Next() {
+		var deletedAt int64
 		err := res.Scan(
 			&outAccount.Identifier,
 			&outAccount.Name,
 			&outAccount.Email,
 			&outAccount.Type,
+			&deletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting account information: %v", err)
 		}
+		outAccount.SetDeletedAt(deletedAt)
 	} else {
 		return nil, nil
 	}
@@ -323,9 +344,6 @@ func (s *SQLite) ChangePassword(email, newPassword string, logout ...bool) error
 	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancelfunc()
 	stmt := "UPDATE account SET account_password=? WHERE account_email=?;"
-	if len(logout) > 0 && logout[0] {
-		stmt = "UPDATE account SET account_password=?, account_token='', account_refresh_token='' WHERE account_email=?;"
-	}
 	res, err := db.ExecContext(
 		ctx,
 		stmt,
@@ -342,33 +360,9 @@ func (s *SQLite) ChangePassword(email, newPassword string, logout ...bool) error
 	if rows != 1 {
 		return fmt.Errorf("error changing password, rows affected: %v", rows)
 	}
-	return nil
-}
-
-// UpdateTokens Updates a user's tokens.
-func (s *SQLite) UpdateTokens(account types.Account) error {
-	db, err := s.GetDB()
-	if err != nil {
-		return err
-	}
-	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancelfunc()
-	res, err := db.ExecContext(
-		ctx,
-		"UPDATE account SET account_token=?, account_refresh_token=? WHERE account_email=?;",
-		account.Token,
-		account.RefreshToken,
-		account.Email,
-	)
-	if err != nil {
-		return fmt.Errorf("error updating tokens: %v", err)
-	}
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error checking rows affected on token update: %v", err)
-	}
-	if rows != 1 {
-		return fmt.Errorf("error updating tokens, rows affected: %v", rows)
+	// Changing someone else's password logs them out everywhere.
+	if len(logout) > 0 && logout[0] {
+		return s.deleteEmailSessions(email)
 	}
 	return nil
 }
@@ -383,7 +377,7 @@ func (s *SQLite) ChangeEmail(oldEmail, newEmail string) error {
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"UPDATE account SET account_email=?, account_token='', account_refresh_token='' WHERE account_email=?;",
+		"UPDATE account SET account_email=? WHERE account_email=?;",
 		newEmail,
 		oldEmail,
 	)
@@ -397,10 +391,11 @@ func (s *SQLite) ChangeEmail(oldEmail, newEmail string) error {
 	if rows != 1 {
 		return fmt.Errorf("error changing email, rows affected: %v", rows)
 	}
-	return nil
+	return s.deleteEmailSessions(newEmail)
 }
 
-// InvalidPassword Increments/locks an account due to an invalid password.
+// InvalidPassword Increments/locks an account due to an invalid password. Under the backoff lockout policy
+// the lock expires on its own, lasting twice as long each time the account is locked before a valid password.
 func (s *SQLite) InvalidPassword(account types.Account) error {
 	db, err := s.GetDB()
 	if err != nil {
@@ -412,19 +407,39 @@ func (s *SQLite) InvalidPassword(account types.Account) error {
 	if err != nil {
 		return fmt.Errorf("error trying to retrieve account: %v", err)
 	}
-	locked := false
-	if pAcc.WrongPassAttempts >= database.MaxLoginAttempts {
+	if pAcc == nil {
+		return errors.New("account not found")
+	}
+	locked := pAcc.Locked
+	var lockedUntil int64
+	if pAcc.LockedUntil != nil {
+		lockedUntil = pAcc.LockedUntil.Unix()
+	}
+	wrongPass := pAcc.WrongPassAttempts + 1
+	lockCount := pAcc.LockCount
+	if !locked && pAcc.WrongPassAttempts >= database.MaxLoginAttempts {
 		locked = true
+		lockCount++
+		if duration := s.config.LockoutDuration(pAcc.LockCount); duration > 0 {
+			lockedUntil = time.Now().Add(duration).Unix()
+			// Wrong passwords are counted again from zero once the lock expires.
+			wrongPass = 0
+		}
 	}
-	stmt := "UPDATE account SET account_locked=?, account_wrong_pass=account_wrong_pass + 1 WHERE account_email=?;"
+	lockReason := ""
 	if locked {
-		stmt = "UPDATE account SET account_locked=?, account_wrong_pass=account_wrong_pass + 1, account_token='', " +
-			"account_refresh_token='' WHERE account_email=?;"
+		lockReason = types.LockReasonWrongPassword
 	}
+	stmt := "UPDATE account SET account_locked=?, account_lock_reason=?, account_locked_until=?, account_lock_count=?, " +
+		"account_wrong_pass=? WHERE account_email=?;"
 	res, err := db.ExecContext(
 		ctx,
 		stmt,
 		locked,
+		lockReason,
+		lockedUntil,
+		lockCount,
+		wrongPass,
 		account.Email,
 	)
 	if err != nil {
@@ -437,6 +452,10 @@ func (s *SQLite) InvalidPassword(account types.Account) error {
 	if rows != 1 {
 		return fmt.Errorf("error updating invalid password information, rows affected: %v", rows)
 	}
+	// Locking an account logs it out everywhere.
+	if locked {
+		return s.deleteEmailSessions(account.Email)
+	}
 	return nil
 }
 
@@ -457,7 +476,8 @@ func (s *SQLite) ValidPassword(account types.Account) error {
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"UPDATE account SET account_wrong_pass=0 WHERE account_email=?;",
+		"UPDATE account SET account_wrong_pass=0, account_locked=FALSE, account_lock_reason='', account_locked_until=0, "+
+			"account_lock_count=0 WHERE account_email=?;",
 		account.Email,
 	)
 	if err != nil {
@@ -483,7 +503,8 @@ func (s *SQLite) UnlockAccount(account types.Account) error {
 	}
 	res, err := db.ExecContext(
 		ctx,
-		"UPDATE account SET account_wrong_pass=0, account_locked=FALSE WHERE account_email=?;",
+		"UPDATE account SET account_wrong_pass=0, account_locked=FALSE, account_lock_reason='', account_locked_until=0, "+
+			"account_lock_count=0 WHERE account_email=?;",
 		account.Email,
 	)
 	if err != nil {
@@ -499,3 +520,172 @@ func (s *SQLite) UnlockAccount(account types.Account) error {
 	return nil
 }
 
+// GetDeletedAccounts Gets every account that has been deleted.
+func (s *SQLite) GetDeletedAccounts() ([]types.Account, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT account_id, account_name, account_email, account_type, account_deleted_at FROM account WHERE account_deleted=TRUE;",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving deleted accounts: %v", err)
+	}
+	defer res.Close()
+	var outAccounts []types.Account
+	for res.Next() {
+		var account types.Account
+		var deletedAt int64
+		err := res.Scan(
+			&account.Identifier,
+			&account.Name,
+			&account.Email,
+			&account.Type,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting account information: %v", err)
+		}
+		account.SetDeletedAt(deletedAt)
+		outAccounts = append(outAccounts, account)
+	}
+	return outAccounts, nil
+}
+
+// RestoreAccount Brings a deleted account back along with the keys deleted with it, those having been given the
+// same deletion time. Keys that were deleted before the account stay deleted.
+func (s *SQLite) RestoreAccount(id int64) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %v", err)
+	}
+	var deletedAt int64
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT account_deleted_at FROM account WHERE account_deleted=TRUE AND account_id=?;",
+		id,
+	).Scan(&deletedAt)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error retrieving deleted account: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE api_key SET key_deleted=FALSE, key_deleted_at=0 WHERE key_deleted=TRUE AND account_id=? AND key_deleted_at=?;",
+		id,
+		deletedAt,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error restoring keys attached to account: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE account SET account_deleted=FALSE, account_deleted_at=0 WHERE account_id=?;",
+		id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error restoring account: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// PurgeAccount Permanently removes an account along with its keys, reads, notifications, sessions and everything
+// else attached to it. With dryRun set nothing is removed and the counts are of the rows that would have been.
+// Accounts that own an organization aren't purged, the organization has to be deleted first.
+func (s *SQLite) PurgeAccount(id int64, dryRun bool) (*types.PurgeCounts, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start transaction: %v", err)
+	}
+	var owned int64
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM organization WHERE account_id=?;", id).Scan(&owned)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error checking owned organizations: %v", err)
+	}
+	if owned > 0 {
+		tx.Rollback()
+		return nil, database.ErrOwnsOrganization
+	}
+	counts := &types.PurgeCounts{}
+	// Ordered so that no row is removed while another still references it.
+	steps := []struct {
+		count *int64
+		table string
+		where string
+		args  []any
+	}{
+		{&counts.Reads, "a_read", "key_id IN (SELECT key_id FROM api_key WHERE account_id=?)", []any{id}},
+		{&counts.Notifications, "notification", "key_id IN (SELECT key_id FROM api_key WHERE account_id=?)", []any{id}},
+		{&counts.WebhookDeliveries, "webhook_delivery", "webhook_id IN (SELECT webhook_id FROM webhook WHERE account_id=?)", []any{id}},
+		{&counts.Webhooks, "webhook", "account_id=?", []any{id}},
+		{&counts.AlertRules, "alert_rule", "account_id=?", []any{id}},
+		{&counts.PasswordResets, "password_reset", "account_id=?", []any{id}},
+		{&counts.RecoveryCodes, "recovery_code", "account_id=?", []any{id}},
+		{&counts.TwoFactor, "two_factor", "account_id=?", []any{id}},
+		{&counts.Sessions, "account_session", "account_id=?", []any{id}},
+		{&counts.Memberships, "org_member", "account_id=?", []any{id}},
+		{&counts.Keys, "api_key", "account_id=?", []any{id}},
+		{&counts.Accounts, "account", "account_id=?", []any{id}},
+	}
+	for _, step := range steps {
+		if dryRun {
+			err = tx.QueryRowContext(
+				ctx,
+				"SELECT COUNT(*) FROM "+step.table+" WHERE "+step.where+";",
+				step.args...,
+			).Scan(step.count)
+		} else {
+			var res sql.Result
+			res, err = tx.ExecContext(
+				ctx,
+				"DELETE FROM "+step.table+" WHERE "+step.where+";",
+				step.args...,
+			)
+			if err == nil {
+				*step.count, err = res.RowsAffected()
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("error purging from %s: %v", step.table, err)
+		}
+	}
+	if counts.Accounts != 1 {
+		tx.Rollback()
+		return nil, fmt.Errorf("error purging account, rows affected: %v", counts.Accounts)
+	}
+	if dryRun {
+		tx.Rollback()
+		return counts, nil
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,122 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddAlertRule Adds an alert rule to an account.
+func (s *SQLite) AddAlertRule(rule types.AlertRule) (*types.AlertRule, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	now := time.Now().UTC().Truncate(time.Second)
+	res, err := db.ExecContext(
+		ctx,
+		"INSERT INTO alert_rule(account_id, rule_types, rule_readers, rule_recipients, rule_created_at) VALUES (?, ?, ?, ?, ?);",
+		rule.AccountIdentifier,
+		rule.TypesValue(),
+		rule.ReadersValue(),
+		rule.RecipientsValue(),
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to add alert rule: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine ID for alert rule: %v", err)
+	}
+	rule.Identifier = id
+	rule.CreatedAt = now
+	return &rule, nil
+}
+
+// GetAccountAlertRules Gets all alert rules for an account.
+func (s *SQLite) GetAccountAlertRules(account int64) ([]types.AlertRule, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT rule_id, account_id, rule_types, rule_readers, rule_recipients, rule_created_at "+
+			"FROM alert_rule WHERE account_id=? ORDER BY rule_id;",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving alert rules: %v", err)
+	}
+	defer res.Close()
+	var outRules []types.AlertRule
+	for res.Next() {
+		var rule types.AlertRule
+		var ruleTypes, readers, recipients string
+		err := res.Scan(
+			&rule.Identifier,
+			&rule.AccountIdentifier,
+			&ruleTypes,
+			&readers,
+			&recipients,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting alert rule: %v", err)
+		}
+		rule.SetValues(ruleTypes, readers, recipients)
+		outRules = append(outRules, rule)
+	}
+	return outRules, nil
+}
+
+// DeleteAlertRule Deletes an alert rule belonging to an account.
+func (s *SQLite) DeleteAlertRule(account, rule int64) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE FROM alert_rule WHERE account_id=? AND rule_id=?;",
+		account,
+		rule,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting alert rule: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error deleting alert rule, rows affected: %v", rows)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"chronokeep/remote/types"
+	"slices"
+	"testing"
+)
+
+func TestAddAlertRule(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	rule := types.AlertRule{
+		AccountIdentifier: account1.Identifier,
+		Types:             []string{"UPS_LOW_BATTERY", "MAX_TEMP"},
+		Readers:           []string{"reader1", "reader2"},
+		Recipients:        []string{"alerts@test.com", "other@test.com"},
+	}
+	added, err := db.AddAlertRule(rule)
+	if err != nil {
+		t.Fatalf("Error adding alert rule: %v", err)
+	}
+	if added.Identifier == 0 || added.CreatedAt.IsZero() {
+		t.Errorf("Expected alert rule to have an id and created time set, found %+v.", *added)
+	}
+	_, err = db.AddAlertRule(types.AlertRule{
+		AccountIdentifier: account2.Identifier,
+		Types:             []string{"SHUTTING_DOWN"},
+		Recipients:        []string{"alerts@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error adding alert rule: %v", err)
+	}
+	rules, err := db.GetAccountAlertRules(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting alert rules: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("Expected %v alert rules, found %v.", 1, len(rules))
+	}
+	if rules[0].Identifier != added.Identifier || rules[0].AccountIdentifier != account1.Identifier ||
+		!slices.Equal(rules[0].Types, rule.Types) || !slices.Equal(rules[0].Readers, rule.Readers) ||
+		!slices.Equal(rules[0].Recipients, rule.Recipients) {
+		t.Errorf("Expected alert rule %+v, found %+v.", *added, rules[0])
+	}
+	// Rules without readers apply to every reader.
+	rules, err = db.GetAccountAlertRules(account2.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting alert rules: %v", err)
+	}
+	if len(rules) != 1 || len(rules[0].Readers) != 0 || !rules[0].Matches("any reader", "SHUTTING_DOWN") {
+		t.Errorf("Expected one alert rule for every reader, found %+v.", rules)
+	}
+	rules, err = db.GetAccountAlertRules(account2.Identifier + 100)
+	if err != nil {
+		t.Fatalf("Error getting alert rules: %v", err)
+	}
+	if len(rules) != 0 {
+		t.Errorf("Expected %v alert rules, found %v.", 0, len(rules))
+	}
+}
+
+func TestDeleteAlertRule(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	rule, _ := db.AddAlertRule(types.AlertRule{
+		AccountIdentifier: account1.Identifier,
+		Types:             []string{"MAX_TEMP"},
+		Recipients:        []string{"alerts@test.com"},
+	})
+	// Rules can only be deleted by the account they belong to.
+	err = db.DeleteAlertRule(account2.Identifier, rule.Identifier)
+	if err == nil {
+		t.Error("Expected error deleting alert rule from the wrong account.")
+	}
+	err = db.DeleteAlertRule(account1.Identifier, rule.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting alert rule: %v", err)
+	}
+	rules, _ := db.GetAccountAlertRules(account1.Identifier)
+	if len(rules) != 0 {
+		t.Errorf("Expected %v alert rules, found %v.", 0, len(rules))
+	}
+	err = db.DeleteAlertRule(account1.Identifier, rule.Identifier)
+	if err == nil {
+		t.Error("Expected error deleting alert rule twice.")
+	}
+}
+
+func TestBadDatabaseAlertRule(t *testing.T) {
+	db := badTestSetup(t)
+	_, err := db.AddAlertRule(types.AlertRule{})
+	if err == nil {
+		t.Fatal("Expected error adding alert rule.")
+	}
+	_, err = db.GetAccountAlertRules(0)
+	if err == nil {
+		t.Fatal("Expected error getting alert rules.")
+	}
+	err = db.DeleteAlertRule(0, 0)
+	if err == nil {
+		t.Fatal("Expected error deleting alert rule.")
+	}
+}
+
+func TestNoDatabaseAlertRule(t *testing.T) {
+	db := SQLite{}
+	_, err := db.AddAlertRule(types.AlertRule{})
+	if err == nil {
+		t.Fatal("Expected error adding alert rule.")
+	}
+	_, err = db.GetAccountAlertRules(0)
+	if err == nil {
+		t.Fatal("Expected error getting alert rules.")
+	}
+	err = db.DeleteAlertRule(0, 0)
+	if err == nil {
+		t.Fatal("Expected error deleting alert rule.")
+	}
+}
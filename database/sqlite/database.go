@@ -50,13 +50,29 @@ func (s *SQLite) GetDatabase(inCfg *util.Config) (*sql.DB, error) {
 	if inCfg == nil {
 		return nil, fmt.Errorf("no valid config supplied")
 	}
+	if inCfg.DBName == "" {
+		return nil, fmt.Errorf("no database file name supplied")
+	}
 
 	s.config = inCfg
 
-	dbCon, err := sql.Open("sqlite3", inCfg.DBName+"?parseTime=true")
+	// WAL lets readers keep going while a write is in progress, the busy timeout makes writers
+	// wait on each other instead of failing, and immediate transactions take the write lock up
+	// front so two transactions can't deadlock trying to upgrade their locks.
+	dbCon, err := sql.Open(
+		"sqlite3",
+		fmt.Sprintf(
+			"%s?_journal_mode=WAL&_busy_timeout=%d&_foreign_keys=on&_txlock=immediate",
+			inCfg.DBName,
+			database.SQLiteBusyTimeout.Milliseconds(),
+		),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("unable to open database connection: %v", err)
 	}
+	dbCon.SetMaxIdleConns(database.MaxIdleConnections)
+	dbCon.SetMaxOpenConns(database.MaxOpenConnections)
+	dbCon.SetConnMaxLifetime(database.MaxConnectionLifetime)
 
 	s.db = dbCon
 	return s.db, nil
@@ -87,6 +103,11 @@ func (s *SQLite) Setup(config *util.Config) error {
 		return fmt.Errorf("error connecting to database: %v", err)
 	}
 
+	err = s.createMigrationTable()
+	if err != nil {
+		return err
+	}
+
 	// Check our database version.
 	dbVersion := s.checkVersion()
 
@@ -99,7 +120,7 @@ func (s *SQLite) Setup(config *util.Config) error {
 		// Otherwise check if our database is out of date and update if necessary.
 	} else if dbVersion < database.CurrentVersion {
 		log.Info(fmt.Sprintf("Updating database from version %v to %v", dbVersion, database.CurrentVersion))
-		err = s.updateTables(dbVersion, database.CurrentVersion)
+		_, err = s.Migrate(database.CurrentVersion, false)
 		if err != nil {
 			return err
 		}
@@ -111,15 +132,16 @@ func (s *SQLite) Setup(config *util.Config) error {
 		return fmt.Errorf("error checking for account: %v", err)
 	}
 	if len(accounts) < 1 {
-		log.Info("Creating admin user.")
 		if config.AdminName == "" || config.AdminEmail == "" || config.AdminPass == "" {
-			return errors.New("admin account doesn't exist and proper credentions have not been supplied")
+			log.Warn("Admin account doesn't exist and proper credentials have not been supplied, one can be added with the account create command.")
+			return nil
 		}
+		log.Info("Creating admin user.")
 		acc := types.Account{
 			Name:     config.AdminName,
 			Email:    config.AdminEmail,
 			Password: config.AdminPass,
-			Type:     "admin",
+			Type:     types.RoleAdmin,
 		}
 		err = s.validate.Struct(acc)
 		if err != nil {
@@ -146,7 +168,7 @@ func (s *SQLite) dropTables() error {
 	defer cancelfunc()
 	_, err = db.ExecContext(
 		ctx,
-		"DROP TABLE notification; DROP TABLE a_read; DROP TABLE api_key; DROP TABLE account; DROP TABLE settings;",
+		"DROP TABLE account_role; DROP TABLE org_member; DROP TABLE organization; DROP TABLE login_failure; DROP TABLE recovery_code; DROP TABLE two_factor; DROP TABLE password_reset_request; DROP TABLE password_reset; DROP TABLE alert_rule; DROP TABLE webhook_delivery; DROP TABLE webhook; DROP TABLE notification; DROP TABLE a_read; DROP TABLE api_key; DROP TABLE account_session; DROP TABLE account; DROP TABLE settings; DROP TABLE schema_migration;",
 	)
 	if err != nil {
 		return fmt.Errorf("error dropping tables: %v", err)
@@ -173,6 +195,33 @@ func (s *SQLite) SetSetting(name, value string) error {
 	return nil
 }
 
+// GetSetting Returns the value of a setting, or an empty string if it hasn't been set.
+func (s *SQLite) GetSetting(name string) (string, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT value FROM settings WHERE name=?;",
+		name,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving settings value: %v", err)
+	}
+	defer res.Close()
+	var value string
+	if res.Next() {
+		err := res.Scan(&value)
+		if err != nil {
+			return "", fmt.Errorf("error getting settings value: %v", err)
+		}
+	}
+	return value, nil
+}
+
 type myQuery struct {
 	name  string
 	query string
@@ -200,11 +249,13 @@ func (s *SQLite) createTables() error {
 				"account_type VARCHAR(20) NOT NULL, " +
 				"account_wrong_pass INT NOT NULL DEFAULT 0, " +
 				"account_locked BOOL DEFAULT FALSE, " +
-				"account_token VARCHAR(1000) NOT NULL DEFAULT '', " +
-				"account_refresh_token VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"account_lock_reason VARCHAR(100) NOT NULL DEFAULT '', " +
+				"account_locked_until BIGINT NOT NULL DEFAULT 0, " +
+				"account_lock_count INT NOT NULL DEFAULT 0, " +
 				"account_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
 				"account_updated_at DATETIME DEFAULT CURRENT_TIMESTAMP," +
 				"account_deleted BOOL DEFAULT FALSE, " +
+				"account_deleted_at BIGINT NOT NULL DEFAULT 0, " +
 				"UNIQUE(account_email)" +
 				");",
 		},
@@ -212,15 +263,23 @@ func (s *SQLite) createTables() error {
 		{
 			name: "KeyTable",
 			query: "CREATE TABLE IF NOT EXISTS api_key(" +
+				"key_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
 				"account_id INTEGER NOT NULL, " +
+				"org_id INTEGER DEFAULT NULL, " +
 				"key_name VARCHAR(100) NOT NULL," +
+				"key_prefix VARCHAR(20) NOT NULL DEFAULT '', " +
 				"key_value VARCHAR(100) NOT NULL, " +
 				"key_type VARCHAR(20) NOT NULL, " +
+				"allowed_hosts VARCHAR(1000) NOT NULL DEFAULT '', " +
 				"valid_until DATETIME DEFAULT NULL, " +
+				"old_key_value VARCHAR(100) DEFAULT NULL, " +
+				"old_key_valid_until DATETIME DEFAULT NULL, " +
 				"key_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
 				"key_updated_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
 				"key_deleted BOOL DEFAULT FALSE, " +
+				"key_deleted_at BIGINT NOT NULL DEFAULT 0, " +
 				"UNIQUE(key_value), " +
+				"UNIQUE(old_key_value), " +
 				"UNIQUE(account_id, key_name), " +
 				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
 				");",
@@ -229,7 +288,8 @@ func (s *SQLite) createTables() error {
 		{
 			name: "ReadTable",
 			query: "CREATE TABLE IF NOT EXISTS a_read(" +
-				"key_value VARCHAR(100) NOT NULL, " +
+				"read_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"key_id INTEGER NOT NULL, " +
 				"identifier VARCHAR(100) NOT NULL, " +
 				"seconds BIGINT NOT NULL DEFAULT 0, " +
 				"milliseconds INT NOT NULL DEFAULT 0, " +
@@ -239,8 +299,8 @@ func (s *SQLite) createTables() error {
 				"reader VARCHAR(50) NOT NULL DEFAULT '', " +
 				"rssi VARCHAR(10) NOT NULL DEFAULT '', " +
 				"read_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
-				"UNIQUE(key_value, identifier, seconds, milliseconds, ident_type), " +
-				"FOREIGN KEY (key_value) REFERENCES api_key(key_value)" +
+				"UNIQUE(key_id, identifier, seconds, milliseconds, ident_type), " +
+				"FOREIGN KEY (key_id) REFERENCES api_key(key_id)" +
 				");",
 		},
 		// NOTIFICATIONS TABLE
@@ -248,14 +308,185 @@ func (s *SQLite) createTables() error {
 			name: "NotificationsTable",
 			query: "CREATE TABLE IF NOT EXISTS notification(" +
 				"notification_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
-				"key_value VARCHAR(100) NOT NULL, " +
+				"key_id INTEGER NOT NULL, " +
 				"notification_type VARCHAR(100) NOT NULL, " +
 				"notification_when BIGINT NOT NULL, " +
 				"notification_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
-				"UNIQUE(key_value, notification_when) ON CONFLICT IGNORE, " +
-				"FOREIGN KEY (key_value) REFERENCES api_key(key_value)" +
+				"notification_acknowledged_at DATETIME DEFAULT NULL, " +
+				"UNIQUE(key_id, notification_when) ON CONFLICT IGNORE, " +
+				"FOREIGN KEY (key_id) REFERENCES api_key(key_id)" +
+				");",
+		},
+		// SESSION TABLE
+		{
+			name: "SessionTable",
+			query: "CREATE TABLE IF NOT EXISTS account_session(" +
+				"session_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"session_token VARCHAR(100) NOT NULL, " +
+				"session_refresh_token VARCHAR(100) NOT NULL, " +
+				"session_user_agent VARCHAR(500) NOT NULL DEFAULT '', " +
+				"session_ip VARCHAR(100) NOT NULL DEFAULT '', " +
+				"session_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"session_last_used DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(session_token), " +
+				"UNIQUE(session_refresh_token), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
 				");",
 		},
+		// WEBHOOK TABLE
+		{
+			name: "WebhookTable",
+			query: "CREATE TABLE IF NOT EXISTS webhook(" +
+				"webhook_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"org_id INTEGER DEFAULT NULL, " +
+				"webhook_url VARCHAR(500) NOT NULL, " +
+				"webhook_secret VARCHAR(100) NOT NULL, " +
+				"webhook_events VARCHAR(200) NOT NULL DEFAULT '', " +
+				"webhook_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		},
+		// WEBHOOK DELIVERY TABLE
+		{
+			name: "WebhookDeliveryTable",
+			query: "CREATE TABLE IF NOT EXISTS webhook_delivery(" +
+				"delivery_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"webhook_id INTEGER NOT NULL, " +
+				"delivery_event VARCHAR(50) NOT NULL, " +
+				"delivery_payload TEXT NOT NULL, " +
+				"delivery_status VARCHAR(20) NOT NULL DEFAULT 'pending', " +
+				"delivery_attempts INT NOT NULL DEFAULT 0, " +
+				"delivery_next_attempt BIGINT NOT NULL, " +
+				"delivery_claimed_until BIGINT NOT NULL DEFAULT 0, " +
+				"delivery_response_code INT NOT NULL DEFAULT 0, " +
+				"delivery_error VARCHAR(500) NOT NULL DEFAULT '', " +
+				"delivery_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"delivery_delivered_at DATETIME DEFAULT NULL, " +
+				"FOREIGN KEY (webhook_id) REFERENCES webhook(webhook_id) ON DELETE CASCADE" +
+				");",
+		},
+		// WEBHOOK DELIVERY INDEX
+		{
+			name:  "WebhookDeliveryIndex",
+			query: "CREATE INDEX IF NOT EXISTS idx_delivery_pending ON webhook_delivery(delivery_status, delivery_next_attempt);",
+		},
+		// ALERT RULE TABLE
+		{
+			name: "AlertRuleTable",
+			query: "CREATE TABLE IF NOT EXISTS alert_rule(" +
+				"rule_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"rule_types VARCHAR(500) NOT NULL, " +
+				"rule_readers VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"rule_recipients VARCHAR(1000) NOT NULL, " +
+				"rule_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		},
+		// PASSWORD RESET TABLE
+		{
+			name: "PasswordResetTable",
+			query: "CREATE TABLE IF NOT EXISTS password_reset(" +
+				"reset_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"reset_token VARCHAR(100) NOT NULL, " +
+				"reset_expires_at BIGINT NOT NULL, " +
+				"reset_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(reset_token), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		},
+		// PASSWORD RESET REQUEST TABLE
+		{
+			name: "PasswordResetRequestTable",
+			query: "CREATE TABLE IF NOT EXISTS password_reset_request(" +
+				"request_key VARCHAR(100) NOT NULL, " +
+				"request_count INT NOT NULL DEFAULT 0, " +
+				"request_window_start BIGINT NOT NULL DEFAULT 0, " +
+				"PRIMARY KEY (request_key)" +
+				");",
+		},
+		// TWO FACTOR TABLE
+		{
+			name: "TwoFactorTable",
+			query: "CREATE TABLE IF NOT EXISTS two_factor(" +
+				"account_id INTEGER NOT NULL, " +
+				"tf_secret VARCHAR(100) NOT NULL, " +
+				"tf_enabled BOOL DEFAULT FALSE, " +
+				"tf_last_step BIGINT NOT NULL DEFAULT 0, " +
+				"tf_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), " +
+				"PRIMARY KEY (account_id)" +
+				");",
+		},
+		// RECOVERY CODE TABLE
+		{
+			name: "RecoveryCodeTable",
+			query: "CREATE TABLE IF NOT EXISTS recovery_code(" +
+				"code_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"code_hash VARCHAR(100) NOT NULL, " +
+				"UNIQUE(account_id, code_hash), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		},
+		// LOGIN FAILURE TABLE
+		{
+			name: "LoginFailureTable",
+			query: "CREATE TABLE IF NOT EXISTS login_failure(" +
+				"failure_ip VARCHAR(100) NOT NULL, " +
+				"failure_count INT NOT NULL DEFAULT 0, " +
+				"failure_last BIGINT NOT NULL DEFAULT 0, " +
+				"failure_blocked_until BIGINT NOT NULL DEFAULT 0, " +
+				"PRIMARY KEY (failure_ip)" +
+				");",
+		},
+		// ORGANIZATION TABLE
+		{
+			name: "OrganizationTable",
+			query: "CREATE TABLE IF NOT EXISTS organization(" +
+				"org_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"org_name VARCHAR(100) NOT NULL, " +
+				"org_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(account_id), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		},
+		// ORGANIZATION MEMBER TABLE
+		{
+			name: "OrganizationMemberTable",
+			query: "CREATE TABLE IF NOT EXISTS org_member(" +
+				"org_id INTEGER NOT NULL, " +
+				"account_id INTEGER NOT NULL, " +
+				"member_role VARCHAR(20) NOT NULL, " +
+				"member_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"PRIMARY KEY (org_id, account_id), " +
+				"FOREIGN KEY (org_id) REFERENCES organization(org_id), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		},
+		// ROLE TABLE
+		{
+			name: "RoleTable",
+			query: "CREATE TABLE IF NOT EXISTS account_role(" +
+				"role_name VARCHAR(20) NOT NULL, " +
+				"role_permissions VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"PRIMARY KEY (role_name)" +
+				");",
+		},
+		// MIGRATION TABLE
+		{
+			name:  "MigrationTable",
+			query: migrationTableQuery,
+		},
+		// READ TIME INDEX
+		{
+			name:  "ReadTimeIndex",
+			query: "CREATE INDEX IF NOT EXISTS idx_read_time ON a_read(key_id, seconds, milliseconds, identifier, ident_type);",
+		},
 		// UPDATE ACCOUNT FUNC
 		{
 			name: "UpdateAccountFunc",
@@ -268,10 +499,10 @@ func (s *SQLite) createTables() error {
 		// UPDATE KEY FUNC
 		{
 			name: "UpdateKeyFunc",
-			query: "CREATE TRIGGER UpdateKeyTime UPDATE OF account_id, key_name, key_type, allowed_hosts, " +
+			query: "CREATE TRIGGER UpdateKeyTime UPDATE OF account_id, key_name, key_value, key_type, allowed_hosts, " +
 				"valid_until, key_deleted ON api_key " +
 				"BEGIN" +
-				"    UPDATE api_key SET key_updated_at=CURRENT_TIMESTAMP WHERE key_value=key_value;" +
+				"    UPDATE api_key SET key_updated_at=CURRENT_TIMESTAMP WHERE key_id=NEW.key_id;" +
 				"END;",
 		},
 	}
@@ -296,6 +527,32 @@ func (s *SQLite) createTables() error {
 			return fmt.Errorf("error executing %s query: %v", single.name, err)
 		}
 	}
+	// Every database starts with the default roles.
+	for _, role := range types.DefaultRoles() {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO account_role(role_name, role_permissions) VALUES (?, ?);",
+			role.Name,
+			role.PermissionsValue(),
+		)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error adding %s role: %v", role.Name, err)
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO schema_migration(migration_version, migration_name, migration_direction, "+
+			"migration_applied_at) VALUES (?, ?, ?, ?);",
+		database.CurrentVersion,
+		"create tables",
+		types.MigrationUp,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error recording table creation: %v", err)
+	}
 	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
@@ -330,53 +587,6 @@ func (s *SQLite) checkVersion() int {
 	return v
 }
 
-func (s *SQLite) updateTables(oldVersion, newVersion int) error {
-	if s.db == nil {
-		return fmt.Errorf("database not set up")
-	}
-	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancelfunc()
-	tx, err := s.db.Begin()
-	if err != nil {
-		return fmt.Errorf("unable to start transaction: %v", err)
-	}
-	// Update from version 1 to 2
-	if oldVersion < 2 && newVersion >= 2 {
-		log.Debug("Updating to database version 2.")
-		_, err := tx.ExecContext(
-			ctx,
-			"CREATE TABLE IF NOT EXISTS notification("+
-				"notification_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
-				"key_value VARCHAR(100) NOT NULL, "+
-				"notification_type VARCHAR(100) NOT NULL, "+
-				"notification_when BIGINT NOT NULL, "+
-				"notification_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
-				"UNIQUE(key_value, notification_when) ON CONFLICT IGNORE, "+
-				"FOREIGN KEY (key_value) REFERENCES api_key(key_value)"+
-				");",
-		)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("error updating from verison %d to %d: %v", oldVersion, newVersion, err)
-		}
-	}
-	_, err = tx.ExecContext(
-		ctx,
-		"UPDATE settings SET value=$1 WHERE name='version';",
-		strconv.Itoa(newVersion),
-	)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error updating from version %d to %d: %v", oldVersion, newVersion, err)
-	}
-	err = tx.Commit()
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error committing transaction: %v", err)
-	}
-	return nil
-}
-
 func (s *SQLite) updateDB(newdb *sql.DB) {
 	s.db = newdb
 }
@@ -385,4 +595,3 @@ func (s *SQLite) updateDB(newdb *sql.DB) {
 func (s *SQLite) Close() {
 	s.db.Close()
 }
-
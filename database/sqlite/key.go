@@ -35,7 +35,7 @@ func (s *SQLite) GetAccountKeys(email string) ([]types.Key, error) {
 	defer cancelfunc()
 	res, err := db.QueryContext(
 		ctx,
-		"SELECT account_id, key_name, key_value, key_type, valid_until FROM api_key NATURAL JOIN account WHERE key_deleted=FALSE AND account_email=?;",
+		"SELECT account_id, key_name, key_prefix, key_value, key_type, allowed_hosts, valid_until, org_id FROM api_key NATURAL JOIN account WHERE key_deleted=FALSE AND account_email=?;",
 		email,
 	)
 	if err != nil {
@@ -45,16 +45,21 @@ func (s *SQLite) GetAccountKeys(email string) ([]types.Key, error) {
 	var outKeys []types.Key
 	for res.Next() {
 		var key types.Key
+		var allowedHosts string
 		err := res.Scan(
 			&key.AccountIdentifier,
 			&key.Name,
-			&key.Value,
+			&key.Prefix,
+			&key.Hash,
 			&key.Type,
+			&allowedHosts,
 			&key.ValidUntil,
+			&key.Organization,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting key: %v", err)
 		}
+		key.SetAllowedHosts(allowedHosts)
 		outKeys = append(outKeys, key)
 	}
 	return outKeys, nil
@@ -69,9 +74,9 @@ func (s *SQLite) GetAccountKeysByKey(key string) ([]types.Key, error) {
 	defer cancelfunc()
 	res, err := db.QueryContext(
 		ctx,
-		"SELECT a.account_id, a.key_name, a.key_value, a.key_type, a.valid_until FROM api_key a WHERE a.key_deleted=FALSE AND "+
+		"SELECT a.account_id, a.key_name, a.key_prefix, a.key_value, a.key_type, a.allowed_hosts, a.valid_until, a.org_id FROM api_key a WHERE a.key_deleted=FALSE AND "+
 			"EXISTS (SELECT * FROM api_key b WHERE a.account_id=b.account_id AND b.key_value=?);",
-		key,
+		types.HashKey(key),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving key: %v", err)
@@ -80,16 +85,103 @@ func (s *SQLite) GetAccountKeysByKey(key string) ([]types.Key, error) {
 	var outKeys []types.Key
 	for res.Next() {
 		var key types.Key
+		var allowedHosts string
 		err := res.Scan(
 			&key.AccountIdentifier,
 			&key.Name,
-			&key.Value,
+			&key.Prefix,
+			&key.Hash,
 			&key.Type,
+			&allowedHosts,
 			&key.ValidUntil,
+			&key.Organization,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting key: %v", err)
 		}
+		key.SetAllowedHosts(allowedHosts)
+		outKeys = append(outKeys, key)
+	}
+	return outKeys, nil
+}
+
+// GetMemberKeys Gets the keys an account can see, its own and those of every organization it is a member of.
+func (s *SQLite) GetMemberKeys(account int64) ([]types.Key, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT account_id, key_name, key_prefix, key_value, key_type, allowed_hosts, valid_until, org_id FROM api_key WHERE key_deleted=FALSE AND "+
+			"(account_id=? OR org_id IN (SELECT org_id FROM org_member WHERE account_id=?));",
+		account,
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving key: %v", err)
+	}
+	defer res.Close()
+	var outKeys []types.Key
+	for res.Next() {
+		var key types.Key
+		var allowedHosts string
+		err := res.Scan(
+			&key.AccountIdentifier,
+			&key.Name,
+			&key.Prefix,
+			&key.Hash,
+			&key.Type,
+			&allowedHosts,
+			&key.ValidUntil,
+			&key.Organization,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting key: %v", err)
+		}
+		key.SetAllowedHosts(allowedHosts)
+		outKeys = append(outKeys, key)
+	}
+	return outKeys, nil
+}
+
+// GetOrganizationKeys Gets the keys belonging to an organization.
+func (s *SQLite) GetOrganizationKeys(org int64) ([]types.Key, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT account_id, key_name, key_prefix, key_value, key_type, allowed_hosts, valid_until, org_id FROM api_key WHERE key_deleted=FALSE AND org_id=?;",
+		org,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving key: %v", err)
+	}
+	defer res.Close()
+	var outKeys []types.Key
+	for res.Next() {
+		var key types.Key
+		var allowedHosts string
+		err := res.Scan(
+			&key.AccountIdentifier,
+			&key.Name,
+			&key.Prefix,
+			&key.Hash,
+			&key.Type,
+			&allowedHosts,
+			&key.ValidUntil,
+			&key.Organization,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting key: %v", err)
+		}
+		key.SetAllowedHosts(allowedHosts)
 		outKeys = append(outKeys, key)
 	}
 	return outKeys, nil
@@ -104,21 +196,25 @@ func (s *SQLite) GetKey(key string) (*types.Key, error) {
 	defer cancelfunc()
 	res, err := db.QueryContext(
 		ctx,
-		"SELECT account_id, key_name, key_value, key_type, valid_until FROM api_key WHERE key_deleted=FALSE AND key_value=?;",
-		key,
+		"SELECT account_id, key_name, key_prefix, key_value, key_type, allowed_hosts, valid_until, org_id FROM api_key WHERE key_deleted=FALSE AND key_value=?;",
+		types.HashKey(key),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving key: %v", err)
 	}
 	defer res.Close()
 	var outKey types.Key
+	var allowedHosts string
 	if res.Next() {
 		err := res.Scan(
 			&outKey.AccountIdentifier,
 			&outKey.Name,
-			&outKey.Value,
+			&outKey.Prefix,
+			&outKey.Hash,
 			&outKey.Type,
+			&allowedHosts,
 			&outKey.ValidUntil,
+			&outKey.Organization,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error getting key: %v", err)
@@ -126,6 +222,7 @@ func (s *SQLite) GetKey(key string) (*types.Key, error) {
 	} else {
 		return nil, nil
 	}
+	outKey.SetAllowedHosts(allowedHosts)
 	return &outKey, nil
 }
 
@@ -138,11 +235,14 @@ func (s *SQLite) AddKey(key types.Key) (*types.Key, error) {
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"INSERT INTO api_key(account_id, key_name, key_value, key_type, valid_until) VALUES (?, ?, ?, ?, ?);",
+		"INSERT INTO api_key(account_id, org_id, key_name, key_prefix, key_value, key_type, allowed_hosts, valid_until) VALUES (?, ?, ?, ?, ?, ?, ?, ?);",
 		key.AccountIdentifier,
+		key.Organization,
 		key.Name,
-		key.Value,
+		types.KeyPrefix(key.Value),
+		types.HashKey(key.Value),
 		key.Type,
+		key.AllowedHostsValue(),
 		key.ValidUntil,
 	)
 	if err != nil {
@@ -157,9 +257,13 @@ func (s *SQLite) AddKey(key types.Key) (*types.Key, error) {
 	}
 	return &types.Key{
 		AccountIdentifier: key.AccountIdentifier,
+		Organization:      key.Organization,
 		Name:              key.Name,
 		Value:             key.Value,
+		Prefix:            types.KeyPrefix(key.Value),
+		Hash:              types.HashKey(key.Value),
 		Type:              key.Type,
+		AllowedHosts:      key.AllowedHosts,
 		ValidUntil:        key.ValidUntil,
 	}, nil
 }
@@ -173,8 +277,9 @@ func (s *SQLite) DeleteKey(key types.Key) error {
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"UPDATE api_key SET key_deleted=TRUE WHERE key_deleted=FALSE AND key_value=?;",
-		key.Value,
+		"UPDATE api_key SET key_deleted=TRUE, key_deleted_at=? WHERE key_deleted=FALSE AND key_value=?;",
+		time.Now().UnixMilli(),
+		key.ValueHash(),
 	)
 	if err != nil {
 		return fmt.Errorf("error deleting key: %v", err)
@@ -198,11 +303,12 @@ func (s *SQLite) UpdateKey(key types.Key) error {
 	defer cancelfunc()
 	res, err := db.ExecContext(
 		ctx,
-		"UPDATE api_key SET key_name=?, key_type=?, valid_until=? WHERE key_deleted=FALSE AND key_value=?;",
+		"UPDATE api_key SET key_name=?, key_type=?, allowed_hosts=?, valid_until=? WHERE key_deleted=FALSE AND key_value=?;",
 		key.Name,
 		key.Type,
+		key.AllowedHostsValue(),
 		key.ValidUntil,
-		key.Value,
+		key.ValueHash(),
 	)
 	if err != nil {
 		return fmt.Errorf("error updating key: %v", err)
@@ -217,3 +323,158 @@ func (s *SQLite) UpdateKey(key types.Key) error {
 	return nil
 }
 
+// RotateKey Replaces the value of a key with a new one. Reads and notifications stay attached to the key.
+// If graceUntil is set the old value keeps working until then, otherwise it stops working immediately.
+func (s *SQLite) RotateKey(key, newValue string, graceUntil *time.Time) (*types.Key, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	var oldHash *string
+	if graceUntil != nil {
+		hash := types.HashKey(key)
+		oldHash = &hash
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE api_key SET key_prefix=?, key_value=?, old_key_value=?, old_key_valid_until=? WHERE key_deleted=FALSE AND key_value=?;",
+		types.KeyPrefix(newValue),
+		types.HashKey(newValue),
+		oldHash,
+		graceUntil,
+		types.HashKey(key),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error rotating key: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return nil, fmt.Errorf("error rotating key, rows affected: %v", rows)
+	}
+	rotated, err := s.GetKey(newValue)
+	if err != nil || rotated == nil {
+		return nil, err
+	}
+	// This is the only time the new value is known, so hand it back.
+	rotated.Value = newValue
+	return rotated, nil
+}
+
+// GetKeysExpiredBetween Gets the keys that stopped being valid after from and at or before to.
+// Expiry times are compared once loaded so keys saved with different time zones compare properly.
+func (s *SQLite) GetKeysExpiredBetween(from, to time.Time) ([]types.Key, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT account_id, key_name, key_prefix, key_value, key_type, allowed_hosts, valid_until, org_id FROM api_key WHERE key_deleted=FALSE AND valid_until IS NOT NULL;",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving key: %v", err)
+	}
+	defer res.Close()
+	var outKeys []types.Key
+	for res.Next() {
+		var key types.Key
+		var allowedHosts string
+		err := res.Scan(
+			&key.AccountIdentifier,
+			&key.Name,
+			&key.Prefix,
+			&key.Hash,
+			&key.Type,
+			&allowedHosts,
+			&key.ValidUntil,
+			&key.Organization,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting key: %v", err)
+		}
+		if !key.ValidUntil.After(from) || key.ValidUntil.After(to) {
+			continue
+		}
+		key.SetAllowedHosts(allowedHosts)
+		outKeys = append(outKeys, key)
+	}
+	return outKeys, nil
+}
+
+// GetDeletedKeys Gets the deleted keys kept under an account, whether or not the account is deleted.
+func (s *SQLite) GetDeletedKeys(account int64) ([]types.Key, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT account_id, key_name, key_prefix, key_value, key_type, allowed_hosts, valid_until, org_id, key_deleted_at FROM api_key WHERE key_deleted=TRUE AND account_id=?;",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving deleted keys: %v", err)
+	}
+	defer res.Close()
+	var outKeys []types.Key
+	for res.Next() {
+		var key types.Key
+		var allowedHosts string
+		var deletedAt int64
+		err := res.Scan(
+			&key.AccountIdentifier,
+			&key.Name,
+			&key.Prefix,
+			&key.Hash,
+			&key.Type,
+			&allowedHosts,
+			&key.ValidUntil,
+			&key.Organization,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting key: %v", err)
+		}
+		key.SetAllowedHosts(allowedHosts)
+		key.SetDeletedAt(deletedAt)
+		outKeys = append(outKeys, key)
+	}
+	return outKeys, nil
+}
+
+// RestoreKey Brings a deleted key back. Keys are identified by their name since deleted keys have no value
+// anyone should still be using.
+func (s *SQLite) RestoreKey(account int64, name string) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE api_key SET key_deleted=FALSE, key_deleted_at=0 WHERE key_deleted=TRUE AND account_id=? AND key_name=?;",
+		account,
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("error restoring key: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error restoring key, rows affected: %v", rows)
+	}
+	return nil
+}
@@ -0,0 +1,641 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// migrations Every change made to the schema since version 1, oldest first. Versions 5 and 6 rebuilt and
+// hashed the key table in place so nothing before version 6 can be reversed.
+var migrations = []database.Migration[*sql.Tx]{
+	{
+		Version: 2,
+		Name:    "add notifications",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS notification(" +
+				"notification_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"key_value VARCHAR(100) NOT NULL, " +
+				"notification_type VARCHAR(100) NOT NULL, " +
+				"notification_when BIGINT NOT NULL, " +
+				"notification_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(key_value, notification_when) ON CONFLICT IGNORE, " +
+				"FOREIGN KEY (key_value) REFERENCES api_key(key_value)" +
+				");",
+		),
+	},
+	{
+		Version: 3,
+		Name:    "add read ids and time index",
+		// Reads are given an id in the order they were added so streams can resume from the last one sent.
+		// SQLite can't add a primary key to an existing table so the read table is rebuilt, numbering the
+		// reads already there by their rowid, which is the order they were added in.
+		Up: execQueries(
+			"CREATE TABLE a_read_new("+
+				"read_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"key_value VARCHAR(100) NOT NULL, "+
+				"identifier VARCHAR(100) NOT NULL, "+
+				"seconds BIGINT NOT NULL DEFAULT 0, "+
+				"milliseconds INT NOT NULL DEFAULT 0, "+
+				"ident_type VARCHAR(25) NOT NULL DEFAULT 'chip', "+
+				"type VARCHAR(25) NOT NULL DEFAULT '', "+
+				"antenna INT NOT NULL DEFAULT 0, "+
+				"reader VARCHAR(50) NOT NULL DEFAULT '', "+
+				"rssi VARCHAR(10) NOT NULL DEFAULT '', "+
+				"read_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"UNIQUE(key_value, identifier, seconds, milliseconds, ident_type), "+
+				"FOREIGN KEY (key_value) REFERENCES api_key(key_value)"+
+				");",
+			"INSERT INTO a_read_new(read_id, key_value, identifier, seconds, milliseconds, ident_type, type, antenna, "+
+				"reader, rssi, read_created_at) SELECT rowid, key_value, identifier, seconds, milliseconds, ident_type, "+
+				"type, antenna, reader, rssi, read_created_at FROM a_read;",
+			"DROP TABLE a_read;",
+			"ALTER TABLE a_read_new RENAME TO a_read;",
+			"CREATE INDEX IF NOT EXISTS idx_read_time ON a_read(key_value, seconds, milliseconds, identifier, ident_type);",
+		),
+	},
+	{
+		Version: 4,
+		Name:    "add key allowed hosts",
+		Up: execQueries(
+			"ALTER TABLE api_key ADD COLUMN allowed_hosts VARCHAR(1000) NOT NULL DEFAULT '';",
+		),
+	},
+	{
+		Version: 5,
+		Name:    "add key ids",
+		// Reads and notifications were tied to the key value, which meant replacing a key orphaned
+		// everything uploaded with it. Give keys an id and point reads and notifications at that instead.
+		// SQLite can't add a primary key to an existing table so the key, read, and notification
+		// tables are rebuilt with reads and notifications pointing at the new key_id column.
+		Up: execQueries(
+			"CREATE TABLE api_key_new("+
+				"key_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"account_id INTEGER NOT NULL, "+
+				"key_name VARCHAR(100) NOT NULL,"+
+				"key_value VARCHAR(100) NOT NULL, "+
+				"key_type VARCHAR(20) NOT NULL, "+
+				"allowed_hosts VARCHAR(1000) NOT NULL DEFAULT '', "+
+				"valid_until DATETIME DEFAULT NULL, "+
+				"old_key_value VARCHAR(100) DEFAULT NULL, "+
+				"old_key_valid_until DATETIME DEFAULT NULL, "+
+				"key_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"key_updated_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"key_deleted BOOL DEFAULT FALSE, "+
+				"UNIQUE(key_value), "+
+				"UNIQUE(old_key_value), "+
+				"UNIQUE(account_id, key_name), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)"+
+				");",
+			"INSERT INTO api_key_new(account_id, key_name, key_value, key_type, allowed_hosts, valid_until, "+
+				"key_created_at, key_updated_at, key_deleted) SELECT account_id, key_name, key_value, key_type, "+
+				"allowed_hosts, valid_until, key_created_at, key_updated_at, key_deleted FROM api_key;",
+			"CREATE TABLE a_read_new("+
+				"read_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"key_id INTEGER NOT NULL, "+
+				"identifier VARCHAR(100) NOT NULL, "+
+				"seconds BIGINT NOT NULL DEFAULT 0, "+
+				"milliseconds INT NOT NULL DEFAULT 0, "+
+				"ident_type VARCHAR(25) NOT NULL DEFAULT 'chip', "+
+				"type VARCHAR(25) NOT NULL DEFAULT '', "+
+				"antenna INT NOT NULL DEFAULT 0, "+
+				"reader VARCHAR(50) NOT NULL DEFAULT '', "+
+				"rssi VARCHAR(10) NOT NULL DEFAULT '', "+
+				"read_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"UNIQUE(key_id, identifier, seconds, milliseconds, ident_type), "+
+				"FOREIGN KEY (key_id) REFERENCES api_key_new(key_id)"+
+				");",
+			"INSERT INTO a_read_new(read_id, key_id, identifier, seconds, milliseconds, ident_type, type, antenna, reader, "+
+				"rssi, read_created_at) SELECT r.read_id, k.key_id, r.identifier, r.seconds, r.milliseconds, r.ident_type, "+
+				"r.type, r.antenna, r.reader, r.rssi, r.read_created_at FROM a_read AS r JOIN api_key_new AS k "+
+				"ON r.key_value=k.key_value;",
+			"CREATE TABLE notification_new("+
+				"notification_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"key_id INTEGER NOT NULL, "+
+				"notification_type VARCHAR(100) NOT NULL, "+
+				"notification_when BIGINT NOT NULL, "+
+				"notification_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"UNIQUE(key_id, notification_when) ON CONFLICT IGNORE, "+
+				"FOREIGN KEY (key_id) REFERENCES api_key_new(key_id)"+
+				");",
+			"INSERT INTO notification_new(notification_id, key_id, notification_type, notification_when, "+
+				"notification_created_at) SELECT n.notification_id, k.key_id, n.notification_type, n.notification_when, "+
+				"n.notification_created_at FROM notification AS n JOIN api_key_new AS k ON n.key_value=k.key_value;",
+			"DROP TABLE a_read;",
+			"DROP TABLE notification;",
+			"DROP TABLE api_key;",
+			"ALTER TABLE api_key_new RENAME TO api_key;",
+			"ALTER TABLE a_read_new RENAME TO a_read;",
+			"ALTER TABLE notification_new RENAME TO notification;",
+			"CREATE INDEX IF NOT EXISTS idx_read_time ON a_read(key_id, seconds, milliseconds, identifier, ident_type);",
+			"CREATE TRIGGER UpdateKeyTime UPDATE OF account_id, key_name, key_value, key_type, allowed_hosts, "+
+				"valid_until, key_deleted ON api_key "+
+				"BEGIN"+
+				"    UPDATE api_key SET key_updated_at=CURRENT_TIMESTAMP WHERE key_id=NEW.key_id;"+
+				"END;",
+		),
+	},
+	{
+		Version: 6,
+		Name:    "hash key values",
+		Up:      hashKeyValues,
+	},
+	{
+		Version: 7,
+		Name:    "add sessions",
+		// Tokens move from the account table to their own table so an account can have
+		// more than one session. Existing tokens are dropped, so everyone has to log in again.
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS account_session("+
+				"session_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"account_id INTEGER NOT NULL, "+
+				"session_token VARCHAR(100) NOT NULL, "+
+				"session_refresh_token VARCHAR(100) NOT NULL, "+
+				"session_user_agent VARCHAR(500) NOT NULL DEFAULT '', "+
+				"session_ip VARCHAR(100) NOT NULL DEFAULT '', "+
+				"session_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"session_last_used DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"UNIQUE(session_token), "+
+				"UNIQUE(session_refresh_token), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)"+
+				");",
+			"ALTER TABLE account DROP COLUMN account_token;",
+			"ALTER TABLE account DROP COLUMN account_refresh_token;",
+		),
+		// Sessions can't be turned back into tokens, so everyone has to log in again going this way too.
+		Down: execQueries(
+			"ALTER TABLE account ADD COLUMN account_token VARCHAR(1000) NOT NULL DEFAULT '';",
+			"ALTER TABLE account ADD COLUMN account_refresh_token VARCHAR(1000) NOT NULL DEFAULT '';",
+			"DROP TABLE account_session;",
+		),
+	},
+	{
+		Version: 8,
+		Name:    "add notification acknowledgement",
+		Up: execQueries(
+			"ALTER TABLE notification ADD COLUMN notification_acknowledged_at DATETIME DEFAULT NULL;",
+		),
+		Down: execQueries(
+			"ALTER TABLE notification DROP COLUMN notification_acknowledged_at;",
+		),
+	},
+	{
+		Version: 9,
+		Name:    "add webhooks",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS webhook("+
+				"webhook_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"account_id INTEGER NOT NULL, "+
+				"webhook_url VARCHAR(500) NOT NULL, "+
+				"webhook_secret VARCHAR(100) NOT NULL, "+
+				"webhook_events VARCHAR(200) NOT NULL DEFAULT '', "+
+				"webhook_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)"+
+				");",
+			"CREATE TABLE IF NOT EXISTS webhook_delivery("+
+				"delivery_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"webhook_id INTEGER NOT NULL, "+
+				"delivery_event VARCHAR(50) NOT NULL, "+
+				"delivery_payload TEXT NOT NULL, "+
+				"delivery_status VARCHAR(20) NOT NULL DEFAULT 'pending', "+
+				"delivery_attempts INT NOT NULL DEFAULT 0, "+
+				"delivery_next_attempt BIGINT NOT NULL, "+
+				"delivery_claimed_until BIGINT NOT NULL DEFAULT 0, "+
+				"delivery_response_code INT NOT NULL DEFAULT 0, "+
+				"delivery_error VARCHAR(500) NOT NULL DEFAULT '', "+
+				"delivery_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"delivery_delivered_at DATETIME DEFAULT NULL, "+
+				"FOREIGN KEY (webhook_id) REFERENCES webhook(webhook_id) ON DELETE CASCADE"+
+				");",
+			"CREATE INDEX IF NOT EXISTS idx_delivery_pending ON webhook_delivery(delivery_status, delivery_next_attempt);",
+		),
+		Down: execQueries(
+			"DROP INDEX idx_delivery_pending;",
+			"DROP TABLE webhook_delivery;",
+			"DROP TABLE webhook;",
+		),
+	},
+	{
+		Version: 10,
+		Name:    "add alert rules",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS alert_rule(" +
+				"rule_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"rule_types VARCHAR(500) NOT NULL, " +
+				"rule_readers VARCHAR(1000) NOT NULL DEFAULT '', " +
+				"rule_recipients VARCHAR(1000) NOT NULL, " +
+				"rule_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE alert_rule;",
+		),
+	},
+	{
+		Version: 11,
+		Name:    "add password resets",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS password_reset(" +
+				"reset_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"account_id INTEGER NOT NULL, " +
+				"reset_token VARCHAR(100) NOT NULL, " +
+				"reset_expires_at BIGINT NOT NULL, " +
+				"reset_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, " +
+				"UNIQUE(reset_token), " +
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)" +
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE password_reset;",
+		),
+	},
+	{
+		Version: 12,
+		Name:    "add two-factor",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS two_factor("+
+				"account_id INTEGER NOT NULL, "+
+				"tf_secret VARCHAR(100) NOT NULL, "+
+				"tf_enabled BOOL DEFAULT FALSE, "+
+				"tf_last_step BIGINT NOT NULL DEFAULT 0, "+
+				"tf_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id), "+
+				"PRIMARY KEY (account_id)"+
+				");",
+			"CREATE TABLE IF NOT EXISTS recovery_code("+
+				"code_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"account_id INTEGER NOT NULL, "+
+				"code_hash VARCHAR(100) NOT NULL, "+
+				"UNIQUE(account_id, code_hash), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE recovery_code;",
+			"DROP TABLE two_factor;",
+		),
+	},
+	{
+		Version: 13,
+		Name:    "add account lockouts",
+		Up: execQueries(
+			"ALTER TABLE account ADD COLUMN account_lock_reason VARCHAR(100) NOT NULL DEFAULT '';",
+			"ALTER TABLE account ADD COLUMN account_locked_until BIGINT NOT NULL DEFAULT 0;",
+			"ALTER TABLE account ADD COLUMN account_lock_count INT NOT NULL DEFAULT 0;",
+			"CREATE TABLE IF NOT EXISTS login_failure("+
+				"failure_ip VARCHAR(100) NOT NULL, "+
+				"failure_count INT NOT NULL DEFAULT 0, "+
+				"failure_last BIGINT NOT NULL DEFAULT 0, "+
+				"failure_blocked_until BIGINT NOT NULL DEFAULT 0, "+
+				"PRIMARY KEY (failure_ip)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE login_failure;",
+			"ALTER TABLE account DROP COLUMN account_lock_count;",
+			"ALTER TABLE account DROP COLUMN account_locked_until;",
+			"ALTER TABLE account DROP COLUMN account_lock_reason;",
+		),
+	},
+	{
+		Version: 14,
+		Name:    "add organizations",
+		Up: execQueries(
+			"ALTER TABLE api_key ADD COLUMN org_id INTEGER DEFAULT NULL;",
+			"CREATE TABLE IF NOT EXISTS organization("+
+				"org_id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+				"account_id INTEGER NOT NULL, "+
+				"org_name VARCHAR(100) NOT NULL, "+
+				"org_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"UNIQUE(account_id), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)"+
+				");",
+			"CREATE TABLE IF NOT EXISTS org_member("+
+				"org_id INTEGER NOT NULL, "+
+				"account_id INTEGER NOT NULL, "+
+				"member_role VARCHAR(20) NOT NULL, "+
+				"member_created_at DATETIME DEFAULT CURRENT_TIMESTAMP, "+
+				"PRIMARY KEY (org_id, account_id), "+
+				"FOREIGN KEY (org_id) REFERENCES organization(org_id), "+
+				"FOREIGN KEY (account_id) REFERENCES account(account_id)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE org_member;",
+			"DROP TABLE organization;",
+			"ALTER TABLE api_key DROP COLUMN org_id;",
+		),
+	},
+	{
+		Version: 15,
+		Name:    "add roles",
+		Up:      addRoles,
+		Down: execQueries(
+			"DROP TABLE account_role;",
+		),
+	},
+	{
+		Version: 16,
+		Name:    "add deletion times",
+		Up: execQueries(
+			"ALTER TABLE account ADD COLUMN account_deleted_at BIGINT NOT NULL DEFAULT 0;",
+			"ALTER TABLE api_key ADD COLUMN key_deleted_at BIGINT NOT NULL DEFAULT 0;",
+		),
+		Down: execQueries(
+			"ALTER TABLE api_key DROP COLUMN key_deleted_at;",
+			"ALTER TABLE account DROP COLUMN account_deleted_at;",
+		),
+	},
+	{
+		Version: 17,
+		Name:    "add webhook organizations",
+		Up: execQueries(
+			"ALTER TABLE webhook ADD COLUMN org_id INTEGER DEFAULT NULL;",
+		),
+		Down: execQueries(
+			"ALTER TABLE webhook DROP COLUMN org_id;",
+		),
+	},
+	{
+		Version: 18,
+		Name:    "add password reset requests",
+		Up: execQueries(
+			"CREATE TABLE IF NOT EXISTS password_reset_request("+
+				"request_key VARCHAR(100) NOT NULL, "+
+				"request_count INT NOT NULL DEFAULT 0, "+
+				"request_window_start BIGINT NOT NULL DEFAULT 0, "+
+				"PRIMARY KEY (request_key)"+
+				");",
+		),
+		Down: execQueries(
+			"DROP TABLE password_reset_request;",
+		),
+	},
+}
+
+// execQueries Returns a migration step that runs each query in order.
+func execQueries(queries ...string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, query := range queries {
+			_, err := tx.ExecContext(ctx, query)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// hashKeyValues Key values were stored in plain text. Keep the start of each value in the clear so keys
+// can still be told apart and replace the values with their hashes.
+func hashKeyValues(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"ALTER TABLE api_key ADD COLUMN key_prefix VARCHAR(20) NOT NULL DEFAULT '';",
+	)
+	if err != nil {
+		return err
+	}
+	res, err := tx.QueryContext(ctx, "SELECT key_id, key_value, old_key_value FROM api_key;")
+	if err != nil {
+		return err
+	}
+	type plainKey struct {
+		id       int64
+		value    string
+		oldValue *string
+	}
+	var plainKeys []plainKey
+	for res.Next() {
+		var key plainKey
+		err := res.Scan(&key.id, &key.value, &key.oldValue)
+		if err != nil {
+			res.Close()
+			return err
+		}
+		plainKeys = append(plainKeys, key)
+	}
+	res.Close()
+	for _, key := range plainKeys {
+		var oldHash *string
+		if key.oldValue != nil {
+			hash := types.HashKey(*key.oldValue)
+			oldHash = &hash
+		}
+		_, err := tx.ExecContext(
+			ctx,
+			"UPDATE api_key SET key_prefix=?, key_value=?, old_key_value=? WHERE key_id=?;",
+			types.KeyPrefix(key.value),
+			types.HashKey(key.value),
+			oldHash,
+			key.id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRoles Account types become roles, starting with the defaults.
+func addRoles(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"CREATE TABLE IF NOT EXISTS account_role("+
+			"role_name VARCHAR(20) NOT NULL, "+
+			"role_permissions VARCHAR(1000) NOT NULL DEFAULT '', "+
+			"PRIMARY KEY (role_name)"+
+			");",
+	)
+	if err != nil {
+		return err
+	}
+	for _, role := range types.DefaultRoles() {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO account_role(role_name, role_permissions) VALUES (?, ?);",
+			role.Name,
+			role.PermissionsValue(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const migrationTableQuery = "CREATE TABLE IF NOT EXISTS schema_migration(" +
+	"migration_id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+	"migration_version INT NOT NULL, " +
+	"migration_name VARCHAR(100) NOT NULL, " +
+	"migration_direction VARCHAR(10) NOT NULL, " +
+	"migration_applied_at BIGINT NOT NULL" +
+	");"
+
+// createMigrationTable Adds the migration history to databases created before it was kept.
+func (s *SQLite) createMigrationTable() error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	_, err = db.ExecContext(ctx, migrationTableQuery)
+	if err != nil {
+		return fmt.Errorf("error creating migration table: %v", err)
+	}
+	return nil
+}
+
+// Bootstrap Creates the tables of an empty database at the current version without adding an admin account,
+// so it can be migrated. Databases that already have tables are left as they are.
+func (s *SQLite) Bootstrap() error {
+	err := s.createMigrationTable()
+	if err != nil {
+		return err
+	}
+	if s.checkVersion() < 1 {
+		return s.createTables()
+	}
+	return nil
+}
+
+// Migrate Moves the database to the target version, running migrations up or reversing them as needed.
+func (s *SQLite) Migrate(target int, dryRun bool) (*types.MigrationPlan, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), database.MigrationTimeout)
+	defer cancelfunc()
+	// Transactions take the write lock as soon as they begin, so holding this one keeps any other
+	// instance from migrating until we're done. The version is read inside it for the same reason.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start transaction: %v", err)
+	}
+	var version string
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT value FROM settings WHERE name='version';",
+	).Scan(&version)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error checking database version: %v", err)
+	}
+	from, err := strconv.Atoi(version)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error checking database version: %v", err)
+	}
+	plan, err := database.PlanMigrations(migrations, from, target)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if dryRun {
+		tx.Rollback()
+		return plan, nil
+	}
+	_, err = tx.ExecContext(ctx, migrationTableQuery)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error creating migration table: %v", err)
+	}
+	err = database.RunMigrations(ctx, tx, migrations, plan, func(step types.MigrationStep) error {
+		log.Info(fmt.Sprintf("Migrated database %s through version %d (%s).", step.Direction, step.Version, step.Name))
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO schema_migration(migration_version, migration_name, migration_direction, "+
+				"migration_applied_at) VALUES (?, ?, ?, ?);",
+			step.Version,
+			step.Name,
+			step.Direction,
+			time.Now().Unix(),
+		)
+		return err
+	})
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error migrating from version %d to %d: %v", from, target, err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE settings SET value=? WHERE name='version';",
+		strconv.Itoa(target),
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error migrating from version %d to %d: %v", from, target, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return plan, nil
+}
+
+// GetMigrationHistory Returns every migration run against the database, oldest first.
+func (s *SQLite) GetMigrationHistory() ([]types.MigrationRecord, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT migration_id, migration_version, migration_name, migration_direction, migration_applied_at "+
+			"FROM schema_migration ORDER BY migration_id;",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving migration history: %v", err)
+	}
+	defer res.Close()
+	outRecords := make([]types.MigrationRecord, 0)
+	for res.Next() {
+		var record types.MigrationRecord
+		var appliedAt int64
+		err := res.Scan(
+			&record.Identifier,
+			&record.Version,
+			&record.Name,
+			&record.Direction,
+			&appliedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting migration record: %v", err)
+		}
+		record.AppliedAt = time.Unix(appliedAt, 0).UTC()
+		outRecords = append(outRecords, record)
+	}
+	return outRecords, nil
+}
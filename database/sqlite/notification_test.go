@@ -131,53 +131,58 @@ func TestSaveNotification(t *testing.T) {
 			When: when.Add(time.Second * -9).UTC().Format(time.RFC3339),
 		},
 	}
-	err = db.SaveNotification(&notifications[0], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[0], keys[0].Value)
 	if err == nil {
 		t.Fatalf("expected error saving notification with invalid type but no error found")
 	}
-	err = db.SaveNotification(&notifications[1], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[1], keys[0].Value)
 	if err == nil {
 		t.Fatalf("expected error saving notification with invalid date but no error found")
 	}
-	err = db.SaveNotification(&notifications[2], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[2], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[3], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[3], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[4], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[4], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[5], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[5], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[6], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[6], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[7], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[7], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[8], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[8], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[9], keys[0].Value)
+	_, err = db.SaveNotification(&notifications[9], keys[0].Value)
 	if err != nil {
 		t.Fatalf("error found saving notification: %v", err)
 	}
-	err = db.SaveNotification(&notifications[10], keys[0].Value)
-	if err != nil {
-		t.Fatalf("error found saving notification: %v", err)
+	saved, err := db.SaveNotification(&notifications[10], keys[0].Value)
+	if err != nil || !saved {
+		t.Fatalf("error found saving notification: %v (saved %v)", err, saved)
 	}
-	err = db.SaveNotification(&notifications[11], keys[0].Value)
+	// A notification for a time the key already has one for is ignored.
+	saved, err = db.SaveNotification(&notifications[11], keys[0].Value)
+	if err != nil || saved {
+		t.Fatalf("expected notification with duplicate when value to be ignored, found %v (saved %v)", err, saved)
+	}
+	_, err = db.SaveNotification(&notifications[2], "unknown-key")
 	if err == nil {
-		t.Fatalf("expected error when adding notification with duplicate when value but no error was found")
+		t.Fatalf("expected error when adding notification with unknown key but no error was found")
 	}
 	/*err = db.SaveNotification(&notifications[2], "invalid key")
 	if err != nil {
@@ -232,18 +237,18 @@ func TestGetNotification(t *testing.T) {
 		},
 	}
 	// No notifications saved.
-	note, err := db.GetNotification(account1.Identifier, keys[0].Name)
+	note, err := db.GetNotification(account1.Identifier, nil, keys[0].Name)
 	if err != nil {
 		t.Fatalf("error when trying to get notification: %v", err)
 	}
 	if note != nil {
 		t.Fatalf("found notification when none was expected: %v", note)
 	}
-	_ = db.SaveNotification(&notifications[0], keys[0].Value)
-	_ = db.SaveNotification(&notifications[1], keys[1].Value)
-	_ = db.SaveNotification(&notifications[2], keys[0].Value)
+	_, _ = db.SaveNotification(&notifications[0], keys[0].Value)
+	_, _ = db.SaveNotification(&notifications[1], keys[1].Value)
+	_, _ = db.SaveNotification(&notifications[2], keys[0].Value)
 	// Saved notification, within time period
-	note, err = db.GetNotification(account1.Identifier, keys[0].Name)
+	note, err = db.GetNotification(account1.Identifier, nil, keys[0].Name)
 	if err != nil {
 		t.Fatalf("error when trying to get notification: %v", err)
 	}
@@ -254,7 +259,7 @@ func TestGetNotification(t *testing.T) {
 		t.Fatalf("expected to find %v for the notification type, found %v", notifications[0].Type, note.Type)
 	}
 	// Notification too long ago
-	note, err = db.GetNotification(account2.Identifier, keys[1].Name)
+	note, err = db.GetNotification(account2.Identifier, nil, keys[1].Name)
 	if err != nil {
 		t.Fatalf("error when trying to get notification: %v", err)
 	}
@@ -262,7 +267,7 @@ func TestGetNotification(t *testing.T) {
 		t.Fatalf("found notification when none was expected: %v", note)
 	}
 	// Invalid key
-	note, err = db.GetNotification(account1.Identifier, "invalid key")
+	note, err = db.GetNotification(account1.Identifier, nil, "invalid key")
 	if err != nil {
 		t.Fatalf("error when trying to get notification: %v", err)
 	}
@@ -271,3 +276,217 @@ func TestGetNotification(t *testing.T) {
 	}
 }
 
+func TestGetNotificationHistory(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("Error setting up test. %v", err)
+	}
+	defer finalize(t)
+	setupNotificationTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-22123B",
+			Type:              "write",
+			Name:              "reader2",
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-KH789A-00123B",
+			Type:              "delete",
+			Name:              "reader3",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	db.AddKey(keys[2])
+	when := time.Now().Truncate(time.Second)
+	// Older than the five minutes GetNotification looks at.
+	for i, note := range []struct {
+		key  string
+		kind string
+		when time.Time
+	}{
+		{keys[0].Value, "UPS_ON_BATTERY", when.Add(time.Hour * -3)},
+		{keys[0].Value, "UPS_ONLINE", when.Add(time.Hour * -2)},
+		{keys[1].Value, "HIGH_TEMP", when.Add(time.Hour * -1)},
+		{keys[0].Value, "UPS_LOW_BATTERY", when.Add(time.Minute * -30)},
+		{keys[2].Value, "MAX_TEMP", when.Add(time.Minute * -10)},
+	} {
+		_, err = db.SaveNotification(&types.RequestNotification{
+			Type: note.kind,
+			When: note.when.UTC().Format(time.RFC3339),
+		}, note.key)
+		if err != nil {
+			t.Fatalf("(%v) error saving notification: %v", i, err)
+		}
+	}
+	// All readers on the account, newest first.
+	notes, err := db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 4 {
+		t.Fatalf("Expected %v notifications, found %v.", 4, len(notes))
+	}
+	expected := []string{"UPS_LOW_BATTERY", "HIGH_TEMP", "UPS_ONLINE", "UPS_ON_BATTERY"}
+	for i, note := range notes {
+		if note.Type != expected[i] {
+			t.Errorf("Expected notification %v to be %v, found %v.", i, expected[i], note.Type)
+		}
+		if note.Acknowledged != nil {
+			t.Errorf("Expected notification %v to not be acknowledged, found %v.", i, *note.Acknowledged)
+		}
+	}
+	if notes[1].Reader != keys[1].Name || notes[0].Reader != keys[0].Name {
+		t.Errorf("Expected reader names to be set, found %v and %v.", notes[0].Reader, notes[1].Reader)
+	}
+	// Single reader.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, keys[0].Name, 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 3 {
+		t.Errorf("Expected %v notifications, found %v.", 3, len(notes))
+	}
+	// Time range.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "", when.Add(time.Hour*-2).Unix(), when.Add(time.Hour*-1).Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Type != "HIGH_TEMP" || notes[1].Type != "UPS_ONLINE" {
+		t.Errorf("Expected HIGH_TEMP and UPS_ONLINE notifications, found %+v.", notes)
+	}
+	// Paging.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 3)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 3 {
+		t.Fatalf("Expected %v notifications, found %v.", 3, len(notes))
+	}
+	after := notes[1].Cursor()
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, &after, 3)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Type != "UPS_ONLINE" || notes[1].Type != "UPS_ON_BATTERY" {
+		t.Errorf("Expected UPS_ONLINE and UPS_ON_BATTERY notifications, found %+v.", notes)
+	}
+	_, err = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 0)
+	if err == nil {
+		t.Error("Expected error getting notification history with no limit.")
+	}
+	// Other account.
+	notes, err = db.GetNotificationHistory(account2.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Type != "MAX_TEMP" {
+		t.Errorf("Expected a MAX_TEMP notification, found %+v.", notes)
+	}
+	// Unknown reader.
+	notes, err = db.GetNotificationHistory(account1.Identifier, nil, "invalid reader", 0, when.Unix(), false, nil, 100)
+	if err != nil {
+		t.Fatalf("error getting notification history: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("Expected no notifications, found %+v.", notes)
+	}
+}
+
+func TestAcknowledgeNotifications(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("Error setting up test. %v", err)
+	}
+	defer finalize(t)
+	setupNotificationTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys := []types.Key{
+		{
+			AccountIdentifier: account1.Identifier,
+			Value:             "030001-1ACSDD-K2389A-00123B",
+			Type:              "write",
+			Name:              "reader1",
+		},
+		{
+			AccountIdentifier: account2.Identifier,
+			Value:             "030001-1ACSDD-KH789A-00123B",
+			Type:              "delete",
+			Name:              "reader2",
+		},
+	}
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	when := time.Now().Truncate(time.Second)
+	for i := 1; i <= 3; i++ {
+		db.SaveNotification(&types.RequestNotification{
+			Type: "HIGH_TEMP",
+			When: when.Add(time.Minute * time.Duration(-i)).UTC().Format(time.RFC3339),
+		}, keys[0].Value)
+	}
+	db.SaveNotification(&types.RequestNotification{
+		Type: "MAX_TEMP",
+		When: when.UTC().Format(time.RFC3339),
+	}, keys[1].Value)
+	notes, _ := db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(notes) != 3 {
+		t.Fatalf("Expected %v notifications, found %v.", 3, len(notes))
+	}
+	others, _ := db.GetNotificationHistory(account2.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(others) != 1 {
+		t.Fatalf("Expected %v notifications, found %v.", 1, len(others))
+	}
+	// Notifications belonging to another account aren't acknowledged.
+	count, err := db.AcknowledgeNotifications(account1.Identifier, nil, []int64{notes[0].Identifier, others[0].Identifier})
+	if err != nil {
+		t.Fatalf("error acknowledging notifications: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected %v notifications acknowledged, found %v.", 1, count)
+	}
+	others, _ = db.GetNotificationHistory(account2.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(others) != 1 || others[0].Acknowledged != nil {
+		t.Errorf("Expected other account's notification to not be acknowledged, found %+v.", others)
+	}
+	notes, _ = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(notes) != 3 || notes[0].Acknowledged == nil || notes[1].Acknowledged != nil {
+		t.Fatalf("Expected only the first notification to be acknowledged, found %+v.", notes)
+	}
+	acknowledged := *notes[0].Acknowledged
+	unacked, _ := db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), true, nil, 100)
+	if len(unacked) != 2 {
+		t.Errorf("Expected %v unacknowledged notifications, found %v.", 2, len(unacked))
+	}
+	// Acknowledging again keeps the original time.
+	time.Sleep(time.Second)
+	count, err = db.AcknowledgeNotifications(account1.Identifier, nil, []int64{notes[0].Identifier, notes[1].Identifier, notes[2].Identifier})
+	if err != nil {
+		t.Fatalf("error acknowledging notifications: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected %v notifications acknowledged, found %v.", 2, count)
+	}
+	notes, _ = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), false, nil, 100)
+	if len(notes) != 3 || notes[0].Acknowledged == nil || !notes[0].Acknowledged.Equal(acknowledged) {
+		t.Errorf("Expected acknowledged time %v to be kept, found %+v.", acknowledged, notes)
+	}
+	unacked, _ = db.GetNotificationHistory(account1.Identifier, nil, "", 0, when.Unix(), true, nil, 100)
+	if len(unacked) != 0 {
+		t.Errorf("Expected no unacknowledged notifications, found %v.", len(unacked))
+	}
+	count, err = db.AcknowledgeNotifications(account1.Identifier, nil, nil)
+	if err != nil || count != 0 {
+		t.Errorf("Expected nothing to be acknowledged, found %v (%v).", count, err)
+	}
+}
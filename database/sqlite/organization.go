@@ -0,0 +1,364 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddOrganization Adds an organization, making the account that created it its first owner.
+func (s *SQLite) AddOrganization(org types.Organization) (*types.Organization, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("unable to begin transaction to add organization: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	res, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO organization(account_id, org_name, org_created_at) VALUES (?, ?, ?);",
+		org.AccountIdentifier,
+		org.Name,
+		now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("unable to add organization: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("unable to determine ID for organization: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO org_member(org_id, account_id, member_role, member_created_at) VALUES (?, ?, ?, ?);",
+		id,
+		org.AccountIdentifier,
+		types.OrgRoleOwner,
+		now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("unable to add organization owner: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	org.Identifier = id
+	org.CreatedAt = now
+	return &org, nil
+}
+
+// GetOrganization Gets an organization. Returns nil if it doesn't exist.
+func (s *SQLite) GetOrganization(id int64) (*types.Organization, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT org_id, account_id, org_name, org_created_at FROM organization WHERE org_id=?;",
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving organization: %v", err)
+	}
+	defer res.Close()
+	if !res.Next() {
+		return nil, nil
+	}
+	var outOrg types.Organization
+	err = res.Scan(
+		&outOrg.Identifier,
+		&outOrg.AccountIdentifier,
+		&outOrg.Name,
+		&outOrg.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting organization: %v", err)
+	}
+	return &outOrg, nil
+}
+
+// UpdateOrganization Updates the name of an organization.
+func (s *SQLite) UpdateOrganization(org types.Organization) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE organization SET org_name=? WHERE org_id=?;",
+		org.Name,
+		org.Identifier,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating organization: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error updating organization, rows affected: %v", rows)
+	}
+	return nil
+}
+
+// DeleteOrganization Deletes an organization and its memberships. Its keys and webhooks go back to
+// being those of the account that created it.
+func (s *SQLite) DeleteOrganization(id int64) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to delete organization: %v", err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE api_key SET org_id=NULL WHERE org_id=?;", id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error removing keys from organization: %v", err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE webhook SET org_id=NULL WHERE org_id=?;", id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error removing webhooks from organization: %v", err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM org_member WHERE org_id=?;", id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error deleting organization members: %v", err)
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM organization WHERE org_id=?;", id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error deleting organization: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		tx.Rollback()
+		return fmt.Errorf("error deleting organization, rows affected: %v", rows)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// GetMembership Gets the role an account has in an organization. Returns nil if it isn't a member.
+func (s *SQLite) GetMembership(org, account int64) (*types.Membership, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT o.org_id, o.account_id, o.org_name, o.org_created_at, m.member_role FROM org_member AS m "+
+			"JOIN organization AS o ON m.org_id=o.org_id WHERE m.org_id=? AND m.account_id=?;",
+		org,
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving membership: %v", err)
+	}
+	defer res.Close()
+	if !res.Next() {
+		return nil, nil
+	}
+	var outMembership types.Membership
+	err = res.Scan(
+		&outMembership.Organization.Identifier,
+		&outMembership.Organization.AccountIdentifier,
+		&outMembership.Organization.Name,
+		&outMembership.Organization.CreatedAt,
+		&outMembership.Role,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting membership: %v", err)
+	}
+	return &outMembership, nil
+}
+
+// GetAccountMemberships Gets every organization an account is a member of, with the role it has in each.
+func (s *SQLite) GetAccountMemberships(account int64) ([]types.Membership, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT o.org_id, o.account_id, o.org_name, o.org_created_at, m.member_role FROM org_member AS m "+
+			"JOIN organization AS o ON m.org_id=o.org_id WHERE m.account_id=? ORDER BY o.org_id;",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memberships: %v", err)
+	}
+	defer res.Close()
+	var outMemberships []types.Membership
+	for res.Next() {
+		var membership types.Membership
+		err := res.Scan(
+			&membership.Organization.Identifier,
+			&membership.Organization.AccountIdentifier,
+			&membership.Organization.Name,
+			&membership.Organization.CreatedAt,
+			&membership.Role,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting membership: %v", err)
+		}
+		outMemberships = append(outMemberships, membership)
+	}
+	return outMemberships, nil
+}
+
+// GetOrganizationMembers Gets the members of an organization. Deleted accounts are left out.
+func (s *SQLite) GetOrganizationMembers(org int64) ([]types.OrganizationMember, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.QueryContext(
+		ctx,
+		"SELECT m.org_id, m.account_id, a.account_name, a.account_email, m.member_role FROM org_member AS m "+
+			"JOIN account AS a ON m.account_id=a.account_id WHERE m.org_id=? AND a.account_deleted=FALSE ORDER BY a.account_email;",
+		org,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving organization members: %v", err)
+	}
+	defer res.Close()
+	var outMembers []types.OrganizationMember
+	for res.Next() {
+		var member types.OrganizationMember
+		err := res.Scan(
+			&member.OrganizationIdentifier,
+			&member.AccountIdentifier,
+			&member.Name,
+			&member.Email,
+			&member.Role,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting organization member: %v", err)
+		}
+		outMembers = append(outMembers, member)
+	}
+	return outMembers, nil
+}
+
+// SetOrganizationMember Adds an account to an organization, or changes its role if it is already a member.
+func (s *SQLite) SetOrganizationMember(member types.OrganizationMember) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to set organization member: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM org_member WHERE org_id=? AND account_id=?;",
+		member.OrganizationIdentifier,
+		member.AccountIdentifier,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error deleting old organization member: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO org_member(org_id, account_id, member_role, member_created_at) VALUES (?, ?, ?, ?);",
+		member.OrganizationIdentifier,
+		member.AccountIdentifier,
+		member.Role,
+		time.Now().UTC().Truncate(time.Second),
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("unable to add organization member: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// RemoveOrganizationMember Removes an account from an organization.
+func (s *SQLite) RemoveOrganizationMember(org, account int64) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	res, err := db.ExecContext(
+		ctx,
+		"DELETE FROM org_member WHERE org_id=? AND account_id=?;",
+		org,
+		account,
+	)
+	if err != nil {
+		return fmt.Errorf("error removing organization member: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("error removing organization member, rows affected: %v", rows)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddPasswordReset Stores a password reset token for an account, replacing any it already had.
+func (s *SQLite) AddPasswordReset(reset types.PasswordReset) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction to add password reset: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM password_reset WHERE account_id=?;",
+		reset.AccountIdentifier,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error deleting old password resets: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO password_reset(account_id, reset_token, reset_expires_at) VALUES (?, ?, ?);",
+		reset.AccountIdentifier,
+		types.HashKey(reset.Token),
+		reset.ExpiresAt.Unix(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("unable to add password reset: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// AddPasswordResetRequest Counts a password reset request against the key, starting the count over once the
+// window has passed since the first request it holds. Counts every key has outlasted are cleared out. Returns
+// the number of requests counted in the current window, this one included.
+func (s *SQLite) AddPasswordResetRequest(key string, window time.Duration, now time.Time) (int, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction to add password reset request: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM password_reset_request WHERE request_window_start<=?;",
+		now.Add(-window).Unix(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("error clearing old password reset requests: %v", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO password_reset_request(request_key, request_count, request_window_start) VALUES (?, 1, ?) "+
+			"ON CONFLICT(request_key) DO UPDATE SET request_count=request_count+1;",
+		key,
+		now.Unix(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("unable to add password reset request: %v", err)
+	}
+	res, err := tx.QueryContext(
+		ctx,
+		"SELECT request_count FROM password_reset_request WHERE request_key=?;",
+		key,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("error retrieving password reset requests: %v", err)
+	}
+	var count int
+	if res.Next() {
+		err = res.Scan(&count)
+	}
+	res.Close()
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("error getting password reset requests: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return count, nil
+}
+
+// ResetPassword Uses a password reset token to set a new password on the account it was issued for.
+// The account is unlocked and every reset token and session it has is removed. Returns
+// database.ErrInvalidResetToken if the token is unknown, has already been used, expired before now,
+// or belongs to an account that has since been deleted.
+func (s *SQLite) ResetPassword(token, newPassword string, now time.Time) (*types.Account, error) {
+	db, err := s.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelfunc()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("unable to begin transaction to reset password: %v", err)
+	}
+	res, err := tx.QueryContext(
+		ctx,
+		"SELECT account_id FROM password_reset WHERE reset_token=? AND reset_expires_at>?;",
+		types.HashKey(token),
+		now.Unix(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error retrieving password reset: %v", err)
+	}
+	var accountID int64
+	found := res.Next()
+	if found {
+		err = res.Scan(&accountID)
+	}
+	res.Close()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error getting password reset: %v", err)
+	}
+	if !found {
+		tx.Rollback()
+		return nil, database.ErrInvalidResetToken
+	}
+	// Deleting the token before anything else makes sure it can only be used once.
+	result, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM password_reset WHERE reset_token=?;",
+		types.HashKey(token),
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error deleting password reset: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rows != 1 {
+		tx.Rollback()
+		return nil, database.ErrInvalidResetToken
+	}
+	for _, query := range []string{
+		"DELETE FROM password_reset WHERE account_id=?;",
+		"DELETE FROM account_session WHERE account_id=?;",
+	} {
+		_, err = tx.ExecContext(ctx, query, accountID)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("error clearing account after password reset: %v", err)
+		}
+	}
+	result, err = tx.ExecContext(
+		ctx,
+		"UPDATE account SET account_password=?, account_wrong_pass=0, account_locked=FALSE, account_lock_reason='', "+
+			"account_locked_until=0, account_lock_count=0 WHERE account_id=? AND account_deleted=FALSE;",
+		newPassword,
+		accountID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error resetting password: %v", err)
+	}
+	rows, err = result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error checking rows affected on password reset: %v", err)
+	}
+	if rows != 1 {
+		tx.Rollback()
+		return nil, database.ErrInvalidResetToken
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return s.GetAccountByID(accountID)
+}
@@ -20,6 +20,8 @@ package sqlite
 
 import (
 	"chronokeep/remote/types"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -181,8 +183,14 @@ func TestAddReads(t *testing.T) {
 	if len(res) != len(reads) {
 		t.Errorf("Expected %v reads to be returned, %v returned.", len(reads), len(res))
 	}
-	db.AddReads(keys[0].Value, reads)
-	res, err = db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+	res, err = db.AddReads(keys[0].Value, reads)
+	if err != nil {
+		t.Fatalf("error adding duplicate reads: %v", err)
+	}
+	if len(res) != 0 {
+		t.Errorf("Expected %v duplicate reads to be added, %v added.", 0, len(res))
+	}
+	res, err = db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 	if err != nil {
 		t.Fatalf("error adding reads: %v", err)
 	}
@@ -207,14 +215,17 @@ func TestAddReads(t *testing.T) {
 	if len(res) != 2 {
 		t.Errorf("Expected %v reads to be added, %v added.", 2, len(res))
 	}
+	// reads[1] was already added, only reads[2] is new
 	res, err = db.AddReads(keys[1].Value, reads[1:3])
 	if err != nil {
 		t.Fatalf("Error adding reads: %v", err)
 	}
-	if len(res) != 2 {
-		t.Errorf("Expected %v reads to be added, %v added.", 2, len(res))
+	if len(res) != 1 {
+		t.Errorf("Expected %v reads to be added, %v added.", 1, len(res))
+	} else if !res[0].Equals(&reads[2]) {
+		t.Errorf("Expected %+v to be added, found %+v.", reads[2], res[0])
 	}
-	res, err = db.GetReads(keys[1].AccountIdentifier, keys[1].Name, now, now+1000)
+	res, err = db.GetReads(keys[1].AccountIdentifier, nil, keys[1].Name, now, now+1000)
 	if err != nil {
 		t.Fatalf("error getting reads: %v", err)
 	}
@@ -223,6 +234,57 @@ func TestAddReads(t *testing.T) {
 	}
 }
 
+func TestAddReadsConcurrent(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupReadsTests()
+	account1, _ := db.AddAccount(accounts[0])
+	keys[0].AccountIdentifier = account1.Identifier
+	keys[1].AccountIdentifier = account1.Identifier
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	// Several writers uploading at the same time should wait on each other instead of failing.
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			batch := make([]types.Read, 0)
+			for j := 0; j < 50; j++ {
+				batch = append(batch, types.Read{
+					Identifier:   strconv.Itoa(i*100 + j),
+					Seconds:      now + int64(j),
+					Milliseconds: 0,
+					IdentType:    "chip",
+					Type:         "reader",
+				})
+			}
+			_, err := db.AddReads(keys[i%2].Value, batch)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error adding reads concurrently: %v", err)
+		}
+	}
+	for _, key := range keys[0:2] {
+		res, err := db.GetReads(key.AccountIdentifier, nil, key.Name, now, now+1000)
+		if err != nil {
+			t.Fatalf("error getting reads: %v", err)
+		}
+		if len(res) != 250 {
+			t.Errorf("Expected %v reads to be returned, %v returned.", 250, len(res))
+		}
+	}
+}
+
 func TestGetReads(t *testing.T) {
 	db, finalize, err := setupTests(t)
 	if err != nil {
@@ -239,7 +301,7 @@ func TestGetReads(t *testing.T) {
 	keys[4].AccountIdentifier = account1.Identifier
 	db.AddKey(keys[0])
 	db.AddKey(keys[1])
-	res, err := db.GetReads(keys[1].AccountIdentifier, keys[1].Name, now, now+1000)
+	res, err := db.GetReads(keys[1].AccountIdentifier, nil, keys[1].Name, now, now+1000)
 	if err != nil {
 		t.Fatalf("error adding reads: %v", err)
 	}
@@ -247,35 +309,35 @@ func TestGetReads(t *testing.T) {
 		t.Fatalf("Found results when none should exist: %v", len(res))
 	}
 	db.AddReads(keys[0].Value, reads)
-	res, err = db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+	res, err = db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 	if err != nil {
 		t.Fatalf("error adding reads: %v", err)
 	}
 	if len(res) != len(reads) {
 		t.Errorf("Expected %v reads to be returned, %v returned.", len(reads), len(res))
 	}
-	res, err = db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+55)
+	res, err = db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+55)
 	if err != nil {
 		t.Fatalf("error adding reads: %v", err)
 	}
 	if len(res) != 4 {
 		t.Errorf("Expected %v reads to be returned, %v returned.", 4, len(res))
 	}
-	res, err = db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now)
+	res, err = db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now)
 	if err != nil {
 		t.Fatalf("error adding reads: %v", err)
 	}
 	if len(res) != 1 {
 		t.Errorf("Expected %v reads to be returned, %v returned.", 1, len(res))
 	}
-	res, err = db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now+35, now+400)
+	res, err = db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now+35, now+400)
 	if err != nil {
 		t.Fatalf("error adding reads: %v", err)
 	}
 	if len(res) != 4 {
 		t.Errorf("Expected %v reads to be returned, %v returned.", 4, len(res))
 	}
-	res, err = db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now-400)
+	res, err = db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now-400)
 	if err != nil {
 		t.Fatalf("error adding reads: %v", err)
 	}
@@ -284,6 +346,154 @@ func TestGetReads(t *testing.T) {
 	}
 }
 
+func TestGetReadsPage(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupReadsTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys[0].AccountIdentifier = account1.Identifier
+	keys[1].AccountIdentifier = account1.Identifier
+	keys[2].AccountIdentifier = account2.Identifier
+	keys[3].AccountIdentifier = account2.Identifier
+	keys[4].AccountIdentifier = account1.Identifier
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	res, err := db.GetReadsPage(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000, nil, 3)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(res))
+	}
+	db.AddReads(keys[0].Value, reads)
+	db.AddReads(keys[1].Value, reads[0:2])
+	// Page through every read three at a time.
+	found := make([]types.Read, 0)
+	var after *types.ReadCursor
+	for i := 0; i < 5; i++ {
+		res, err = db.GetReadsPage(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000, after, 3)
+		if !assert.NoError(t, err) {
+			break
+		}
+		assert.True(t, len(res) <= 3)
+		found = append(found, res...)
+		if len(res) < 3 {
+			break
+		}
+		cursor := res[len(res)-1].Cursor()
+		after = &cursor
+	}
+	if assert.Equal(t, len(reads), len(found)) {
+		for i := range reads {
+			assert.True(t, reads[i].Equals(&found[i]))
+		}
+	}
+	// Reads sharing a time are ordered by identifier.
+	tied := []types.Read{
+		{
+			Identifier:   "b",
+			Seconds:      now + 800,
+			Milliseconds: 5,
+			IdentType:    "chip",
+			Type:         "reader",
+		},
+		{
+			Identifier:   "a",
+			Seconds:      now + 800,
+			Milliseconds: 5,
+			IdentType:    "chip",
+			Type:         "reader",
+		},
+	}
+	db.AddReads(keys[0].Value, tied)
+	cursor := reads[len(reads)-1].Cursor()
+	res, err = db.GetReadsPage(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000, &cursor, 1)
+	if assert.NoError(t, err) && assert.Equal(t, 1, len(res)) {
+		assert.Equal(t, "a", res[0].Identifier)
+		cursor = res[0].Cursor()
+	}
+	res, err = db.GetReadsPage(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000, &cursor, 10)
+	if assert.NoError(t, err) && assert.Equal(t, 1, len(res)) {
+		assert.Equal(t, "b", res[0].Identifier)
+	}
+	// Time window still applies.
+	res, err = db.GetReadsPage(keys[0].AccountIdentifier, nil, keys[0].Name, now+35, now+400, nil, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, len(res))
+	}
+	_, err = db.GetReadsPage(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000, nil, 0)
+	assert.Error(t, err)
+}
+
+func TestGetReadsAfter(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupReadsTests()
+	account1, _ := db.AddAccount(accounts[0])
+	keys[0].AccountIdentifier = account1.Identifier
+	keys[1].AccountIdentifier = account1.Identifier
+	db.AddKey(keys[0])
+	db.AddKey(keys[1])
+	res, err := db.GetReadsAfter(keys[0].AccountIdentifier, nil, keys[0].Name, 0, 3)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(res))
+	}
+	// Added reads are given ids in the order they were added.
+	added, err := db.AddReads(keys[0].Value, reads[2:])
+	if !assert.NoError(t, err) || !assert.Equal(t, len(reads)-2, len(added)) {
+		return
+	}
+	for i := 1; i < len(added); i++ {
+		assert.Greater(t, added[i].ID, added[i-1].ID)
+	}
+	db.AddReads(keys[1].Value, reads[0:2])
+	late, err := db.AddReads(keys[0].Value, reads[0:2])
+	if !assert.NoError(t, err) || !assert.Equal(t, 2, len(late)) {
+		return
+	}
+	assert.Greater(t, late[0].ID, added[len(added)-1].ID)
+	// Duplicates aren't added again.
+	dupes, err := db.AddReads(keys[0].Value, reads[0:2])
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(dupes))
+	}
+	// Reads are returned in the order they were added, not by their time.
+	found := make([]types.Read, 0)
+	var after int64
+	for i := 0; i < 5; i++ {
+		res, err = db.GetReadsAfter(keys[0].AccountIdentifier, nil, keys[0].Name, after, 3)
+		if !assert.NoError(t, err) {
+			break
+		}
+		assert.True(t, len(res) <= 3)
+		found = append(found, res...)
+		if len(res) < 3 {
+			break
+		}
+		after = res[len(res)-1].ID
+	}
+	expected := append(added, late...)
+	if assert.Equal(t, len(expected), len(found)) {
+		for i := range expected {
+			assert.True(t, expected[i].Equals(&found[i]))
+			assert.Equal(t, expected[i].ID, found[i].ID)
+			assert.Equal(t, types.KeyPrefix(keys[0].Value), found[i].Key)
+		}
+	}
+	// Reads added before the late ones aren't returned again.
+	res, err = db.GetReadsAfter(keys[0].AccountIdentifier, nil, keys[0].Name, added[len(added)-1].ID, 10)
+	if assert.NoError(t, err) && assert.Equal(t, 2, len(res)) {
+		assert.True(t, reads[0].Equals(&res[0]))
+		assert.True(t, reads[1].Equals(&res[1]))
+	}
+	_, err = db.GetReadsAfter(keys[0].AccountIdentifier, nil, keys[0].Name, 0, 0)
+	assert.Error(t, err)
+}
+
 func TestDeleteReads(t *testing.T) {
 	db, finalize, err := setupTests(t)
 	if err != nil {
@@ -300,33 +510,33 @@ func TestDeleteReads(t *testing.T) {
 	keys[4].AccountIdentifier = account1.Identifier
 	db.AddKey(keys[0])
 	db.AddKey(keys[1])
-	count, err := db.DeleteReaderReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+	count, err := db.DeleteReaderReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(0), count)
 	}
 	db.AddReads(keys[0].Value, reads)
 	db.AddReads(keys[1].Value, reads)
-	count, err = db.DeleteReaderReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+	count, err = db.DeleteReaderReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(len(reads)), count)
-		res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+		res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 		assert.Equal(t, 0, len(res))
 	}
 	db.AddReads(keys[0].Value, reads)
-	count, err = db.DeleteReaderReads(keys[0].AccountIdentifier, keys[0].Name, now, now+35)
+	count, err = db.DeleteReaderReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+35)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(3), count)
-		res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+		res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 		assert.Equal(t, 4, len(res))
 	}
 	db.AddReads(keys[0].Value, reads)
-	count, err = db.DeleteReaderReads(keys[0].AccountIdentifier, keys[0].Name, now+100, now+500)
+	count, err = db.DeleteReaderReads(keys[0].AccountIdentifier, nil, keys[0].Name, now+100, now+500)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(2), count)
-		res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+		res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 		assert.Equal(t, 5, len(res))
 	}
-	res, _ := db.GetReads(keys[1].AccountIdentifier, keys[1].Name, now, now+1000)
+	res, _ := db.GetReads(keys[1].AccountIdentifier, nil, keys[1].Name, now, now+1000)
 	assert.Equal(t, len(reads), len(res))
 }
 
@@ -362,11 +572,11 @@ func TestDeleteKeyReads(t *testing.T) {
 	if count != int64(len(reads)) {
 		t.Fatalf("count expected to be %v, deleted %v", len(reads), count)
 	}
-	res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+	res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 	if len(res) != 0 {
 		t.Fatalf("epected to find %v reads but found %v", 0, len(res))
 	}
-	res, _ = db.GetReads(keys[1].AccountIdentifier, keys[1].Name, now, now+1000)
+	res, _ = db.GetReads(keys[1].AccountIdentifier, nil, keys[1].Name, now, now+1000)
 	assert.Equal(t, len(reads), len(res))
 }
 
@@ -386,33 +596,33 @@ func TestDeleteReaderReadsBefore(t *testing.T) {
 	keys[4].AccountIdentifier = account1.Identifier
 	db.AddKey(keys[0])
 	db.AddKey(keys[1])
-	count, err := db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, keys[0].Name, now+1000)
+	count, err := db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, nil, keys[0].Name, now+1000)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(0), count)
 	}
 	db.AddReads(keys[1].Value, reads)
 	db.AddReads(keys[0].Value, reads)
-	count, err = db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, keys[0].Name, now+1000)
+	count, err = db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, nil, keys[0].Name, now+1000)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(len(reads)), count)
-		res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+		res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 		assert.Equal(t, 0, len(res))
 	}
 	db.AddReads(keys[0].Value, reads)
-	count, err = db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, keys[0].Name, now+35)
+	count, err = db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, nil, keys[0].Name, now+35)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(3), count)
-		res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+		res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 		assert.Equal(t, 4, len(res))
 	}
 	db.AddReads(keys[0].Value, reads)
-	count, err = db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, keys[0].Name, now+500)
+	count, err = db.DeleteReaderReadsBefore(keys[0].AccountIdentifier, nil, keys[0].Name, now+500)
 	if assert.NoError(t, err) {
 		assert.Equal(t, int64(6), count)
-		res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+		res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 		assert.Equal(t, 1, len(res))
 	}
-	res, _ := db.GetReads(keys[1].AccountIdentifier, keys[1].Name, now, now+1000)
+	res, _ := db.GetReads(keys[1].AccountIdentifier, nil, keys[1].Name, now, now+1000)
 	assert.Equal(t, len(reads), len(res))
 }
 
@@ -432,7 +642,7 @@ func TestDeleteReaderReadsBetween(t *testing.T) {
 	keys[4].AccountIdentifier = account1.Identifier
 	db.AddKey(keys[0])
 	db.AddKey(keys[1])
-	count, err := db.DeleteReaderReadsBetween(keys[0].AccountIdentifier, keys[0].Name)
+	count, err := db.DeleteReaderReadsBetween(keys[0].AccountIdentifier, nil, keys[0].Name)
 	if err != nil {
 		t.Fatalf("error deleting non existant reads: %v", err)
 	}
@@ -441,34 +651,77 @@ func TestDeleteReaderReadsBetween(t *testing.T) {
 	}
 	db.AddReads(keys[0].Value, reads)
 	db.AddReads(keys[1].Value, reads)
-	count, err = db.DeleteReaderReadsBetween(keys[0].AccountIdentifier, keys[0].Name)
+	count, err = db.DeleteReaderReadsBetween(keys[0].AccountIdentifier, nil, keys[0].Name)
 	if err != nil {
 		t.Fatalf("error deleting non existant reads: %v", err)
 	}
 	if count != int64(len(reads)) {
 		t.Fatalf("count expected to be %v, deleted %v", 0, count)
 	}
-	res, _ := db.GetReads(keys[0].AccountIdentifier, keys[0].Name, now, now+1000)
+	res, _ := db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
 	if len(res) != 0 {
 		t.Fatalf("epected to find %v reads but found %v", 0, len(res))
 	}
-	res, _ = db.GetReads(keys[1].AccountIdentifier, keys[1].Name, now, now+1000)
+	res, _ = db.GetReads(keys[1].AccountIdentifier, nil, keys[1].Name, now, now+1000)
 	if len(res) != len(reads) {
 		t.Fatalf("epected to find %v reads but found %v", 0, len(res))
 	}
 }
 
+func TestDeleteAccountTypeReadsBefore(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupReadsTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	keys[0].AccountIdentifier = account1.Identifier
+	keys[2].AccountIdentifier = account2.Identifier
+	db.AddKey(keys[0])
+	db.AddKey(keys[2])
+	db.AddReads(keys[0].Value, reads)
+	db.AddReads(keys[2].Value, reads)
+	// Nothing was uploaded an hour ago.
+	count, err := db.DeleteAccountTypeReadsBefore(accounts[1].Type, time.Now().Add(time.Hour*-1).Unix())
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(0), count)
+	}
+	// Only reads belonging to accounts of the given type should be removed.
+	count, err = db.DeleteAccountTypeReadsBefore(accounts[1].Type, time.Now().Add(time.Hour).Unix())
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(len(reads)), count)
+	}
+	res, _ := db.GetReads(keys[2].AccountIdentifier, nil, keys[2].Name, now, now+1000)
+	assert.Equal(t, 0, len(res))
+	res, _ = db.GetReads(keys[0].AccountIdentifier, nil, keys[0].Name, now, now+1000)
+	assert.Equal(t, len(reads), len(res))
+	count, err = db.DeleteAccountTypeReadsBefore("unknown", time.Now().Add(time.Hour).Unix())
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(0), count)
+	}
+}
+
 func TestBadDatabaseRead(t *testing.T) {
 	db := badTestSetup(t)
-	_, err := db.GetReads(0, "", 0, 0)
+	_, err := db.GetReads(0, nil, "", 0, 0)
 	if err == nil {
 		t.Fatal("Expected error on get reads.")
 	}
+	_, err = db.GetReadsPage(0, nil, "", 0, 0, nil, 1)
+	if err == nil {
+		t.Fatal("Expected error on get reads page.")
+	}
+	_, err = db.GetReadsAfter(0, nil, "", 0, 1)
+	if err == nil {
+		t.Fatal("Expected error on get reads after.")
+	}
 	_, err = db.AddReads("", make([]types.Read, 0))
 	if err == nil {
 		t.Fatal("Expected error on add reads.")
 	}
-	_, err = db.DeleteReaderReads(0, "", 0, 0)
+	_, err = db.DeleteReaderReads(0, nil, "", 0, 0)
 	if err == nil {
 		t.Fatal("Expected error on delete reads.")
 	}
@@ -476,23 +729,35 @@ func TestBadDatabaseRead(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error on delete key reads.")
 	}
-	_, err = db.DeleteReaderReadsBetween(0, "")
+	_, err = db.DeleteReaderReadsBetween(0, nil, "")
 	if err == nil {
 		t.Fatal("Expected error on delete reader reads.")
 	}
+	_, err = db.DeleteAccountTypeReadsBefore("", 0)
+	if err == nil {
+		t.Fatal("Expected error on delete account type reads.")
+	}
 }
 
 func TestNoDatabaseRead(t *testing.T) {
 	db := SQLite{}
-	_, err := db.GetReads(0, "", 0, 0)
+	_, err := db.GetReads(0, nil, "", 0, 0)
 	if err == nil {
 		t.Fatal("Expected error on get reads.")
 	}
+	_, err = db.GetReadsPage(0, nil, "", 0, 0, nil, 1)
+	if err == nil {
+		t.Fatal("Expected error on get reads page.")
+	}
+	_, err = db.GetReadsAfter(0, nil, "", 0, 1)
+	if err == nil {
+		t.Fatal("Expected error on get reads after.")
+	}
 	_, err = db.AddReads("", make([]types.Read, 0))
 	if err == nil {
 		t.Fatal("Expected error on add reads.")
 	}
-	_, err = db.DeleteReaderReads(0, "", 0, 0)
+	_, err = db.DeleteReaderReads(0, nil, "", 0, 0)
 	if err == nil {
 		t.Fatal("Expected error on delete reads.")
 	}
@@ -500,9 +765,12 @@ func TestNoDatabaseRead(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error on delete key reads.")
 	}
-	_, err = db.DeleteReaderReadsBetween(0, "")
+	_, err = db.DeleteReaderReadsBetween(0, nil, "")
 	if err == nil {
 		t.Fatal("Expected error on delete reader reads.")
 	}
+	_, err = db.DeleteAccountTypeReadsBefore("", 0)
+	if err == nil {
+		t.Fatal("Expected error on delete account type reads.")
+	}
 }
-
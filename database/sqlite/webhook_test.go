@@ -0,0 +1,387 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"chronokeep/remote/types"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAddWebhook(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	webhook := types.Webhook{
+		AccountIdentifier: account1.Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret1",
+		Events:            []string{types.WebhookReadsAdded, types.WebhookKeyExpired},
+	}
+	added, err := db.AddWebhook(webhook)
+	if err != nil {
+		t.Fatalf("Error adding webhook: %v", err)
+	}
+	if added.Identifier == 0 || added.CreatedAt.IsZero() {
+		t.Errorf("Expected webhook to have an id and created time set, found %+v.", *added)
+	}
+	_, err = db.AddWebhook(types.Webhook{
+		AccountIdentifier: account2.Identifier,
+		URL:               "http://example.org/other",
+		Secret:            "secret2",
+		Events:            []string{types.WebhookNotificationSaved},
+	})
+	if err != nil {
+		t.Fatalf("Error adding webhook: %v", err)
+	}
+	hooks, err := db.GetAccountWebhooks(account1.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting webhooks: %v", err)
+	}
+	if len(hooks) != 1 {
+		t.Fatalf("Expected %v webhooks, found %v.", 1, len(hooks))
+	}
+	if hooks[0].Identifier != added.Identifier || hooks[0].AccountIdentifier != account1.Identifier ||
+		hooks[0].URL != webhook.URL || hooks[0].Secret != webhook.Secret || !slices.Equal(hooks[0].Events, webhook.Events) {
+		t.Errorf("Expected webhook %+v, found %+v.", *added, hooks[0])
+	}
+	hooks, err = db.GetAccountWebhooks(account2.Identifier)
+	if err != nil {
+		t.Fatalf("Error getting webhooks: %v", err)
+	}
+	if len(hooks) != 1 || !hooks[0].Subscribed(types.WebhookNotificationSaved) || hooks[0].Subscribed(types.WebhookReadsAdded) {
+		t.Errorf("Expected one webhook for notifications, found %+v.", hooks)
+	}
+	hooks, err = db.GetAccountWebhooks(account2.Identifier + 100)
+	if err != nil {
+		t.Fatalf("Error getting webhooks: %v", err)
+	}
+	if len(hooks) != 0 {
+		t.Errorf("Expected %v webhooks, found %v.", 0, len(hooks))
+	}
+	// Organization webhooks are kept under the account that created the organization.
+	org, err := db.AddOrganization(types.Organization{AccountIdentifier: account1.Identifier, Name: "Timing Company"})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	orgHook, err := db.AddWebhook(types.Webhook{
+		AccountIdentifier: account1.Identifier,
+		Organization:      &org.Identifier,
+		URL:               "https://example.com/org",
+		Secret:            "secret3",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	if err != nil {
+		t.Fatalf("Error adding webhook: %v", err)
+	}
+	hooks, _ = db.GetAccountWebhooks(account1.Identifier)
+	if len(hooks) != 2 || hooks[0].Organization != nil || hooks[1].Identifier != orgHook.Identifier || !hooks[1].InOrganization(&org.Identifier) {
+		t.Errorf("Expected an account webhook and an organization webhook, found %+v.", hooks)
+	}
+	// Deleting the organization gives its webhooks back to the account.
+	err = db.DeleteOrganization(org.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting organization: %v", err)
+	}
+	hooks, _ = db.GetAccountWebhooks(account1.Identifier)
+	if len(hooks) != 2 || hooks[1].Organization != nil {
+		t.Errorf("Expected webhooks to be the account's own, found %+v.", hooks)
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	webhook, _ := db.AddWebhook(types.Webhook{
+		AccountIdentifier: account1.Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret1",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	err = db.AddWebhookDeliveries([]types.WebhookDelivery{
+		{
+			WebhookIdentifier: webhook.Identifier,
+			Event:             types.WebhookReadsAdded,
+			Payload:           "{}",
+			NextAttempt:       time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error adding webhook deliveries: %v", err)
+	}
+	// Webhooks can only be deleted by the account they belong to.
+	err = db.DeleteWebhook(account2.Identifier, webhook.Identifier)
+	if err == nil {
+		t.Error("Expected error deleting webhook from the wrong account.")
+	}
+	err = db.DeleteWebhook(account1.Identifier, webhook.Identifier)
+	if err != nil {
+		t.Fatalf("Error deleting webhook: %v", err)
+	}
+	hooks, _ := db.GetAccountWebhooks(account1.Identifier)
+	if len(hooks) != 0 {
+		t.Errorf("Expected %v webhooks, found %v.", 0, len(hooks))
+	}
+	// Deliveries go along with the webhook.
+	deliveries, err := db.ClaimWebhookDeliveries(time.Now().Add(time.Minute), time.Now().Add(time.Minute).Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Error claiming pending deliveries: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Errorf("Expected %v pending deliveries, found %v.", 0, len(deliveries))
+	}
+	err = db.DeleteWebhook(account1.Identifier, webhook.Identifier)
+	if err == nil {
+		t.Error("Expected error deleting webhook twice.")
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	db, finalize, err := setupTests(t)
+	if err != nil {
+		t.Fatalf("setup error: %v", err)
+	}
+	defer finalize(t)
+	setupAccountTests()
+	account1, _ := db.AddAccount(accounts[0])
+	account2, _ := db.AddAccount(accounts[1])
+	webhook1, _ := db.AddWebhook(types.Webhook{
+		AccountIdentifier: account1.Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret1",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	webhook2, _ := db.AddWebhook(types.Webhook{
+		AccountIdentifier: account2.Identifier,
+		URL:               "https://example.org/hook",
+		Secret:            "secret2",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	now := time.Now().Truncate(time.Second)
+	err = db.AddWebhookDeliveries([]types.WebhookDelivery{
+		{
+			WebhookIdentifier: webhook1.Identifier,
+			Event:             types.WebhookReadsAdded,
+			Payload:           `{"event":"reads.added","n":1}`,
+			NextAttempt:       now,
+		},
+		{
+			WebhookIdentifier: webhook1.Identifier,
+			Event:             types.WebhookReadsAdded,
+			Payload:           `{"event":"reads.added","n":2}`,
+			NextAttempt:       now.Add(time.Minute),
+		},
+		{
+			WebhookIdentifier: webhook2.Identifier,
+			Event:             types.WebhookReadsAdded,
+			Payload:           `{"event":"reads.added","n":3}`,
+			NextAttempt:       now.Add(-time.Minute),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error adding webhook deliveries: %v", err)
+	}
+	err = db.AddWebhookDeliveries(nil)
+	if err != nil {
+		t.Fatalf("Error adding no webhook deliveries: %v", err)
+	}
+	// Only deliveries that are due are pending, oldest first.
+	pending, err := db.ClaimWebhookDeliveries(now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Error claiming pending deliveries: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("Expected %v pending deliveries, found %v.", 2, len(pending))
+	}
+	if pending[0].WebhookIdentifier != webhook2.Identifier || pending[1].WebhookIdentifier != webhook1.Identifier {
+		t.Errorf("Expected deliveries oldest first, found %+v.", pending)
+	}
+	if pending[1].URL != webhook1.URL || pending[1].Secret != webhook1.Secret {
+		t.Errorf("Expected delivery to carry webhook url and secret, found %+v.", pending[1])
+	}
+	if pending[1].Status != types.DeliveryPending || pending[1].Attempts != 0 || !pending[1].NextAttempt.Equal(now) ||
+		pending[1].Payload != `{"event":"reads.added","n":1}` || pending[1].DeliveredAt != nil {
+		t.Errorf("Unexpected pending delivery found: %+v.", pending[1])
+	}
+	// Claimed deliveries aren't handed out again until the claim runs out.
+	claimed, err := db.ClaimWebhookDeliveries(now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Error claiming pending deliveries: %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("Expected %v pending deliveries while claimed, found %v.", 0, len(claimed))
+	}
+	pending, err = db.ClaimWebhookDeliveries(now.Add(time.Hour), now.Add(time.Hour).Add(time.Minute), 1)
+	if err != nil {
+		t.Fatalf("Error claiming pending deliveries: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("Expected %v pending deliveries, found %v.", 1, len(pending))
+	}
+	// Record a successful delivery and a failed one.
+	delivered := now
+	pending[0].Status = types.DeliveryDelivered
+	pending[0].Attempts = 1
+	pending[0].ResponseCode = 200
+	pending[0].DeliveredAt = &delivered
+	err = db.UpdateWebhookDelivery(pending[0])
+	if err != nil {
+		t.Fatalf("Error updating delivery: %v", err)
+	}
+	history, err := db.GetWebhookDeliveries(account1.Identifier, webhook1.Identifier, 10)
+	if err != nil {
+		t.Fatalf("Error getting delivery log: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected %v deliveries, found %v.", 2, len(history))
+	}
+	failed := history[1]
+	failed.Status = types.DeliveryPending
+	failed.Attempts = 1
+	failed.ResponseCode = 500
+	failed.Error = "unexpected response status: 500 Internal Server Error"
+	failed.NextAttempt = now.Add(time.Hour)
+	err = db.UpdateWebhookDelivery(failed)
+	if err != nil {
+		t.Fatalf("Error updating delivery: %v", err)
+	}
+	pending, err = db.ClaimWebhookDeliveries(now.Add(time.Minute*2), now.Add(time.Minute*2).Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Error claiming pending deliveries: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Payload != `{"event":"reads.added","n":2}` {
+		t.Errorf("Expected only the delivery not yet attempted to be pending, found %+v.", pending)
+	}
+	// The log is newest first and only shows the account's own webhooks.
+	history, err = db.GetWebhookDeliveries(account1.Identifier, webhook1.Identifier, 10)
+	if err != nil {
+		t.Fatalf("Error getting delivery log: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected %v deliveries, found %v.", 2, len(history))
+	}
+	if history[0].Identifier < history[1].Identifier {
+		t.Errorf("Expected newest delivery first, found %+v.", history)
+	}
+	if history[1].Status != types.DeliveryPending || history[1].Attempts != 1 || history[1].ResponseCode != 500 ||
+		history[1].Error != failed.Error || !history[1].NextAttempt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected failed delivery to be recorded, found %+v.", history[1])
+	}
+	history, err = db.GetWebhookDeliveries(account2.Identifier, webhook2.Identifier, 10)
+	if err != nil {
+		t.Fatalf("Error getting delivery log: %v", err)
+	}
+	if len(history) != 1 || history[0].Status != types.DeliveryDelivered || history[0].DeliveredAt == nil || history[0].ResponseCode != 200 {
+		t.Errorf("Expected delivered delivery, found %+v.", history)
+	}
+	history, err = db.GetWebhookDeliveries(account2.Identifier, webhook1.Identifier, 10)
+	if err != nil {
+		t.Fatalf("Error getting delivery log: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected %v deliveries for another account's webhook, found %v.", 0, len(history))
+	}
+	history, err = db.GetWebhookDeliveries(account1.Identifier, webhook1.Identifier, 1)
+	if err != nil {
+		t.Fatalf("Error getting delivery log: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("Expected %v deliveries, found %v.", 1, len(history))
+	}
+	err = db.UpdateWebhookDelivery(types.WebhookDelivery{Identifier: 1000})
+	if err == nil {
+		t.Error("Expected error updating a delivery that doesn't exist.")
+	}
+}
+
+func TestBadDatabaseWebhook(t *testing.T) {
+	db := badTestSetup(t)
+	_, err := db.AddWebhook(types.Webhook{})
+	if err == nil {
+		t.Fatal("Expected error adding webhook.")
+	}
+	_, err = db.GetAccountWebhooks(0)
+	if err == nil {
+		t.Fatal("Expected error getting webhooks.")
+	}
+	err = db.DeleteWebhook(0, 0)
+	if err == nil {
+		t.Fatal("Expected error deleting webhook.")
+	}
+	err = db.AddWebhookDeliveries([]types.WebhookDelivery{{}})
+	if err == nil {
+		t.Fatal("Expected error adding webhook deliveries.")
+	}
+	_, err = db.ClaimWebhookDeliveries(time.Now(), time.Now().Add(time.Minute), 1)
+	if err == nil {
+		t.Fatal("Expected error claiming pending webhook deliveries.")
+	}
+	_, err = db.GetWebhookDeliveries(0, 0, 1)
+	if err == nil {
+		t.Fatal("Expected error getting webhook deliveries.")
+	}
+	err = db.UpdateWebhookDelivery(types.WebhookDelivery{})
+	if err == nil {
+		t.Fatal("Expected error updating webhook delivery.")
+	}
+}
+
+func TestNoDatabaseWebhook(t *testing.T) {
+	db := SQLite{}
+	_, err := db.AddWebhook(types.Webhook{})
+	if err == nil {
+		t.Fatal("Expected error adding webhook.")
+	}
+	_, err = db.GetAccountWebhooks(0)
+	if err == nil {
+		t.Fatal("Expected error getting webhooks.")
+	}
+	err = db.DeleteWebhook(0, 0)
+	if err == nil {
+		t.Fatal("Expected error deleting webhook.")
+	}
+	err = db.AddWebhookDeliveries(nil)
+	if err == nil {
+		t.Fatal("Expected error adding webhook deliveries.")
+	}
+	_, err = db.ClaimWebhookDeliveries(time.Now(), time.Now().Add(time.Minute), 1)
+	if err == nil {
+		t.Fatal("Expected error claiming pending webhook deliveries.")
+	}
+	_, err = db.GetWebhookDeliveries(0, 0, 1)
+	if err == nil {
+		t.Fatal("Expected error getting webhook deliveries.")
+	}
+	err = db.UpdateWebhookDelivery(types.WebhookDelivery{})
+	if err == nil {
+		t.Fatal("Expected error updating webhook delivery.")
+	}
+}
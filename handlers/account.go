@@ -20,6 +20,7 @@ package handlers
 
 import (
 	"chronokeep/remote/auth"
+	db "chronokeep/remote/database"
 	"chronokeep/remote/types"
 	"errors"
 	"fmt"
@@ -33,8 +34,12 @@ import (
 )
 
 const (
-	expirationWindow = time.Minute * 15
-	refreshWindow    = time.Hour * 24 * 7
+	expirationWindow   = time.Minute * 15
+	refreshWindow      = time.Hour * 24 * 7
+	sessionTouchWindow = time.Minute
+	maxUserAgentLength = 500
+	// refreshTokenPurpose is the purpose claim given to refresh tokens.
+	refreshTokenPurpose = "refresh"
 )
 
 func (h Handler) GetAccount(c *echo.Context) error {
@@ -43,16 +48,9 @@ func (h Handler) GetAccount(c *echo.Context) error {
 	if err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request", nil)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	// check if the user is trying to use a locked account
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
-	// only allow admins to modify accounts not their own
-	if account.Type != "admin" && request.Email != nil && account.Email != *request.Email {
+	account := authorizedAccount(c)
+	// only allow accounts that can view other accounts to look at accounts not their own
+	if request.Email != nil && account.Email != *request.Email && !hasPermission(account, types.PermAccountsView) {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", nil)
 	}
 	email := account.Email
@@ -74,27 +72,30 @@ func (h Handler) GetAccount(c *echo.Context) error {
 	if account == nil {
 		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
 	}
-	keys, err := database.GetAccountKeys(email)
+	keys, err := database.GetMemberKeys(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	memberships, err := database.GetAccountMemberships(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if memberships == nil {
+		memberships = make([]types.Membership, 0)
+	}
+	permissions, err := accountPermissions(account)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
 	}
 	return c.JSON(http.StatusOK, types.GetAccountResponse{
-		Account: *account,
-		Keys:    keys,
+		Account:       *account,
+		Keys:          keys,
+		Organizations: memberships,
+		Permissions:   permissions,
 	})
 }
 
 func (h Handler) GetAccounts(c *echo.Context) error {
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account == nil || account.Type != "admin" {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", nil)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
 	accounts, err := database.GetAccounts()
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
@@ -109,19 +110,12 @@ func (h Handler) AddAccount(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account == nil || account.Type != "admin" {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", nil)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
-	if err = request.Account.Validate(h.validate); err != nil {
+	if err := request.Account.Validate(h.validate); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Account Information", err)
 	}
+	if role, err := accountRole(c, request.Account.Type); role == nil {
+		return err
+	}
 	if len(request.Password) < 8 {
 		return getAPIError(c, http.StatusBadRequest, "Minimum Password Length (8) Not Met", nil)
 	}
@@ -130,7 +124,7 @@ func (h Handler) AddAccount(c *echo.Context) error {
 		return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
 	}
 	request.Account.Password = password
-	account, err = database.AddAccount(request.Account)
+	account, err := database.AddAccount(request.Account)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Unable To Add Account", err)
 	}
@@ -144,26 +138,14 @@ func (h Handler) UpdateAccount(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if err = request.Account.Validate(h.validate); err != nil {
+	account := authorizedAccount(c)
+	if err := request.Account.Validate(h.validate); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Account Information", err)
 	}
-	// Only admins and the owner of an account can update it.
-	if account.Type != "admin" && account.Email != request.Account.Email {
+	// Only the owner of an account and accounts that manage accounts can update it.
+	if account.Email != request.Account.Email && !hasPermission(account, types.PermAccountsManage) {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", nil)
 	}
-	if account.Locked {
-		account.RefreshToken = ""
-		account.Token = ""
-		err = database.UpdateTokens(*account)
-		if err != nil {
-			return getAPIError(c, http.StatusInternalServerError, "Database Error", nil)
-		}
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
 	acc, err := database.GetAccount(request.Account.Email)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
@@ -171,8 +153,13 @@ func (h Handler) UpdateAccount(c *echo.Context) error {
 	if acc == nil {
 		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
 	}
-	if account.Type != "admin" && account.Type != request.Account.Type {
-		request.Account.Type = account.Type
+	// Changing the type of an account changes its role, which only accounts that manage accounts can do.
+	if acc.Type != request.Account.Type {
+		if !hasPermission(account, types.PermAccountsManage) {
+			request.Account.Type = acc.Type
+		} else if role, err := accountRole(c, request.Account.Type); role == nil {
+			return err
+		}
 	}
 	err = database.UpdateAccount(request.Account)
 	if err != nil {
@@ -192,19 +179,13 @@ func (h Handler) DeleteAccount(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
-	err = h.validate.Struct(request)
+	account := authorizedAccount(c)
+	err := h.validate.Struct(request)
 	if len(request.Email) < 2 || err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
 	}
-	// Only admins and the owner of an account can delete it.
-	if account.Type != "admin" && account.Email != request.Email {
+	// Only the owner of an account and accounts that manage accounts can delete it.
+	if account.Email != request.Email && !hasPermission(account, types.PermAccountsManage) {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", nil)
 	}
 	account, err = database.GetAccount(request.Email)
@@ -226,16 +207,7 @@ func (h Handler) ChangePassword(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account == nil {
-		return getAPIError(c, http.StatusUnauthorized, "Account Not Found", nil)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
+	account := authorizedAccount(c)
 	if len(request.NewPassword) == 0 && len(request.OldPassword) == 0 && len(request.Email) == 0 {
 		return getAPIError(c, http.StatusBadRequest, "Empty Request", nil)
 	}
@@ -258,8 +230,8 @@ func (h Handler) ChangePassword(c *echo.Context) error {
 			return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
 		}
 		return c.NoContent(http.StatusOK)
-		// Otherwise if an admin is changing a password for a user let them.
-	} else if account.Type == "admin" {
+		// Otherwise if an account that manages accounts is changing a password for a user let them.
+	} else if hasPermission(account, types.PermAccountsManage) {
 		account, err = database.GetAccount(request.Email)
 		if err != nil {
 			return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
@@ -274,7 +246,7 @@ func (h Handler) ChangePassword(c *echo.Context) error {
 		}
 		return c.NoContent(http.StatusOK)
 	}
-	// Not their own account and not an admin, unauthorized.
+	// Not their own account and not allowed to manage accounts, unauthorized.
 	return getAPIError(c, http.StatusUnauthorized, "Unauthorized", nil)
 }
 
@@ -283,21 +255,10 @@ func (h Handler) ChangeEmail(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account == nil {
-		return getAPIError(c, http.StatusUnauthorized, "Account Not Found", nil)
-	}
-	if err = h.validate.Struct(request); err != nil {
+	if err := h.validate.Struct(request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Email(s)", err)
 	}
-	// Only let admins change emails.
-	if account.Locked || account.Type != "admin" {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked / not admin"))
-	}
-	account, err = database.GetAccount(request.OldEmail)
+	account, err := database.GetAccount(request.OldEmail)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Account", err)
 	}
@@ -317,6 +278,13 @@ func (h Handler) Login(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
+	blocked, err := loginBlocked(c)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if blocked {
+		return getAPIError(c, http.StatusTooManyRequests, "Too Many Failed Logins", fmt.Errorf("logins from %s blocked", c.RealIP()))
+	}
 	log.Info("Bind success, getting account.")
 	// Get User
 	account, err := database.GetAccount(request.Email)
@@ -324,6 +292,7 @@ func (h Handler) Login(c *echo.Context) error {
 		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
 	}
 	if account == nil {
+		failedLogin(c, nil)
 		return getAPIError(c, http.StatusUnauthorized, "Invalid Credentials", errors.New("user not found"))
 	}
 	log.Info("User found.")
@@ -336,39 +305,99 @@ func (h Handler) Login(c *echo.Context) error {
 	log.Info("Verifying password.")
 	err = auth.VerifyPassword(account.Password, request.Password)
 	if err != nil {
-		database.InvalidPassword(*account)
+		failedLogin(c, account)
 		return getAPIError(c, http.StatusUnauthorized, "Invalid Credentials", err)
 	}
+	// With two-factor the wrong password count is only reset once the code is verified as well,
+	// otherwise logging in with the password would allow unlimited guesses at the code.
+	log.Info("Checking two-factor.")
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if twoFactor != nil && twoFactor.Enabled {
+		challenge, err := createChallenge(account.Email, false)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Token Generation Error", err)
+		}
+		return c.JSON(http.StatusOK, types.LoginChallengeResponse{
+			Challenge: challenge,
+		})
+	}
+	required, err := twoFactorRequired(account)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if required {
+		setup, err := newTwoFactorSetup(account)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Unable To Set Up Two-Factor", err)
+		}
+		challenge, err := createChallenge(account.Email, true)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Token Generation Error", err)
+		}
+		return c.JSON(http.StatusOK, types.LoginChallengeResponse{
+			Challenge: challenge,
+			Setup:     setup,
+		})
+	}
 	err = database.ValidPassword(*account)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
 	}
+	response, err := startSession(c, account)
+	if response == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// loginBlocked Checks if logins from the address a request came from are blocked after too many failed logins.
+func loginBlocked(c *echo.Context) (bool, error) {
+	failures, err := database.GetLoginFailures(c.RealIP())
+	if err != nil {
+		return false, err
+	}
+	return failures != nil && failures.Blocked(), nil
+}
+
+// failedLogin Counts a failed login against the account it was for, if it exists, and the address it came from.
+func failedLogin(c *echo.Context, account *types.Account) {
+	if account != nil {
+		database.InvalidPassword(*account)
+	}
+	database.AddLoginFailure(c.RealIP())
+}
+
+// startSession Creates tokens for an account that has logged in and starts a session with them.
+// If nil is returned an error response has already been written and the error is the result of writing it.
+func startSession(c *echo.Context, account *types.Account) (*types.LoginResponse, error) {
 	log.Info("Generating tokens.")
 	token, refresh, err := createTokens(account.Email)
 	if err != nil || token == nil || refresh == nil {
-		return getAPIError(c, http.StatusInternalServerError, "Token Generation Error", err)
-	}
-	log.Info("Updating tokens on account.")
-	account.Token = *token
-	account.RefreshToken = *refresh
-	err = database.UpdateTokens(*account)
+		return nil, getAPIError(c, http.StatusInternalServerError, "Token Generation Error", err)
+	}
+	log.Info("Starting session.")
+	_, err = database.AddSession(types.Session{
+		AccountIdentifier: account.Identifier,
+		Token:             *token,
+		RefreshToken:      *refresh,
+		UserAgent:         userAgent(c.Request()),
+		IP:                c.RealIP(),
+	})
 	if err != nil {
-		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+		return nil, getAPIError(c, http.StatusInternalServerError, "Database Error", err)
 	}
-	return c.JSON(http.StatusOK, map[string]string{
-		"access_token":  *token,
-		"refresh_token": *refresh,
-	})
+	return &types.LoginResponse{
+		AccessToken:  *token,
+		RefreshToken: *refresh,
+	}, nil
 }
 
 func (h Handler) Logout(c *echo.Context) error {
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	account.Token = ""
-	account.RefreshToken = ""
-	err = database.UpdateTokens(*account)
+	// Only end the session used to log out, other devices stay logged in.
+	err := database.DeleteSession(authorizedAccount(c).Identifier, authorizedSession(c).Identifier)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
 	}
@@ -380,12 +409,7 @@ func (h Handler) Refresh(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	rtoken, err := jwt.Parse(request.RefreshToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.RefreshKey), nil
-	})
+	rtoken, err := parseToken(request.RefreshToken, config.RefreshKey)
 	// Probably expired or doesn't exist.
 	if err != nil {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", err)
@@ -395,6 +419,13 @@ func (h Handler) Refresh(c *echo.Context) error {
 	if !ok || !rtoken.Valid {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("token not valid or claims issue"))
 	}
+	// Tokens signed with a signing key share it with access tokens, so their purpose has to be checked.
+	// Refresh tokens signed with the refresh secret before the purpose was added don't have one.
+	purpose, _ := claims["purpose"].(string)
+	_, shared := rtoken.Method.(*jwt.SigningMethodHMAC)
+	if purpose != refreshTokenPurpose && (purpose != "" || !shared) {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("token is not a refresh token"))
+	}
 	// Valid not expired token.
 	email, ok := claims["email"].(string)
 	if !ok {
@@ -408,25 +439,30 @@ func (h Handler) Refresh(c *echo.Context) error {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account not found"))
 	}
 	if account.Locked {
-		account.RefreshToken = ""
-		account.Token = ""
-		err = database.UpdateTokens(*account)
+		_, err = database.DeleteAccountSessions(account.Identifier)
 		if err != nil {
 			return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
 		}
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
 	}
-	// Verify the token matches, throw in an empty check for if the user logged out as well.
-	if account.RefreshToken != request.RefreshToken || account.RefreshToken == "" {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("refresh token does not match account token"))
+	// Verify the token belongs to a session on this account. Sessions are removed on logout
+	// and each refresh replaces the token, so old refresh tokens won't be found.
+	session, err := database.GetSessionByRefreshToken(request.RefreshToken)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if session == nil || session.AccountIdentifier != account.Identifier {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("refresh token does not match a session"))
 	}
 	token, refresh, err := createTokens(account.Email)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Token Generation Error", err)
 	}
-	account.Token = *token
-	account.RefreshToken = *refresh
-	err = database.UpdateTokens(*account)
+	session.Token = *token
+	session.RefreshToken = *refresh
+	session.UserAgent = userAgent(c.Request())
+	session.IP = c.RealIP()
+	err = database.UpdateSession(*session)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
 	}
@@ -441,15 +477,7 @@ func (h Handler) Unlock(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	// Only let admins unlock accounts.
-	if account.Locked || account.Type != "admin" {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", nil)
-	}
-	err = h.validate.Struct(request)
+	err := h.validate.Struct(request)
 	if len(request.Email) < 2 || err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
 	}
@@ -467,3 +495,150 @@ func (h Handler) Unlock(c *echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+func (h Handler) GetDeletedAccounts(c *echo.Context) error {
+	accounts, err := database.GetDeletedAccounts()
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if accounts == nil {
+		accounts = make([]types.Account, 0)
+	}
+	return c.JSON(http.StatusOK, types.GetAllAccountsResponse{
+		Accounts: accounts,
+	})
+}
+
+func (h Handler) RestoreAccount(c *echo.Context) error {
+	var request types.RestoreAccountRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	deleted, err := database.GetDeletedAccount(request.Email)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if deleted == nil {
+		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	// Deleted accounts keep their email, so it can't have been given to another account since.
+	// The account's role may have been removed since it was deleted though.
+	role, err := database.GetRole(deleted.Type)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Role", err)
+	}
+	if role == nil {
+		return getAPIError(c, http.StatusConflict, "Role No Longer Exists", fmt.Errorf("role '%s' does not exist", deleted.Type))
+	}
+	err = database.RestoreAccount(deleted.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Unable To Restore Account", err)
+	}
+	account, err := database.GetAccount(request.Email)
+	if err != nil || account == nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	return c.JSON(http.StatusOK, types.ModifyAccountResponse{
+		Account: *account,
+	})
+}
+
+func (h Handler) PurgeAccount(c *echo.Context) error {
+	var request types.PurgeAccountRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	// Accounts can be purged whether or not they've been deleted first.
+	account, err := database.GetAccount(request.Email)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if account == nil {
+		account, err = database.GetDeletedAccount(request.Email)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+		}
+	}
+	if account == nil {
+		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	if account.Identifier == authorizedAccount(c).Identifier {
+		return getAPIError(c, http.StatusBadRequest, "Unable To Purge Own Account", nil)
+	}
+	removed, err := database.PurgeAccount(account.Identifier, request.DryRun)
+	if errors.Is(err, db.ErrOwnsOrganization) {
+		return getAPIError(c, http.StatusConflict, "Account Owns Organization", err)
+	}
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Unable To Purge Account", err)
+	}
+	if !request.DryRun {
+		log.WithFields(log.Fields{
+			"account":       account.Email,
+			"purged_by":     authorizedAccount(c).Email,
+			"keys":          removed.Keys,
+			"reads":         removed.Reads,
+			"notifications": removed.Notifications,
+		}).Info("Account purged.")
+	}
+	return c.JSON(http.StatusOK, types.PurgeAccountResponse{
+		Email:   account.Email,
+		DryRun:  request.DryRun,
+		Removed: *removed,
+	})
+}
+
+func (h Handler) GetSessions(c *echo.Context) error {
+	current := authorizedSession(c)
+	sessions, err := database.GetAccountSessions(authorizedAccount(c).Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Identifier == current.Identifier
+	}
+	return c.JSON(http.StatusOK, types.GetSessionsResponse{
+		Sessions: sessions,
+	})
+}
+
+func (h Handler) RevokeSession(c *echo.Context) error {
+	var request types.RevokeSessionRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	// Only sessions on the caller's own account can be revoked.
+	sessions, err := database.GetAccountSessions(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	found := false
+	for _, session := range sessions {
+		if session.Identifier == request.Session {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return getAPIError(c, http.StatusNotFound, "Session Not Found", nil)
+	}
+	err = database.DeleteSession(account.Identifier, request.Session)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h Handler) RevokeOtherSessions(c *echo.Context) error {
+	_, err := database.DeleteAccountSessions(authorizedAccount(c).Identifier, authorizedSession(c).Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,225 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxAlertRulesPerAccount = 20
+)
+
+// alertDescriptions are used to explain notification types in alert emails.
+var alertDescriptions = map[string]string{
+	"UPS_DISCONNECTED": "The UPS has been disconnected",
+	"UPS_CONNECTED":    "The UPS has been connected",
+	"UPS_ON_BATTERY":   "The UPS is running on battery",
+	"UPS_LOW_BATTERY":  "The UPS battery is low",
+	"UPS_ONLINE":       "The UPS is back on line power",
+	"SHUTTING_DOWN":    "The reader is shutting down",
+	"RESTARTING":       "The reader is restarting",
+	"HIGH_TEMP":        "The reader is running hot",
+	"MAX_TEMP":         "The reader has reached its maximum temperature",
+}
+
+var alerts = &alerter{
+	sent: make(map[string]time.Time),
+}
+
+// alerter emails the recipients of an account's alert rules when one of its readers sends a matching
+// notification. An alert rule won't send the same notification type for a reader more than once every
+// AlertThrottle minutes.
+type alerter struct {
+	mu     sync.Mutex
+	sent   map[string]time.Time
+	pruned time.Time
+}
+
+// notify Emails the notification to everyone it's due to go to. The email is sent in the background,
+// failures are logged rather than returned so they never fail the request that saved the notification.
+func (a *alerter) notify(account *types.Account, reader string, note types.Notification, now time.Time) {
+	m := mailer
+	if m == nil {
+		return
+	}
+	rules, err := database.GetAccountAlertRules(account.Identifier)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"account": account.Identifier,
+			"error":   err,
+		}).Error("Unable to get alert rules.")
+		return
+	}
+	throttle := time.Duration(config.AlertThrottle) * time.Minute
+	var recipients, sent []string
+	a.mu.Lock()
+	a.prune(now, throttle)
+	for _, rule := range rules {
+		if !rule.Matches(reader, note.Type) {
+			continue
+		}
+		key := fmt.Sprintf("%d|%s|%s", rule.Identifier, reader, note.Type)
+		if last, ok := a.sent[key]; ok && now.Sub(last) < throttle {
+			continue
+		}
+		a.sent[key] = now
+		sent = append(sent, key)
+		for _, recipient := range rule.Recipients {
+			if !slices.Contains(recipients, recipient) {
+				recipients = append(recipients, recipient)
+			}
+		}
+	}
+	a.mu.Unlock()
+	if len(recipients) < 1 {
+		return
+	}
+	subject := fmt.Sprintf("Chronokeep alert for %s: %s", reader, note.Type)
+	sendInBackground(m, recipients, subject, alertBody(account, reader, note), func(err error) {
+		log.WithFields(log.Fields{
+			"account": account.Identifier,
+			"reader":  reader,
+			"type":    note.Type,
+			"error":   err,
+		}).Error("Unable to send alert email.")
+		// Don't hold back the next alert for one that was never sent.
+		a.mu.Lock()
+		for _, key := range sent {
+			if a.sent[key].Equal(now) {
+				delete(a.sent, key)
+			}
+		}
+		a.mu.Unlock()
+	})
+}
+
+// prune Forgets alerts sent longer than the throttle ago since they no longer hold anything back, sweeping
+// at most once per throttle period. The lock must be held.
+func (a *alerter) prune(now time.Time, throttle time.Duration) {
+	if now.Sub(a.pruned) < throttle {
+		return
+	}
+	for key, last := range a.sent {
+		if now.Sub(last) >= throttle {
+			delete(a.sent, key)
+		}
+	}
+	a.pruned = now
+}
+
+func alertBody(account *types.Account, reader string, note types.Notification) string {
+	description, ok := alertDescriptions[note.Type]
+	if !ok {
+		description = note.Type
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s.\n\n", description)
+	fmt.Fprintf(&body, "Reader: %s\n", reader)
+	fmt.Fprintf(&body, "Notification: %s\n", note.Type)
+	fmt.Fprintf(&body, "When: %s\n", note.When.Format(time.RFC1123Z))
+	fmt.Fprintf(&body, "Account: %s\n\n", account.Email)
+	fmt.Fprintf(&body, "This alert won't be sent again for this reader for %d minutes.\n", config.AlertThrottle)
+	return body.String()
+}
+
+func (h Handler) GetAlertRules(c *echo.Context) error {
+	var request types.GetAlertRulesRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	ruleAccount, err := managedAccount(c, account, request.Email)
+	if ruleAccount == nil {
+		return err
+	}
+	rules, err := database.GetAccountAlertRules(ruleAccount.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Alert Rules", err)
+	}
+	if rules == nil {
+		rules = make([]types.AlertRule, 0)
+	}
+	return c.JSON(http.StatusOK, types.GetAlertRulesResponse{
+		Rules: rules,
+	})
+}
+
+func (h Handler) AddAlertRule(c *echo.Context) error {
+	var request types.AddAlertRuleRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if err := request.Rule.Validate(h.validate); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
+	}
+	ruleAccount, err := managedAccount(c, account, request.Email)
+	if ruleAccount == nil {
+		return err
+	}
+	rules, err := database.GetAccountAlertRules(ruleAccount.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Alert Rules", err)
+	}
+	if len(rules) >= maxAlertRulesPerAccount {
+		return getAPIError(c, http.StatusBadRequest, "Too Many Alert Rules", fmt.Errorf("accounts are limited to %d alert rules", maxAlertRulesPerAccount))
+	}
+	rule := request.Rule.ToAlertRule()
+	rule.AccountIdentifier = ruleAccount.Identifier
+	added, err := database.AddAlertRule(rule)
+	if err != nil || added == nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Adding Alert Rule", err)
+	}
+	return c.JSON(http.StatusOK, types.ModifyAlertRuleResponse{
+		Rule: *added,
+	})
+}
+
+func (h Handler) DeleteAlertRule(c *echo.Context) error {
+	var request types.DeleteAlertRuleRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	ruleAccount, err := managedAccount(c, account, request.Email)
+	if ruleAccount == nil {
+		return err
+	}
+	rules, err := database.GetAccountAlertRules(ruleAccount.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Alert Rules", err)
+	}
+	if !slices.ContainsFunc(rules, func(rule types.AlertRule) bool { return rule.Identifier == request.Rule }) {
+		return getAPIError(c, http.StatusNotFound, "Alert Rule Not Found", nil)
+	}
+	if err := database.DeleteAlertRule(ruleAccount.Identifier, request.Rule); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Deleting Alert Rule", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
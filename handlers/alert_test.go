@@ -0,0 +1,585 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"bufio"
+	"chronokeep/remote/types"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpMessage is a message received by the SMTP stub.
+type smtpMessage struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+// smtpStub is a bare bones SMTP server that records the messages sent to it.
+type smtpStub struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []smtpMessage
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error starting SMTP stub: %v", err)
+	}
+	stub := &smtpStub{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+	return stub
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+	reply("220 localhost ESMTP stub")
+	var msg smtpMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg = smtpMessage{from: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.recipients = append(msg.recipients, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *smtpStub) received() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestAddAlertRule(t *testing.T) {
+	// POST, /r/alert/add
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	body, err := json.Marshal(types.AddAlertRuleRequest{
+		Rule: types.RequestAlertRule{
+			Types:      []string{"UPS_LOW_BATTERY"},
+			Recipients: []string{"alerts@test.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/r/alert/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddAlertRule)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	token := loginSession(t, variables.accounts[1], "")
+	// Test bad request
+	t.Log("Testing bad request.")
+	request = httptest.NewRequest(http.MethodPost, "/r/alert/add", strings.NewReader("////"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddAlertRule)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test invalid fields
+	for _, rule := range []types.RequestAlertRule{
+		{Types: []string{}, Recipients: []string{"alerts@test.com"}},
+		{Types: []string{"NOT_A_TYPE"}, Recipients: []string{"alerts@test.com"}},
+		{Types: []string{"MAX_TEMP"}, Recipients: []string{}},
+		{Types: []string{"MAX_TEMP"}, Recipients: []string{"not an email"}},
+		{Types: []string{"MAX_TEMP"}, Readers: []string{""}, Recipients: []string{"alerts@test.com"}},
+		{Types: []string{"MAX_TEMP"}, Readers: []string{"reader1,reader2"}, Recipients: []string{"alerts@test.com"}},
+	} {
+		t.Logf("Testing invalid alert rule: %+v.", rule)
+		body, err = json.Marshal(types.AddAlertRuleRequest{Rule: rule})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request = httptest.NewRequest(http.MethodPost, "/r/alert/add", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		response = httptest.NewRecorder()
+		c = e.NewContext(request, response)
+		if assert.NoError(t, h.authorize()(h.AddAlertRule)(c)) {
+			assert.Equal(t, http.StatusBadRequest, response.Code)
+		}
+	}
+	// Test not admin adding to another account
+	t.Log("Testing not admin adding to another account.")
+	body, err = json.Marshal(types.AddAlertRuleRequest{
+		Email: &variables.accounts[2].Email,
+		Rule: types.RequestAlertRule{
+			Types:      []string{"UPS_LOW_BATTERY"},
+			Recipients: []string{"alerts@test.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/alert/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddAlertRule)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	body, err = json.Marshal(types.AddAlertRuleRequest{
+		Rule: types.RequestAlertRule{
+			Types:      []string{"UPS_LOW_BATTERY", "MAX_TEMP", "UPS_LOW_BATTERY"},
+			Readers:    []string{"reader6"},
+			Recipients: []string{"alerts@test.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/alert/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddAlertRule)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ModifyAlertRuleResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEqual(t, int64(0), resp.Rule.Identifier)
+			assert.Equal(t, []string{"UPS_LOW_BATTERY", "MAX_TEMP"}, resp.Rule.Types)
+			assert.Equal(t, []string{"reader6"}, resp.Rule.Readers)
+			assert.Equal(t, []string{"alerts@test.com"}, resp.Rule.Recipients)
+		}
+	}
+	rules, err := database.GetAccountAlertRules(variables.accounts[1].Identifier)
+	if assert.NoError(t, err) {
+		assert.Len(t, rules, 1)
+	}
+	// Test admin adding to another account
+	t.Log("Testing admin adding to another account.")
+	adminToken := loginSession(t, variables.accounts[0], "")
+	body, err = json.Marshal(types.AddAlertRuleRequest{
+		Email: &variables.accounts[2].Email,
+		Rule: types.RequestAlertRule{
+			Types:      []string{"SHUTTING_DOWN"},
+			Recipients: []string{"alerts@test.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/alert/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddAlertRule)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	rules, err = database.GetAccountAlertRules(variables.accounts[2].Identifier)
+	if assert.NoError(t, err) {
+		assert.Len(t, rules, 1)
+	}
+	// Test too many alert rules
+	t.Log("Testing too many alert rules.")
+	for i := 1; i < maxAlertRulesPerAccount; i++ {
+		_, err := database.AddAlertRule(types.AlertRule{
+			AccountIdentifier: variables.accounts[1].Identifier,
+			Types:             []string{"MAX_TEMP"},
+			Recipients:        []string{"alerts" + strconv.Itoa(i) + "@test.com"},
+		})
+		if err != nil {
+			t.Fatalf("Error adding test alert rule: %v", err)
+		}
+	}
+	body, err = json.Marshal(types.AddAlertRuleRequest{
+		Rule: types.RequestAlertRule{
+			Types:      []string{"MAX_TEMP"},
+			Recipients: []string{"alerts@test.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/alert/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddAlertRule)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+func TestGetAlertRules(t *testing.T) {
+	// POST, /r/alert
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	request := httptest.NewRequest(http.MethodPost, "/r/alert", strings.NewReader(""))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetAlertRules)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	token := loginSession(t, variables.accounts[1], "")
+	// Test no alert rules
+	t.Log("Testing no alert rules.")
+	request = httptest.NewRequest(http.MethodPost, "/r/alert", strings.NewReader(""))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetAlertRules)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetAlertRulesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotNil(t, resp.Rules)
+			assert.Empty(t, resp.Rules)
+		}
+	}
+	for _, account := range variables.accounts[1:] {
+		_, err := database.AddAlertRule(types.AlertRule{
+			AccountIdentifier: account.Identifier,
+			Types:             []string{"MAX_TEMP"},
+			Recipients:        []string{account.Email},
+		})
+		if err != nil {
+			t.Fatalf("Error adding test alert rule: %v", err)
+		}
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	request = httptest.NewRequest(http.MethodPost, "/r/alert", strings.NewReader(""))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetAlertRules)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetAlertRulesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Rules, 1) {
+				assert.Equal(t, []string{variables.accounts[1].Email}, resp.Rules[0].Recipients)
+			}
+		}
+	}
+	// Test not admin getting another account's alert rules
+	t.Log("Testing not admin getting another account's alert rules.")
+	body, err := json.Marshal(types.GetAlertRulesRequest{
+		Email: &variables.accounts[2].Email,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/alert", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetAlertRules)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test admin getting another account's alert rules
+	t.Log("Testing admin getting another account's alert rules.")
+	request = httptest.NewRequest(http.MethodPost, "/r/alert", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+loginSession(t, variables.accounts[0], ""))
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetAlertRules)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetAlertRulesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Rules, 1) {
+				assert.Equal(t, []string{variables.accounts[2].Email}, resp.Rules[0].Recipients)
+			}
+		}
+	}
+}
+
+func TestDeleteAlertRule(t *testing.T) {
+	// DELETE, /r/alert/delete
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	rule, err := database.AddAlertRule(types.AlertRule{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		Types:             []string{"MAX_TEMP"},
+		Recipients:        []string{"alerts@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test alert rule: %v", err)
+	}
+	body, err := json.Marshal(types.DeleteAlertRuleRequest{
+		Rule: rule.Identifier,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	request := httptest.NewRequest(http.MethodDelete, "/r/alert/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteAlertRule)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test another account's alert rule
+	t.Log("Testing another account's alert rule.")
+	request = httptest.NewRequest(http.MethodDelete, "/r/alert/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+loginSession(t, variables.accounts[2], ""))
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteAlertRule)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	token := loginSession(t, variables.accounts[1], "")
+	request = httptest.NewRequest(http.MethodDelete, "/r/alert/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteAlertRule)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	rules, err := database.GetAccountAlertRules(variables.accounts[1].Identifier)
+	if assert.NoError(t, err) {
+		assert.Empty(t, rules)
+	}
+	// Test already deleted
+	t.Log("Testing already deleted.")
+	request = httptest.NewRequest(http.MethodDelete, "/r/alert/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteAlertRule)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+}
+
+func TestAlertEmails(t *testing.T) {
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	stub := newSMTPStub(t)
+	defer stub.listener.Close()
+	alerts = &alerter{sent: make(map[string]time.Time)}
+	config.SMTPHost = "127.0.0.1"
+	config.SMTPPort = stub.port()
+	config.SMTPFrom = "chronokeep@test.com"
+	config.AlertThrottle = 15
+	mailer = newSMTPMailer(config)
+	_, err := database.AddAlertRule(types.AlertRule{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		Types:             []string{"UPS_LOW_BATTERY", "MAX_TEMP"},
+		Readers:           []string{"reader6"},
+		Recipients:        []string{"alerts@test.com", "tech@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test alert rule: %v", err)
+	}
+	_, err = database.AddAlertRule(types.AlertRule{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		Types:             []string{"MAX_TEMP"},
+		Recipients:        []string{"tech@test.com", "oncall@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test alert rule: %v", err)
+	}
+	saveNotification := func(noteType string, when time.Time) {
+		body, err := json.Marshal(types.SaveNotificationRequest{
+			Note: types.RequestNotification{
+				Type: noteType,
+				When: when.UTC().Format(time.RFC3339),
+			},
+		})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request := httptest.NewRequest(http.MethodPost, "/notifications/save", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write2"])
+		response := httptest.NewRecorder()
+		c := e.NewContext(request, response)
+		if assert.NoError(t, h.SaveNotification(c)) {
+			assert.Equal(t, http.StatusOK, response.Code)
+		}
+		waitForMail()
+	}
+	// Notifications without a matching rule aren't emailed.
+	t.Log("Testing notification without a matching rule.")
+	// The test setup already saved a notification for the reader at the current time.
+	now := time.Now().Add(time.Minute)
+	saveNotification("UPS_ONLINE", now)
+	assert.Empty(t, stub.received())
+	// Matching notifications are emailed to everyone on the matching rules once.
+	t.Log("Testing matching notification.")
+	saveNotification("MAX_TEMP", now.Add(time.Second))
+	messages := stub.received()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "chronokeep@test.com", messages[0].from)
+		assert.ElementsMatch(t, []string{"alerts@test.com", "tech@test.com", "oncall@test.com"}, messages[0].recipients)
+		assert.Contains(t, messages[0].data, "Subject: Chronokeep alert for reader6: MAX_TEMP\r\n")
+		assert.Contains(t, messages[0].data, "Account: "+variables.accounts[1].Email)
+	}
+	t.Log("Testing single matching rule.")
+	saveNotification("UPS_LOW_BATTERY", now.Add(time.Second*2))
+	messages = stub.received()
+	if assert.Len(t, messages, 2) {
+		assert.ElementsMatch(t, []string{"alerts@test.com", "tech@test.com"}, messages[1].recipients)
+		assert.Contains(t, messages[1].data, "UPS battery is low")
+	}
+	// Repeats are throttled.
+	t.Log("Testing repeated notification.")
+	saveNotification("MAX_TEMP", now.Add(time.Second*3))
+	assert.Len(t, stub.received(), 2)
+	// Until the throttle period has passed.
+	t.Log("Testing repeated notification after the throttle period.")
+	alerts.mu.Lock()
+	for key := range alerts.sent {
+		alerts.sent[key] = alerts.sent[key].Add(-time.Minute * time.Duration(config.AlertThrottle))
+	}
+	alerts.mu.Unlock()
+	saveNotification("MAX_TEMP", now.Add(time.Second*4))
+	assert.Len(t, stub.received(), 3)
+	// Failed emails don't count towards the throttle.
+	t.Log("Testing unreachable SMTP server.")
+	config.SMTPPort = 1
+	mailer = newSMTPMailer(config)
+	saveNotification("SHUTTING_DOWN", now.Add(time.Second*5))
+	_, err = database.AddAlertRule(types.AlertRule{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		Types:             []string{"SHUTTING_DOWN"},
+		Recipients:        []string{"oncall@test.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test alert rule: %v", err)
+	}
+	saveNotification("SHUTTING_DOWN", now.Add(time.Second*6))
+	assert.Len(t, stub.received(), 3)
+	config.SMTPPort = stub.port()
+	mailer = newSMTPMailer(config)
+	saveNotification("SHUTTING_DOWN", now.Add(time.Second*7))
+	messages = stub.received()
+	if assert.Len(t, messages, 4) {
+		assert.Equal(t, []string{"oncall@test.com"}, messages[3].recipients)
+	}
+	// No emails are sent without an SMTP server.
+	t.Log("Testing no SMTP server.")
+	mailer = nil
+	saveNotification("UPS_LOW_BATTERY", now.Add(time.Hour))
+	assert.Len(t, stub.received(), 4)
+}
+
+func TestAlertThrottlePruning(t *testing.T) {
+	now := time.Now()
+	a := &alerter{sent: map[string]time.Time{
+		"1|reader1|MAX_TEMP": now.Add(-time.Minute * 20),
+		"1|reader2|MAX_TEMP": now.Add(-time.Minute * 5),
+	}}
+	// Alerts sent before the throttle period are forgotten.
+	a.prune(now, time.Minute*15)
+	assert.Equal(t, map[string]time.Time{"1|reader2|MAX_TEMP": now.Add(-time.Minute * 5)}, a.sent)
+	// The sent alerts are only swept once per throttle period.
+	a.sent["1|reader3|MAX_TEMP"] = now.Add(-time.Hour)
+	a.prune(now.Add(time.Minute), time.Minute*15)
+	assert.Len(t, a.sent, 2)
+	a.prune(now.Add(time.Minute*15), time.Minute*15)
+	assert.Empty(t, a.sent)
+}
@@ -0,0 +1,91 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/archive"
+	"chronokeep/remote/types"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// archiveContentType is the content type of account archives, which are newline delimited JSON.
+const archiveContentType = "application/x-ndjson"
+
+func (h Handler) ExportAccount(c *echo.Context) error {
+	var request types.ExportAccountRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if request.Email != nil && account.Email != *request.Email && !hasPermission(account, types.PermAccountsManage) {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("missing permission / ownership error"))
+	}
+	email := account.Email
+	if request.Email != nil {
+		email = *request.Email
+	}
+	c.Response().Header().Set(echo.HeaderContentType, archiveContentType)
+	c.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", strings.ReplaceAll(email, "@", "_at_")+"-"+time.Now().Format("20060102")+".ndjson"),
+	)
+	err := archive.Export(database, email, c.Response())
+	if err == nil {
+		return nil
+	}
+	// Once the archive has started there's no way to tell the client it failed other than cutting it short.
+	if response, uErr := echo.UnwrapResponse(c.Response()); uErr == nil && response.Committed {
+		log.WithFields(log.Fields{
+			"account": email,
+			"error":   err,
+		}).Error("Unable to finish account export.")
+		return nil
+	}
+	c.Response().Header().Del(echo.HeaderContentDisposition)
+	if errors.Is(err, archive.ErrAccountNotFound) {
+		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	return getAPIError(c, http.StatusInternalServerError, "Unable To Export Account", err)
+}
+
+func (h Handler) ImportAccount(c *echo.Context) error {
+	imported, err := archive.Import(database, c.Request().Body)
+	if err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Unable To Import Account", err)
+	}
+	log.WithFields(log.Fields{
+		"account":       imported.Account.Email,
+		"created":       imported.Created,
+		"imported_by":   authorizedAccount(c).Email,
+		"keys":          len(imported.Keys),
+		"reads":         imported.Reads,
+		"notifications": imported.Notifications,
+	}).Info("Account imported.")
+	return c.JSON(http.StatusOK, types.ImportAccountResponse{
+		Imported: *imported,
+	})
+}
@@ -0,0 +1,161 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"chronokeep/remote/archive"
+	"chronokeep/remote/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// archiveRecords Counts the records of each type in an account archive after checking its header.
+func archiveRecords(t *testing.T, body []byte) map[string]int {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	var header types.ArchiveHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil {
+		t.Fatal("Expected archive to start with a header.")
+	}
+	assert.Equal(t, types.ArchiveFormat, header.Format)
+	assert.Equal(t, types.ArchiveVersion, header.Version)
+	out := make(map[string]int)
+	for scanner.Scan() {
+		var record types.ArchiveRecord
+		if assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record)) {
+			out[record.Type]++
+		}
+	}
+	return out
+}
+
+func TestExportAccount(t *testing.T) {
+	// POST, /r/account/export
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	adminToken := loginSession(t, variables.accounts[0], "")
+	userToken := loginSession(t, variables.accounts[2], "")
+	// Test another account without permission
+	t.Log("Testing another account without permission.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/account/export", userToken, types.ExportAccountRequest{Email: &variables.accounts[1].Email})
+	if assert.NoError(t, h.authorize()(h.ExportAccount)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test own account
+	t.Log("Testing own account.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/export", userToken, types.ExportAccountRequest{})
+	if assert.NoError(t, h.authorize()(h.ExportAccount)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, archiveContentType, response.Header().Get(echo.HeaderContentType))
+		keys, _ := database.GetAccountKeys(variables.accounts[2].Email)
+		records := archiveRecords(t, response.Body.Bytes())
+		assert.Equal(t, 1, records[types.ArchiveRecordAccount])
+		assert.Equal(t, len(keys), records[types.ArchiveRecordKey])
+	}
+	// Test unknown account
+	t.Log("Testing unknown account.")
+	unknown := "unknown@test.com"
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/export", adminToken, types.ExportAccountRequest{Email: &unknown})
+	if assert.NoError(t, h.authorize()(h.ExportAccount)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Empty(t, response.Header().Get(echo.HeaderContentDisposition))
+	}
+	// Test another account
+	t.Log("Testing another account.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/export", adminToken, types.ExportAccountRequest{Email: &variables.accounts[1].Email})
+	if assert.NoError(t, h.authorize()(h.ExportAccount)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.NotEmpty(t, response.Header().Get(echo.HeaderContentDisposition))
+		keys, _ := database.GetAccountKeys(variables.accounts[1].Email)
+		records := archiveRecords(t, response.Body.Bytes())
+		assert.Equal(t, len(keys), records[types.ArchiveRecordKey])
+	}
+}
+
+func TestImportAccount(t *testing.T) {
+	// POST, /r/account/import
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	adminToken := loginSession(t, variables.accounts[0], "")
+	userToken := loginSession(t, variables.accounts[2], "")
+	account := variables.accounts[1]
+	keys, _ := database.GetAccountKeys(account.Email)
+	var buf bytes.Buffer
+	if err := archive.Export(database, account.Email, &buf); err != nil {
+		t.Fatalf("Error exporting account: %v", err)
+	}
+	records := archiveRecords(t, buf.Bytes())
+	if _, err := database.PurgeAccount(account.Identifier, false); err != nil {
+		t.Fatalf("Error purging account: %v", err)
+	}
+	// Test account without permission
+	t.Log("Testing account without permission.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/account/import", userToken, buf.String())
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.ImportAccount)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test invalid archive
+	t.Log("Testing invalid archive.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/import", adminToken, "{\"format\":\"other\",\"version\":1}\n")
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.ImportAccount)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/import", adminToken, buf.String())
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.ImportAccount)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ImportAccountResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.True(t, resp.Imported.Created)
+			assert.Equal(t, account.Email, resp.Imported.Account.Email)
+			assert.Len(t, resp.Imported.Keys, len(keys))
+			assert.Empty(t, resp.Imported.RenamedKeys)
+			assert.Equal(t, int64(records[types.ArchiveRecordRead]), resp.Imported.Reads)
+			assert.Equal(t, int64(records[types.ArchiveRecordNotification]), resp.Imported.Notifications)
+			for _, key := range resp.Imported.Keys {
+				assert.NotEmpty(t, key.Value)
+			}
+		}
+	}
+	imported, err := database.GetAccount(account.Email)
+	if assert.NoError(t, err) && assert.NotNil(t, imported) {
+		assert.NotEqual(t, account.Identifier, imported.Identifier)
+	}
+	// Test importing again renames the keys
+	t.Log("Testing import into an existing account.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/import", adminToken, buf.String())
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.ImportAccount)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ImportAccountResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.False(t, resp.Imported.Created)
+			assert.Len(t, resp.Imported.RenamedKeys, len(keys))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// authorizedAccountKey is where authorize keeps the account on the context.
+	authorizedAccountKey = "authorized_account"
+	// authorizedSessionKey is where authorize keeps the session the token belongs to on the context.
+	authorizedSessionKey = "authorized_session"
+)
+
+// authorize Returns middleware only letting requests through with the token of an unlocked account whose
+// role has every permission given. The account and its session are kept on the context for the handler, see
+// authorizedAccount and authorizedSession.
+func (h Handler) authorize(permissions ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			account, session, err := verifySession(c.Request())
+			if err != nil {
+				return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
+			}
+			if account.Locked {
+				return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
+			}
+			for _, permission := range permissions {
+				if !hasPermission(account, permission) {
+					return getAPIError(c, http.StatusUnauthorized, "Unauthorized", fmt.Errorf("missing permission '%s'", permission))
+				}
+			}
+			c.Set(authorizedAccountKey, account)
+			c.Set(authorizedSessionKey, session)
+			return next(c)
+		}
+	}
+}
+
+// authorizedAccount Returns the account authorize let through.
+func authorizedAccount(c *echo.Context) *types.Account {
+	account, _ := c.Get(authorizedAccountKey).(*types.Account)
+	return account
+}
+
+// authorizedSession Returns the session of the token authorize let through.
+func authorizedSession(c *echo.Context) *types.Session {
+	session, _ := c.Get(authorizedSessionKey).(*types.Session)
+	return session
+}
+
+// hasPermission Checks if the account's role grants a permission. Accounts without a known role have no
+// permissions, as does every account when the role can't be retrieved.
+func hasPermission(account *types.Account, permission string) bool {
+	role, err := database.GetRole(account.Type)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"role":  account.Type,
+			"error": err,
+		}).Error("Unable to retrieve role.")
+		return false
+	}
+	return role != nil && role.Can(permission)
+}
+
+// accountRole Returns the role an account is being given. Accounts can only be given roles that exist.
+// If nil is returned an error response has already been written and the error is the result of writing it.
+func accountRole(c *echo.Context, name string) (*types.Role, error) {
+	role, err := database.GetRole(name)
+	if err != nil {
+		return nil, getAPIError(c, http.StatusInternalServerError, "Error Retrieving Role", err)
+	}
+	if role == nil {
+		return nil, getAPIError(c, http.StatusBadRequest, "Unknown Role", fmt.Errorf("role '%s' does not exist", name))
+	}
+	return role, nil
+}
+
+// accountPermissions Returns the permissions the account's role grants.
+func accountPermissions(account *types.Account) ([]string, error) {
+	role, err := database.GetRole(account.Type)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return make([]string, 0), nil
+	}
+	return role.Permissions, nil
+}
@@ -19,6 +19,8 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package handlers
 
 import (
+	"chronokeep/remote/types"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v5"
 )
@@ -31,33 +33,82 @@ type Handler struct {
 func (h Handler) Bind(group *echo.Group) {
 	// Read handlers
 	group.GET("/reads", h.GetReads)
+	group.GET("/reads/stream", h.StreamReads)
 	group.POST("/reads/add", h.AddReads)
 	group.DELETE("/reads/delete", h.DeleteReads)
 	// Reader handler(s)
 	group.GET("/readers", h.GetReaders)
 	// Account Login
 	group.POST("/account/login", h.Login)
+	group.POST("/account/login/2fa", h.LoginTwoFactor)
 	group.POST("/account/refresh", h.Refresh)
+	group.POST("/account/password/forgot", h.ForgotPassword)
+	group.POST("/account/password/reset", h.ResetPassword)
+	// Keys used to verify tokens
+	group.GET("/.well-known/jwks.json", h.GetJWKS)
 	// Notification handlers
 	group.POST("/notifications/save", h.SaveNotification)
 	group.GET("/notifications/get", h.GetNotifications)
+	group.GET("/notifications/history", h.GetNotificationHistory)
+	group.POST("/notifications/acknowledge", h.AcknowledgeNotifications)
 }
 
 func (h Handler) BindRestricted(group *echo.Group) {
 	// Account handlers
-	group.POST("/account", h.GetAccount)
-	group.GET("/account/all", h.GetAccounts)
-	group.POST("/account/logout", h.Logout)
-	group.POST("/account/add", h.AddAccount)
-	group.PUT("/account/update", h.UpdateAccount)
-	group.PUT("/account/password", h.ChangePassword)
-	group.PUT("/account/email", h.ChangeEmail)
-	group.POST("/account/unlock", h.Unlock)
-	group.DELETE("/account/delete", h.DeleteAccount)
+	group.POST("/account", h.GetAccount, h.authorize())
+	group.GET("/account/all", h.GetAccounts, h.authorize(types.PermAccountsView))
+	group.POST("/account/logout", h.Logout, h.authorize())
+	group.POST("/account/add", h.AddAccount, h.authorize(types.PermAccountsManage))
+	group.PUT("/account/update", h.UpdateAccount, h.authorize())
+	group.PUT("/account/password", h.ChangePassword, h.authorize())
+	group.PUT("/account/email", h.ChangeEmail, h.authorize(types.PermAccountsManage))
+	group.POST("/account/unlock", h.Unlock, h.authorize(types.PermAccountsUnlock))
+	group.DELETE("/account/delete", h.DeleteAccount, h.authorize())
+	group.GET("/account/deleted", h.GetDeletedAccounts, h.authorize(types.PermAccountsManage))
+	group.POST("/account/restore", h.RestoreAccount, h.authorize(types.PermAccountsManage))
+	group.DELETE("/account/purge", h.PurgeAccount, h.authorize(types.PermAccountsManage))
+	group.POST("/account/export", h.ExportAccount, h.authorize())
+	group.POST("/account/import", h.ImportAccount, h.authorize(types.PermAccountsManage))
+	group.GET("/account/sessions", h.GetSessions, h.authorize())
+	group.DELETE("/account/session", h.RevokeSession, h.authorize())
+	group.DELETE("/account/sessions/others", h.RevokeOtherSessions, h.authorize())
+	// Two-factor handlers
+	group.POST("/account/2fa", h.GetTwoFactor, h.authorize())
+	group.POST("/account/2fa/setup", h.SetupTwoFactor, h.authorize())
+	group.POST("/account/2fa/enable", h.EnableTwoFactor, h.authorize())
+	group.POST("/account/2fa/recovery", h.RegenerateRecoveryCodes, h.authorize())
+	group.DELETE("/account/2fa/disable", h.DisableTwoFactor, h.authorize())
+	group.POST("/account/2fa/require", h.RequireTwoFactor, h.authorize(types.PermAccountsManage))
 	// Key handlers
-	group.POST("/key", h.GetKeys)
-	group.POST("/key/add", h.AddKey)
-	group.PUT("/key/update", h.UpdateKey)
-	group.DELETE("/key/delete", h.DeleteKey)
+	group.POST("/key", h.GetKeys, h.authorize())
+	group.POST("/key/add", h.AddKey, h.authorize(types.PermKeysCreate))
+	group.PUT("/key/update", h.UpdateKey, h.authorize())
+	group.DELETE("/key/delete", h.DeleteKey, h.authorize())
+	group.POST("/key/rotate", h.RotateKey, h.authorize())
+	group.POST("/key/deleted", h.GetDeletedKeys, h.authorize(types.PermKeysManage))
+	group.POST("/key/restore", h.RestoreKey, h.authorize(types.PermKeysManage))
+	// Organization handlers
+	group.POST("/organization", h.GetOrganizations, h.authorize())
+	group.POST("/organization/add", h.AddOrganization, h.authorize())
+	group.PUT("/organization/update", h.UpdateOrganization, h.authorize())
+	group.DELETE("/organization/delete", h.DeleteOrganization, h.authorize())
+	group.POST("/organization/members", h.GetOrganizationMembers, h.authorize())
+	group.POST("/organization/member", h.SetOrganizationMember, h.authorize())
+	group.DELETE("/organization/member", h.RemoveOrganizationMember, h.authorize())
+	// Webhook handlers
+	group.POST("/webhook", h.GetWebhooks, h.authorize())
+	group.POST("/webhook/add", h.AddWebhook, h.authorize())
+	group.DELETE("/webhook/delete", h.DeleteWebhook, h.authorize())
+	group.POST("/webhook/deliveries", h.GetWebhookDeliveries, h.authorize())
+	// Alert rule handlers
+	group.POST("/alert", h.GetAlertRules, h.authorize())
+	group.POST("/alert/add", h.AddAlertRule, h.authorize())
+	group.DELETE("/alert/delete", h.DeleteAlertRule, h.authorize())
+	// Retention handlers
+	group.GET("/retention", h.GetRetention, h.authorize(types.PermRetentionView))
+	// Role handlers
+	group.GET("/role", h.GetRoles, h.authorize(types.PermRolesManage))
+	group.POST("/role/save", h.SaveRole, h.authorize(types.PermRolesManage))
+	group.DELETE("/role/delete", h.DeleteRole, h.authorize(types.PermRolesManage))
 }
 
@@ -0,0 +1,37 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+)
+
+func (h Handler) GetJWKS(c *echo.Context) error {
+	keys := make([]types.JSONWebKey, 0, len(signingKeys))
+	for _, key := range signingKeys {
+		keys = append(keys, key.JWK())
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(http.StatusOK, types.JSONWebKeySet{
+		Keys: keys,
+	})
+}
@@ -0,0 +1,260 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/auth"
+	"chronokeep/remote/types"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSigningKey Writes a new Ed25519 or RSA private key to a PEM file and returns the path to it.
+func writeSigningKey(t *testing.T, keyType string) string {
+	var key any
+	var err error
+	switch keyType {
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, auth.MinRSAKeyBits)
+	}
+	if err != nil {
+		t.Fatalf("Error generating %s key: %v", keyType, err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error encoding %s key: %v", keyType, err)
+	}
+	file, err := os.CreateTemp(t.TempDir(), keyType+"-*.pem")
+	if err != nil {
+		t.Fatalf("Error creating key file: %v", err)
+	}
+	defer file.Close()
+	if err = pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		t.Fatalf("Error writing key file: %v", err)
+	}
+	return file.Name()
+}
+
+// useSigningKeys Sets the keys tokens are signed with for a test.
+func useSigningKeys(t *testing.T, paths ...string) {
+	keys, err := auth.LoadSigningKeys(paths)
+	if err != nil {
+		t.Fatalf("Error loading signing keys: %v", err)
+	}
+	signingKeys = keys
+}
+
+// getJWKS Gets the published key set.
+func getJWKS(t *testing.T, h Handler) types.JSONWebKeySet {
+	request := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	response := httptest.NewRecorder()
+	c := echo.New().NewContext(request, response)
+	if err := h.GetJWKS(c); err != nil || response.Code != http.StatusOK {
+		t.Fatalf("Error getting key set: %v (%v)", err, response.Code)
+	}
+	var keySet types.JSONWebKeySet
+	if err := json.Unmarshal(response.Body.Bytes(), &keySet); err != nil {
+		t.Fatalf("Error decoding key set: %v", err)
+	}
+	return keySet
+}
+
+func TestGetJWKS(t *testing.T) {
+	// GET, /.well-known/jwks.json
+	_, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	// Test no signing keys
+	t.Log("Testing no signing keys.")
+	keySet := getJWKS(t, h)
+	assert.NotNil(t, keySet.Keys)
+	assert.Empty(t, keySet.Keys)
+	// Test signing keys
+	t.Log("Testing signing keys.")
+	useSigningKeys(t, writeSigningKey(t, "ed25519"), writeSigningKey(t, "rsa"))
+	keySet = getJWKS(t, h)
+	if assert.Len(t, keySet.Keys, 2) {
+		assert.Equal(t, signingKeys[0].ID, keySet.Keys[0].KeyID)
+		assert.Equal(t, "OKP", keySet.Keys[0].KeyType)
+		assert.Equal(t, "Ed25519", keySet.Keys[0].Curve)
+		assert.Equal(t, "EdDSA", keySet.Keys[0].Algorithm)
+		assert.NotEmpty(t, keySet.Keys[0].X)
+		assert.Equal(t, signingKeys[1].ID, keySet.Keys[1].KeyID)
+		assert.Equal(t, "RSA", keySet.Keys[1].KeyType)
+		assert.Equal(t, "RS256", keySet.Keys[1].Algorithm)
+		assert.NotEmpty(t, keySet.Keys[1].N)
+		assert.Equal(t, "AQAB", keySet.Keys[1].E)
+		for _, key := range keySet.Keys {
+			assert.Equal(t, "sig", key.Use)
+		}
+	}
+	// Test the first key has to be able to sign
+	t.Log("Testing public key first.")
+	der, err := x509.MarshalPKIXPublicKey(signingKeys[0].Public)
+	if err != nil {
+		t.Fatalf("Error encoding public key: %v", err)
+	}
+	public := filepath.Join(t.TempDir(), "public.pem")
+	if err = os.WriteFile(public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Error writing public key: %v", err)
+	}
+	_, err = auth.LoadSigningKeys([]string{public})
+	assert.Error(t, err)
+	keys, err := auth.LoadSigningKeys([]string{writeSigningKey(t, "rsa"), public})
+	if assert.NoError(t, err) && assert.Len(t, keys, 2) {
+		assert.Nil(t, keys[1].Private)
+		assert.Equal(t, signingKeys[0].ID, keys[1].ID)
+	}
+}
+
+func TestSigningKeyTokens(t *testing.T) {
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	account := variables.accounts[0]
+	// Tokens issued with the shared secret before signing keys were set up.
+	oldToken := loginSession(t, account, "")
+	oldKey := writeSigningKey(t, "ed25519")
+	useSigningKeys(t, oldKey)
+	// Test the token header names the key and other services can verify it with the published key
+	t.Log("Testing token signed with a signing key.")
+	token := loginSession(t, account, "")
+	keySet := getJWKS(t, h)
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		for _, key := range keySet.Keys {
+			if key.KeyID == token.Header["kid"] {
+				x, err := base64.RawURLEncoding.DecodeString(key.X)
+				return ed25519.PublicKey(x), err
+			}
+		}
+		return nil, jwt.ErrTokenUnverifiable
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, signingKeys[0].ID, parsed.Header["kid"])
+		claims := parsed.Claims.(jwt.MapClaims)
+		assert.Equal(t, account.Email, claims["email"])
+	}
+	for _, tok := range []string{oldToken, token} {
+		request := httptest.NewRequest(http.MethodGet, "/r/account/sessions", nil)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+tok)
+		response := httptest.NewRecorder()
+		c := e.NewContext(request, response)
+		if assert.NoError(t, h.authorize()(h.GetSessions)(c)) {
+			assert.Equal(t, http.StatusOK, response.Code)
+		}
+	}
+	// Test refresh tokens work and can't be used as access tokens, or access tokens as refresh tokens
+	t.Log("Testing refresh token.")
+	access, refresh, err := createTokens(account.Email)
+	if err != nil {
+		t.Fatalf("Error creating tokens: %v", err)
+	}
+	_, err = database.AddSession(types.Session{
+		AccountIdentifier: account.Identifier,
+		Token:             *access,
+		RefreshToken:      *refresh,
+	})
+	if err != nil {
+		t.Fatalf("Error adding session: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "/r/account/sessions", nil)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+*refresh)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetSessions)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	for _, tc := range []struct {
+		token  string
+		status int
+	}{
+		{*access, http.StatusUnauthorized},
+		{*refresh, http.StatusOK},
+	} {
+		body, err := json.Marshal(types.RefreshTokenRequest{
+			RefreshToken: tc.token,
+		})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request = httptest.NewRequest(http.MethodPost, "/account/refresh", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		response = httptest.NewRecorder()
+		c = e.NewContext(request, response)
+		if assert.NoError(t, h.Refresh(c)) {
+			assert.Equal(t, tc.status, response.Code)
+		}
+	}
+	// Test rotation, tokens signed with the old key work while it's still listed
+	t.Log("Testing key rotation.")
+	useSigningKeys(t, writeSigningKey(t, "rsa"), oldKey)
+	newToken := loginSession(t, account, "")
+	for _, tok := range []string{token, newToken} {
+		request = httptest.NewRequest(http.MethodGet, "/r/account/sessions", nil)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+tok)
+		response = httptest.NewRecorder()
+		c = e.NewContext(request, response)
+		if assert.NoError(t, h.authorize()(h.GetSessions)(c)) {
+			assert.Equal(t, http.StatusOK, response.Code)
+		}
+	}
+	parsed, _, err = jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "RS256", parsed.Header["alg"])
+		assert.Equal(t, signingKeys[0].ID, parsed.Header["kid"])
+	}
+	// Test removed keys and shared secret tokens without a secret
+	t.Log("Testing removed key.")
+	signingKeys = signingKeys[:1]
+	config.SecretKey = ""
+	for _, tc := range []struct {
+		token  string
+		status int
+	}{
+		{token, http.StatusUnauthorized},
+		{oldToken, http.StatusUnauthorized},
+		{newToken, http.StatusOK},
+	} {
+		request = httptest.NewRequest(http.MethodGet, "/r/account/sessions", nil)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+tc.token)
+		response = httptest.NewRecorder()
+		c = e.NewContext(request, response)
+		if assert.NoError(t, h.authorize()(h.GetSessions)(c)) {
+			assert.Equal(t, tc.status, response.Code)
+		}
+	}
+}
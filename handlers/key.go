@@ -23,28 +23,40 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v5"
 )
 
+const (
+	maxRotationGracePeriod = time.Hour * 24 * 7
+)
+
 func (h Handler) GetKeys(c *echo.Context) error {
 	var request types.GetKeysRequest
 	err := c.Bind(&request)
 	if err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
+	account := authorizedAccount(c)
+	if request.Organization != nil {
+		org, err := memberOrganization(c, account, *request.Organization)
+		if org == nil {
+			return err
+		}
+		keys, err := database.GetOrganizationKeys(org.Identifier)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Keys", err)
+		}
+		return c.JSON(http.StatusOK, types.GetKeysResponse{
+			Keys: keys,
+		})
 	}
-	if account.Type != "admin" && request.Email != nil && account.Email != *request.Email {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("not admin / ownership error"))
+	if request.Email != nil && account.Email != *request.Email && !hasPermission(account, types.PermKeysManage) {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("missing permission / ownership error"))
 	}
-	email := account.Email
+	accountid := account.Identifier
 	if request.Email != nil {
 		keyAccount, err := database.GetAccount(*request.Email)
 		if err != nil {
@@ -53,9 +65,10 @@ func (h Handler) GetKeys(c *echo.Context) error {
 		if keyAccount == nil {
 			return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
 		}
-		email = keyAccount.Email
+		accountid = keyAccount.Identifier
 	}
-	keys, err := database.GetAccountKeys(email)
+	// Members see their own keys along with the keys of their organizations.
+	keys, err := database.GetMemberKeys(accountid)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Keys", err)
 	}
@@ -70,25 +83,31 @@ func (h Handler) AddKey(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	// Verifying token.
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
+	// The token was verified when authorizing the request.
+	account := authorizedAccount(c)
 	// Validating key.
 	if err := request.Key.Validate(h.validate); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
 	}
-	// Checking for admin or ownership.
-	if account.Type != "admin" && request.Email != nil && account.Email != *request.Email {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("not admin / ownership error"))
+	if request.Email != nil && request.Organization != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("key can't be added to an account and an organization"))
+	}
+	// Checking for permission or ownership.
+	if request.Email != nil && account.Email != *request.Email && !hasPermission(account, types.PermKeysManage) {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("missing permission / ownership error"))
 	}
 	// If email is set we add a key to that account, otherwise add it to the calling person's account.
+	// Organization keys are kept under the account that created the organization.
 	accountid := account.Identifier
-	if request.Email != nil {
+	var orgid *int64
+	if request.Organization != nil {
+		org, err := memberOrganization(c, account, *request.Organization, types.OrgRoleOwner, types.OrgRoleManager)
+		if org == nil {
+			return err
+		}
+		accountid = org.AccountIdentifier
+		orgid = &org.Identifier
+	} else if request.Email != nil {
 		// Getting key account holder for id value.
 		keyAccount, err := database.GetAccount(*request.Email)
 		if err != nil {
@@ -99,6 +118,14 @@ func (h Handler) AddKey(c *echo.Context) error {
 		}
 		accountid = keyAccount.Identifier
 	}
+	// Organization keys are kept under an account so they share its key names, deleted keys included.
+	taken, err := keyNameTaken(accountid, strings.TrimSpace(request.Key.Name))
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Keys", err)
+	}
+	if taken {
+		return getAPIError(c, http.StatusConflict, "Key Name In Use", nil)
+	}
 	// Adding key to database.
 	// Create new API Key for our key to add.
 	newKey, err := uuid.NewRandom()
@@ -107,9 +134,11 @@ func (h Handler) AddKey(c *echo.Context) error {
 	}
 	key, err := database.AddKey(types.Key{
 		AccountIdentifier: accountid,
+		Organization:      orgid,
 		Name:              strings.TrimSpace(request.Key.Name),
 		Value:             newKey.String(),
 		Type:              request.Key.Type,
+		AllowedHosts:      request.Key.ToKey().AllowedHosts,
 		ValidUntil:        request.Key.GetValidUntil(),
 	})
 	if err != nil || key == nil {
@@ -125,13 +154,7 @@ func (h Handler) DeleteKey(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
+	account := authorizedAccount(c)
 	if len(request.Key) < 1 {
 		return getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("no key specified"))
 	}
@@ -143,8 +166,12 @@ func (h Handler) DeleteKey(c *echo.Context) error {
 	if multiKey == nil || multiKey.Key == nil || multiKey.Account == nil {
 		return getAPIError(c, http.StatusNotFound, "Key Not Found", nil)
 	}
-	// Deny access to non admins who do not own the key
-	if account.Type != "admin" && account.Email != multiKey.Account.Email {
+	// Deny access to non admins who do not own the key or manage its organization
+	allowed, err := canManageKey(account, multiKey.Key)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organization", err)
+	}
+	if !allowed {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("not an admin / ownership error"))
 	}
 	err = database.DeleteKey(*multiKey.Key)
@@ -159,26 +186,24 @@ func (h Handler) UpdateKey(c *echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
 	}
-	account, err := verifyToken(c.Request())
-	if err != nil {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized Token", err)
-	}
-	if account.Locked {
-		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
-	}
+	account := authorizedAccount(c)
 	if err := request.Key.Validate(h.validate); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
 	}
-	// Get Account associated with this key
-	keyAccount, err := database.GetAccountByKey(request.Key.Value)
+	// Get the key being updated
+	oldKey, err := database.GetKey(request.Key.Value)
 	if err != nil {
-		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key Account", err)
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key", err)
 	}
-	if keyAccount == nil {
+	if oldKey == nil {
 		return getAPIError(c, http.StatusNotFound, "Key Not Found", nil)
 	}
-	// Deny access to non admins who do not own the key
-	if account.Type != "admin" && account.Email != keyAccount.Email {
+	// Deny access to non admins who do not own the key or manage its organization
+	allowed, err := canManageKey(account, oldKey)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organization", err)
+	}
+	if !allowed {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("not an admin / ownership error"))
 	}
 	err = database.UpdateKey(request.Key.ToKey())
@@ -197,3 +222,133 @@ func (h Handler) UpdateKey(c *echo.Context) error {
 	})
 }
 
+func (h Handler) RotateKey(c *echo.Context) error {
+	var request types.RotateKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if len(request.Key) < 1 {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("no key specified"))
+	}
+	gracePeriod := time.Duration(request.GracePeriod) * time.Second
+	if gracePeriod < 0 || gracePeriod > maxRotationGracePeriod {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Grace Period", nil)
+	}
+	// Get Key to be rotated.
+	multiKey, err := database.GetKeyAndAccount(request.Key)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key/Account", err)
+	}
+	if multiKey == nil || multiKey.Key == nil || multiKey.Account == nil {
+		return getAPIError(c, http.StatusNotFound, "Key Not Found", nil)
+	}
+	// Deny access to non admins who do not own the key or manage its organization
+	allowed, err := canManageKey(account, multiKey.Key)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organization", err)
+	}
+	if !allowed {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("not an admin / ownership error"))
+	}
+	// Values still in their grace period can be used, but they can't be rotated again.
+	if multiKey.Key.Hash != types.HashKey(request.Key) {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("key has already been rotated"))
+	}
+	newKey, err := uuid.NewRandom()
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Key Generation Error", err)
+	}
+	var graceUntil *time.Time
+	if gracePeriod > 0 {
+		until := time.Now().Add(gracePeriod)
+		graceUntil = &until
+	}
+	key, err := database.RotateKey(request.Key, newKey.String(), graceUntil)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Rotating Key", err)
+	}
+	if key == nil {
+		return getAPIError(c, http.StatusNotFound, "Key Not Found After Rotation", nil)
+	}
+	return c.JSON(http.StatusOK, types.ModifyKeyResponse{
+		Key: *key,
+	})
+}
+
+func (h Handler) GetDeletedKeys(c *echo.Context) error {
+	var request types.GetDeletedKeysRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	// Deleted accounts keep their keys, so the account may be deleted as well.
+	account, err := database.GetAccount(request.Email)
+	if err == nil && account == nil {
+		account, err = database.GetDeletedAccount(request.Email)
+	}
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key Account", err)
+	}
+	if account == nil {
+		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	keys, err := database.GetDeletedKeys(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Keys", err)
+	}
+	if keys == nil {
+		keys = make([]types.Key, 0)
+	}
+	return c.JSON(http.StatusOK, types.GetKeysResponse{
+		Keys: keys,
+	})
+}
+
+func (h Handler) RestoreKey(c *echo.Context) error {
+	var request types.RestoreKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	account, err := database.GetAccount(request.Email)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key Account", err)
+	}
+	// Keys of a deleted account come back when the account is restored.
+	if account == nil {
+		deleted, err := database.GetDeletedAccount(request.Email)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key Account", err)
+		}
+		if deleted != nil {
+			return getAPIError(c, http.StatusConflict, "Account Deleted", nil)
+		}
+		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	keys, err := database.GetDeletedKeys(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Keys", err)
+	}
+	var key *types.Key
+	for i := range keys {
+		if keys[i].Name == request.Name {
+			key = &keys[i]
+		}
+	}
+	if key == nil {
+		return getAPIError(c, http.StatusNotFound, "Key Not Found", nil)
+	}
+	err = database.RestoreKey(account.Identifier, key.Name)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Restoring Key", err)
+	}
+	key.DeletedAt = nil
+	return c.JSON(http.StatusOK, types.ModifyKeyResponse{
+		Key: *key,
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"chronokeep/remote/util"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mailTimeout = time.Second * 10
+)
+
+var (
+	// mailer sends the emails the server sends out, nil if email isn't configured.
+	mailer Mailer
+	// mailing tracks emails being sent in the background.
+	mailing sync.WaitGroup
+)
+
+// Mailer sends plain text emails.
+type Mailer interface {
+	Send(recipients []string, subject, body string) error
+}
+
+// sendInBackground Sends an email without holding up the caller. If sending fails the error is handed to failed.
+func sendInBackground(m Mailer, recipients []string, subject, body string, failed func(error)) {
+	mailing.Add(1)
+	go func() {
+		defer mailing.Done()
+		if err := m.Send(recipients, subject, body); err != nil {
+			failed(err)
+		}
+	}()
+}
+
+// waitForMail Blocks until every email being sent in the background has been.
+func waitForMail() {
+	mailing.Wait()
+}
+
+// smtpMailer sends email through an SMTP server. The connection is upgraded to TLS if the
+// server supports it and authenticated if a user is set.
+type smtpMailer struct {
+	host     string
+	port     int
+	user     string
+	password string
+	from     string
+}
+
+func newSMTPMailer(cfg *util.Config) *smtpMailer {
+	return &smtpMailer{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		user:     cfg.SMTPUser,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+	}
+}
+
+func (m *smtpMailer) Send(recipients []string, subject, body string) error {
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	conn, err := net.DialTimeout("tcp", addr, mailTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(mailTimeout))
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.user != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.user, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(m.message(recipients, subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (m *smtpMailer) message(recipients []string, subject, body string) []byte {
+	// Subjects can include values sent by clients, make sure they can't add headers.
+	subject = strings.NewReplacer("\r", "", "\n", "").Replace(subject)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
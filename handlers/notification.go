@@ -19,11 +19,16 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package handlers
 
 import (
+	db "chronokeep/remote/database"
 	"chronokeep/remote/types"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v5"
+	log "github.com/sirupsen/logrus"
 )
 
 func (h Handler) GetNotifications(c *echo.Context) error {
@@ -50,7 +55,10 @@ func (h Handler) GetNotifications(c *echo.Context) error {
 	if mkey.Key.Expired() {
 		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
 	}
-	note, err := database.GetNotification(mkey.Account.Identifier, request.ReaderName)
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
+	note, err := database.GetNotification(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Notification", err)
 	}
@@ -90,6 +98,9 @@ func (h Handler) SaveNotification(c *echo.Context) error {
 	if mkey.Key.Expired() {
 		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
 	}
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
 	// Check to ensure a write/delete key
 	if mkey.Key.Type != "write" && mkey.Key.Type != "delete" {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("read key attempting to write"))
@@ -97,10 +108,137 @@ func (h Handler) SaveNotification(c *echo.Context) error {
 	if err := request.Note.Validate(h.validate); err != nil {
 		return getAPIError(c, http.StatusBadRequest, "Invalid Notification", err)
 	}
-	if err := database.SaveNotification(&request.Note, mkey.Key.Value); err != nil {
+	added, err := database.SaveNotification(&request.Note, *k)
+	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Saving Notification", err)
 	}
+	// A notification the reader already sent is ignored rather than alerted on again.
+	if note, err := request.Note.ToNotification(); err == nil && added {
+		alerts.notify(mkey.Account, mkey.Key.Name, *note, time.Now())
+		// Look the notification up again so webhooks are sent it with its id.
+		saved, err := database.GetNotificationHistory(mkey.Account.Identifier, mkey.Key.Organization, mkey.Key.Name, note.When.Unix(), note.When.Unix(), false, nil, 1)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"reader": mkey.Key.Name,
+				"error":  err,
+			}).Error("Unable to retrieve saved notification.")
+		} else if len(saved) > 0 {
+			webhooks.queue(mkey.Account.Identifier, mkey.Key.Organization, types.WebhookPayload{
+				Event:        types.WebhookNotificationSaved,
+				When:         time.Now(),
+				Reader:       mkey.Key.Name,
+				Notification: &saved[0],
+			})
+		}
+	}
 
 	return c.NoContent(http.StatusOK)
 }
 
+func (h Handler) GetNotificationHistory(c *echo.Context) error {
+	// Get Key from Authorization Header
+	k, err := retrieveKey(c.Request())
+	if err != nil {
+		return getAPIError(c, http.StatusUnauthorized, "Error Getting Key From Authorization Header", err)
+	}
+	if k == nil {
+		return getAPIError(c, http.StatusUnauthorized, "Key Not Provided in Authorization Header", nil)
+	}
+	var request types.GetNotificationHistoryRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	mkey, err := database.GetKeyAndAccount(*k)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key/Account", err)
+	}
+	if mkey == nil || mkey.Key == nil || mkey.Account == nil {
+		return getAPIError(c, http.StatusUnauthorized, "Key/Account Not Found", nil)
+	}
+	// Check for expired key
+	if mkey.Key.Expired() {
+		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
+	}
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
+	end := request.End
+	if end == 0 {
+		end = math.MaxInt64
+	}
+	if end < request.Start {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Time Range", nil)
+	}
+	limit := request.Limit
+	if limit < 1 || limit > db.MaxNotificationsPageSize {
+		limit = db.MaxNotificationsPageSize
+	}
+	var after *types.NotificationCursor
+	if request.Cursor != "" {
+		after, err = types.DecodeNotificationCursor(request.Cursor)
+		if err != nil {
+			return getAPIError(c, http.StatusBadRequest, "Invalid Cursor", err)
+		}
+	}
+	// Ask for one more than the limit so we know if there's another page.
+	notes, err := database.GetNotificationHistory(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName, request.Start, end, request.Unacknowledged, after, limit+1)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Notifications", err)
+	}
+	nextCursor := ""
+	if len(notes) > limit {
+		notes = notes[:limit]
+		nextCursor = notes[limit-1].Cursor().Encode()
+	}
+	if notes == nil {
+		notes = []types.Notification{}
+	}
+	return c.JSON(http.StatusOK, types.GetNotificationHistoryResponse{
+		Count:         int64(len(notes)),
+		Notifications: notes,
+		NextCursor:    nextCursor,
+	})
+}
+
+func (h Handler) AcknowledgeNotifications(c *echo.Context) error {
+	// Get Key from Authorization Header
+	k, err := retrieveKey(c.Request())
+	if err != nil {
+		return getAPIError(c, http.StatusUnauthorized, "Error Getting Key From Authorization Header", err)
+	}
+	if k == nil {
+		return getAPIError(c, http.StatusUnauthorized, "Key Not Provided in Authorization Header", nil)
+	}
+	var request types.AcknowledgeNotificationsRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	mkey, err := database.GetKeyAndAccount(*k)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key/Account", err)
+	}
+	if mkey == nil || mkey.Key == nil || mkey.Account == nil {
+		return getAPIError(c, http.StatusUnauthorized, "Key/Account Not Found", nil)
+	}
+	// Check for expired key
+	if mkey.Key.Expired() {
+		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
+	}
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
+	// Acknowledging only marks a notification as seen, so dashboards using read keys are allowed to.
+	if len(request.Notifications) < 1 {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("no notifications specified"))
+	}
+	if len(request.Notifications) > db.MaxNotificationsPageSize {
+		return getAPIError(c, http.StatusBadRequest, "Too Many Notifications", nil)
+	}
+	count, err := database.AcknowledgeNotifications(mkey.Account.Identifier, mkey.Key.Organization, request.Notifications)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Acknowledging Notifications", err)
+	}
+	return c.JSON(http.StatusOK, types.AcknowledgeNotificationsResponse{
+		Count: count,
+	})
+}
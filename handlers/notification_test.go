@@ -302,12 +302,26 @@ func TestSaveNotification(t *testing.T) {
 		assert.Equal(t, 0, response.Body.Len())
 	}
 	// Verify the notification was saved.
-	note, err := database.GetNotification(variables.accounts[0].Identifier, variables.knownValues["writeName"])
+	note, err := database.GetNotification(variables.accounts[0].Identifier, nil, variables.knownValues["writeName"])
 	if assert.NoError(t, err) {
 		if assert.NotNil(t, note) {
 			assert.Equal(t, "UPS_DISCONNECTED", note.Type)
 		}
 	}
+	// Test sending the same notification again, which is ignored
+	t.Log("Testing repeated request.")
+	request = httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.SaveNotification(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	history, err := database.GetNotificationHistory(variables.accounts[0].Identifier, nil, variables.knownValues["writeName"], 0, when.Unix()+1, false, nil, 10)
+	if assert.NoError(t, err) {
+		assert.Len(t, history, 1)
+	}
 	// Test validation -- Type
 	t.Log("Testing validation -- Type")
 	body, err = json.Marshal(types.SaveNotificationRequest{
@@ -348,3 +362,315 @@ func TestSaveNotification(t *testing.T) {
 	}
 }
 
+func TestGetNotificationHistory(t *testing.T) {
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	// Test no key
+	t.Log("Testing no key given.")
+	body, err := json.Marshal(types.GetNotificationHistoryRequest{})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test expired key
+	t.Log("Testing expired key.")
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["expired"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test invalid key
+	t.Log("Testing invalid key.")
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer not-a-valid-key")
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test bad request
+	t.Log("Testing bad request.")
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader("////"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test invalid time range
+	t.Log("Testing invalid time range.")
+	body, err = json.Marshal(types.GetNotificationHistoryRequest{
+		Start: 1000,
+		End:   500,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test invalid cursor
+	t.Log("Testing invalid cursor.")
+	body, err = json.Marshal(types.GetNotificationHistoryRequest{
+		Cursor: "////",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test valid, all readers
+	t.Log("Testing valid request (all readers).")
+	body, err = json.Marshal(types.GetNotificationHistoryRequest{})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetNotificationHistoryResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, int64(2), resp.Count)
+			if assert.Len(t, resp.Notifications, 2) {
+				assert.Equal(t, "reader6", resp.Notifications[0].Reader)
+				assert.Equal(t, "UPS_DISCONNECTED", resp.Notifications[0].Type)
+				assert.Equal(t, "reader7", resp.Notifications[1].Reader)
+				assert.Equal(t, "UPS_LOW_BATTERY", resp.Notifications[1].Type)
+			}
+			assert.Equal(t, "", resp.NextCursor)
+		}
+	}
+	// Test valid, single reader with a notification older than five minutes
+	t.Log("Testing valid request (old notification).")
+	body, err = json.Marshal(types.GetNotificationHistoryRequest{
+		ReaderName: "reader7",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetNotificationHistoryResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Notifications, 1) {
+				assert.Equal(t, "UPS_LOW_BATTERY", resp.Notifications[0].Type)
+			}
+		}
+	}
+	// Test account mis-match
+	t.Log("Testing account mis-match.")
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetNotificationHistoryResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, int64(0), resp.Count)
+			assert.Empty(t, resp.Notifications)
+		}
+	}
+	// Test paging
+	t.Log("Testing paging.")
+	body, err = json.Marshal(types.GetNotificationHistoryRequest{
+		Limit: 1,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	cursor := ""
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetNotificationHistoryResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Notifications, 1) {
+				assert.Equal(t, "UPS_DISCONNECTED", resp.Notifications[0].Type)
+			}
+			assert.NotEqual(t, "", resp.NextCursor)
+			cursor = resp.NextCursor
+		}
+	}
+	body, err = json.Marshal(types.GetNotificationHistoryRequest{
+		Limit:  1,
+		Cursor: cursor,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetNotificationHistoryResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Notifications, 1) {
+				assert.Equal(t, "UPS_LOW_BATTERY", resp.Notifications[0].Type)
+			}
+			assert.Equal(t, "", resp.NextCursor)
+		}
+	}
+}
+
+func TestAcknowledgeNotifications(t *testing.T) {
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	notes, err := database.GetNotificationHistory(variables.accounts[1].Identifier, nil, "", 0, time.Now().Add(time.Hour).Unix(), false, nil, 10)
+	if err != nil || len(notes) != 2 {
+		t.Fatalf("Error getting test notifications: %v (%v)", err, notes)
+	}
+	// Test no key
+	t.Log("Testing no key given.")
+	body, err := json.Marshal(types.AcknowledgeNotificationsRequest{
+		Notifications: []int64{notes[0].Identifier},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/notifications/acknowledge", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test expired key
+	t.Log("Testing expired key.")
+	request = httptest.NewRequest(http.MethodPost, "/notifications/acknowledge", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["expired"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test bad request
+	t.Log("Testing bad request.")
+	request = httptest.NewRequest(http.MethodPost, "/notifications/acknowledge", strings.NewReader("////"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test no notifications
+	t.Log("Testing no notifications.")
+	request = httptest.NewRequest(http.MethodPost, "/notifications/acknowledge", strings.NewReader("{}"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test account mis-match
+	t.Log("Testing account mis-match.")
+	request = httptest.NewRequest(http.MethodPost, "/notifications/acknowledge", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.AcknowledgeNotificationsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, int64(0), resp.Count)
+		}
+	}
+	// Test valid, read keys are allowed to acknowledge
+	t.Log("Testing valid request.")
+	request = httptest.NewRequest(http.MethodPost, "/notifications/acknowledge", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.AcknowledgeNotificationsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, int64(1), resp.Count)
+		}
+	}
+	// Test only unacknowledged notifications are returned when asked for
+	t.Log("Testing unacknowledged history.")
+	historyBody, err := json.Marshal(types.GetNotificationHistoryRequest{
+		Unacknowledged: true,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/notifications/history", strings.NewReader(string(historyBody)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetNotificationHistoryResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Notifications, 1) {
+				assert.Equal(t, notes[1].Identifier, resp.Notifications[0].Identifier)
+				assert.Nil(t, resp.Notifications[0].Acknowledged)
+			}
+		}
+	}
+	// Test already acknowledged
+	t.Log("Testing already acknowledged.")
+	request = httptest.NewRequest(http.MethodPost, "/notifications/acknowledge", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.AcknowledgeNotificationsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, int64(0), resp.Count)
+		}
+	}
+}
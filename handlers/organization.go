@@ -0,0 +1,235 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+)
+
+func (h Handler) GetOrganizations(c *echo.Context) error {
+	var request types.GetOrganizationsRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	memberAccount, err := managedAccount(c, account, request.Email)
+	if memberAccount == nil {
+		return err
+	}
+	memberships, err := database.GetAccountMemberships(memberAccount.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organizations", err)
+	}
+	if memberships == nil {
+		memberships = make([]types.Membership, 0)
+	}
+	return c.JSON(http.StatusOK, types.GetOrganizationsResponse{
+		Organizations: memberships,
+	})
+}
+
+func (h Handler) AddOrganization(c *echo.Context) error {
+	var request types.AddOrganizationRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	request.Name = strings.TrimSpace(request.Name)
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
+	}
+	// The organization's keys are kept under the account creating it, so each account can only create one.
+	memberships, err := database.GetAccountMemberships(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organizations", err)
+	}
+	for _, membership := range memberships {
+		if membership.Organization.AccountIdentifier == account.Identifier {
+			return getAPIError(c, http.StatusBadRequest, "Organization Already Exists", errors.New("accounts can only create one organization"))
+		}
+	}
+	org, err := database.AddOrganization(types.Organization{
+		AccountIdentifier: account.Identifier,
+		Name:              request.Name,
+	})
+	if err != nil || org == nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Adding Organization", err)
+	}
+	return c.JSON(http.StatusOK, types.ModifyOrganizationResponse{
+		Organization: *org,
+	})
+}
+
+func (h Handler) UpdateOrganization(c *echo.Context) error {
+	var request types.UpdateOrganizationRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	request.Name = strings.TrimSpace(request.Name)
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
+	}
+	org, err := memberOrganization(c, account, request.Organization, types.OrgRoleOwner)
+	if org == nil {
+		return err
+	}
+	org.Name = request.Name
+	if err := database.UpdateOrganization(*org); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Updating Organization", err)
+	}
+	return c.JSON(http.StatusOK, types.ModifyOrganizationResponse{
+		Organization: *org,
+	})
+}
+
+func (h Handler) DeleteOrganization(c *echo.Context) error {
+	var request types.OrganizationRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	org, err := memberOrganization(c, account, request.Organization, types.OrgRoleOwner)
+	if org == nil {
+		return err
+	}
+	if err := database.DeleteOrganization(org.Identifier); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Deleting Organization", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h Handler) GetOrganizationMembers(c *echo.Context) error {
+	var request types.OrganizationRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	org, err := memberOrganization(c, account, request.Organization)
+	if org == nil {
+		return err
+	}
+	return organizationMembers(c, org)
+}
+
+func (h Handler) SetOrganizationMember(c *echo.Context) error {
+	var request types.SetOrganizationMemberRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
+	}
+	if !types.ValidOrgRole(request.Role) {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", errors.New("invalid organization role"))
+	}
+	org, err := memberOrganization(c, account, request.Organization, types.OrgRoleOwner)
+	if org == nil {
+		return err
+	}
+	memberAccount, err := database.GetAccount(request.Email)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Account", err)
+	}
+	if memberAccount == nil {
+		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	// The organization's keys are kept under the account that created it, so it always stays an owner.
+	if memberAccount.Identifier == org.AccountIdentifier && request.Role != types.OrgRoleOwner {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("the organization's creator must stay an owner"))
+	}
+	err = database.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      memberAccount.Identifier,
+		Role:                   request.Role,
+	})
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Setting Organization Member", err)
+	}
+	return organizationMembers(c, org)
+}
+
+func (h Handler) RemoveOrganizationMember(c *echo.Context) error {
+	var request types.RemoveOrganizationMemberRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	// Members can leave an organization on their own, only owners can remove someone else.
+	var org *types.Organization
+	var err error
+	if request.Email == account.Email {
+		org, err = memberOrganization(c, account, request.Organization)
+	} else {
+		org, err = memberOrganization(c, account, request.Organization, types.OrgRoleOwner)
+	}
+	if org == nil {
+		return err
+	}
+	memberAccount, err := database.GetAccount(request.Email)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Account", err)
+	}
+	if memberAccount == nil {
+		return getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	if memberAccount.Identifier == org.AccountIdentifier {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("the organization's creator can't be removed"))
+	}
+	membership, err := database.GetMembership(org.Identifier, memberAccount.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organization Member", err)
+	}
+	if membership == nil {
+		return getAPIError(c, http.StatusNotFound, "Member Not Found", nil)
+	}
+	if err := database.RemoveOrganizationMember(org.Identifier, memberAccount.Identifier); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Removing Organization Member", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// organizationMembers Responds with the members of an organization.
+func organizationMembers(c *echo.Context, org *types.Organization) error {
+	members, err := database.GetOrganizationMembers(org.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organization Members", err)
+	}
+	if members == nil {
+		members = make([]types.OrganizationMember, 0)
+	}
+	return c.JSON(http.StatusOK, types.GetOrganizationMembersResponse{
+		Members: members,
+	})
+}
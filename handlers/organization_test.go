@@ -0,0 +1,565 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddOrganization(t *testing.T) {
+	// POST, /r/organization/add
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/organization/add", "", types.AddOrganizationRequest{Name: "Timing Company"})
+	if assert.NoError(t, h.authorize()(h.AddOrganization)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	token := loginSession(t, variables.accounts[1], "")
+	// Test invalid name
+	t.Log("Testing invalid name.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/add", token, types.AddOrganizationRequest{Name: "   "})
+	if assert.NoError(t, h.authorize()(h.AddOrganization)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/add", token, types.AddOrganizationRequest{Name: " Timing Company "})
+	if assert.NoError(t, h.authorize()(h.AddOrganization)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ModifyOrganizationResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEqual(t, int64(0), resp.Organization.Identifier)
+			assert.Equal(t, "Timing Company", resp.Organization.Name)
+		}
+	}
+	memberships, err := database.GetAccountMemberships(variables.accounts[1].Identifier)
+	if assert.NoError(t, err) && assert.Len(t, memberships, 1) {
+		assert.Equal(t, types.OrgRoleOwner, memberships[0].Role)
+	}
+	// Test second organization
+	t.Log("Testing second organization for the same account.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/add", token, types.AddOrganizationRequest{Name: "Second Company"})
+	if assert.NoError(t, h.authorize()(h.AddOrganization)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test get organizations
+	t.Log("Testing get organizations.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization", token, types.GetOrganizationsRequest{})
+	if assert.NoError(t, h.authorize()(h.GetOrganizations)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetOrganizationsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) && assert.Len(t, resp.Organizations, 1) {
+			assert.Equal(t, "Timing Company", resp.Organizations[0].Organization.Name)
+			assert.Equal(t, types.OrgRoleOwner, resp.Organizations[0].Role)
+		}
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization", token, types.GetOrganizationsRequest{Email: &variables.accounts[2].Email})
+	if assert.NoError(t, h.authorize()(h.GetOrganizations)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	// PUT, /r/organization/update
+	// DELETE, /r/organization/delete
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	org, err := database.AddOrganization(types.Organization{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		Name:              "Timing Company",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	database.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      variables.accounts[2].Identifier,
+		Role:                   types.OrgRoleManager,
+	})
+	ownerToken := loginSession(t, variables.accounts[1], "")
+	managerToken := loginSession(t, variables.accounts[2], "")
+	// Test manager
+	t.Log("Testing manager renaming organization.")
+	c, response := newRequestContext(t, http.MethodPut, "/r/organization/update", managerToken, types.UpdateOrganizationRequest{
+		Organization: org.Identifier,
+		Name:         "Renamed Company",
+	})
+	if assert.NoError(t, h.authorize()(h.UpdateOrganization)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test unknown organization
+	t.Log("Testing unknown organization.")
+	c, response = newRequestContext(t, http.MethodPut, "/r/organization/update", ownerToken, types.UpdateOrganizationRequest{
+		Organization: org.Identifier + 100,
+		Name:         "Renamed Company",
+	})
+	if assert.NoError(t, h.authorize()(h.UpdateOrganization)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test owner
+	t.Log("Testing owner renaming organization.")
+	c, response = newRequestContext(t, http.MethodPut, "/r/organization/update", ownerToken, types.UpdateOrganizationRequest{
+		Organization: org.Identifier,
+		Name:         "Renamed Company",
+	})
+	if assert.NoError(t, h.authorize()(h.UpdateOrganization)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ModifyOrganizationResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, "Renamed Company", resp.Organization.Name)
+		}
+	}
+	// Test manager deleting
+	t.Log("Testing manager deleting organization.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/organization/delete", managerToken, types.OrganizationRequest{Organization: org.Identifier})
+	if assert.NoError(t, h.authorize()(h.DeleteOrganization)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test admin deleting
+	t.Log("Testing admin deleting organization.")
+	adminToken := loginSession(t, variables.accounts[0], "")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/organization/delete", adminToken, types.OrganizationRequest{Organization: org.Identifier})
+	if assert.NoError(t, h.authorize()(h.DeleteOrganization)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	deleted, err := database.GetOrganization(org.Identifier)
+	if assert.NoError(t, err) {
+		assert.Nil(t, deleted)
+	}
+}
+
+func TestOrganizationMembers(t *testing.T) {
+	// POST, /r/organization/members
+	// POST, /r/organization/member
+	// DELETE, /r/organization/member
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	org, err := database.AddOrganization(types.Organization{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		Name:              "Timing Company",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	ownerToken := loginSession(t, variables.accounts[1], "")
+	memberToken := loginSession(t, variables.accounts[2], "")
+	// Test not a member
+	t.Log("Testing members request from someone outside the organization.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/organization/members", memberToken, types.OrganizationRequest{Organization: org.Identifier})
+	if assert.NoError(t, h.authorize()(h.GetOrganizationMembers)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test invalid role
+	t.Log("Testing invalid role.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/member", ownerToken, types.SetOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[2].Email,
+		Role:         "janitor",
+	})
+	if assert.NoError(t, h.authorize()(h.SetOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test unknown account
+	t.Log("Testing unknown account.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/member", ownerToken, types.SetOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        "nobody@test.com",
+		Role:         types.OrgRoleViewer,
+	})
+	if assert.NoError(t, h.authorize()(h.SetOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test demoting the creator
+	t.Log("Testing demoting the organization's creator.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/member", ownerToken, types.SetOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[1].Email,
+		Role:         types.OrgRoleViewer,
+	})
+	if assert.NoError(t, h.authorize()(h.SetOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test valid
+	t.Log("Testing adding a viewer.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/member", ownerToken, types.SetOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[2].Email,
+		Role:         types.OrgRoleViewer,
+	})
+	if assert.NoError(t, h.authorize()(h.SetOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetOrganizationMembersResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.Members, 2)
+		}
+	}
+	// Test viewer listing members
+	t.Log("Testing viewer listing members.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/members", memberToken, types.OrganizationRequest{Organization: org.Identifier})
+	if assert.NoError(t, h.authorize()(h.GetOrganizationMembers)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetOrganizationMembersResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) && assert.Len(t, resp.Members, 2) {
+			roles := make(map[string]string)
+			for _, member := range resp.Members {
+				roles[member.Email] = member.Role
+			}
+			assert.Equal(t, types.OrgRoleOwner, roles[variables.accounts[1].Email])
+			assert.Equal(t, types.OrgRoleViewer, roles[variables.accounts[2].Email])
+		}
+	}
+	// Test viewer adding members
+	t.Log("Testing viewer changing roles.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/organization/member", memberToken, types.SetOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[2].Email,
+		Role:         types.OrgRoleOwner,
+	})
+	if assert.NoError(t, h.authorize()(h.SetOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test viewer removing someone else
+	t.Log("Testing viewer removing the owner.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/organization/member", memberToken, types.RemoveOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[1].Email,
+	})
+	if assert.NoError(t, h.authorize()(h.RemoveOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test removing the creator
+	t.Log("Testing removing the organization's creator.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/organization/member", ownerToken, types.RemoveOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[1].Email,
+	})
+	if assert.NoError(t, h.authorize()(h.RemoveOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test leaving
+	t.Log("Testing viewer leaving the organization.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/organization/member", memberToken, types.RemoveOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[2].Email,
+	})
+	if assert.NoError(t, h.authorize()(h.RemoveOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	membership, err := database.GetMembership(org.Identifier, variables.accounts[2].Identifier)
+	if assert.NoError(t, err) {
+		assert.Nil(t, membership)
+	}
+	// Test removing someone who isn't a member
+	t.Log("Testing removing someone who isn't a member.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/organization/member", ownerToken, types.RemoveOrganizationMemberRequest{
+		Organization: org.Identifier,
+		Email:        variables.accounts[2].Email,
+	})
+	if assert.NoError(t, h.authorize()(h.RemoveOrganizationMember)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+}
+
+func TestOrganizationKeys(t *testing.T) {
+	// Keys, readers and accounts seen through an organization.
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	org, err := database.AddOrganization(types.Organization{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		Name:              "Timing Company",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	database.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      variables.accounts[2].Identifier,
+		Role:                   types.OrgRoleViewer,
+	})
+	memberToken := loginSession(t, variables.accounts[2], "")
+	addRequest := types.AddKeyRequest{
+		Organization: &org.Identifier,
+		Key: types.RequestKey{
+			Name: "finish",
+			Type: "write",
+		},
+	}
+	// Test viewer adding a key
+	t.Log("Testing viewer adding an organization key.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/key/add", memberToken, addRequest)
+	if assert.NoError(t, h.authorize(types.PermKeysCreate)(h.AddKey)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test account and organization
+	t.Log("Testing adding a key to an account and an organization.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/key/add", memberToken, types.AddKeyRequest{
+		Email:        &variables.accounts[2].Email,
+		Organization: &org.Identifier,
+		Key:          addRequest.Key,
+	})
+	if assert.NoError(t, h.authorize(types.PermKeysCreate)(h.AddKey)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test manager adding a key
+	t.Log("Testing manager adding an organization key.")
+	database.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      variables.accounts[2].Identifier,
+		Role:                   types.OrgRoleManager,
+	})
+	var orgKey types.Key
+	c, response = newRequestContext(t, http.MethodPost, "/r/key/add", memberToken, addRequest)
+	if assert.NoError(t, h.authorize(types.PermKeysCreate)(h.AddKey)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ModifyKeyResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			orgKey = resp.Key
+			assert.Equal(t, variables.accounts[1].Identifier, orgKey.AccountIdentifier)
+			if assert.NotNil(t, orgKey.Organization) {
+				assert.Equal(t, org.Identifier, *orgKey.Organization)
+			}
+		}
+	}
+	// Test get keys
+	t.Log("Testing member getting keys.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/key", memberToken, types.GetKeysRequest{})
+	if assert.NoError(t, h.authorize()(h.GetKeys)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetKeysResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) && assert.Len(t, resp.Keys, 1) {
+			assert.Equal(t, orgKey.Prefix, resp.Keys[0].Prefix)
+		}
+	}
+	ownerKeys, _ := database.GetAccountKeys(variables.accounts[1].Email)
+	ownerToken := loginSession(t, variables.accounts[1], "")
+	c, response = newRequestContext(t, http.MethodPost, "/r/key", ownerToken, types.GetKeysRequest{Organization: &org.Identifier})
+	if assert.NoError(t, h.authorize()(h.GetKeys)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetKeysResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.Keys, 1)
+			assert.Greater(t, len(ownerKeys), 1)
+		}
+	}
+	// Test get account
+	t.Log("Testing member getting their account.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account", memberToken, types.GetAccountRequest{})
+	if assert.NoError(t, h.authorize()(h.GetAccount)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetAccountResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.Keys, 1)
+			if assert.Len(t, resp.Organizations, 1) {
+				assert.Equal(t, org.Identifier, resp.Organizations[0].Organization.Identifier)
+				assert.Equal(t, types.OrgRoleManager, resp.Organizations[0].Role)
+			}
+		}
+	}
+	// Test readers
+	t.Log("Testing organization key getting readers.")
+	c, response = newRequestContext(t, http.MethodGet, "/readers", orgKey.Value, nil)
+	if assert.NoError(t, h.GetReaders(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetReadersResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) && assert.Len(t, resp.Readers, 1) {
+			assert.Equal(t, "finish", resp.Readers[0].Name)
+		}
+	}
+	// Test managing keys
+	t.Log("Testing manager updating an organization key.")
+	c, response = newRequestContext(t, http.MethodPut, "/r/key/update", memberToken, types.UpdateKeyRequest{
+		Key: types.RequestKey{
+			Name:  "finish line",
+			Value: orgKey.Value,
+			Type:  "write",
+		},
+	})
+	if assert.NoError(t, h.authorize()(h.UpdateKey)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	t.Log("Testing manager rotating an organization key.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/key/rotate", memberToken, types.RotateKeyRequest{Key: orgKey.Value})
+	if assert.NoError(t, h.authorize()(h.RotateKey)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ModifyKeyResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			orgKey = resp.Key
+		}
+	}
+	t.Log("Testing manager deleting a key of the organization's creator.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/key/delete", memberToken, types.DeleteKeyRequest{Key: variables.knownValues["write2"]})
+	if assert.NoError(t, h.authorize()(h.DeleteKey)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	t.Log("Testing viewer deleting an organization key.")
+	database.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      variables.accounts[2].Identifier,
+		Role:                   types.OrgRoleViewer,
+	})
+	c, response = newRequestContext(t, http.MethodDelete, "/r/key/delete", memberToken, types.DeleteKeyRequest{Key: orgKey.Value})
+	if assert.NoError(t, h.authorize()(h.DeleteKey)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	t.Log("Testing owner deleting an organization key.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/key/delete", ownerToken, types.DeleteKeyRequest{Key: orgKey.Value})
+	if assert.NoError(t, h.authorize()(h.DeleteKey)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	keys, err := database.GetOrganizationKeys(org.Identifier)
+	if assert.NoError(t, err) {
+		assert.Len(t, keys, 0)
+	}
+}
+
+func TestOrganizationKeyScope(t *testing.T) {
+	// Organization keys are kept under the organization's creator, but can't reach the creator's own readers.
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	owner := variables.accounts[1]
+	org, err := database.AddOrganization(types.Organization{
+		AccountIdentifier: owner.Identifier,
+		Name:              "Timing Company",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	database.SetOrganizationMember(types.OrganizationMember{
+		OrganizationIdentifier: org.Identifier,
+		AccountIdentifier:      variables.accounts[2].Identifier,
+		Role:                   types.OrgRoleManager,
+	})
+	managerToken := loginSession(t, variables.accounts[2], "")
+	// Test a name the creator already uses
+	t.Log("Testing organization key with the name of one of the creator's keys.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/key/add", managerToken, types.AddKeyRequest{
+		Organization: &org.Identifier,
+		Key: types.RequestKey{
+			Name: "reader6",
+			Type: "read",
+		},
+	})
+	if assert.NoError(t, h.authorize(types.PermKeysCreate)(h.AddKey)(c)) {
+		assert.Equal(t, http.StatusConflict, response.Code)
+	}
+	orgKeys := make(map[string]types.Key)
+	for _, keyType := range []string{"read", "delete", "write"} {
+		c, response = newRequestContext(t, http.MethodPost, "/r/key/add", managerToken, types.AddKeyRequest{
+			Organization: &org.Identifier,
+			Key: types.RequestKey{
+				Name: "finish-" + keyType,
+				Type: keyType,
+			},
+		})
+		if assert.NoError(t, h.authorize(types.PermKeysCreate)(h.AddKey)(c)) && assert.Equal(t, http.StatusOK, response.Code) {
+			var resp types.ModifyKeyResponse
+			if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+				orgKeys[keyType] = resp.Key
+			}
+		}
+	}
+	ownerReads, _ := database.GetReads(owner.Identifier, nil, "reader6", 0, 10000)
+	if len(ownerReads) == 0 {
+		t.Fatal("Expected the creator's reader to have reads.")
+	}
+	// Test reads
+	t.Log("Testing organization key getting reads of the creator's reader.")
+	c, response = newRequestContext(t, http.MethodGet, "/reads", orgKeys["read"].Value, types.GetReadsRequest{
+		ReaderName: "reader6",
+		Start:      0,
+		End:        10000,
+	})
+	if assert.NoError(t, h.GetReads(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetReadsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.Reads, 0)
+			assert.Nil(t, resp.Note)
+		}
+	}
+	// Test notifications
+	t.Log("Testing organization key getting the creator's notifications.")
+	c, response = newRequestContext(t, http.MethodPost, "/notifications/history", orgKeys["read"].Value, types.GetNotificationHistoryRequest{
+		Start: 0,
+		End:   time.Now().Add(time.Hour).Unix(),
+	})
+	if assert.NoError(t, h.GetNotificationHistory(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetNotificationHistoryResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.Notifications, 0)
+		}
+	}
+	ownerNotes, _ := database.GetNotificationHistory(owner.Identifier, nil, "", 0, math.MaxInt64, true, nil, 10)
+	if len(ownerNotes) == 0 {
+		t.Fatal("Expected the creator to have notifications.")
+	}
+	t.Log("Testing organization key acknowledging the creator's notifications.")
+	c, response = newRequestContext(t, http.MethodPost, "/notifications/acknowledge", orgKeys["read"].Value, types.AcknowledgeNotificationsRequest{
+		Notifications: []int64{ownerNotes[0].Identifier},
+	})
+	if assert.NoError(t, h.AcknowledgeNotifications(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.AcknowledgeNotificationsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, int64(0), resp.Count)
+		}
+	}
+	// Test deleting reads
+	t.Log("Testing organization key deleting reads of the creator's reader.")
+	c, response = newRequestContext(t, http.MethodDelete, "/reads/delete", orgKeys["delete"].Value, types.DeleteReadsRequest{
+		ReaderName: "reader6",
+	})
+	if assert.NoError(t, h.DeleteReads(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	reads, _ := database.GetReads(owner.Identifier, nil, "reader6", 0, 10000)
+	assert.Len(t, reads, len(ownerReads))
+	// Test readers
+	t.Log("Testing the creator's key getting readers.")
+	c, response = newRequestContext(t, http.MethodGet, "/readers", variables.knownValues["read"], nil)
+	if assert.NoError(t, h.GetReaders(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetReadersResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			for _, reader := range resp.Readers {
+				assert.NotEqual(t, "finish-write", reader.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/auth"
+	db "chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	passwordResetExpiry = time.Hour
+	// passwordResetWindow is how long reset requests are counted for before the counts start over.
+	passwordResetWindow = time.Hour
+	// passwordResetMaxAddressRequests is how many reset requests an address can make in the window.
+	passwordResetMaxAddressRequests = 10
+	// passwordResetMaxAccountRequests is how many reset tokens an account can be sent in the window.
+	passwordResetMaxAccountRequests = 3
+)
+
+func (h Handler) ForgotPassword(c *echo.Context) error {
+	var request types.ForgotPasswordRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Email", err)
+	}
+	m := mailer
+	if m == nil {
+		return getAPIError(c, http.StatusServiceUnavailable, "Password Reset Unavailable", errors.New("email not configured"))
+	}
+	// Requests are counted against the address they came from, apart from failed logins, so asking for
+	// resets can't be used to find accounts or flood inboxes without locking anyone out of logging in.
+	now := time.Now()
+	requests, err := database.AddPasswordResetRequest("address:"+c.RealIP(), passwordResetWindow, now)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if requests > passwordResetMaxAddressRequests {
+		return getAPIError(c, http.StatusTooManyRequests, "Too Many Requests", fmt.Errorf("password resets from %s blocked", c.RealIP()))
+	}
+	account, err := database.GetAccount(request.Email)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	// Respond the same way whether or not the account exists so this can't be used to find accounts.
+	if account == nil {
+		log.WithFields(log.Fields{
+			"email": request.Email,
+		}).Info("Password reset requested for unknown account.")
+		return c.NoContent(http.StatusOK)
+	}
+	requests, err = database.AddPasswordResetRequest(fmt.Sprintf("account:%d", account.Identifier), passwordResetWindow, now)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if requests > passwordResetMaxAccountRequests {
+		log.WithFields(log.Fields{
+			"account": account.Identifier,
+		}).Info("Password reset requested too many times.")
+		return c.NoContent(http.StatusOK)
+	}
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Token Generation Error", err)
+	}
+	reset := types.PasswordReset{
+		AccountIdentifier: account.Identifier,
+		Token:             hex.EncodeToString(token),
+		ExpiresAt:         now.Add(passwordResetExpiry),
+	}
+	if err := database.AddPasswordReset(reset); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	sendInBackground(m, []string{account.Email}, "Chronokeep password reset", passwordResetBody(reset), func(err error) {
+		log.WithFields(log.Fields{
+			"account": account.Identifier,
+			"error":   err,
+		}).Error("Unable to send password reset email.")
+	})
+	return c.NoContent(http.StatusOK)
+}
+
+func passwordResetBody(reset types.PasswordReset) string {
+	return fmt.Sprintf(
+		"Someone asked to reset the password on your Chronokeep account.\n\n"+
+			"Reset token: %s\n\n"+
+			"The token can be used once and expires at %s. "+
+			"If you didn't ask for this you can ignore this email, your password hasn't been changed.\n",
+		reset.Token,
+		reset.ExpiresAt.Format(time.RFC1123Z),
+	)
+}
+
+func (h Handler) ResetPassword(c *echo.Context) error {
+	var request types.ResetPasswordRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if request.Token == "" {
+		return getAPIError(c, http.StatusBadRequest, "Empty Request", nil)
+	}
+	if len(request.NewPassword) < 8 {
+		return getAPIError(c, http.StatusBadRequest, "Minimum Password Length (8) Not Met", nil)
+	}
+	hashedPassword, err := auth.HashPassword(request.NewPassword)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
+	}
+	account, err := database.ResetPassword(request.Token, hashedPassword, time.Now())
+	if errors.Is(err, db.ErrInvalidResetToken) {
+		return getAPIError(c, http.StatusUnauthorized, "Invalid Reset Token", err)
+	}
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	log.WithFields(log.Fields{
+		"account": account.Identifier,
+	}).Info("Password reset.")
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,355 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	db "chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMail is an email sent through the testMailer.
+type testMail struct {
+	recipients []string
+	subject    string
+	body       string
+}
+
+// testMailer records the emails sent through it instead of sending them.
+type testMailer struct {
+	mu   sync.Mutex
+	sent []testMail
+}
+
+func (m *testMailer) Send(recipients []string, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, testMail{recipients: recipients, subject: subject, body: body})
+	return nil
+}
+
+func (m *testMailer) received() []testMail {
+	waitForMail()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]testMail(nil), m.sent...)
+}
+
+var resetTokenPattern = regexp.MustCompile(`Reset token: ([0-9a-f]{64})`)
+
+// forgotPassword Asks for a password reset for the email and returns the token that was emailed out.
+func forgotPassword(t *testing.T, h Handler, m *testMailer, email string) string {
+	body, err := json.Marshal(types.ForgotPasswordRequest{
+		Email: email,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/account/password/forgot", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := echo.New().NewContext(request, response)
+	if err := h.ForgotPassword(c); err != nil || response.Code != http.StatusOK {
+		t.Fatalf("Error asking for password reset: %v (%v)", err, response.Code)
+	}
+	sent := m.received()
+	if len(sent) < 1 {
+		t.Fatal("Expected a password reset email to be sent.")
+	}
+	match := resetTokenPattern.FindStringSubmatch(sent[len(sent)-1].body)
+	if match == nil {
+		t.Fatalf("Expected a reset token in the email, found '%s'.", sent[len(sent)-1].body)
+	}
+	return match[1]
+}
+
+func TestForgotPassword(t *testing.T) {
+	// POST, /account/password/forgot
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	// Test no mailer
+	t.Log("Testing no mailer.")
+	body, err := json.Marshal(types.ForgotPasswordRequest{
+		Email: variables.accounts[1].Email,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/account/password/forgot", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.ForgotPassword(c)) {
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	}
+	m := &testMailer{}
+	mailer = m
+	// Test bad request
+	t.Log("Testing bad request.")
+	request = httptest.NewRequest(http.MethodPost, "/account/password/forgot", strings.NewReader("////"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.ForgotPassword(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test invalid email
+	t.Log("Testing invalid email.")
+	body, err = json.Marshal(types.ForgotPasswordRequest{
+		Email: "not an email",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/account/password/forgot", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.ForgotPassword(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test unknown email, answered the same as a known one but nothing is sent
+	t.Log("Testing unknown email.")
+	body, err = json.Marshal(types.ForgotPasswordRequest{
+		Email: "unknown@test.com",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/account/password/forgot", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.ForgotPassword(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	assert.Empty(t, m.received())
+	// Test valid
+	t.Log("Testing valid request.")
+	token := forgotPassword(t, h, m, variables.accounts[1].Email)
+	sent := m.received()
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, []string{variables.accounts[1].Email}, sent[0].recipients)
+		assert.Equal(t, "Chronokeep password reset", sent[0].subject)
+	}
+	// Test asking again sends a different token
+	t.Log("Testing second request.")
+	for i := 1; i < passwordResetMaxAccountRequests; i++ {
+		next := forgotPassword(t, h, m, variables.accounts[1].Email)
+		assert.NotEqual(t, token, next)
+		token = next
+	}
+	assert.Len(t, m.received(), passwordResetMaxAccountRequests)
+	// Test asking too many times doesn't send another token
+	t.Log("Testing too many requests for an account.")
+	request = httptest.NewRequest(http.MethodPost, "/account/password/forgot", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.ForgotPassword(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	assert.Len(t, m.received(), passwordResetMaxAccountRequests)
+}
+
+func TestForgotPasswordTooManyRequests(t *testing.T) {
+	// POST, /account/password/forgot
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	m := &testMailer{}
+	mailer = m
+	config.IPMaxFailures = 3
+	config.IPLockoutMinutes = 15
+	forgotFrom := func(email, ip string) int {
+		body, err := json.Marshal(types.ForgotPasswordRequest{
+			Email: email,
+		})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request := httptest.NewRequest(http.MethodPost, "/account/password/forgot", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.RemoteAddr = ip + ":1234"
+		response := httptest.NewRecorder()
+		c := e.NewContext(request, response)
+		assert.NoError(t, h.ForgotPassword(c))
+		return response.Code
+	}
+	// Requests count against the address whatever account they were for, even ones that don't exist.
+	t.Log("Testing requests from an address.")
+	emails := []string{"unknown@test.com", variables.accounts[1].Email, variables.accounts[2].Email}
+	for i := 0; i < passwordResetMaxAddressRequests; i++ {
+		assert.Equal(t, http.StatusOK, forgotFrom(emails[i%len(emails)], "192.0.2.10"))
+	}
+	// Blocked
+	t.Log("Testing request from a blocked address.")
+	assert.Equal(t, http.StatusTooManyRequests, forgotFrom(variables.accounts[0].Email, "192.0.2.10"))
+	assert.Len(t, m.received(), passwordResetMaxAccountRequests*2)
+	// Reset requests aren't failed logins, so logging in from the address still works.
+	t.Log("Testing login from an address blocked from resets.")
+	failures, err := database.GetLoginFailures("192.0.2.10")
+	if assert.NoError(t, err) {
+		assert.Nil(t, failures)
+	}
+	assert.Equal(t, http.StatusOK, loginFrom(t, e, h, variables.accounts[0].Email, variables.testPassword1, "192.0.2.10"))
+	// Other addresses aren't blocked.
+	t.Log("Testing request from another address.")
+	assert.Equal(t, http.StatusOK, forgotFrom(variables.accounts[0].Email, "192.0.2.11"))
+	assert.Len(t, m.received(), passwordResetMaxAccountRequests*2+1)
+}
+
+func TestResetPassword(t *testing.T) {
+	// POST, /account/password/reset
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	m := &testMailer{}
+	mailer = m
+	account := variables.accounts[1]
+	sessionToken := loginSession(t, account, "")
+	for i := 0; i <= db.MaxLoginAttempts; i++ {
+		database.InvalidPassword(account)
+	}
+	locked, err := database.GetAccount(account.Email)
+	if err != nil || !locked.Locked {
+		t.Fatalf("Expected account to be locked: %+v (%v)", locked, err)
+	}
+	// Test bad request
+	t.Log("Testing bad request.")
+	request := httptest.NewRequest(http.MethodPost, "/account/password/reset", strings.NewReader("////"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.ResetPassword(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	token := forgotPassword(t, h, m, account.Email)
+	for _, req := range []types.ResetPasswordRequest{
+		{Token: "", NewPassword: "newpassword"},
+		{Token: token, NewPassword: "short"},
+	} {
+		t.Logf("Testing invalid request: %+v.", req)
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request = httptest.NewRequest(http.MethodPost, "/account/password/reset", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		response = httptest.NewRecorder()
+		c = e.NewContext(request, response)
+		if assert.NoError(t, h.ResetPassword(c)) {
+			assert.Equal(t, http.StatusBadRequest, response.Code)
+		}
+	}
+	// Test invalid token
+	t.Log("Testing invalid token.")
+	body, err := json.Marshal(types.ResetPasswordRequest{
+		Token:       strings.Repeat("0", 64),
+		NewPassword: "newpassword",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/account/password/reset", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.ResetPassword(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	body, err = json.Marshal(types.ResetPasswordRequest{
+		Token:       token,
+		NewPassword: "newpassword",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/account/password/reset", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.ResetPassword(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	reset, err := database.GetAccount(account.Email)
+	if assert.NoError(t, err) {
+		assert.False(t, reset.Locked)
+		assert.Equal(t, 0, reset.WrongPassAttempts)
+	}
+	// Existing sessions are ended.
+	t.Log("Testing old session.")
+	request = httptest.NewRequest(http.MethodGet, "/r/account/sessions", nil)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+sessionToken)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetSessions)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// The new password works.
+	t.Log("Testing login with new password.")
+	body, err = json.Marshal(types.LoginRequest{
+		Email:    account.Email,
+		Password: "newpassword",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/account/login", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.Login(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	// Tokens can only be used once.
+	t.Log("Testing used token.")
+	body, err = json.Marshal(types.ResetPasswordRequest{
+		Token:       token,
+		NewPassword: "otherpassword",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/account/password/reset", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.ResetPassword(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+}
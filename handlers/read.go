@@ -19,10 +19,12 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package handlers
 
 import (
+	db "chronokeep/remote/database"
 	"chronokeep/remote/types"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v5"
 )
@@ -51,18 +53,48 @@ func (h Handler) GetReads(c *echo.Context) error {
 	if mkey.Key.Expired() {
 		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
 	}
-	reads, err := database.GetReads(mkey.Account.Identifier, request.ReaderName, request.Start, request.End)
-	if err != nil {
-		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Reads", err)
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
+	var reads []types.Read
+	nextCursor := ""
+	// A limit or cursor means the caller wants the reads one page at a time.
+	if request.Limit > 0 || request.Cursor != "" {
+		limit := request.Limit
+		if limit < 1 || limit > db.MaxReadsPageSize {
+			limit = db.MaxReadsPageSize
+		}
+		var after *types.ReadCursor
+		if request.Cursor != "" {
+			after, err = types.DecodeReadCursor(request.Cursor)
+			if err != nil {
+				return getAPIError(c, http.StatusBadRequest, "Invalid Cursor", err)
+			}
+		}
+		// Ask for one more than the limit so we know if there's another page.
+		reads, err = database.GetReadsPage(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName, request.Start, request.End, after, limit+1)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Reads", err)
+		}
+		if len(reads) > limit {
+			reads = reads[:limit]
+			nextCursor = reads[limit-1].Cursor().Encode()
+		}
+	} else {
+		reads, err = database.GetReads(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName, request.Start, request.End)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Reads", err)
+		}
 	}
-	note, err := database.GetNotification(mkey.Account.Identifier, request.ReaderName)
+	note, err := database.GetNotification(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Notification", err)
 	}
 	return c.JSON(http.StatusOK, types.GetReadsResponse{
-		Count: int64(len(reads)),
-		Reads: reads,
-		Note:  note,
+		Count:      int64(len(reads)),
+		Reads:      reads,
+		Note:       note,
+		NextCursor: nextCursor,
 	})
 }
 
@@ -93,24 +125,62 @@ func (h Handler) AddReads(c *echo.Context) error {
 	if mkey.Key.Expired() {
 		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
 	}
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
 	// Check to ensure a write/delete key
 	if mkey.Key.Type != "write" && mkey.Key.Type != "delete" {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("read key attempting to write"))
 	}
 	// validate read data
+	results := make([]types.ReadResult, len(request.Reads))
 	upload := make([]types.Read, 0)
-	for _, r := range request.Reads {
-		if err := r.Validate(h.validate); err == nil {
-			upload = append(upload, r)
+	uploadIndex := make([]int, 0)
+	for i, r := range request.Reads {
+		if err := r.Validate(h.validate); err != nil {
+			results[i] = types.ReadResult{
+				Index:      i,
+				Identifier: r.Identifier,
+				Status:     types.ReadInvalid,
+				Reason:     err.Error(),
+			}
+			continue
 		}
+		upload = append(upload, r)
+		uploadIndex = append(uploadIndex, i)
 	}
 	// update reads
-	uploaded, err := database.AddReads(mkey.Key.Value, upload)
+	uploaded, err := database.AddReads(*k, upload)
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Adding Keys to Database", err)
 	}
+	// uploaded holds the reads actually inserted in the order given, anything skipped was a duplicate
+	next := 0
+	for i, r := range upload {
+		status := types.ReadDuplicate
+		if next < len(uploaded) && r.Equals(&uploaded[next]) {
+			status = types.ReadAccepted
+			next++
+		}
+		results[uploadIndex[i]] = types.ReadResult{
+			Index:      uploadIndex[i],
+			Identifier: r.Identifier,
+			Status:     status,
+		}
+	}
+	// let anyone streaming this reader know about the new reads
+	hub.publish(mkey.Account.Identifier, mkey.Key.Organization, mkey.Key.Name, uploaded)
+	if len(uploaded) > 0 {
+		webhooks.queue(mkey.Account.Identifier, mkey.Key.Organization, types.WebhookPayload{
+			Event:  types.WebhookReadsAdded,
+			When:   time.Now(),
+			Reader: mkey.Key.Name,
+			Reads:  uploaded,
+		})
+	}
 	return c.JSON(http.StatusOK, types.UploadReadsResponse{
-		Count: int64(len(uploaded)),
+		Count:   int64(len(uploaded)),
+		Results: results,
 	})
 }
 
@@ -142,17 +212,23 @@ func (h Handler) DeleteReads(c *echo.Context) error {
 	if mkey.Key.Expired() {
 		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
 	}
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
 	if mkey.Key.Type != "delete" {
 		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("attempt to delete with read/write key"))
 	}
+	if !hasPermission(mkey.Account, types.PermReadsDelete) {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account can't delete reads"))
+	}
 	// delete reads
 	var count int64
 	if request.Start != nil && request.End != nil {
-		count, err = database.DeleteReaderReads(mkey.Account.Identifier, request.ReaderName, *request.Start, *request.End)
+		count, err = database.DeleteReaderReads(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName, *request.Start, *request.End)
 	} else if request.End != nil {
-		count, err = database.DeleteReaderReadsBefore(mkey.Account.Identifier, request.ReaderName, *request.End)
+		count, err = database.DeleteReaderReadsBefore(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName, *request.End)
 	} else {
-		count, err = database.DeleteReaderReadsBetween(mkey.Account.Identifier, request.ReaderName)
+		count, err = database.DeleteReaderReadsBetween(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName)
 	}
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Deleting Reads", fmt.Errorf("delete returned error: %v", err))
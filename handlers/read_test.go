@@ -243,6 +243,96 @@ func TestGetReads(t *testing.T) {
 	}
 }
 
+func TestGetReadsPaged(t *testing.T) {
+	// GET, /reads with limit/cursor
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	// Test invalid cursor
+	t.Log("Testing invalid cursor.")
+	body, err := json.Marshal(types.GetReadsRequest{
+		ReaderName: "reader6",
+		Start:      0,
+		End:        10000,
+		Cursor:     "not-a-cursor",
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "/reads", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.GetReads(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test paging through all reads
+	t.Log("Testing paging through reads.")
+	cursor := ""
+	found := make([]types.Read, 0)
+	for i := 0; i < 10; i++ {
+		body, err = json.Marshal(types.GetReadsRequest{
+			ReaderName: "reader6",
+			Start:      0,
+			End:        10000,
+			Limit:      100,
+			Cursor:     cursor,
+		})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request = httptest.NewRequest(http.MethodGet, "/reads", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+		response = httptest.NewRecorder()
+		c = e.NewContext(request, response)
+		if !assert.NoError(t, h.GetReads(c)) || !assert.Equal(t, http.StatusOK, response.Code) {
+			break
+		}
+		var resp types.GetReadsResponse
+		if !assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			break
+		}
+		assert.Equal(t, resp.Count, int64(len(resp.Reads)))
+		assert.True(t, len(resp.Reads) <= 100)
+		found = append(found, resp.Reads...)
+		cursor = resp.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, 300, len(found))
+	for i := 1; i < len(found); i++ {
+		assert.True(t, found[i-1].Seconds <= found[i].Seconds)
+	}
+	// Test a final page smaller than the limit has no cursor
+	t.Log("Testing last page.")
+	body, err = json.Marshal(types.GetReadsRequest{
+		ReaderName: "reader6",
+		Start:      0,
+		End:        10000,
+		Limit:      1000,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/reads", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.GetReads(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetReadsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, 300, len(resp.Reads))
+			assert.Equal(t, "", resp.NextCursor)
+		}
+	}
+}
+
 func TestAddReads(t *testing.T) {
 	// POST, /reads/add
 	variables, finalize := setupTests(t)
@@ -513,6 +603,66 @@ func TestAddReads(t *testing.T) {
 			assert.Equal(t, int64(0), resp.Count)
 		}
 	}
+	// Test per read results
+	t.Log("Testing per read results.")
+	body, err = json.Marshal(types.UploadReadsRequest{
+		Reads: []types.Read{
+			{
+				Type:         "reader",
+				Identifier:   "1000",
+				IdentType:    "chip",
+				Milliseconds: 0,
+				Seconds:      0,
+			},
+			{
+				Type:         "reader",
+				Identifier:   "9001",
+				IdentType:    "not-a-type",
+				Milliseconds: 0,
+				Seconds:      0,
+			},
+			{
+				Type:         "reader",
+				Identifier:   "9002",
+				IdentType:    "chip",
+				Milliseconds: 0,
+				Seconds:      12,
+			},
+			{
+				Type:         "reader",
+				Identifier:   "9002",
+				IdentType:    "chip",
+				Milliseconds: 0,
+				Seconds:      12,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AddReads(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.UploadReadsResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, int64(1), resp.Count)
+			if assert.Equal(t, 4, len(resp.Results)) {
+				for i, result := range resp.Results {
+					assert.Equal(t, i, result.Index)
+				}
+				assert.Equal(t, types.ReadDuplicate, resp.Results[0].Status)
+				assert.Equal(t, types.ReadInvalid, resp.Results[1].Status)
+				assert.NotEqual(t, "", resp.Results[1].Reason)
+				assert.Equal(t, types.ReadAccepted, resp.Results[2].Status)
+				assert.Equal(t, "9002", resp.Results[2].Identifier)
+				assert.Equal(t, types.ReadDuplicate, resp.Results[3].Status)
+			}
+		}
+	}
 }
 
 func TestDeleteReads(t *testing.T) {
@@ -633,7 +783,7 @@ func TestDeleteReads(t *testing.T) {
 		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
 			assert.Equal(t, int64(0), resp.Count)
 		}
-		nr, err := database.GetReads(variables.accounts[1].Identifier, "reader6", 0, 10000)
+		nr, err := database.GetReads(variables.accounts[1].Identifier, nil, "reader6", 0, 10000)
 		if assert.NoError(t, err) {
 			assert.Equal(t, 300, len(nr))
 		}
@@ -657,7 +807,7 @@ func TestDeleteReads(t *testing.T) {
 		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
 			assert.Equal(t, int64(300), resp.Count)
 		}
-		nr, err := database.GetReads(variables.accounts[0].Identifier, "reader1", 0, 10000)
+		nr, err := database.GetReads(variables.accounts[0].Identifier, nil, "reader1", 0, 10000)
 		if assert.NoError(t, err) {
 			assert.Equal(t, 0, len(nr))
 		}
@@ -679,7 +829,7 @@ func TestDeleteReads(t *testing.T) {
 	c = e.NewContext(request, response)
 	if assert.NoError(t, h.DeleteReads(c)) {
 		assert.Equal(t, http.StatusOK, response.Code)
-		nr, err := database.GetReads(variables.accounts[0].Identifier, "reader2", 0, 10000)
+		nr, err := database.GetReads(variables.accounts[0].Identifier, nil, "reader2", 0, 10000)
 		if assert.NoError(t, err) {
 			for _, r := range nr {
 				assert.True(t, r.Seconds > end)
@@ -701,7 +851,7 @@ func TestDeleteReads(t *testing.T) {
 	c = e.NewContext(request, response)
 	if assert.NoError(t, h.DeleteReads(c)) {
 		assert.Equal(t, http.StatusOK, response.Code)
-		nr, err := database.GetReads(variables.accounts[0].Identifier, "reader2", 0, 10000)
+		nr, err := database.GetReads(variables.accounts[0].Identifier, nil, "reader2", 0, 10000)
 		if assert.NoError(t, err) {
 			for _, r := range nr {
 				assert.True(t, r.Seconds > end)
@@ -727,7 +877,7 @@ func TestDeleteReads(t *testing.T) {
 	c = e.NewContext(request, response)
 	if assert.NoError(t, h.DeleteReads(c)) {
 		assert.Equal(t, http.StatusOK, response.Code)
-		nr, err := database.GetReads(variables.accounts[0].Identifier, "reader2", 0, 10000)
+		nr, err := database.GetReads(variables.accounts[0].Identifier, nil, "reader2", 0, 10000)
 		if assert.NoError(t, err) {
 			for _, r := range nr {
 				assert.True(t, r.Seconds < start || r.Seconds > end)
@@ -751,7 +901,7 @@ func TestDeleteReads(t *testing.T) {
 	c = e.NewContext(request, response)
 	if assert.NoError(t, h.DeleteReads(c)) {
 		assert.Equal(t, http.StatusOK, response.Code)
-		nr, err := database.GetReads(variables.accounts[0].Identifier, "reader2", 0, 10000)
+		nr, err := database.GetReads(variables.accounts[0].Identifier, nil, "reader2", 0, 10000)
 		if assert.NoError(t, err) {
 			for _, r := range nr {
 				assert.True(t, r.Seconds < start || r.Seconds > end)
@@ -20,7 +20,9 @@ package handlers
 
 import (
 	"chronokeep/remote/types"
+	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/labstack/echo/v5"
 )
@@ -46,7 +48,19 @@ func (h Handler) GetReaders(c *echo.Context) error {
 	if mkey.Key.Expired() {
 		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
 	}
-	keys, err := database.GetAccountKeys(mkey.Account.Email)
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
+	// Organization keys only see the organization's readers, and other keys only the readers outside of one.
+	var keys []types.Key
+	if mkey.Key.Organization != nil {
+		keys, err = database.GetOrganizationKeys(*mkey.Key.Organization)
+	} else {
+		keys, err = database.GetAccountKeys(mkey.Account.Email)
+		keys = slices.DeleteFunc(keys, func(k types.Key) bool {
+			return k.Organization != nil
+		})
+	}
 	if err != nil {
 		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Reader Names", err)
 	}
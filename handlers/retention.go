@@ -0,0 +1,131 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+var retention = &retentionWorker{}
+
+// retentionWorker removes reads that are older than the retention period set for the role of the
+// account that owns them and keeps track of the result of the last pass.
+type retentionWorker struct {
+	mu   sync.Mutex
+	last *types.RetentionRun
+}
+
+func (r *retentionWorker) run(now time.Time) types.RetentionRun {
+	run := types.RetentionRun{
+		Started: now,
+		Removed: make(map[string]int64),
+	}
+	roles, err := database.GetRoles()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Unable to retrieve roles to purge reads for.")
+		run.Errors = append(run.Errors, err.Error())
+	}
+	// An account's type is its role.
+	for _, role := range roles {
+		days := config.RetentionDays(role.Name)
+		if days < 1 {
+			continue
+		}
+		before := now.AddDate(0, 0, -days)
+		count, err := database.DeleteAccountTypeReadsBefore(role.Name, before.Unix())
+		if err != nil {
+			log.WithFields(log.Fields{
+				"role":  role.Name,
+				"error": err,
+			}).Error("Unable to purge reads.")
+			run.Errors = append(run.Errors, role.Name+": "+err.Error())
+			continue
+		}
+		run.Removed[role.Name] = count
+		log.WithFields(log.Fields{
+			"role":    role.Name,
+			"before":  before.Format(time.RFC3339),
+			"removed": count,
+		}).Info("Purged old reads.")
+	}
+	run.Finished = time.Now()
+	r.mu.Lock()
+	r.last = &run
+	r.mu.Unlock()
+	return run
+}
+
+func (r *retentionWorker) lastRun() *types.RetentionRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// StartRetention starts the retention worker, which runs once immediately and then every
+// RecordInterval seconds. Calling the returned function stops it.
+func StartRetention() func() {
+	interval := time.Duration(config.RecordInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute * 5
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		retention.run(time.Now())
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				retention.run(now)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+func (h Handler) GetRetention(c *echo.Context) error {
+	roles, err := database.GetRoles()
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Roles", err)
+	}
+	days := make(map[string]int)
+	for _, role := range roles {
+		days[role.Name] = config.RetentionDays(role.Name)
+	}
+	return c.JSON(http.StatusOK, types.GetRetentionResponse{
+		Interval:      config.RecordInterval,
+		RetentionDays: days,
+		LastRun:       retention.lastRun(),
+	})
+}
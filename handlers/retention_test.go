@@ -0,0 +1,153 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetention(t *testing.T) {
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	config.Retention = map[string]int{"free": 1, "paid": 0}
+	config.RetentionDefault = 30
+	defer func() {
+		config.Retention = nil
+		config.RetentionDefault = 0
+	}()
+	if err := database.SaveRole(types.Role{Name: "timer", Permissions: []string{types.PermKeysCreate}}); err != nil {
+		t.Fatalf("Error adding role for test: %v", err)
+	}
+	// Nothing is older than the retention period yet.
+	t.Log("Testing retention with nothing to remove.")
+	run := retention.run(time.Now())
+	assert.Equal(t, int64(0), run.Removed["free"])
+	assert.Equal(t, int64(0), run.Removed["admin"])
+	_, found := run.Removed["paid"]
+	assert.False(t, found)
+	// Roles without their own retention period use the default.
+	_, found = run.Removed["timer"]
+	assert.True(t, found)
+	assert.Empty(t, run.Errors)
+	// Two days from now the free account's reads are past their retention period.
+	t.Log("Testing retention removing free account reads.")
+	run = retention.run(time.Now().Add(time.Hour * 48))
+	assert.Equal(t, int64(4*300), run.Removed["free"])
+	assert.Equal(t, int64(0), run.Removed["admin"])
+	assert.Empty(t, run.Errors)
+	reads, err := database.GetReads(variables.accounts[1].Identifier, nil, "reader6", 0, 300*25)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(reads))
+	}
+	reads, err = database.GetReads(variables.accounts[0].Identifier, nil, variables.knownValues["writeName"], 0, 300*25)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 300, len(reads))
+	}
+	last := retention.lastRun()
+	if assert.NotNil(t, last) {
+		assert.Equal(t, run.Started, last.Started)
+		assert.Equal(t, run.Removed, last.Removed)
+	}
+}
+
+func TestGetRetention(t *testing.T) {
+	// GET, /r/retention
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	config.Retention = map[string]int{"free": 7}
+	config.RetentionDefault = 30
+	defer func() {
+		config.Retention = nil
+		config.RetentionDefault = 0
+	}()
+	run := retention.run(time.Now())
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	request := httptest.NewRequest(http.MethodGet, "/r/retention", strings.NewReader(string("")))
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize(types.PermRetentionView)(h.GetRetention)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test not admin
+	t.Log("Testing not admin.")
+	account := variables.accounts[2]
+	token, refresh, err := createTokens(account.Email)
+	if err != nil {
+		t.Fatalf("Error creating test tokens: %v", err)
+	}
+	_, err = database.AddSession(types.Session{
+		AccountIdentifier: account.Identifier,
+		Token:             *token,
+		RefreshToken:      *refresh,
+	})
+	if err != nil {
+		t.Fatalf("Error updating tokens for test: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/r/retention", strings.NewReader(string("")))
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+*token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize(types.PermRetentionView)(h.GetRetention)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test admin
+	t.Log("Testing admin.")
+	account = variables.accounts[0]
+	token, refresh, err = createTokens(account.Email)
+	if err != nil {
+		t.Fatalf("Error creating test tokens: %v", err)
+	}
+	_, err = database.AddSession(types.Session{
+		AccountIdentifier: account.Identifier,
+		Token:             *token,
+		RefreshToken:      *refresh,
+	})
+	if err != nil {
+		t.Fatalf("Error updating tokens for test: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodGet, "/r/retention", strings.NewReader(string("")))
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+*token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize(types.PermRetentionView)(h.GetRetention)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetRetentionResponse
+		err = json.Unmarshal(response.Body.Bytes(), &resp)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 7, resp.RetentionDays["free"])
+			assert.Equal(t, 30, resp.RetentionDays["paid"])
+			assert.Equal(t, 30, resp.RetentionDays["admin"])
+			if assert.NotNil(t, resp.LastRun) {
+				assert.Equal(t, run.Removed, resp.LastRun.Removed)
+			}
+		}
+	}
+}
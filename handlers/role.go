@@ -0,0 +1,99 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+)
+
+func (h Handler) GetRoles(c *echo.Context) error {
+	roles, err := database.GetRoles()
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Roles", err)
+	}
+	if roles == nil {
+		roles = make([]types.Role, 0)
+	}
+	return c.JSON(http.StatusOK, types.GetRolesResponse{
+		Roles:       roles,
+		Permissions: types.Permissions(),
+	})
+}
+
+func (h Handler) SaveRole(c *echo.Context) error {
+	var request types.SaveRoleRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if err := request.Role.Validate(h.validate); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Role", err)
+	}
+	// Don't let an account take away its own ability to manage roles, nobody might be left to give it back.
+	account := authorizedAccount(c)
+	if request.Role.Name == account.Type && !request.Role.Can(types.PermRolesManage) {
+		return getAPIError(c, http.StatusBadRequest, "Unable To Remove Own Role Management", nil)
+	}
+	err := database.SaveRole(request.Role)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Unable To Save Role", err)
+	}
+	role, err := database.GetRole(request.Role.Name)
+	if err != nil || role == nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Role", err)
+	}
+	return c.JSON(http.StatusOK, types.ModifyRoleResponse{
+		Role: *role,
+	})
+}
+
+func (h Handler) DeleteRole(c *echo.Context) error {
+	var request types.DeleteRoleRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	if err := h.validate.Struct(request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Bad Request", err)
+	}
+	role, err := database.GetRole(request.Name)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Role", err)
+	}
+	if role == nil {
+		return getAPIError(c, http.StatusNotFound, "Role Not Found", nil)
+	}
+	// Accounts can't be left without a role.
+	accounts, err := database.GetAccounts()
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	for _, account := range accounts {
+		if account.Type == role.Name {
+			return getAPIError(c, http.StatusBadRequest, "Role In Use", fmt.Errorf("role '%s' is used by %s", role.Name, account.Email))
+		}
+	}
+	err = database.DeleteRole(role.Name)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Unable To Delete Role", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
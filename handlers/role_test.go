@@ -0,0 +1,196 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRoles(t *testing.T) {
+	// GET, /r/role
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	c, response := newRequestContext(t, http.MethodGet, "/r/role", "", nil)
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.GetRoles)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test account without permission
+	t.Log("Testing account without permission.")
+	c, response = newRequestContext(t, http.MethodGet, "/r/role", loginSession(t, variables.accounts[1], ""), nil)
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.GetRoles)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodGet, "/r/role", loginSession(t, variables.accounts[0], ""), nil)
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.GetRoles)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetRolesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.Roles, len(types.DefaultRoles()))
+			assert.ElementsMatch(t, types.Permissions(), resp.Permissions)
+		}
+	}
+}
+
+func TestSaveRole(t *testing.T) {
+	// POST, /r/role/save
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	adminToken := loginSession(t, variables.accounts[0], "")
+	// Test account without permission
+	t.Log("Testing account without permission.")
+	support := types.Role{Name: "support", Permissions: []string{types.PermAccountsView}}
+	c, response := newRequestContext(t, http.MethodPost, "/r/role/save", loginSession(t, variables.accounts[1], ""), types.SaveRoleRequest{Role: support})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.SaveRole)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test invalid name
+	t.Log("Testing invalid role name.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/role/save", adminToken, types.SaveRoleRequest{Role: types.Role{Name: "Not A Name"}})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.SaveRole)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test unknown permission
+	t.Log("Testing unknown permission.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/role/save", adminToken, types.SaveRoleRequest{Role: types.Role{
+		Name:        "support",
+		Permissions: []string{"everything.allowed"},
+	}})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.SaveRole)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test removing own role management
+	t.Log("Testing removing own role management.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/role/save", adminToken, types.SaveRoleRequest{Role: types.Role{
+		Name:        types.RoleAdmin,
+		Permissions: []string{types.PermAccountsView},
+	}})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.SaveRole)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/role/save", adminToken, types.SaveRoleRequest{Role: support})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.SaveRole)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ModifyRoleResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Equal(t, support, resp.Role)
+		}
+	}
+	// Accounts given the role get its permissions.
+	t.Log("Testing account given the new role.")
+	account := variables.accounts[1]
+	account.Type = "support"
+	assert.NoError(t, database.UpdateAccount(account))
+	supportToken := loginSession(t, account, "")
+	c, response = newRequestContext(t, http.MethodGet, "/r/account/all", supportToken, nil)
+	if assert.NoError(t, h.authorize(types.PermAccountsView)(h.GetAccounts)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/unlock", supportToken, types.DeleteAccountRequest{Email: variables.accounts[2].Email})
+	if assert.NoError(t, h.authorize(types.PermAccountsUnlock)(h.Unlock)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Roles can be changed at runtime.
+	t.Log("Testing changing the role's permissions.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/role/save", adminToken, types.SaveRoleRequest{Role: types.Role{
+		Name:        "support",
+		Permissions: []string{types.PermAccountsUnlock},
+	}})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.SaveRole)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	lockAccount(t, variables.accounts[2].Email, echo.New(), h)
+	c, response = newRequestContext(t, http.MethodGet, "/r/account/all", supportToken, nil)
+	if assert.NoError(t, h.authorize(types.PermAccountsView)(h.GetAccounts)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/unlock", supportToken, types.DeleteAccountRequest{Email: variables.accounts[2].Email})
+	if assert.NoError(t, h.authorize(types.PermAccountsUnlock)(h.Unlock)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	// DELETE, /r/role/delete
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	adminToken := loginSession(t, variables.accounts[0], "")
+	err := database.SaveRole(types.Role{Name: "support", Permissions: []string{types.PermAccountsView}})
+	if err != nil {
+		t.Fatalf("Error saving role: %v", err)
+	}
+	// Test account without permission
+	t.Log("Testing account without permission.")
+	c, response := newRequestContext(t, http.MethodDelete, "/r/role/delete", loginSession(t, variables.accounts[1], ""), types.DeleteRoleRequest{Name: "support"})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.DeleteRole)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test unknown role
+	t.Log("Testing unknown role.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/role/delete", adminToken, types.DeleteRoleRequest{Name: "unknown"})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.DeleteRole)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test role in use
+	t.Log("Testing role in use.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/role/delete", adminToken, types.DeleteRoleRequest{Name: types.RoleFree})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.DeleteRole)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/role/delete", adminToken, types.DeleteRoleRequest{Name: "support"})
+	if assert.NoError(t, h.authorize(types.PermRolesManage)(h.DeleteRole)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	role, err := database.GetRole("support")
+	if assert.NoError(t, err) {
+		assert.Nil(t, role)
+	}
+	// Accounts can't be given a role that doesn't exist.
+	t.Log("Testing account with deleted role.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/add", adminToken, types.AddAccountRequest{
+		Account: types.Account{
+			Name:  "Support Person",
+			Email: "support@test.com",
+			Type:  "support",
+		},
+		Password: variables.testPassword1,
+	})
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.AddAccount)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+}
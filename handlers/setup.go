@@ -19,9 +19,12 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package handlers
 
 import (
+	"chronokeep/remote/auth"
 	db "chronokeep/remote/database"
+	"chronokeep/remote/database/memory"
 	"chronokeep/remote/database/mysql"
 	"chronokeep/remote/database/postgres"
+	"chronokeep/remote/database/sqlite"
 	"chronokeep/remote/util"
 	"errors"
 
@@ -32,10 +35,22 @@ import (
 var (
 	database db.Database
 	config   *util.Config
+	// signingKeys holds the keys tokens are signed with, the first signs new tokens.
+	// Tokens are signed with the shared secrets in the config when there are none.
+	signingKeys []*auth.SigningKey
 )
 
 func Setup(inCfg *util.Config) error {
 	config = inCfg
+	mailer = nil
+	if config.SMTPHost != "" {
+		mailer = newSMTPMailer(config)
+	}
+	var err error
+	signingKeys, err = auth.LoadSigningKeys(config.JWTKeyFiles)
+	if err != nil {
+		return err
+	}
 	switch config.DBDriver {
 	case "mysql":
 		log.Info("Database set to MySQL")
@@ -45,15 +60,62 @@ func Setup(inCfg *util.Config) error {
 		log.Info("Database set to Postgresql")
 		database = &postgres.Postgres{}
 		return database.Setup(config)
+	case "sqlite", "sqlite3":
+		log.Info("Database set to SQLite")
+		database = &sqlite.SQLite{}
+		return database.Setup(config)
+	case "memory":
+		log.Info("Database set to memory, nothing will be kept once the server stops")
+		database = &memory.Memory{}
+		return database.Setup(config)
 	default:
 		return errors.New("unknown database driver specified")
 	}
 }
 
+// Connect Connects to the configured database without updating its tables, so the migrate command can see
+// and change the version it's at. The tables of an empty database are created at the current version.
+func Connect(inCfg *util.Config) error {
+	config = inCfg
+	var err error
+	switch config.DBDriver {
+	case "mysql":
+		conn := &mysql.MySQL{}
+		database = conn
+		if _, err = conn.GetDatabase(config); err == nil {
+			err = conn.Bootstrap()
+		}
+	case "postgres":
+		conn := &postgres.Postgres{}
+		database = conn
+		if _, err = conn.GetDatabase(config); err == nil {
+			err = conn.Bootstrap()
+		}
+	case "sqlite", "sqlite3":
+		conn := &sqlite.SQLite{}
+		database = conn
+		if _, err = conn.GetDatabase(config); err == nil {
+			err = conn.Bootstrap()
+		}
+	case "memory":
+		// There's nothing to connect to, the tables only exist once it's set up.
+		database = &memory.Memory{}
+		err = database.Setup(config)
+	default:
+		return errors.New("unknown database driver specified")
+	}
+	return err
+}
+
 func Finalize() {
 	database.Close()
 }
 
+// Database Returns the database set up by Setup, for commands that work on it without the server running.
+func Database() db.Database {
+	return database
+}
+
 func (h *Handler) Setup() {
 	// Set up Validator.
 	h.validate = validator.New()
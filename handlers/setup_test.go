@@ -20,18 +20,27 @@ package handlers
 
 import (
 	"chronokeep/remote/auth"
+	"chronokeep/remote/auth/authtest"
+	"chronokeep/remote/database/memory"
 	"chronokeep/remote/database/sqlite"
 	"chronokeep/remote/types"
 	"chronokeep/remote/util"
+	"encoding/json"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/labstack/echo/v5"
 )
 
+func TestMain(m *testing.M) {
+	authtest.Main(m)
+}
+
 func setupTests(t *testing.T) (SetupVariables, func(t *testing.T)) {
-	t.Log("Setting up sqlite database.")
-	database = &sqlite.SQLite{}
 	config = &util.Config{
 		DBName:     "./remote_test.sqlite",
 		DBHost:     "",
@@ -39,8 +48,21 @@ func setupTests(t *testing.T) (SetupVariables, func(t *testing.T)) {
 		DBPassword: "",
 		DBPort:     0,
 		DBDriver:   "sqlite3",
+		SecretKey:  "test-secret-key-for-tokens",
+		RefreshKey: "test-refresh-key-for-tokens",
+	}
+	// TEST_DB_CONNECTOR=memory runs the tests against the in-memory database instead of a file.
+	if os.Getenv("TEST_DB_CONNECTOR") == "memory" {
+		t.Log("Setting up memory database.")
+		config.DBDriver = "memory"
+		database = &memory.Memory{}
+	} else {
+		t.Log("Setting up sqlite database.")
+		database = &sqlite.SQLite{}
 	}
 	database.Setup(config)
+	mailer = nil
+	signingKeys = nil
 	t.Log("Setting up config variables to export.")
 	output := SetupVariables{
 		testPassword1: "amazingpassword",
@@ -104,6 +126,8 @@ func setupTests(t *testing.T) (SetupVariables, func(t *testing.T)) {
 	output.knownValues["write"] = "030001-1ACSDD-K2389A-22123B"
 	output.knownValues["writeName"] = "reader2"
 	output.knownValues["write2"] = "030001-1ACSCT-K2389A-22423BAA"
+	// Only the key hashes are stored, keep the values so the keys retrieved can be used in tests.
+	values := make(map[string]string)
 	for _, key := range []types.Key{
 		{
 			AccountIdentifier: output.accounts[0].Identifier,
@@ -169,6 +193,7 @@ func setupTests(t *testing.T) (SetupVariables, func(t *testing.T)) {
 		if k == nil {
 			t.Errorf("Error adding key: %v -- %v : %v", key, key.AccountIdentifier, key.Name)
 		} else {
+			values[k.Hash] = k.Value
 			t.Logf("Adding reads for key: %v", k.Value)
 			r, err := database.AddReads(k.Value, reads)
 			if err != nil {
@@ -190,11 +215,11 @@ func setupTests(t *testing.T) (SetupVariables, func(t *testing.T)) {
 			When: when.Add(time.Minute * -10).UTC().Format(time.RFC3339),
 		},
 	}
-	err = database.SaveNotification(&notes[0], "030001-1ACSCT-K2389A-22423BAA")
+	_, err = database.SaveNotification(&notes[0], "030001-1ACSCT-K2389A-22423BAA")
 	if err != nil {
 		t.Fatalf("Unexpected error saving notification: %v", err)
 	}
-	err = database.SaveNotification(&notes[1], "030001-1ACSCT-K2389A-22023BAA")
+	_, err = database.SaveNotification(&notes[1], "030001-1ACSCT-K2389A-22023BAA")
 	if err != nil {
 		t.Fatalf("Unexpected error saving notification: %v", err)
 	}
@@ -206,9 +231,18 @@ func setupTests(t *testing.T) (SetupVariables, func(t *testing.T)) {
 	if err != nil {
 		t.Fatalf("Unexptected error getting keys: %v", err)
 	}
+	for email, keys := range output.keys {
+		for i := range keys {
+			output.keys[email][i].Value = values[keys[i].Hash]
+		}
+	}
 	return output, func(t *testing.T) {
 		t.Log("Deleting old database.")
 		database.Close()
+		if config.DBDriver == "memory" {
+			t.Log("Cleanup successful.")
+			return
+		}
 		// sleep here for a small period of time so the program has time to close the database and we don't default to
 		// an error on the os.Remove call
 		time.Sleep(200 * time.Millisecond)
@@ -220,6 +254,23 @@ func setupTests(t *testing.T) (SetupVariables, func(t *testing.T)) {
 	}
 }
 
+// expireLock Moves the end of an account's lock into the past. The in-memory database can't be changed
+// from outside of it, so the rest of the test is skipped there.
+func expireLock(t *testing.T, account int64) {
+	sqliteDB, ok := database.(*sqlite.SQLite)
+	if !ok {
+		t.Skip("Expiring a lock needs the sqlite database.")
+	}
+	con, err := sqliteDB.GetDB()
+	if err != nil {
+		t.Fatalf("Error getting database: %v", err)
+	}
+	_, err = con.Exec("UPDATE account SET account_locked_until=? WHERE account_id=?;", time.Now().Add(-time.Second).Unix(), account)
+	if err != nil {
+		t.Fatalf("Error expiring lock: %v", err)
+	}
+}
+
 func testHashPassword(pass string) string {
 	hash, _ := auth.HashPassword(pass)
 	return hash
@@ -234,3 +285,22 @@ type SetupVariables struct {
 	knownValues   map[string]string
 }
 
+// newRequestContext Creates a context for a request with an optional access token. Strings are sent as the
+// body as they are, anything else is encoded as json.
+func newRequestContext(t *testing.T, method, path, token string, req any) (*echo.Context, *httptest.ResponseRecorder) {
+	body, ok := req.(string)
+	if !ok {
+		encoded, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		body = string(encoded)
+	}
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	response := httptest.NewRecorder()
+	return echo.New().NewContext(request, response), response
+}
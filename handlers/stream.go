@@ -0,0 +1,250 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	db "chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v5"
+)
+
+const (
+	streamHeartbeat  = time.Second * 15
+	streamBufferSize = 64
+)
+
+var (
+	hub = newReadHub()
+)
+
+// streamTarget identifies the reads of a reader. Organization keys are kept under the account that created
+// the organization, so the organization, 0 for none, keeps their readers apart from the account's own.
+type streamTarget struct {
+	account      int64
+	organization int64
+	reader       string
+}
+
+func newStreamTarget(account int64, org *int64, reader string) streamTarget {
+	target := streamTarget{account: account, reader: reader}
+	if org != nil {
+		target.organization = *org
+	}
+	return target
+}
+
+// readSubscriber receives batches of reads for a single stream. The channel is closed
+// if the subscriber falls too far behind, the client is then expected to reconnect and
+// resume from its Last-Event-ID.
+type readSubscriber struct {
+	reads chan []types.Read
+}
+
+// readHub fans reads accepted by AddReads out to every open stream for that reader.
+type readHub struct {
+	mu          sync.Mutex
+	subscribers map[streamTarget]map[*readSubscriber]struct{}
+}
+
+func newReadHub() *readHub {
+	return &readHub{
+		subscribers: make(map[streamTarget]map[*readSubscriber]struct{}),
+	}
+}
+
+func (h *readHub) subscribe(account int64, org *int64, reader string) *readSubscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	target := newStreamTarget(account, org, reader)
+	if h.subscribers[target] == nil {
+		h.subscribers[target] = make(map[*readSubscriber]struct{})
+	}
+	sub := &readSubscriber{
+		reads: make(chan []types.Read, streamBufferSize),
+	}
+	h.subscribers[target][sub] = struct{}{}
+	return sub
+}
+
+func (h *readHub) unsubscribe(account int64, org *int64, reader string, sub *readSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	target := newStreamTarget(account, org, reader)
+	if _, ok := h.subscribers[target][sub]; ok {
+		delete(h.subscribers[target], sub)
+		close(sub.reads)
+	}
+	if len(h.subscribers[target]) == 0 {
+		delete(h.subscribers, target)
+	}
+}
+
+// publish Sends reads to every subscriber of the reader without blocking. Subscribers
+// whose buffer is full are dropped.
+func (h *readHub) publish(account int64, org *int64, reader string, reads []types.Read) {
+	if len(reads) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	target := newStreamTarget(account, org, reader)
+	for sub := range h.subscribers[target] {
+		select {
+		case sub.reads <- reads:
+		default:
+			delete(h.subscribers[target], sub)
+			close(sub.reads)
+		}
+	}
+	if len(h.subscribers[target]) == 0 {
+		delete(h.subscribers, target)
+	}
+}
+
+// unreplayed Returns the reads added after the last one replayed.
+func unreplayed(reads []types.Read, replayed int64) []types.Read {
+	out := make([]types.Read, 0, len(reads))
+	for _, read := range reads {
+		if read.ID > replayed {
+			out = append(out, read)
+		}
+	}
+	return out
+}
+
+func writeReadEvent(w http.ResponseWriter, read types.Read) error {
+	data, err := json.Marshal(read)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: read\ndata: %s\n\n", read.ID, data)
+	return err
+}
+
+func (h Handler) StreamReads(c *echo.Context) error {
+	// Get Key from Authorization Header
+	k, err := retrieveKey(c.Request())
+	if err != nil {
+		return getAPIError(c, http.StatusUnauthorized, "Error Getting Key From Authorization Header", err)
+	}
+	if k == nil {
+		return getAPIError(c, http.StatusUnauthorized, "Key Not Provided in Authorization Header", nil)
+	}
+	var request types.StreamReadsRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request", err)
+	}
+	mkey, err := database.GetKeyAndAccount(*k)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Key/Account", err)
+	}
+	if mkey == nil || mkey.Key == nil || mkey.Account == nil {
+		return getAPIError(c, http.StatusUnauthorized, "Key/Account Not Found", nil)
+	}
+	// Check for expired key
+	if mkey.Key.Expired() {
+		return getAPIError(c, http.StatusUnauthorized, "Expired Key", nil)
+	}
+	if !hostAllowed(mkey.Key, c.RealIP()) {
+		return getAPIError(c, http.StatusForbidden, "Host Not Allowed", fmt.Errorf("request from '%s' not allowed", c.RealIP()))
+	}
+	// Event ids are the order reads were added in, so reads with earlier times that arrive late are still replayed.
+	var after *int64
+	if lastID := c.Request().Header.Get("Last-Event-ID"); lastID != "" {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || id < 0 {
+			return getAPIError(c, http.StatusBadRequest, "Invalid Last-Event-ID", err)
+		}
+		after = &id
+	}
+	// Subscribe before catching up from the database so nothing added in between is missed.
+	sub := hub.subscribe(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName)
+	defer hub.unsubscribe(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName, sub)
+	w := c.Response()
+	rc := http.NewResponseController(w)
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	// Replay anything added after the last event the client received.
+	var replayed int64
+	if after != nil {
+		replayed = *after
+	}
+	for after != nil {
+		reads, err := database.GetReadsAfter(mkey.Account.Identifier, mkey.Key.Organization, request.ReaderName, *after, db.MaxReadsPageSize)
+		if err != nil {
+			return fmt.Errorf("error retrieving reads to resume stream: %v", err)
+		}
+		for _, read := range reads {
+			if err := writeReadEvent(w, read); err != nil {
+				return nil
+			}
+		}
+		if len(reads) < 1 {
+			break
+		}
+		replayed = reads[len(reads)-1].ID
+		if len(reads) < db.MaxReadsPageSize {
+			break
+		}
+		after = &replayed
+	}
+	// Reads published while replaying may have been replayed too, only the batches queued by now can be.
+	overlapping := len(sub.reads)
+	if err := rc.Flush(); err != nil {
+		return nil
+	}
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case reads, ok := <-sub.reads:
+			if !ok {
+				// Dropped for falling behind, the client will reconnect with its Last-Event-ID.
+				return nil
+			}
+			if overlapping > 0 {
+				overlapping--
+				reads = unreplayed(reads, replayed)
+			}
+			for _, read := range reads {
+				if err := writeReadEvent(w, read); err != nil {
+					return nil
+				}
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"bufio"
+	"chronokeep/remote/types"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// readStreamEvents reads count read events from an SSE stream.
+func readStreamEvents(t *testing.T, scanner *bufio.Scanner, count int) []types.Read {
+	out := make([]types.Read, 0)
+	id := ""
+	for len(out) < count && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "id: ") {
+			id = strings.TrimPrefix(line, "id: ")
+		} else if strings.HasPrefix(line, "data: ") {
+			var read types.Read
+			if assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &read)) {
+				var err error
+				read.ID, err = strconv.ParseInt(id, 10, 64)
+				if assert.NoError(t, err) {
+					assert.Greater(t, read.ID, int64(0))
+				}
+				out = append(out, read)
+			}
+		}
+	}
+	return out
+}
+
+func TestReadHub(t *testing.T) {
+	h := newReadHub()
+	org := int64(3)
+	sub := h.subscribe(1, nil, "reader1")
+	other := h.subscribe(2, nil, "reader1")
+	orgSub := h.subscribe(1, &org, "reader1")
+	h.publish(1, nil, "reader1", []types.Read{{Identifier: "1"}})
+	select {
+	case reads := <-sub.reads:
+		assert.Equal(t, 1, len(reads))
+	default:
+		t.Fatal("Expected reads to be published to subscriber.")
+	}
+	assert.Equal(t, 0, len(other.reads))
+	// Organization keys are kept under an account but don't share its readers.
+	assert.Equal(t, 0, len(orgSub.reads))
+	h.publish(1, &org, "reader1", []types.Read{{Identifier: "2"}})
+	assert.Equal(t, 0, len(sub.reads))
+	assert.Equal(t, 1, len(orgSub.reads))
+	// Subscribers that fall behind get dropped.
+	for i := 0; i <= streamBufferSize; i++ {
+		h.publish(1, nil, "reader1", []types.Read{{Identifier: "1"}})
+	}
+	for range sub.reads {
+	}
+	_, ok := <-sub.reads
+	assert.False(t, ok)
+	h.unsubscribe(1, nil, "reader1", sub)
+	h.unsubscribe(2, nil, "reader1", other)
+	h.unsubscribe(1, &org, "reader1", orgSub)
+	assert.Equal(t, 0, len(h.subscribers))
+}
+
+func TestUnreplayed(t *testing.T) {
+	reads := []types.Read{
+		{ID: 4, Identifier: "1000", Seconds: 10, Milliseconds: 0, IdentType: "chip"},
+		{ID: 5, Identifier: "1001", Seconds: 10, Milliseconds: 0, IdentType: "chip"},
+		{ID: 6, Identifier: "1000", Seconds: 9, Milliseconds: 500, IdentType: "chip"},
+		{ID: 7, Identifier: "1000", Seconds: 11, Milliseconds: 0, IdentType: "chip"},
+	}
+	// Nothing was replayed, so every read is sent.
+	assert.Equal(t, reads, unreplayed(reads, 0))
+	// Reads up to and including the last one replayed were already sent, whatever their time.
+	assert.Equal(t, reads[2:], unreplayed(reads, 5))
+	assert.Empty(t, unreplayed(reads, 7))
+}
+
+func TestStreamReads(t *testing.T) {
+	// GET, /reads/stream
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	e.GET("/reads/stream", h.StreamReads)
+	server := httptest.NewServer(e)
+	defer server.Close()
+	// Test no key
+	t.Log("Testing no key given.")
+	response, err := http.Get(server.URL + "/reads/stream?reader=reader6")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		response.Body.Close()
+	}
+	// Test expired key
+	t.Log("Testing expired key.")
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/reads/stream?reader=reader6", nil)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["expired"])
+	response, err = http.DefaultClient.Do(request)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		response.Body.Close()
+	}
+	// Test invalid Last-Event-ID
+	t.Log("Testing invalid Last-Event-ID.")
+	request, _ = http.NewRequest(http.MethodGet, server.URL+"/reads/stream?reader=reader6", nil)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	request.Header.Set("Last-Event-ID", "not-a-cursor")
+	response, err = http.DefaultClient.Do(request)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		response.Body.Close()
+	}
+	// Test live reads
+	t.Log("Testing live reads.")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	request, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/reads/stream?reader=reader6", nil)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	response, err = http.DefaultClient.Do(request)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get(echo.HeaderContentType))
+	newReads := []types.Read{
+		{
+			Identifier:   "5000",
+			Seconds:      100000,
+			Milliseconds: 20,
+			IdentType:    "chip",
+			Type:         "reader",
+		},
+		{
+			Identifier:   "5001",
+			Seconds:      100001,
+			Milliseconds: 20,
+			IdentType:    "chip",
+			Type:         "reader",
+		},
+	}
+	body, err := json.Marshal(types.UploadReadsRequest{
+		Reads: newReads,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	addRequest := httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+	addRequest.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	addRequest.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write2"])
+	addResponse := httptest.NewRecorder()
+	if assert.NoError(t, h.AddReads(e.NewContext(addRequest, addResponse))) {
+		assert.Equal(t, http.StatusOK, addResponse.Code)
+	}
+	found := readStreamEvents(t, bufio.NewScanner(response.Body), len(newReads))
+	if assert.Equal(t, len(newReads), len(found)) {
+		assert.True(t, newReads[0].Equals(&found[0]))
+		assert.True(t, newReads[1].Equals(&found[1]))
+	}
+	cancel()
+	response.Body.Close()
+	if len(found) < 1 {
+		return
+	}
+	// A read from earlier in the race that arrives while the client is gone is still replayed.
+	body, err = json.Marshal(types.UploadReadsRequest{
+		Reads: []types.Read{
+			{
+				Identifier:   "4999",
+				Seconds:      99990,
+				Milliseconds: 20,
+				IdentType:    "chip",
+				Type:         "reader",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	addRequest = httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+	addRequest.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	addRequest.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write2"])
+	addResponse = httptest.NewRecorder()
+	if assert.NoError(t, h.AddReads(e.NewContext(addRequest, addResponse))) {
+		assert.Equal(t, http.StatusOK, addResponse.Code)
+	}
+	// Test resuming from Last-Event-ID
+	t.Log("Testing resume from Last-Event-ID.")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	request, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/reads/stream?reader=reader6", nil)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["read"])
+	request.Header.Set("Last-Event-ID", strconv.FormatInt(found[0].ID, 10))
+	response, err = http.DefaultClient.Do(request)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	resumed := readStreamEvents(t, bufio.NewScanner(response.Body), 2)
+	if assert.Equal(t, 2, len(resumed)) {
+		assert.Equal(t, "5001", resumed[0].Identifier)
+		assert.Equal(t, "4999", resumed[1].Identifier)
+		assert.Greater(t, resumed[1].ID, resumed[0].ID)
+	}
+}
+
+func TestStreamReadsOrganizationScope(t *testing.T) {
+	// Organization keys are kept under the organization's creator, but streams only get the reads of their own keys.
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	e.GET("/reads/stream", h.StreamReads)
+	server := httptest.NewServer(e)
+	defer server.Close()
+	owner := variables.accounts[1]
+	org, err := database.AddOrganization(types.Organization{
+		AccountIdentifier: owner.Identifier,
+		Name:              "Timing Company",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	orgKeys := make(map[string]string)
+	for _, keyType := range []string{"read", "write"} {
+		key, err := database.AddKey(types.Key{
+			AccountIdentifier: owner.Identifier,
+			Organization:      &org.Identifier,
+			Name:              "finish-" + keyType,
+			Value:             "organization-" + keyType + "-key",
+			Type:              keyType,
+		})
+		if err != nil || key == nil {
+			t.Fatalf("Error adding organization key: %v", err)
+		}
+		orgKeys[keyType] = key.Value
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	// openStream Opens a stream of a reader's reads with the key.
+	openStream := func(key, reader string) *bufio.Scanner {
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/reads/stream?reader="+reader, nil)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+key)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Error opening stream: %v", err)
+		}
+		t.Cleanup(func() { response.Body.Close() })
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("Expected stream to open, found %v.", response.StatusCode)
+		}
+		return bufio.NewScanner(response.Body)
+	}
+	// Both keys ask for the creator's reader and the organization's reader by the same names.
+	ownerStream := openStream(variables.knownValues["read"], "reader6")
+	orgOwnerStream := openStream(orgKeys["read"], "reader6")
+	ownerOrgStream := openStream(variables.knownValues["read"], "finish-write")
+	orgStream := openStream(orgKeys["read"], "finish-write")
+	addReads := func(key string, reads []types.Read) {
+		body, err := json.Marshal(types.UploadReadsRequest{
+			Reads: reads,
+		})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request := httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+key)
+		response := httptest.NewRecorder()
+		if assert.NoError(t, h.AddReads(e.NewContext(request, response))) {
+			assert.Equal(t, http.StatusOK, response.Code)
+		}
+	}
+	t.Log("Testing reads from the creator's key and the organization's key.")
+	addReads(variables.knownValues["write2"], []types.Read{
+		{Identifier: "6000", Seconds: 100000, IdentType: "chip", Type: "reader"},
+	})
+	addReads(orgKeys["write"], []types.Read{
+		{Identifier: "7000", Seconds: 100000, IdentType: "chip", Type: "reader"},
+	})
+	// Anything that reached the wrong stream would come before these.
+	hub.publish(owner.Identifier, &org.Identifier, "reader6", []types.Read{{ID: 1, Identifier: "marker"}})
+	hub.publish(owner.Identifier, nil, "finish-write", []types.Read{{ID: 1, Identifier: "marker"}})
+	found := readStreamEvents(t, ownerStream, 1)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "6000", found[0].Identifier)
+	}
+	found = readStreamEvents(t, orgStream, 1)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "7000", found[0].Identifier)
+	}
+	found = readStreamEvents(t, orgOwnerStream, 1)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "marker", found[0].Identifier)
+	}
+	found = readStreamEvents(t, ownerOrgStream, 1)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "marker", found[0].Identifier)
+	}
+}
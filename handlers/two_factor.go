@@ -0,0 +1,390 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/auth"
+	"chronokeep/remote/types"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+const (
+	twoFactorIssuer          = "Chronokeep Remote"
+	twoFactorChallengeWindow = time.Minute * 5
+	twoFactorChallengeClaim  = "two_factor"
+	recoveryCodeCount        = 10
+	recoveryCodeLength       = 10
+	// requireAdminTwoFactorSetting holds "true" when every account that can manage accounts has to use
+	// two-factor authentication.
+	requireAdminTwoFactorSetting = "require_admin_two_factor"
+)
+
+// twoFactorRequired Reports whether an account has to use two-factor authentication to log in.
+func twoFactorRequired(account *types.Account) (bool, error) {
+	role, err := database.GetRole(account.Type)
+	if err != nil {
+		return false, err
+	}
+	if role == nil || !role.Can(types.PermAccountsManage) {
+		return false, nil
+	}
+	required, err := database.GetSetting(requireAdminTwoFactorSetting)
+	if err != nil {
+		return false, err
+	}
+	return required == "true", nil
+}
+
+// createChallenge Creates the short lived token a login has to be finished with once the password is verified.
+// Setup challenges are given to accounts that have to enroll in two-factor authentication before they can log in.
+func createChallenge(email string, setup bool) (string, error) {
+	claims := jwt.MapClaims{}
+	claims["email"] = email
+	claims["purpose"] = twoFactorChallengeClaim
+	claims["setup"] = setup
+	claims["exp"] = time.Now().Add(twoFactorChallengeWindow).Unix()
+	claims["jti"] = uuid.NewString()
+	return signToken(claims, config.SecretKey)
+}
+
+// verifyChallenge Checks a login challenge, returning the email it was issued for and whether it's a setup challenge.
+// Challenges don't belong to a session so they can't be used as access tokens, and access tokens don't have the
+// challenge purpose so they can't be used as challenges.
+func verifyChallenge(challenge string) (string, bool, error) {
+	token, err := parseToken(challenge, config.SecretKey)
+	if err != nil {
+		return "", false, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return "", false, errors.New("claims not set or challenge is not valid")
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != twoFactorChallengeClaim {
+		return "", false, errors.New("token is not a login challenge")
+	}
+	email, ok := claims["email"].(string)
+	if !ok {
+		return "", false, errors.New("email not found in challenge claims")
+	}
+	setup, _ := claims["setup"].(bool)
+	return email, setup, nil
+}
+
+// newTwoFactorSetup Creates a new secret for an account and stores it as a pending two-factor setup.
+func newTwoFactorSetup(account *types.Account) (*types.TwoFactorSetup, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = database.AddTwoFactor(types.TwoFactor{
+		AccountIdentifier: account.Identifier,
+		Secret:            secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.TwoFactorSetup{
+		Secret: secret,
+		URI:    auth.TOTPProvisioningURI(twoFactorIssuer, account.Email, secret),
+	}, nil
+}
+
+// generateRecoveryCodes Creates a new set of recovery codes to show the account holder.
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, recoveryCodeLength/2)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("unable to generate recovery code: %v", err)
+		}
+		code := hex.EncodeToString(buf)
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode Returns a recovery code the way it's stored, so codes match however they were typed in.
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
+
+// checkTwoFactorCode Reports whether a code is a valid TOTP code for an account, or one of its recovery codes
+// if those are allowed. Valid codes are used up so they can't be used again.
+func checkTwoFactorCode(twoFactor *types.TwoFactor, code string, allowRecovery bool) (bool, error) {
+	if step, ok := auth.VerifyTOTP(twoFactor.Secret, code, time.Now(), twoFactor.LastStep); ok {
+		return database.UseTwoFactorStep(twoFactor.AccountIdentifier, step)
+	}
+	code = normalizeRecoveryCode(code)
+	if !allowRecovery || len(code) != recoveryCodeLength {
+		return false, nil
+	}
+	return database.UseRecoveryCode(twoFactor.AccountIdentifier, code)
+}
+
+func (h Handler) LoginTwoFactor(c *echo.Context) error {
+	var request types.LoginTwoFactorRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	blocked, err := loginBlocked(c)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if blocked {
+		return getAPIError(c, http.StatusTooManyRequests, "Too Many Failed Logins", fmt.Errorf("logins from %s blocked", c.RealIP()))
+	}
+	email, setup, err := verifyChallenge(request.Challenge)
+	if err != nil {
+		return getAPIError(c, http.StatusUnauthorized, "Invalid Challenge", err)
+	}
+	account, err := database.GetAccount(email)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if account == nil {
+		return getAPIError(c, http.StatusUnauthorized, "Invalid Challenge", errors.New("account not found"))
+	}
+	if account.Locked {
+		return getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("account locked"))
+	}
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	// Setup challenges finish an enrollment, other challenges need two-factor to already be enabled.
+	if twoFactor == nil || twoFactor.Enabled == setup {
+		return getAPIError(c, http.StatusUnauthorized, "Invalid Challenge", errors.New("two factor state does not match challenge"))
+	}
+	// Wrong codes count as wrong passwords so guessing codes locks the account and blocks the address.
+	var recoveryCodes []string
+	if setup {
+		step, ok := auth.VerifyTOTP(twoFactor.Secret, request.Code, time.Now(), 0)
+		if !ok {
+			failedLogin(c, account)
+			return getAPIError(c, http.StatusUnauthorized, "Invalid Two-Factor Code", nil)
+		}
+		recoveryCodes, err = generateRecoveryCodes()
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
+		}
+		err = database.EnableTwoFactor(account.Identifier, step, recoveryCodes)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+		}
+	} else {
+		ok, err := checkTwoFactorCode(twoFactor, request.Code, true)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+		}
+		if !ok {
+			failedLogin(c, account)
+			return getAPIError(c, http.StatusUnauthorized, "Invalid Two-Factor Code", nil)
+		}
+	}
+	err = database.ValidPassword(*account)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	response, err := startSession(c, account)
+	if response == nil {
+		return err
+	}
+	response.RecoveryCodes = recoveryCodes
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h Handler) GetTwoFactor(c *echo.Context) error {
+	var request types.GetTwoFactorRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account, err := managedAccount(c, authorizedAccount(c), request.Email)
+	if account == nil {
+		return err
+	}
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if twoFactor == nil {
+		twoFactor = &types.TwoFactor{AccountIdentifier: account.Identifier}
+	}
+	required, err := twoFactorRequired(account)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	return c.JSON(http.StatusOK, types.GetTwoFactorResponse{
+		TwoFactor: *twoFactor,
+		Required:  required,
+	})
+}
+
+func (h Handler) SetupTwoFactor(c *echo.Context) error {
+	account := authorizedAccount(c)
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if twoFactor != nil && twoFactor.Enabled {
+		return getAPIError(c, http.StatusBadRequest, "Two-Factor Already Enabled", nil)
+	}
+	setup, err := newTwoFactorSetup(account)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Unable To Set Up Two-Factor", err)
+	}
+	return c.JSON(http.StatusOK, setup)
+}
+
+func (h Handler) EnableTwoFactor(c *echo.Context) error {
+	var request types.TwoFactorCodeRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if twoFactor == nil {
+		return getAPIError(c, http.StatusBadRequest, "Two-Factor Not Set Up", nil)
+	}
+	if twoFactor.Enabled {
+		return getAPIError(c, http.StatusBadRequest, "Two-Factor Already Enabled", nil)
+	}
+	step, ok := auth.VerifyTOTP(twoFactor.Secret, request.Code, time.Now(), 0)
+	if !ok {
+		return getAPIError(c, http.StatusUnauthorized, "Invalid Two-Factor Code", nil)
+	}
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
+	}
+	err = database.EnableTwoFactor(account.Identifier, step, recoveryCodes)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Unable To Enable Two-Factor", err)
+	}
+	return c.JSON(http.StatusOK, types.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func (h Handler) RegenerateRecoveryCodes(c *echo.Context) error {
+	var request types.TwoFactorCodeRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return getAPIError(c, http.StatusBadRequest, "Two-Factor Not Enabled", nil)
+	}
+	ok, err := checkTwoFactorCode(twoFactor, request.Code, false)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if !ok {
+		return getAPIError(c, http.StatusUnauthorized, "Invalid Two-Factor Code", nil)
+	}
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Server Error", err)
+	}
+	err = database.SetRecoveryCodes(account.Identifier, recoveryCodes)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	return c.JSON(http.StatusOK, types.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func (h Handler) DisableTwoFactor(c *echo.Context) error {
+	var request types.DisableTwoFactorRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	owner := request.Email == nil || *request.Email == account.Email
+	account, err := managedAccount(c, account, request.Email)
+	if account == nil {
+		return err
+	}
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	if twoFactor == nil {
+		return getAPIError(c, http.StatusNotFound, "Two-Factor Not Set Up", nil)
+	}
+	// Admins can turn it off for other accounts, e.g. when someone loses their device.
+	// Account holders need their password and a code to turn it off themselves.
+	if owner {
+		required, err := twoFactorRequired(account)
+		if err != nil {
+			return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+		}
+		if required {
+			return getAPIError(c, http.StatusBadRequest, "Two-Factor Required", nil)
+		}
+		err = auth.VerifyPassword(account.Password, request.Password)
+		if err != nil {
+			return getAPIError(c, http.StatusUnauthorized, "Invalid Credentials", err)
+		}
+		if twoFactor.Enabled {
+			ok, err := checkTwoFactorCode(twoFactor, request.Code, true)
+			if err != nil {
+				return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+			}
+			if !ok {
+				return getAPIError(c, http.StatusUnauthorized, "Invalid Two-Factor Code", nil)
+			}
+		}
+	}
+	err = database.DeleteTwoFactor(account.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Unable To Disable Two-Factor", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h Handler) RequireTwoFactor(c *echo.Context) error {
+	var request types.RequireTwoFactorRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	err := database.SetSetting(requireAdminTwoFactorSetting, strconv.FormatBool(request.Required))
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Database Error", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,541 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/auth"
+	db "chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// totpCode Returns the TOTP code for a secret a number of steps from now.
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("Error creating totp code: %v", err)
+	}
+	return code
+}
+
+// enableTwoFactor Turns on two-factor for an account with a known secret and recovery codes.
+func enableTwoFactor(t *testing.T, account types.Account, secret string, recoveryCodes []string) {
+	err := database.AddTwoFactor(types.TwoFactor{
+		AccountIdentifier: account.Identifier,
+		Secret:            secret,
+	})
+	if err != nil {
+		t.Fatalf("Error adding two factor: %v", err)
+	}
+	err = database.EnableTwoFactor(account.Identifier, 0, recoveryCodes)
+	if err != nil {
+		t.Fatalf("Error enabling two factor: %v", err)
+	}
+}
+
+// loginChallenge Logs in with a password and returns the challenge the login has to be finished with.
+func loginChallenge(t *testing.T, h Handler, email, password string) types.LoginChallengeResponse {
+	c, response := newRequestContext(t, http.MethodPost, "/account/login", "", types.LoginRequest{
+		Email:    email,
+		Password: password,
+	})
+	if err := h.Login(c); err != nil || response.Code != http.StatusOK {
+		t.Fatalf("Error logging in: %v (%v)", err, response.Code)
+	}
+	var resp types.LoginChallengeResponse
+	if err := json.Unmarshal(response.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Error decoding login response: %v", err)
+	}
+	if resp.Challenge == "" {
+		t.Fatalf("Expected a login challenge, found '%s'.", response.Body.String())
+	}
+	return resp
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	// POST, /account/login/2fa
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	account := variables.accounts[1]
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("Error generating secret: %v", err)
+	}
+	enableTwoFactor(t, account, secret, []string{"abcde12345"})
+	// Test login gives a challenge instead of tokens
+	t.Log("Testing login.")
+	c, response := newRequestContext(t, http.MethodPost, "/account/login", "", types.LoginRequest{
+		Email:    account.Email,
+		Password: variables.testPassword1,
+	})
+	if assert.NoError(t, h.Login(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp map[string]any
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEmpty(t, resp["challenge"])
+			assert.Nil(t, resp["access_token"])
+			assert.Nil(t, resp["setup"])
+		}
+	}
+	challenge := loginChallenge(t, h, account.Email, variables.testPassword1).Challenge
+	// Test bad request
+	t.Log("Testing bad request.")
+	request := httptest.NewRequest(http.MethodPost, "/account/login/2fa", strings.NewReader("////"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response = httptest.NewRecorder()
+	c = echo.New().NewContext(request, response)
+	if assert.NoError(t, h.LoginTwoFactor(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test invalid challenges, access tokens can't be used as challenges
+	accessToken := loginSession(t, account, "")
+	for _, invalid := range []string{"", "not-a-challenge", accessToken} {
+		t.Logf("Testing invalid challenge '%s'.", invalid)
+		c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+			Challenge: invalid,
+			Code:      totpCode(t, secret, 0),
+		})
+		if assert.NoError(t, h.LoginTwoFactor(c)) {
+			assert.Equal(t, http.StatusUnauthorized, response.Code)
+		}
+	}
+	// Test challenges can't be used as access tokens
+	t.Log("Testing challenge as access token.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account", challenge, types.GetAccountRequest{})
+	if assert.NoError(t, h.authorize()(h.GetAccount)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test wrong code
+	t.Log("Testing wrong code.")
+	wrong := "000000"
+	if totpCode(t, secret, 0) == wrong {
+		wrong = "111111"
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+		Challenge: challenge,
+		Code:      wrong,
+	})
+	if assert.NoError(t, h.LoginTwoFactor(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	updated, err := database.GetAccount(account.Email)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, updated.WrongPassAttempts)
+	}
+	// Test valid code
+	t.Log("Testing valid code.")
+	code := totpCode(t, secret, 0)
+	c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+		Challenge: challenge,
+		Code:      code,
+	})
+	if assert.NoError(t, h.LoginTwoFactor(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.LoginResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEmpty(t, resp.AccessToken)
+			assert.NotEmpty(t, resp.RefreshToken)
+			assert.Empty(t, resp.RecoveryCodes)
+			session, err := database.GetSession(resp.AccessToken)
+			if assert.NoError(t, err) {
+				assert.NotNil(t, session)
+			}
+		}
+	}
+	updated, err = database.GetAccount(account.Email)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, updated.WrongPassAttempts)
+	}
+	// Test codes can only be used once
+	t.Log("Testing used code.")
+	c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+		Challenge: loginChallenge(t, h, account.Email, variables.testPassword1).Challenge,
+		Code:      code,
+	})
+	if assert.NoError(t, h.LoginTwoFactor(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test recovery code, can only be used once
+	for _, status := range []int{http.StatusOK, http.StatusUnauthorized} {
+		t.Logf("Testing recovery code, expecting %v.", status)
+		c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+			Challenge: loginChallenge(t, h, account.Email, variables.testPassword1).Challenge,
+			Code:      "ABCDE-12345",
+		})
+		if assert.NoError(t, h.LoginTwoFactor(c)) {
+			assert.Equal(t, status, response.Code)
+		}
+	}
+	// Test wrong codes lock the account
+	t.Log("Testing account lock.")
+	challenge = loginChallenge(t, h, account.Email, variables.testPassword1).Challenge
+	for i := 0; i <= db.MaxLoginAttempts; i++ {
+		c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+			Challenge: challenge,
+			Code:      wrong,
+		})
+		if assert.NoError(t, h.LoginTwoFactor(c)) {
+			assert.Equal(t, http.StatusUnauthorized, response.Code)
+		}
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+		Challenge: challenge,
+		Code:      totpCode(t, secret, 1),
+	})
+	if assert.NoError(t, h.LoginTwoFactor(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	updated, err = database.GetAccount(account.Email)
+	if assert.NoError(t, err) {
+		assert.True(t, updated.Locked)
+	}
+}
+
+func TestSetupTwoFactor(t *testing.T) {
+	// POST, /r/account/2fa/setup and /r/account/2fa/enable
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	account := variables.accounts[1]
+	token := loginSession(t, account, "")
+	// Test no token
+	t.Log("Testing no token.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/account/2fa/setup", "", nil)
+	if assert.NoError(t, h.authorize()(h.SetupTwoFactor)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test enable before setup
+	t.Log("Testing enable before setup.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/enable", token, types.TwoFactorCodeRequest{
+		Code: "123456",
+	})
+	if assert.NoError(t, h.authorize()(h.EnableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test setup
+	t.Log("Testing setup.")
+	var setup types.TwoFactorSetup
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/setup", token, nil)
+	if assert.NoError(t, h.authorize()(h.SetupTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &setup)) {
+			assert.NotEmpty(t, setup.Secret)
+			assert.True(t, strings.HasPrefix(setup.URI, "otpauth://totp/"))
+			assert.Contains(t, setup.URI, "secret="+setup.Secret)
+		}
+	}
+	// A pending setup doesn't change how logging in works.
+	t.Log("Testing login with pending setup.")
+	c, response = newRequestContext(t, http.MethodPost, "/account/login", "", types.LoginRequest{
+		Email:    account.Email,
+		Password: variables.testPassword1,
+	})
+	if assert.NoError(t, h.Login(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.LoginResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEmpty(t, resp.AccessToken)
+		}
+	}
+	// Test wrong code
+	t.Log("Testing wrong code.")
+	wrong := "000000"
+	if totpCode(t, setup.Secret, 0) == wrong {
+		wrong = "111111"
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/enable", token, types.TwoFactorCodeRequest{
+		Code: wrong,
+	})
+	if assert.NoError(t, h.authorize()(h.EnableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test enable
+	t.Log("Testing enable.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/enable", token, types.TwoFactorCodeRequest{
+		Code: totpCode(t, setup.Secret, 0),
+	})
+	if assert.NoError(t, h.authorize()(h.EnableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.RecoveryCodesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+		}
+	}
+	// Test setup and enable once enabled
+	t.Log("Testing setup when enabled.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/setup", token, nil)
+	if assert.NoError(t, h.authorize()(h.SetupTwoFactor)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/enable", token, types.TwoFactorCodeRequest{
+		Code: totpCode(t, setup.Secret, 1),
+	})
+	if assert.NoError(t, h.authorize()(h.EnableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test status
+	t.Log("Testing status.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa", token, types.GetTwoFactorRequest{})
+	if assert.NoError(t, h.authorize()(h.GetTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetTwoFactorResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.True(t, resp.TwoFactor.Enabled)
+			assert.Equal(t, recoveryCodeCount, resp.TwoFactor.RecoveryCodes)
+			assert.False(t, resp.Required)
+		}
+	}
+	// Test other accounts can't see the status
+	t.Log("Testing status of another account.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa", token, types.GetTwoFactorRequest{
+		Email: &variables.accounts[2].Email,
+	})
+	if assert.NoError(t, h.authorize()(h.GetTwoFactor)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// The login now needs a code.
+	loginChallenge(t, h, account.Email, variables.testPassword1)
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	// POST, /r/account/2fa/recovery
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	account := variables.accounts[1]
+	token := loginSession(t, account, "")
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("Error generating secret: %v", err)
+	}
+	// Test not enabled
+	t.Log("Testing not enabled.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/account/2fa/recovery", token, types.TwoFactorCodeRequest{
+		Code: totpCode(t, secret, 0),
+	})
+	if assert.NoError(t, h.authorize()(h.RegenerateRecoveryCodes)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	enableTwoFactor(t, account, secret, []string{"abcde12345"})
+	// Test recovery codes can't be used to make new ones
+	t.Log("Testing recovery code.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/recovery", token, types.TwoFactorCodeRequest{
+		Code: "abcde-12345",
+	})
+	if assert.NoError(t, h.authorize()(h.RegenerateRecoveryCodes)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/recovery", token, types.TwoFactorCodeRequest{
+		Code: totpCode(t, secret, 0),
+	})
+	if assert.NoError(t, h.authorize()(h.RegenerateRecoveryCodes)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.RecoveryCodesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+		}
+	}
+	// The old codes are replaced.
+	used, err := database.UseRecoveryCode(account.Identifier, "abcde12345")
+	if assert.NoError(t, err) {
+		assert.False(t, used)
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	// DELETE, /r/account/2fa/disable
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	account := variables.accounts[1]
+	token := loginSession(t, account, "")
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("Error generating secret: %v", err)
+	}
+	// Test not set up
+	t.Log("Testing not set up.")
+	c, response := newRequestContext(t, http.MethodDelete, "/r/account/2fa/disable", token, types.DisableTwoFactorRequest{
+		Password: variables.testPassword1,
+		Code:     totpCode(t, secret, 0),
+	})
+	if assert.NoError(t, h.authorize()(h.DisableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	enableTwoFactor(t, account, secret, nil)
+	// Test wrong password and wrong code
+	for _, req := range []types.DisableTwoFactorRequest{
+		{Password: "wrongpassword", Code: totpCode(t, secret, 0)},
+		{Password: variables.testPassword1, Code: "abcde-12345"},
+	} {
+		t.Logf("Testing invalid request: %+v.", req)
+		c, response = newRequestContext(t, http.MethodDelete, "/r/account/2fa/disable", token, req)
+		if assert.NoError(t, h.authorize()(h.DisableTwoFactor)(c)) {
+			assert.Equal(t, http.StatusUnauthorized, response.Code)
+		}
+	}
+	// Test other accounts can't disable it
+	t.Log("Testing non admin disabling another account.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/account/2fa/disable", loginSession(t, variables.accounts[2], ""), types.DisableTwoFactorRequest{
+		Email: &account.Email,
+	})
+	if assert.NoError(t, h.authorize()(h.DisableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodDelete, "/r/account/2fa/disable", token, types.DisableTwoFactorRequest{
+		Password: variables.testPassword1,
+		Code:     totpCode(t, secret, 0),
+	})
+	if assert.NoError(t, h.authorize()(h.DisableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	twoFactor, err := database.GetTwoFactor(account.Identifier)
+	if assert.NoError(t, err) {
+		assert.Nil(t, twoFactor)
+	}
+	// Test admins can disable it for other accounts without a code
+	t.Log("Testing admin disabling another account.")
+	enableTwoFactor(t, account, secret, nil)
+	c, response = newRequestContext(t, http.MethodDelete, "/r/account/2fa/disable", loginSession(t, variables.accounts[0], ""), types.DisableTwoFactorRequest{
+		Email: &account.Email,
+	})
+	if assert.NoError(t, h.authorize()(h.DisableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	twoFactor, err = database.GetTwoFactor(account.Identifier)
+	if assert.NoError(t, err) {
+		assert.Nil(t, twoFactor)
+	}
+}
+
+func TestRequireTwoFactor(t *testing.T) {
+	// POST, /r/account/2fa/require
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	h := Handler{}
+	h.Setup()
+	admin := variables.accounts[0]
+	adminToken := loginSession(t, admin, "")
+	// Test non admin
+	t.Log("Testing non admin.")
+	c, response := newRequestContext(t, http.MethodPost, "/r/account/2fa/require", loginSession(t, variables.accounts[1], ""), types.RequireTwoFactorRequest{
+		Required: true,
+	})
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.RequireTwoFactor)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/require", adminToken, types.RequireTwoFactorRequest{
+		Required: true,
+	})
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.RequireTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	// Admins can't turn it off for themselves while it's required.
+	t.Log("Testing admin disabling their own.")
+	enableTwoFactor(t, admin, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", nil)
+	c, response = newRequestContext(t, http.MethodDelete, "/r/account/2fa/disable", adminToken, types.DisableTwoFactorRequest{
+		Password: variables.testPassword1,
+		Code:     totpCode(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 0),
+	})
+	if assert.NoError(t, h.authorize()(h.DisableTwoFactor)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	database.DeleteTwoFactor(admin.Identifier)
+	// Test admin login has to enroll
+	t.Log("Testing admin login.")
+	challenge := loginChallenge(t, h, admin.Email, variables.testPassword1)
+	if !assert.NotNil(t, challenge.Setup) {
+		t.FailNow()
+	}
+	assert.True(t, strings.HasPrefix(challenge.Setup.URI, "otpauth://totp/"))
+	c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+		Challenge: challenge.Challenge,
+		Code:      totpCode(t, challenge.Setup.Secret, 0),
+	})
+	if assert.NoError(t, h.LoginTwoFactor(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.LoginResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEmpty(t, resp.AccessToken)
+			assert.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+		}
+	}
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa", adminToken, types.GetTwoFactorRequest{})
+	if assert.NoError(t, h.authorize()(h.GetTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetTwoFactorResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.True(t, resp.TwoFactor.Enabled)
+			assert.True(t, resp.Required)
+		}
+	}
+	// Once enrolled the setup challenge can't be used again.
+	t.Log("Testing used setup challenge.")
+	c, response = newRequestContext(t, http.MethodPost, "/account/login/2fa", "", types.LoginTwoFactorRequest{
+		Challenge: challenge.Challenge,
+		Code:      totpCode(t, challenge.Setup.Secret, 1),
+	})
+	if assert.NoError(t, h.LoginTwoFactor(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Other account types aren't affected.
+	t.Log("Testing non admin login.")
+	c, response = newRequestContext(t, http.MethodPost, "/account/login", "", types.LoginRequest{
+		Email:    variables.accounts[1].Email,
+		Password: variables.testPassword1,
+	})
+	if assert.NoError(t, h.Login(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.LoginResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEmpty(t, resp.AccessToken)
+		}
+	}
+	// Test turning it back off
+	t.Log("Testing no longer required.")
+	c, response = newRequestContext(t, http.MethodPost, "/r/account/2fa/require", adminToken, types.RequireTwoFactorRequest{
+		Required: false,
+	})
+	if assert.NoError(t, h.authorize(types.PermAccountsManage)(h.RequireTwoFactor)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	required, err := twoFactorRequired(&admin)
+	if assert.NoError(t, err) {
+		assert.False(t, required)
+	}
+}
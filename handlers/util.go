@@ -20,13 +20,17 @@ package handlers
 
 import (
 	"chronokeep/remote/types"
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v5"
 	log "github.com/sirupsen/logrus"
 )
@@ -54,40 +58,106 @@ func retrieveKey(r *http.Request) (*string, error) {
 	return &strArr[1], nil
 }
 
-func verifyToken(r *http.Request) (*types.Account, error) {
+// lookupHost is used to resolve hostnames in a key's allowed hosts.
+var lookupHost = net.DefaultResolver.LookupHost
+
+// hostAllowed Reports whether a request from the given address is allowed to use the key.
+func hostAllowed(key *types.Key, remote string) bool {
+	if len(key.AllowedHosts) == 0 {
+		return true
+	}
+	ip := net.ParseIP(remote)
+	if ip == nil {
+		return false
+	}
+	for _, host := range key.AllowedHosts {
+		if allowed := net.ParseIP(host); allowed != nil {
+			if allowed.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(host); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		ctx, cancelfunc := context.WithTimeout(context.Background(), time.Second*2)
+		addrs, err := lookupHost(ctx, host)
+		cancelfunc()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"host":  host,
+				"error": err,
+			}).Warn("Unable to resolve allowed host.")
+			continue
+		}
+		for _, addr := range addrs {
+			if allowed := net.ParseIP(addr); allowed != nil && allowed.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifySession Checks the token in the request and returns the account and session it belongs to.
+func verifySession(r *http.Request) (*types.Account, *types.Session, error) {
 	bearToken := r.Header.Get("Authorization")
 	strArr := strings.Split(bearToken, " ")
 	if len(strArr) != 2 {
-		return nil, errors.New("unknown authorization header")
+		return nil, nil, errors.New("unknown authorization header")
 	}
-	token, err := jwt.Parse(strArr[1], func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.SecretKey), nil
-	})
+	token, err := parseToken(strArr[1], config.SecretKey)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return nil, errors.New("claims not set or token is not valid")
+		return nil, nil, errors.New("claims not set or token is not valid")
+	}
+	// Only access tokens are used without a purpose.
+	if _, ok := claims["purpose"]; ok {
+		return nil, nil, errors.New("token is not an access token")
 	}
 	email, ok := claims["email"].(string)
 	if !ok {
-		return nil, errors.New("email not found in token claims")
+		return nil, nil, errors.New("email not found in token claims")
 	}
 	account, err := database.GetAccount(email)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if account == nil {
-		return nil, errors.New("account not found")
+		return nil, nil, errors.New("account not found")
 	}
-	if account.Token != strArr[1] || account.Token == "" {
-		return nil, errors.New("token no longer valid")
+	session, err := database.GetSession(strArr[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil || session.AccountIdentifier != account.Identifier {
+		return nil, nil, errors.New("token no longer valid")
+	}
+	// Only touch the session every so often so every request doesn't turn into a write.
+	if time.Since(session.LastUsed) > sessionTouchWindow {
+		if err := database.UseSession(session.Identifier); err != nil {
+			log.WithFields(log.Fields{
+				"session": session.Identifier,
+				"error":   err,
+			}).Warn("Unable to update session last used time.")
+		}
+	}
+	return account, session, nil
+}
+
+// userAgent Returns the user agent of a request, shortened to fit in a session.
+func userAgent(r *http.Request) string {
+	agent := r.UserAgent()
+	if len(agent) > maxUserAgentLength {
+		agent = agent[:maxUserAgentLength]
 	}
-	return account, nil
+	return agent
 }
 
 func createTokens(email string) (*string, *string, error) {
@@ -96,20 +166,144 @@ func createTokens(email string) (*string, *string, error) {
 	claims["email"] = email
 	claims["authorized"] = true
 	claims["exp"] = time.Now().Add(expirationWindow).Unix()
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err := t.SignedString([]byte(config.SecretKey))
+	// Give every token a unique id so logging in twice in the same second doesn't produce the same token.
+	claims["jti"] = uuid.NewString()
+	token, err := signToken(claims, config.SecretKey)
 	if err != nil {
 		return nil, nil, err
 	}
 	// Create refresh token
 	claims = jwt.MapClaims{}
 	claims["email"] = email
+	claims["purpose"] = refreshTokenPurpose
 	claims["exp"] = time.Now().Add(refreshWindow).Unix()
-	r := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	refresh, err := r.SignedString([]byte(config.RefreshKey))
+	claims["jti"] = uuid.NewString()
+	refresh, err := signToken(claims, config.RefreshKey)
 	if err != nil {
 		return nil, nil, err
 	}
 	return &token, &refresh, nil
 }
 
+// signToken Signs a token with the current signing key and adds its id to the header. Without signing keys
+// the token is signed with the shared secret given.
+func signToken(claims jwt.MapClaims, secret string) (string, error) {
+	if len(signingKeys) > 0 {
+		key := signingKeys[0]
+		t := jwt.NewWithClaims(key.Method, claims)
+		t.Header["kid"] = key.ID
+		return t.SignedString(key.Private)
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return t.SignedString([]byte(secret))
+}
+
+// parseToken Parses a token signed with any of the signing keys, found using the id in its header.
+// Tokens signed with the shared secret given are accepted as long as there is one, so tokens issued before
+// signing keys were set up keep working.
+func parseToken(tokenString, secret string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if secret == "" {
+				return nil, errors.New("tokens signed with a shared secret are not accepted")
+			}
+			return []byte(secret), nil
+		}
+		kid, _ := token.Header["kid"].(string)
+		for _, key := range signingKeys {
+			if key.ID != kid {
+				continue
+			}
+			if key.Method.Alg() != token.Method.Alg() {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.Public, nil
+		}
+		return nil, fmt.Errorf("unknown signing key: %v", token.Header["kid"])
+	})
+}
+
+// managedAccount Returns the account whose settings (webhooks, alert rules) are being managed. Accounts that
+// manage accounts can manage another account by giving its email, everyone else can only manage their own.
+// If nil is returned an error response has already been written and the error is the result of writing it.
+func managedAccount(c *echo.Context, account *types.Account, email *string) (*types.Account, error) {
+	if email == nil || *email == account.Email {
+		return account, nil
+	}
+	if !hasPermission(account, types.PermAccountsManage) {
+		return nil, getAPIError(c, http.StatusUnauthorized, "Unauthorized", errors.New("missing permission / ownership error"))
+	}
+	managed, err := database.GetAccount(*email)
+	if err != nil {
+		return nil, getAPIError(c, http.StatusInternalServerError, "Error Retrieving Account", err)
+	}
+	if managed == nil {
+		return nil, getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	return managed, nil
+}
+
+// memberOrganization Returns an organization the account is a member of, as long as its role there is one of
+// the roles given. Any role will do if none are given. Accounts that manage organizations are treated as owners
+// of every organization.
+// If nil is returned an error response has already been written and the error is the result of writing it.
+func memberOrganization(c *echo.Context, account *types.Account, id int64, roles ...string) (*types.Organization, error) {
+	if hasPermission(account, types.PermOrganizationsManage) {
+		org, err := database.GetOrganization(id)
+		if err != nil {
+			return nil, getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organization", err)
+		}
+		if org == nil {
+			return nil, getAPIError(c, http.StatusNotFound, "Organization Not Found", nil)
+		}
+		return org, nil
+	}
+	membership, err := database.GetMembership(id, account.Identifier)
+	if err != nil {
+		return nil, getAPIError(c, http.StatusInternalServerError, "Error Retrieving Organization", err)
+	}
+	// Organizations the account isn't part of are treated the same as ones that don't exist.
+	if membership == nil {
+		return nil, getAPIError(c, http.StatusNotFound, "Organization Not Found", nil)
+	}
+	if len(roles) > 0 && !slices.Contains(roles, membership.Role) {
+		return nil, getAPIError(c, http.StatusUnauthorized, "Unauthorized", fmt.Errorf("organization role '%s' not allowed", membership.Role))
+	}
+	return &membership.Organization, nil
+}
+
+// canManageKey Checks if an account can add, update, rotate or delete a key. Accounts that manage keys can
+// manage every key, everyone else can manage the keys kept under their account and, as an owner or manager,
+// the keys of their organizations.
+func canManageKey(account *types.Account, key *types.Key) (bool, error) {
+	if key.AccountIdentifier == account.Identifier || hasPermission(account, types.PermKeysManage) {
+		return true, nil
+	}
+	if key.Organization == nil {
+		return false, nil
+	}
+	membership, err := database.GetMembership(*key.Organization, account.Identifier)
+	if err != nil {
+		return false, err
+	}
+	return membership != nil && membership.CanManageKeys(), nil
+}
+
+// keyNameTaken Checks if an account already has a key, deleted or not, with the given name.
+func keyNameTaken(account int64, name string) (bool, error) {
+	keyAccount, err := database.GetAccountByID(account)
+	if err != nil || keyAccount == nil {
+		return false, err
+	}
+	keys, err := database.GetAccountKeys(keyAccount.Email)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := database.GetDeletedKeys(account)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(slices.Concat(keys, deleted), func(k types.Key) bool {
+		return k.Name == name
+	}), nil
+}
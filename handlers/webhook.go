@@ -0,0 +1,545 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	db "chronokeep/remote/database"
+	"chronokeep/remote/types"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxWebhooksPerAccount = 10
+	webhookInterval       = time.Second * 15
+	webhookTimeout        = time.Second * 10
+	webhookBatchSize      = 100
+	// webhookHookBatch is the most deliveries sent to a single webhook in a run, so a slow endpoint
+	// only holds up its own deliveries, and only for so long.
+	webhookHookBatch = 10
+	// webhookClaimWindow is how long deliveries are claimed for, long enough to send a webhook's share of a run.
+	webhookClaimWindow = webhookTimeout*webhookHookBatch + time.Minute
+	// webhookMaxRuns is the most runs sending at once.
+	webhookMaxRuns       = 4
+	webhookMaxAttempts   = 8
+	webhookBaseBackoff   = time.Second * 30
+	webhookMaxBackoff    = time.Hour * 6
+	maxDeliveryErrorSize = 500
+	// keyExpiryCheckedSetting holds the unix time the worker last looked for expired keys,
+	// so keys that expire while the server is down are still reported once it's back up.
+	keyExpiryCheckedSetting = "webhook_key_expiry_checked"
+)
+
+var webhooks = &webhookWorker{
+	client: newWebhookClient(),
+	wake:   make(chan struct{}, 1),
+	runs:   make(chan struct{}, webhookMaxRuns),
+}
+
+// webhookWorker sends queued webhook deliveries, retrying failures with an exponential backoff.
+type webhookWorker struct {
+	// mu keeps runs from checking for expired keys at the same time.
+	mu     sync.Mutex
+	client *http.Client
+	wake   chan struct{}
+	runs   chan struct{}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which like the private ranges isn't on the internet.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress Reports whether webhooks can be sent to an address. Loopback, private, link-local (where cloud
+// metadata endpoints live), multicast and unspecified addresses are refused so a webhook can't be used to reach
+// the server itself or the network it's on.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// Tests replace these to resolve made up hosts and to send to servers on the loopback address.
+var (
+	webhookAddressAllowed = publicAddress
+	lookupWebhookHost     = net.DefaultResolver.LookupNetIP
+)
+
+// checkWebhookURL Resolves the host of a webhook URL, returning an error if it resolves to any address
+// webhooks can't be sent to.
+func checkWebhookURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	addrs, err := lookupWebhookHost(ctx, "ip", parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("unable to resolve webhook host: %v", err)
+	}
+	for _, addr := range addrs {
+		if !webhookAddressAllowed(addr) {
+			return fmt.Errorf("webhook host %s resolves to %s, webhooks can't be sent there", parsed.Hostname(), addr)
+		}
+	}
+	return nil
+}
+
+// newWebhookClient Returns the client deliveries are sent with. Addresses are checked as they're dialed, so a
+// host that resolves somewhere else once it's been added, or redirects there, still can't be reached.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !webhookAddressAllowed(addrPort.Addr()) {
+				return fmt.Errorf("webhooks can't be sent to %s", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// The address dialed has to be the one checked, so deliveries don't go through a proxy.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+	}
+}
+
+// queue Records a delivery of the payload for every webhook on the account subscribed to its event. Events
+// for an organization's keys only go to the organization's webhooks, nil is for the account's own keys.
+// Failing to queue is logged rather than returned so it never fails the request that caused the event.
+func (w *webhookWorker) queue(account int64, org *int64, payload types.WebhookPayload) {
+	hooks, err := database.GetAccountWebhooks(account)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"account": account,
+			"event":   payload.Event,
+			"error":   err,
+		}).Error("Unable to get webhooks.")
+		return
+	}
+	var subscribed []types.Webhook
+	for _, hook := range hooks {
+		if hook.InOrganization(org) && hook.Subscribed(payload.Event) {
+			subscribed = append(subscribed, hook)
+		}
+	}
+	if len(subscribed) < 1 {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"account": account,
+			"event":   payload.Event,
+			"error":   err,
+		}).Error("Unable to encode webhook payload.")
+		return
+	}
+	now := time.Now()
+	deliveries := make([]types.WebhookDelivery, len(subscribed))
+	for i, hook := range subscribed {
+		deliveries[i] = types.WebhookDelivery{
+			WebhookIdentifier: hook.Identifier,
+			Event:             payload.Event,
+			Payload:           string(body),
+			NextAttempt:       now,
+		}
+	}
+	if err := database.AddWebhookDeliveries(deliveries); err != nil {
+		log.WithFields(log.Fields{
+			"account": account,
+			"event":   payload.Event,
+			"error":   err,
+		}).Error("Unable to queue webhook deliveries.")
+		return
+	}
+	// Let the worker know there's something to send without waiting on it.
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run Queues events for keys that have expired since the last run and sends every delivery due by now.
+// Deliveries are claimed first so no other run, here or on another instance, sends them as well.
+// Each webhook's deliveries are sent in order, alongside those of every other webhook.
+func (w *webhookWorker) run(now time.Time) {
+	w.mu.Lock()
+	w.queueExpiredKeys(now)
+	w.mu.Unlock()
+	deliveries, err := database.ClaimWebhookDeliveries(now, now.Add(webhookClaimWindow), webhookBatchSize)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Unable to claim pending webhook deliveries.")
+		return
+	}
+	byWebhook := make(map[int64][]types.WebhookDelivery)
+	for _, delivery := range deliveries {
+		byWebhook[delivery.WebhookIdentifier] = append(byWebhook[delivery.WebhookIdentifier], delivery)
+	}
+	var wg sync.WaitGroup
+	for _, queued := range byWebhook {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.deliverAll(queued, now)
+		}()
+	}
+	wg.Wait()
+}
+
+// start Starts a run without waiting on it, unless webhookMaxRuns are already sending. A run started while
+// another is still waiting on a slow endpoint sends what's been queued since, so nobody else waits along with it.
+func (w *webhookWorker) start(now time.Time) {
+	select {
+	case w.runs <- struct{}{}:
+		go func() {
+			defer func() { <-w.runs }()
+			w.run(now)
+		}()
+	default:
+	}
+}
+
+// deliverAll Sends deliveries to a single webhook in order and records the results. Once one fails, or
+// webhookHookBatch have been sent, the rest are released to be sent by a later run.
+func (w *webhookWorker) deliverAll(deliveries []types.WebhookDelivery, now time.Time) {
+	failed := false
+	for i, delivery := range deliveries {
+		if !failed && i < webhookHookBatch {
+			w.deliver(&delivery, now)
+			failed = delivery.Status != types.DeliveryDelivered
+		}
+		if err := database.UpdateWebhookDelivery(delivery); err != nil {
+			log.WithFields(log.Fields{
+				"delivery": delivery.Identifier,
+				"error":    err,
+			}).Error("Unable to record webhook delivery.")
+		}
+	}
+}
+
+func (w *webhookWorker) queueExpiredKeys(now time.Time) {
+	checked, err := database.GetSetting(keyExpiryCheckedSetting)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Unable to get last key expiry check.")
+		return
+	}
+	// The first run only records when it looked, keys that expired before then aren't reported.
+	if last, err := strconv.ParseInt(checked, 10, 64); err == nil {
+		if last >= now.Unix() {
+			return
+		}
+		keys, err := database.GetKeysExpiredBetween(time.Unix(last, 0), now)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("Unable to get expired keys.")
+			return
+		}
+		for _, key := range keys {
+			w.queue(key.AccountIdentifier, key.Organization, types.WebhookPayload{
+				Event: types.WebhookKeyExpired,
+				When:  *key.ValidUntil,
+				Key:   &key,
+			})
+		}
+	}
+	if err := database.SetSetting(keyExpiryCheckedSetting, strconv.FormatInt(now.Unix(), 10)); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Unable to save last key expiry check.")
+	}
+}
+
+// deliver Sends a delivery and records the result on it. Deliveries that fail are scheduled
+// to be tried again after a backoff that doubles with each attempt, until they run out of attempts.
+func (w *webhookWorker) deliver(delivery *types.WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+	delivery.ResponseCode = 0
+	delivery.Error = ""
+	err := w.send(delivery)
+	if err == nil {
+		delivered := now
+		delivery.Status = types.DeliveryDelivered
+		delivery.DeliveredAt = &delivered
+		return
+	}
+	delivery.Error = err.Error()
+	if len(delivery.Error) > maxDeliveryErrorSize {
+		delivery.Error = delivery.Error[:maxDeliveryErrorSize]
+	}
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = types.DeliveryFailed
+		return
+	}
+	backoff := webhookBaseBackoff << (delivery.Attempts - 1)
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	delivery.NextAttempt = now.Add(backoff)
+}
+
+func (w *webhookWorker) send(delivery *types.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	request, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set("User-Agent", "Chronokeep-Webhook")
+	request.Header.Set("X-Chronokeep-Event", delivery.Event)
+	request.Header.Set("X-Chronokeep-Delivery", strconv.FormatInt(delivery.Identifier, 10))
+	request.Header.Set("X-Chronokeep-Timestamp", strconv.FormatInt(timestamp, 10))
+	request.Header.Set("X-Chronokeep-Signature", types.SignWebhook(delivery.Secret, timestamp, body))
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// Drain some of the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+	delivery.ResponseCode = response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %v", response.Status)
+	}
+	return nil
+}
+
+// StartWebhooks starts the webhook worker, which sends deliveries as they're queued and retries
+// failed ones every webhookInterval. Calling the returned function stops it.
+func StartWebhooks() func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(webhookInterval)
+		defer ticker.Stop()
+		webhooks.start(time.Now())
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				webhooks.start(now)
+			case <-webhooks.wake:
+				webhooks.start(time.Now())
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+func (h Handler) GetWebhooks(c *echo.Context) error {
+	var request types.GetWebhooksRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	hookAccount, org, err := webhookScope(c, account, request.Email, request.Organization)
+	if hookAccount == nil {
+		return err
+	}
+	hooks, err := database.GetAccountWebhooks(hookAccount.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Webhooks", err)
+	}
+	outHooks := make([]types.Webhook, 0)
+	for _, hook := range hooks {
+		if hook.InOrganization(org) {
+			// Secrets are only handed out when a webhook is created.
+			hook.Secret = ""
+			outHooks = append(outHooks, hook)
+		}
+	}
+	return c.JSON(http.StatusOK, types.GetWebhooksResponse{
+		Webhooks: outHooks,
+	})
+}
+
+func (h Handler) AddWebhook(c *echo.Context) error {
+	var request types.AddWebhookRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	if err := request.Webhook.Validate(h.validate); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
+	}
+	hook := request.Webhook.ToWebhook()
+	if err := checkWebhookURL(c.Request().Context(), hook.URL); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Field(s)", err)
+	}
+	hookAccount, org, err := webhookScope(c, account, request.Email, request.Organization)
+	if hookAccount == nil {
+		return err
+	}
+	hooks, err := database.GetAccountWebhooks(hookAccount.Identifier)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Webhooks", err)
+	}
+	if len(hooks) >= maxWebhooksPerAccount {
+		return getAPIError(c, http.StatusBadRequest, "Too Many Webhooks", fmt.Errorf("accounts are limited to %d webhooks", maxWebhooksPerAccount))
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Secret Generation Error", err)
+	}
+	hook.AccountIdentifier = hookAccount.Identifier
+	hook.Organization = org
+	hook.Secret = hex.EncodeToString(secret)
+	added, err := database.AddWebhook(hook)
+	if err != nil || added == nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Adding Webhook", err)
+	}
+	return c.JSON(http.StatusOK, types.ModifyWebhookResponse{
+		Webhook: *added,
+	})
+}
+
+func (h Handler) DeleteWebhook(c *echo.Context) error {
+	var request types.DeleteWebhookRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	hookAccount, org, err := webhookScope(c, account, request.Email, request.Organization)
+	if hookAccount == nil {
+		return err
+	}
+	found, err := accountHasWebhook(hookAccount.Identifier, org, request.Webhook)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Webhooks", err)
+	}
+	if !found {
+		return getAPIError(c, http.StatusNotFound, "Webhook Not Found", nil)
+	}
+	if err := database.DeleteWebhook(hookAccount.Identifier, request.Webhook); err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Deleting Webhook", err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h Handler) GetWebhookDeliveries(c *echo.Context) error {
+	var request types.GetWebhookDeliveriesRequest
+	if err := c.Bind(&request); err != nil {
+		return getAPIError(c, http.StatusBadRequest, "Invalid Request Body", err)
+	}
+	account := authorizedAccount(c)
+	hookAccount, org, err := webhookScope(c, account, request.Email, request.Organization)
+	if hookAccount == nil {
+		return err
+	}
+	found, err := accountHasWebhook(hookAccount.Identifier, org, request.Webhook)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Webhooks", err)
+	}
+	if !found {
+		return getAPIError(c, http.StatusNotFound, "Webhook Not Found", nil)
+	}
+	limit := request.Limit
+	if limit < 1 || limit > db.MaxWebhookDeliveriesPageSize {
+		limit = db.MaxWebhookDeliveriesPageSize
+	}
+	deliveries, err := database.GetWebhookDeliveries(hookAccount.Identifier, request.Webhook, limit)
+	if err != nil {
+		return getAPIError(c, http.StatusInternalServerError, "Error Retrieving Webhook Deliveries", err)
+	}
+	if deliveries == nil {
+		deliveries = make([]types.WebhookDelivery, 0)
+	}
+	return c.JSON(http.StatusOK, types.GetWebhookDeliveriesResponse{
+		Deliveries: deliveries,
+	})
+}
+
+// webhookScope Returns the account whose webhooks are being managed and the organization they're for, nil
+// for the account's own. Organization webhooks are kept under the account that created the organization.
+// If nil is returned an error response has already been written and the error is the result of writing it.
+func webhookScope(c *echo.Context, account *types.Account, email *string, org *int64) (*types.Account, *int64, error) {
+	if org == nil {
+		hookAccount, err := managedAccount(c, account, email)
+		return hookAccount, nil, err
+	}
+	if email != nil {
+		return nil, nil, getAPIError(c, http.StatusBadRequest, "Bad Request", errors.New("webhooks can't be for an account and an organization"))
+	}
+	organization, err := memberOrganization(c, account, *org, types.OrgRoleOwner, types.OrgRoleManager)
+	if organization == nil {
+		return nil, nil, err
+	}
+	hookAccount, err := database.GetAccountByID(organization.AccountIdentifier)
+	if err != nil {
+		return nil, nil, getAPIError(c, http.StatusInternalServerError, "Error Retrieving Account", err)
+	}
+	if hookAccount == nil {
+		return nil, nil, getAPIError(c, http.StatusNotFound, "Account Not Found", nil)
+	}
+	return hookAccount, &organization.Identifier, nil
+}
+
+// accountHasWebhook Reports whether the webhook belongs to the account and is for the organization, or for
+// the account's own keys if org is nil.
+func accountHasWebhook(account int64, org *int64, webhook int64) (bool, error) {
+	hooks, err := database.GetAccountWebhooks(account)
+	if err != nil {
+		return false, err
+	}
+	for _, hook := range hooks {
+		if hook.Identifier == webhook && hook.InOrganization(org) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,934 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"chronokeep/remote/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver records the deliveries sent to it and responds with the status it's set to.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *webhookReceiver) received() ([]*http.Request, [][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests, r.bodies
+}
+
+// stubWebhookHosts Resolves webhook hosts with the given addresses instead of looking them up, for the rest of the test.
+func stubWebhookHosts(t *testing.T, hosts map[string]string) {
+	lookup := lookupWebhookHost
+	lookupWebhookHost = func(_ context.Context, _, host string) ([]netip.Addr, error) {
+		if addr, err := netip.ParseAddr(host); err == nil {
+			return []netip.Addr{addr}, nil
+		}
+		addr, ok := hosts[host]
+		if !ok {
+			return nil, fmt.Errorf("no such host %s", host)
+		}
+		return []netip.Addr{netip.MustParseAddr(addr)}, nil
+	}
+	t.Cleanup(func() {
+		lookupWebhookHost = lookup
+	})
+}
+
+// allowLoopbackWebhooks Lets webhooks be sent to the loopback address for the rest of the test,
+// so they can be delivered to test servers.
+func allowLoopbackWebhooks(t *testing.T) {
+	allowed := webhookAddressAllowed
+	webhookAddressAllowed = func(addr netip.Addr) bool {
+		return addr.Unmap().IsLoopback() || publicAddress(addr)
+	}
+	t.Cleanup(func() {
+		webhookAddressAllowed = allowed
+	})
+}
+
+func TestAddWebhook(t *testing.T) {
+	stubWebhookHosts(t, map[string]string{
+		"example.com":          "93.184.215.14",
+		"example.org":          "93.184.215.15",
+		"internal.example.com": "10.1.2.3",
+	})
+	// POST, /r/webhook/add
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	body, err := json.Marshal(types.AddWebhookRequest{
+		Webhook: types.RequestWebhook{
+			URL:    "https://example.com/hook",
+			Events: []string{types.WebhookReadsAdded},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	token := loginSession(t, variables.accounts[1], "")
+	// Test bad request
+	t.Log("Testing bad request.")
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader("////"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+	// Test invalid fields
+	for _, hook := range []types.RequestWebhook{
+		{URL: "", Events: []string{types.WebhookReadsAdded}},
+		{URL: "ftp://example.com/hook", Events: []string{types.WebhookReadsAdded}},
+		{URL: "not a url", Events: []string{types.WebhookReadsAdded}},
+		{URL: "https://example.com/hook", Events: []string{}},
+		{URL: "https://example.com/hook", Events: []string{"reads.deleted"}},
+		{URL: "http://127.0.0.1/hook", Events: []string{types.WebhookReadsAdded}},
+		{URL: "http://[::1]:8080/hook", Events: []string{types.WebhookReadsAdded}},
+		{URL: "http://169.254.169.254/latest/meta-data", Events: []string{types.WebhookReadsAdded}},
+		{URL: "https://192.168.1.1/hook", Events: []string{types.WebhookReadsAdded}},
+		{URL: "https://internal.example.com/hook", Events: []string{types.WebhookReadsAdded}},
+		{URL: "https://unknown.example.com/hook", Events: []string{types.WebhookReadsAdded}},
+	} {
+		t.Logf("Testing invalid webhook: %+v.", hook)
+		body, err = json.Marshal(types.AddWebhookRequest{Webhook: hook})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request = httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		response = httptest.NewRecorder()
+		c = e.NewContext(request, response)
+		if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+			assert.Equal(t, http.StatusBadRequest, response.Code)
+		}
+	}
+	// Test not admin adding to another account
+	t.Log("Testing not admin adding to another account.")
+	body, err = json.Marshal(types.AddWebhookRequest{
+		Email: &variables.accounts[2].Email,
+		Webhook: types.RequestWebhook{
+			URL:    "https://example.com/hook",
+			Events: []string{types.WebhookReadsAdded},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	body, err = json.Marshal(types.AddWebhookRequest{
+		Webhook: types.RequestWebhook{
+			URL:    "https://example.com/hook",
+			Events: []string{types.WebhookReadsAdded, types.WebhookNotificationSaved, types.WebhookReadsAdded},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.ModifyWebhookResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotEqual(t, int64(0), resp.Webhook.Identifier)
+			assert.Equal(t, "https://example.com/hook", resp.Webhook.URL)
+			assert.Equal(t, []string{types.WebhookReadsAdded, types.WebhookNotificationSaved}, resp.Webhook.Events)
+			assert.Len(t, resp.Webhook.Secret, 64)
+		}
+	}
+	hooks, err := database.GetAccountWebhooks(variables.accounts[1].Identifier)
+	if assert.NoError(t, err) {
+		assert.Len(t, hooks, 1)
+	}
+	// Test admin adding to another account
+	t.Log("Testing admin adding to another account.")
+	adminToken := loginSession(t, variables.accounts[0], "")
+	body, err = json.Marshal(types.AddWebhookRequest{
+		Email: &variables.accounts[2].Email,
+		Webhook: types.RequestWebhook{
+			URL:    "http://example.org/hook",
+			Events: []string{types.WebhookKeyExpired},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	hooks, err = database.GetAccountWebhooks(variables.accounts[2].Identifier)
+	if assert.NoError(t, err) {
+		assert.Len(t, hooks, 1)
+	}
+	// Test admin adding to an unknown account
+	t.Log("Testing admin adding to an unknown account.")
+	unknown := "unknown@test.com"
+	body, err = json.Marshal(types.AddWebhookRequest{
+		Email: &unknown,
+		Webhook: types.RequestWebhook{
+			URL:    "http://example.org/hook",
+			Events: []string{types.WebhookKeyExpired},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test too many webhooks
+	t.Log("Testing too many webhooks.")
+	for i := 1; i < maxWebhooksPerAccount; i++ {
+		_, err := database.AddWebhook(types.Webhook{
+			AccountIdentifier: variables.accounts[1].Identifier,
+			URL:               "https://example.com/hook" + strconv.Itoa(i),
+			Secret:            "secret",
+			Events:            []string{types.WebhookReadsAdded},
+		})
+		if err != nil {
+			t.Fatalf("Error adding test webhook: %v", err)
+		}
+	}
+	body, err = json.Marshal(types.AddWebhookRequest{
+		Webhook: types.RequestWebhook{
+			URL:    "https://example.com/hook",
+			Events: []string{types.WebhookReadsAdded},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+func TestGetWebhooks(t *testing.T) {
+	// POST, /r/webhook
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	request := httptest.NewRequest(http.MethodPost, "/r/webhook", strings.NewReader(""))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhooks)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	token := loginSession(t, variables.accounts[1], "")
+	// Test no webhooks
+	t.Log("Testing no webhooks.")
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook", strings.NewReader(""))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhooks)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetWebhooksResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.NotNil(t, resp.Webhooks)
+			assert.Empty(t, resp.Webhooks)
+		}
+	}
+	for _, account := range variables.accounts[1:] {
+		_, err := database.AddWebhook(types.Webhook{
+			AccountIdentifier: account.Identifier,
+			URL:               "https://example.com/" + account.Type,
+			Secret:            "secret",
+			Events:            []string{types.WebhookReadsAdded},
+		})
+		if err != nil {
+			t.Fatalf("Error adding test webhook: %v", err)
+		}
+	}
+	// Test valid, secrets aren't returned
+	t.Log("Testing valid request.")
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook", strings.NewReader(""))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhooks)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetWebhooksResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Webhooks, 1) {
+				assert.Equal(t, "https://example.com/"+variables.accounts[1].Type, resp.Webhooks[0].URL)
+				assert.Equal(t, "", resp.Webhooks[0].Secret)
+			}
+		}
+		assert.NotContains(t, response.Body.String(), "secret")
+	}
+	// Test not admin getting another account's webhooks
+	t.Log("Testing not admin getting another account's webhooks.")
+	body, err := json.Marshal(types.GetWebhooksRequest{
+		Email: &variables.accounts[2].Email,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhooks)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test admin getting another account's webhooks
+	t.Log("Testing admin getting another account's webhooks.")
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+loginSession(t, variables.accounts[0], ""))
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhooks)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetWebhooksResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Webhooks, 1) {
+				assert.Equal(t, "https://example.com/"+variables.accounts[2].Type, resp.Webhooks[0].URL)
+			}
+		}
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	// DELETE, /r/webhook/delete
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	hook, err := database.AddWebhook(types.Webhook{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test webhook: %v", err)
+	}
+	body, err := json.Marshal(types.DeleteWebhookRequest{
+		Webhook: hook.Identifier,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	request := httptest.NewRequest(http.MethodDelete, "/r/webhook/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteWebhook)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test another account's webhook
+	t.Log("Testing another account's webhook.")
+	request = httptest.NewRequest(http.MethodDelete, "/r/webhook/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+loginSession(t, variables.accounts[2], ""))
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteWebhook)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	token := loginSession(t, variables.accounts[1], "")
+	request = httptest.NewRequest(http.MethodDelete, "/r/webhook/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteWebhook)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	hooks, err := database.GetAccountWebhooks(variables.accounts[1].Identifier)
+	if assert.NoError(t, err) {
+		assert.Empty(t, hooks)
+	}
+	// Test already deleted
+	t.Log("Testing already deleted.")
+	request = httptest.NewRequest(http.MethodDelete, "/r/webhook/delete", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.DeleteWebhook)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	// POST, /r/webhook/deliveries
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	e := echo.New()
+	h := Handler{}
+	hook, err := database.AddWebhook(types.Webhook{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		URL:               "https://example.com/hook",
+		Secret:            "secret",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test webhook: %v", err)
+	}
+	err = database.AddWebhookDeliveries([]types.WebhookDelivery{
+		{WebhookIdentifier: hook.Identifier, Event: types.WebhookReadsAdded, Payload: "{}", NextAttempt: time.Now().Add(time.Hour)},
+		{WebhookIdentifier: hook.Identifier, Event: types.WebhookReadsAdded, Payload: "{}", NextAttempt: time.Now().Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test deliveries: %v", err)
+	}
+	body, err := json.Marshal(types.GetWebhookDeliveriesRequest{
+		Webhook: hook.Identifier,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	// Test empty auth header
+	t.Log("Testing empty auth header.")
+	request := httptest.NewRequest(http.MethodPost, "/r/webhook/deliveries", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhookDeliveries)(c)) {
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+	// Test another account's webhook
+	t.Log("Testing another account's webhook.")
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/deliveries", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+loginSession(t, variables.accounts[2], ""))
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhookDeliveries)(c)) {
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	}
+	// Test valid
+	t.Log("Testing valid request.")
+	token := loginSession(t, variables.accounts[1], "")
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/deliveries", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhookDeliveries)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetWebhookDeliveriesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			if assert.Len(t, resp.Deliveries, 2) {
+				assert.Equal(t, types.DeliveryPending, resp.Deliveries[0].Status)
+				assert.Equal(t, hook.Identifier, resp.Deliveries[0].WebhookIdentifier)
+			}
+		}
+		assert.NotContains(t, response.Body.String(), "secret")
+	}
+	// Test limit
+	t.Log("Testing limit.")
+	body, err = json.Marshal(types.GetWebhookDeliveriesRequest{
+		Webhook: hook.Identifier,
+		Limit:   1,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook/deliveries", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhookDeliveries)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetWebhookDeliveriesResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) {
+			assert.Len(t, resp.Deliveries, 1)
+		}
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	allowLoopbackWebhooks(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	hook, err := database.AddWebhook(types.Webhook{
+		AccountIdentifier: variables.accounts[1].Identifier,
+		URL:               server.URL,
+		Secret:            "webhook-secret",
+		Events:            []string{types.WebhookReadsAdded, types.WebhookNotificationSaved},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test webhook: %v", err)
+	}
+	// Reads added with a key on the account are delivered.
+	t.Log("Testing reads added.")
+	body, err := json.Marshal(types.UploadReadsRequest{
+		Reads: []types.Read{
+			{
+				Type:       "manual",
+				Identifier: "webhook1",
+				IdentType:  "bib",
+				Seconds:    99999,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write2"])
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.AddReads(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	webhooks.run(time.Now())
+	requests, bodies := receiver.received()
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, types.WebhookReadsAdded, requests[0].Header.Get("X-Chronokeep-Event"))
+		timestamp, err := strconv.ParseInt(requests[0].Header.Get("X-Chronokeep-Timestamp"), 10, 64)
+		if assert.NoError(t, err) {
+			assert.Equal(t, types.SignWebhook("webhook-secret", timestamp, bodies[0]), requests[0].Header.Get("X-Chronokeep-Signature"))
+		}
+		var payload types.WebhookPayload
+		if assert.NoError(t, json.Unmarshal(bodies[0], &payload)) {
+			assert.Equal(t, types.WebhookReadsAdded, payload.Event)
+			assert.Equal(t, "reader6", payload.Reader)
+			if assert.Len(t, payload.Reads, 1) {
+				assert.Equal(t, "webhook1", payload.Reads[0].Identifier)
+			}
+		}
+	}
+	deliveries, err := database.GetWebhookDeliveries(variables.accounts[1].Identifier, hook.Identifier, 10)
+	if assert.NoError(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Equal(t, types.DeliveryDelivered, deliveries[0].Status)
+		assert.Equal(t, http.StatusOK, deliveries[0].ResponseCode)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.NotNil(t, deliveries[0].DeliveredAt)
+		assert.Equal(t, strconv.FormatInt(deliveries[0].Identifier, 10), requests[0].Header.Get("X-Chronokeep-Delivery"))
+	}
+	// Duplicate reads aren't new, so nothing is sent.
+	t.Log("Testing duplicate reads.")
+	request = httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write2"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AddReads(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	webhooks.run(time.Now())
+	requests, _ = receiver.received()
+	assert.Len(t, requests, 1)
+	// Saved notifications are delivered with their id.
+	t.Log("Testing notification saved.")
+	body, err = json.Marshal(types.SaveNotificationRequest{
+		Note: types.RequestNotification{
+			Type: "UPS_ONLINE",
+			When: time.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/notifications/save", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write2"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.SaveNotification(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	webhooks.run(time.Now())
+	requests, bodies = receiver.received()
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, types.WebhookNotificationSaved, requests[1].Header.Get("X-Chronokeep-Event"))
+		var payload types.WebhookPayload
+		if assert.NoError(t, json.Unmarshal(bodies[1], &payload)) {
+			assert.Equal(t, "reader6", payload.Reader)
+			if assert.NotNil(t, payload.Notification) {
+				assert.NotEqual(t, int64(0), payload.Notification.Identifier)
+				assert.Equal(t, "UPS_ONLINE", payload.Notification.Type)
+			}
+		}
+	}
+	// Failed deliveries are retried with a backoff.
+	t.Log("Testing failed delivery.")
+	receiver.setStatus(http.StatusInternalServerError)
+	body, err = json.Marshal(types.UploadReadsRequest{
+		Reads: []types.Read{
+			{
+				Type:       "manual",
+				Identifier: "webhook2",
+				IdentType:  "bib",
+				Seconds:    99999,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+variables.knownValues["write2"])
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.AddReads(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	now := time.Now()
+	webhooks.run(now)
+	requests, _ = receiver.received()
+	assert.Len(t, requests, 3)
+	deliveries, err = database.GetWebhookDeliveries(variables.accounts[1].Identifier, hook.Identifier, 1)
+	if assert.NoError(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Equal(t, types.DeliveryPending, deliveries[0].Status)
+		assert.Equal(t, http.StatusInternalServerError, deliveries[0].ResponseCode)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.NotEqual(t, "", deliveries[0].Error)
+		assert.Equal(t, now.Add(webhookBaseBackoff).Unix(), deliveries[0].NextAttempt.Unix())
+	}
+	// Not due yet.
+	webhooks.run(now.Add(webhookBaseBackoff / 2))
+	requests, _ = receiver.received()
+	assert.Len(t, requests, 3)
+	webhooks.run(now.Add(webhookBaseBackoff))
+	requests, _ = receiver.received()
+	assert.Len(t, requests, 4)
+	deliveries, err = database.GetWebhookDeliveries(variables.accounts[1].Identifier, hook.Identifier, 1)
+	if assert.NoError(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Equal(t, 2, deliveries[0].Attempts)
+		assert.Equal(t, now.Add(webhookBaseBackoff*3).Unix(), deliveries[0].NextAttempt.Unix())
+	}
+	receiver.setStatus(http.StatusNoContent)
+	webhooks.run(now.Add(webhookBaseBackoff * 3))
+	deliveries, err = database.GetWebhookDeliveries(variables.accounts[1].Identifier, hook.Identifier, 1)
+	if assert.NoError(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Equal(t, types.DeliveryDelivered, deliveries[0].Status)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+		assert.Equal(t, "", deliveries[0].Error)
+	}
+	// Deliveries that run out of attempts are given up on.
+	t.Log("Testing delivery running out of attempts.")
+	delivery := types.WebhookDelivery{
+		URL:      "http://127.0.0.1:1/unreachable",
+		Attempts: webhookMaxAttempts - 1,
+		Status:   types.DeliveryPending,
+	}
+	webhooks.deliver(&delivery, now)
+	assert.Equal(t, types.DeliveryFailed, delivery.Status)
+	assert.Equal(t, webhookMaxAttempts, delivery.Attempts)
+	assert.NotEqual(t, "", delivery.Error)
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	client := newWebhookClient()
+	// The loopback address is refused when it's dialed, even though the URL was never checked.
+	t.Log("Testing loopback address.")
+	_, err := client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	assert.Error(t, err)
+	requests, _ := receiver.received()
+	assert.Empty(t, requests)
+	t.Log("Testing checking URLs.")
+	stubWebhookHosts(t, map[string]string{
+		"example.com":        "93.184.215.14",
+		"mapped.example.com": "::ffff:127.0.0.1",
+		"cgnat.example.com":  "100.64.0.1",
+	})
+	assert.NoError(t, checkWebhookURL(context.Background(), "https://example.com/hook"))
+	assert.Error(t, checkWebhookURL(context.Background(), "https://mapped.example.com/hook"))
+	assert.Error(t, checkWebhookURL(context.Background(), "https://cgnat.example.com/hook"))
+	assert.Error(t, checkWebhookURL(context.Background(), "http://[fe80::1]/hook"))
+	// Redirects to a private address are refused as well.
+	t.Log("Testing redirect to a private address.")
+	allowLoopbackWebhooks(t)
+	redirect := httptest.NewServer(http.RedirectHandler("http://10.0.0.1/hook", http.StatusTemporaryRedirect))
+	defer redirect.Close()
+	_, err = client.Post(redirect.URL, "application/json", strings.NewReader("{}"))
+	assert.Error(t, err)
+}
+
+func TestWebhookKeyExpired(t *testing.T) {
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	allowLoopbackWebhooks(t)
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	_, err := database.AddWebhook(types.Webhook{
+		AccountIdentifier: variables.accounts[0].Identifier,
+		URL:               server.URL,
+		Secret:            "webhook-secret",
+		Events:            []string{types.WebhookKeyExpired},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test webhook: %v", err)
+	}
+	// The first run only records when it checked.
+	t.Log("Testing first run.")
+	webhooks.run(time.Now())
+	requests, _ := receiver.received()
+	assert.Empty(t, requests)
+	checked, err := database.GetSetting(keyExpiryCheckedSetting)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, "", checked)
+	}
+	// Keys that expired since the last check are reported once.
+	t.Log("Testing expired keys.")
+	err = database.SetSetting(keyExpiryCheckedSetting, strconv.FormatInt(time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), 10))
+	if err != nil {
+		t.Fatalf("Error setting last key expiry check: %v", err)
+	}
+	webhooks.run(time.Now())
+	requests, bodies := receiver.received()
+	if assert.Len(t, requests, 2) {
+		names := make([]string, 0)
+		for i := range requests {
+			assert.Equal(t, types.WebhookKeyExpired, requests[i].Header.Get("X-Chronokeep-Event"))
+			var payload types.WebhookPayload
+			if assert.NoError(t, json.Unmarshal(bodies[i], &payload)) && assert.NotNil(t, payload.Key) {
+				names = append(names, payload.Key.Name)
+				assert.Equal(t, "", payload.Key.Value)
+			}
+		}
+		assert.ElementsMatch(t, []string{"reader1", "reader8"}, names)
+	}
+	webhooks.run(time.Now())
+	requests, _ = receiver.received()
+	assert.Len(t, requests, 2)
+}
+
+func TestWebhookOrganizationScope(t *testing.T) {
+	// Organization webhooks are kept under the organization's creator, but each only hears about its own keys.
+	variables, finalize := setupTests(t)
+	defer finalize(t)
+	allowLoopbackWebhooks(t)
+	e := echo.New()
+	h := Handler{}
+	h.Setup()
+	owner := variables.accounts[1]
+	org, err := database.AddOrganization(types.Organization{
+		AccountIdentifier: owner.Identifier,
+		Name:              "Timing Company",
+	})
+	if err != nil {
+		t.Fatalf("Error adding organization: %v", err)
+	}
+	orgKey, err := database.AddKey(types.Key{
+		AccountIdentifier: owner.Identifier,
+		Organization:      &org.Identifier,
+		Name:              "finish",
+		Value:             "organization-write-key",
+		Type:              "write",
+	})
+	if err != nil || orgKey == nil {
+		t.Fatalf("Error adding organization key: %v", err)
+	}
+	accountReceiver := &webhookReceiver{status: http.StatusOK}
+	accountServer := httptest.NewServer(accountReceiver)
+	defer accountServer.Close()
+	orgReceiver := &webhookReceiver{status: http.StatusOK}
+	orgServer := httptest.NewServer(orgReceiver)
+	defer orgServer.Close()
+	_, err = database.AddWebhook(types.Webhook{
+		AccountIdentifier: owner.Identifier,
+		URL:               accountServer.URL,
+		Secret:            "account-secret",
+		Events:            []string{types.WebhookReadsAdded},
+	})
+	if err != nil {
+		t.Fatalf("Error adding test webhook: %v", err)
+	}
+	token := loginSession(t, owner, "")
+	// Test adding an organization webhook
+	t.Log("Testing adding an organization webhook.")
+	body, err := json.Marshal(types.AddWebhookRequest{
+		Organization: &org.Identifier,
+		Webhook: types.RequestWebhook{
+			URL:    orgServer.URL,
+			Events: []string{types.WebhookReadsAdded},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/r/webhook/add", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response := httptest.NewRecorder()
+	c := e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.AddWebhook)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+	// Test getting only the organization's webhooks
+	t.Log("Testing getting the organization's webhooks.")
+	body, err = json.Marshal(types.GetWebhooksRequest{
+		Organization: &org.Identifier,
+	})
+	if err != nil {
+		t.Fatalf("Error encoding request body into json object: %v", err)
+	}
+	request = httptest.NewRequest(http.MethodPost, "/r/webhook", strings.NewReader(string(body)))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	response = httptest.NewRecorder()
+	c = e.NewContext(request, response)
+	if assert.NoError(t, h.authorize()(h.GetWebhooks)(c)) {
+		assert.Equal(t, http.StatusOK, response.Code)
+		var resp types.GetWebhooksResponse
+		if assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &resp)) && assert.Len(t, resp.Webhooks, 1) {
+			assert.Equal(t, orgServer.URL, resp.Webhooks[0].URL)
+			assert.True(t, resp.Webhooks[0].InOrganization(&org.Identifier))
+		}
+	}
+	addReads := func(key, identifier string) {
+		body, err := json.Marshal(types.UploadReadsRequest{
+			Reads: []types.Read{
+				{
+					Type:       "manual",
+					Identifier: identifier,
+					IdentType:  "bib",
+					Seconds:    99999,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Error encoding request body into json object: %v", err)
+		}
+		request := httptest.NewRequest(http.MethodPost, "/reads/add", strings.NewReader(string(body)))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+key)
+		response := httptest.NewRecorder()
+		if assert.NoError(t, h.AddReads(e.NewContext(request, response))) {
+			assert.Equal(t, http.StatusOK, response.Code)
+		}
+	}
+	// Reads from the creator's own key only go to the creator's webhook.
+	t.Log("Testing reads from the creator's key.")
+	addReads(variables.knownValues["write2"], "account1")
+	// Reads from the organization's key only go to the organization's webhook.
+	t.Log("Testing reads from the organization's key.")
+	addReads(orgKey.Value, "organization1")
+	webhooks.run(time.Now())
+	for _, check := range []struct {
+		receiver   *webhookReceiver
+		identifier string
+	}{
+		{accountReceiver, "account1"},
+		{orgReceiver, "organization1"},
+	} {
+		requests, bodies := check.receiver.received()
+		if assert.Len(t, requests, 1) {
+			var payload types.WebhookPayload
+			if assert.NoError(t, json.Unmarshal(bodies[0], &payload)) && assert.Len(t, payload.Reads, 1) {
+				assert.Equal(t, check.identifier, payload.Reads[0].Identifier)
+			}
+		}
+	}
+}
@@ -21,6 +21,8 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -39,10 +41,24 @@ import (
 )
 
 func main() {
+	// Without a command the server is started.
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	os.Exit(runCommand(args))
+}
+
+func serveCommand(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	source := util.ConfigFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
 	log.Info("Starting remote.")
-	config, err := util.GetConfig()
+	config, err := source.Load()
 	if err != nil {
-		log.Fatal("Failed to get configuration. ", err)
+		return fmt.Errorf("failed to get configuration: %v", err)
 	}
 	e := echo.New()
 
@@ -86,9 +102,21 @@ func main() {
 	log.Info("Calling handler setup.")
 	// Handlers has a setup function which sets up the database for use.
 	err = handlers.Setup(config)
-	defer handlers.Finalize()
 	if err != nil {
-		log.Fatalf("Error setting up database. %v", err)
+		return fmt.Errorf("error setting up database: %v", err)
+	}
+	defer handlers.Finalize()
+	log.Info("Starting read retention worker.")
+	stopRetention := handlers.StartRetention()
+	defer stopRetention()
+	log.Info("Starting webhook worker.")
+	stopWebhooks := handlers.StartWebhooks()
+	defer stopWebhooks()
+	if len(config.JWTKeyFiles) == 0 {
+		log.Info("JWT_KEY_FILES not set, tokens are signed with SECRET_KEY and REFRESH_KEY.")
+	}
+	if config.SMTPHost == "" {
+		log.Info("SMTP_HOST not set, email alerts and password resets are disabled.")
 	}
 	log.Info("Binding ")
 	// Set up API handlers.
@@ -115,7 +143,7 @@ func main() {
 	}
 	if !config.AutoTLS {
 		log.Info("Starting non https echo server.")
-		log.Fatal(e.Start(":" + strconv.Itoa(config.Port)))
+		return e.Start(":" + strconv.Itoa(config.Port))
 	} else {
 		log.Info("Starting auto tls echo server.")
 		// Set up auto tls manager - Cache certificates
@@ -136,7 +164,7 @@ func main() {
 				NextProtos:     []string{acme.ALPNProto},
 			},
 		}
-		log.Fatal(s.ListenAndServeTLS("", ""))
+		return s.ListenAndServeTLS("", "")
 	}
 }
 
@@ -151,4 +179,3 @@ func init() {
 	log.SetOutput(os.Stdout)
 	log.SetLevel(log.InfoLevel)
 }
-
@@ -20,23 +20,59 @@ package types
 
 import (
 	"errors"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// LockReasonWrongPassword is the lock reason given to accounts locked after too many wrong passwords.
+const LockReasonWrongPassword = "too many wrong passwords"
+
 // Account is a structure holding information on accounts that have access
 // to this module
 type Account struct {
-	Identifier        int64  `json:"-"`
-	Password          string `json:"-"`
-	Name              string `json:"name" validate:"required"`
-	Email             string `json:"email" validate:"email,required"`
-	Type              string `json:"type" validate:"required"`
-	Locked            bool   `json:"locked"`
-	WrongPassAttempts int    `json:"-"`
-	Token             string `json:"-"`
-	RefreshToken      string `json:"-"`
+	Identifier        int64      `json:"-"`
+	Password          string     `json:"-"`
+	Name              string     `json:"name" validate:"required"`
+	Email             string     `json:"email" validate:"email,required"`
+	Type              string     `json:"type" validate:"required"`
+	Locked            bool       `json:"locked"`
+	LockReason        string     `json:"lock_reason,omitempty"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
+	WrongPassAttempts int        `json:"-"`
+	LockCount         int        `json:"-"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`
+}
+
+// SetLockedUntil sets when the account's lock expires from a unix timestamp, 0 meaning it doesn't expire.
+// An account whose lock has already expired isn't locked.
+func (a *Account) SetLockedUntil(until int64) {
+	a.LockedUntil = nil
+	if !a.Locked || until == 0 {
+		return
+	}
+	lockedUntil := time.Unix(until, 0)
+	if !lockedUntil.After(time.Now()) {
+		a.Locked = false
+		a.LockReason = ""
+		return
+	}
+	a.LockedUntil = &lockedUntil
+}
+
+// SetDeletedAt sets when the account was deleted from a unix timestamp in milliseconds, 0 meaning it isn't known.
+func (a *Account) SetDeletedAt(at int64) {
+	a.DeletedAt = unixMilliTime(at)
+}
+
+// unixMilliTime Returns the time of a unix timestamp in milliseconds, or nil for 0.
+func unixMilliTime(at int64) *time.Time {
+	if at == 0 {
+		return nil
+	}
+	out := time.UnixMilli(at)
+	return &out
 }
 
 // Equals is used to check if the fields of an Account other than the identifier are identical.
@@ -69,16 +105,8 @@ func (a *Account) PasswordIsHashed() bool {
 
 // Validate Ensures that the struct is viable for entry into the database.
 func (a *Account) Validate(validate *validator.Validate) error {
-	valid := false
-	switch a.Type {
-	case "admin":
-		valid = true
-	case "free":
-		valid = true
-	case "paid":
-		valid = true
-	}
-	if !valid {
+	// The type is the name of the account's role, whether that role exists is checked where it is stored.
+	if !ValidRoleName(a.Type) {
 		return errors.New("invalid account type specified")
 	}
 	return validate.Struct(a)
@@ -0,0 +1,119 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// AlertRule tells the server who to email when one of an account's readers sends a notification.
+// A rule without readers applies to every reader on the account.
+type AlertRule struct {
+	Identifier        int64     `json:"id"`
+	AccountIdentifier int64     `json:"-"`
+	Types             []string  `json:"types"`
+	Readers           []string  `json:"readers"`
+	Recipients        []string  `json:"recipients"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type RequestAlertRule struct {
+	Types      []string `json:"types" validate:"required,min=1"`
+	Readers    []string `json:"readers" validate:"max=50,dive,required,max=100"`
+	Recipients []string `json:"recipients" validate:"required,min=1,max=10,dive,required,email,max=100"`
+}
+
+// Validate Ensures valid data in the structure.
+func (r RequestAlertRule) Validate(validate *validator.Validate) error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	for _, notificationType := range r.Types {
+		if !ValidNotificationType(notificationType) {
+			return fmt.Errorf("%v is not a valid type", notificationType)
+		}
+	}
+	for _, reader := range r.Readers {
+		if strings.Contains(reader, ",") {
+			return fmt.Errorf("invalid reader specified: '%s'", reader)
+		}
+	}
+	return nil
+}
+
+// ToAlertRule Returns an AlertRule struct with proper information.
+func (r RequestAlertRule) ToAlertRule() AlertRule {
+	return AlertRule{
+		Types:      uniqueList(r.Types),
+		Readers:    uniqueList(r.Readers),
+		Recipients: uniqueList(r.Recipients),
+	}
+}
+
+// Matches Reports whether a notification of the given type sent by the reader should be emailed out.
+func (a AlertRule) Matches(reader, notificationType string) bool {
+	if !slices.Contains(a.Types, notificationType) {
+		return false
+	}
+	return len(a.Readers) == 0 || slices.Contains(a.Readers, reader)
+}
+
+// TypesValue Returns the types in the form they're stored in the database.
+func (a AlertRule) TypesValue() string {
+	return strings.Join(a.Types, ",")
+}
+
+// ReadersValue Returns the readers in the form they're stored in the database.
+func (a AlertRule) ReadersValue() string {
+	return strings.Join(a.Readers, ",")
+}
+
+// RecipientsValue Returns the recipients in the form they're stored in the database.
+func (a AlertRule) RecipientsValue() string {
+	return strings.Join(a.Recipients, ",")
+}
+
+// SetValues Sets the types, readers and recipients from the form they're stored in the database.
+func (a *AlertRule) SetValues(types, readers, recipients string) {
+	a.Types = splitList(types)
+	a.Readers = splitList(readers)
+	a.Recipients = splitList(recipients)
+}
+
+// uniqueList Returns the trimmed, non-empty values in the list with duplicates removed.
+func uniqueList(values []string) []string {
+	out := make([]string, 0)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !slices.Contains(out, value) {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// splitList Splits a comma separated list stored in the database.
+func splitList(value string) []string {
+	return uniqueList(strings.Split(value, ","))
+}
@@ -0,0 +1,64 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import "time"
+
+// ArchiveFormat identifies account archives, it is the first thing in every archive.
+const ArchiveFormat = "chronokeep-remote-account"
+
+// ArchiveVersion is the version of account archives written. Archives from newer versions can't be imported.
+const ArchiveVersion = 1
+
+// Types of record found in an account archive.
+const (
+	ArchiveRecordAccount      = "account"
+	ArchiveRecordKey          = "key"
+	ArchiveRecordRead         = "read"
+	ArchiveRecordNotification = "notification"
+)
+
+// ArchiveHeader is the first line of an account archive.
+type ArchiveHeader struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// ArchiveRecord is every line after the header of an account archive, one per account, key, read or notification.
+// Reads and notifications name the key they were saved with as key values can't be exported.
+type ArchiveRecord struct {
+	Type         string        `json:"type"`
+	Account      *Account      `json:"account,omitempty"`
+	Key          *Key          `json:"key,omitempty"`
+	KeyName      string        `json:"key_name,omitempty"`
+	Read         *Read         `json:"read,omitempty"`
+	Notification *Notification `json:"notification,omitempty"`
+}
+
+// ArchiveImport holds the result of importing an account archive.
+// Keys are given new values when imported, so Keys is the only place to find them.
+type ArchiveImport struct {
+	Account       Account           `json:"account"`
+	Created       bool              `json:"created"`
+	Keys          []Key             `json:"keys"`
+	RenamedKeys   map[string]string `json:"renamed_keys,omitempty"`
+	Reads         int64             `json:"reads"`
+	Notifications int64             `json:"notifications"`
+}
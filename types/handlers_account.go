@@ -24,8 +24,10 @@ package types
 
 // GetAccountResponse Struct used for the response of the Get Account Request.
 type GetAccountResponse struct {
-	Account Account `json:"account"`
-	Keys    []Key   `json:"keys"`
+	Account       Account      `json:"account"`
+	Keys          []Key        `json:"keys"`
+	Organizations []Membership `json:"organizations"`
+	Permissions   []string     `json:"permissions"`
 }
 
 // GetAllAccountsResponse Struct used to get all of the accounts.
@@ -38,6 +40,23 @@ type ModifyAccountResponse struct {
 	Account Account `json:"account"`
 }
 
+// GetSessionsResponse Struct used to respond to a request for the sessions on an account.
+type GetSessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+}
+
+// PurgeAccountResponse Struct used to respond to a purge account request, or to report what one would remove.
+type PurgeAccountResponse struct {
+	Email   string      `json:"email"`
+	DryRun  bool        `json:"dry_run"`
+	Removed PurgeCounts `json:"removed"`
+}
+
+// ImportAccountResponse Struct used to respond to an account import with what was imported.
+type ImportAccountResponse struct {
+	Imported ArchiveImport `json:"imported"`
+}
+
 /*
 	Requests
 */
@@ -80,6 +99,22 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// RevokeSessionRequest Struct used to end a single session.
+type RevokeSessionRequest struct {
+	Session int64 `json:"session"`
+}
+
+// ForgotPasswordRequest Struct used to ask for a password reset token to be emailed out.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"email,required"`
+}
+
+// ResetPasswordRequest Struct used to set a new password with a password reset token.
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
 // ChangePasswordRequest Struct used to change the password on an account.
 type ChangePasswordRequest struct {
 	Email       string `json:"email"`
@@ -87,3 +122,18 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password"`
 }
 
+// RestoreAccountRequest Struct used to bring a deleted account back.
+type RestoreAccountRequest struct {
+	Email string `json:"email" validate:"email,required"`
+}
+
+// PurgeAccountRequest Struct used to permanently remove an account and everything attached to it.
+type PurgeAccountRequest struct {
+	Email  string `json:"email" validate:"email,required"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// ExportAccountRequest Struct used to request an archive of an account, the caller's own when email isn't set.
+type ExportAccountRequest struct {
+	Email *string `json:"email"`
+}
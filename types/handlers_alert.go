@@ -0,0 +1,54 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+/*
+	Responses
+*/
+
+// ModifyAlertRuleResponse Struct used to respond to an Add Alert Rule request.
+type ModifyAlertRuleResponse struct {
+	Rule AlertRule `json:"rule"`
+}
+
+// GetAlertRulesResponse Struct used to respond to the request for account alert rules.
+type GetAlertRulesResponse struct {
+	Rules []AlertRule `json:"rules"`
+}
+
+/*
+	Requests
+*/
+
+// GetAlertRulesRequest Struct used for the Get Alert Rules request.
+type GetAlertRulesRequest struct {
+	Email *string `json:"email"`
+}
+
+// AddAlertRuleRequest Struct used for the Add Alert Rule request.
+type AddAlertRuleRequest struct {
+	Email *string          `json:"email"`
+	Rule  RequestAlertRule `json:"rule"`
+}
+
+// DeleteAlertRuleRequest Struct used for the Delete Alert Rule request.
+type DeleteAlertRuleRequest struct {
+	Email *string `json:"email"`
+	Rule  int64   `json:"rule"`
+}
@@ -42,9 +42,11 @@ type DeleteKeyRequest struct {
 }
 
 // AddKeyRequest Struct used for the Add Key request.
+// Setting an organization adds the key to that organization instead of an account.
 type AddKeyRequest struct {
-	Email *string    `json:"email"`
-	Key   RequestKey `json:"key"`
+	Email        *string    `json:"email"`
+	Organization *int64     `json:"organization"`
+	Key          RequestKey `json:"key"`
 }
 
 // UpdateKeyRequest Struct used for the Update Key request.
@@ -52,8 +54,27 @@ type UpdateKeyRequest struct {
 	Key RequestKey `json:"key"`
 }
 
+// RotateKeyRequest Struct used for the Rotate Key request.
+// GracePeriod is how many seconds the old value keeps working, 0 stops it working immediately.
+type RotateKeyRequest struct {
+	Key         string `json:"key"`
+	GracePeriod int    `json:"grace_period"`
+}
+
 // GetKeysRequest Struct used for the Get Keys request.
+// Without an organization the keys of the account and of every organization it belongs to are returned.
 type GetKeysRequest struct {
-	Email *string `json:"email"`
+	Email        *string `json:"email"`
+	Organization *int64  `json:"organization"`
 }
 
+// GetDeletedKeysRequest Struct used for the Get Deleted Keys request.
+type GetDeletedKeysRequest struct {
+	Email string `json:"email" validate:"email,required"`
+}
+
+// RestoreKeyRequest Struct used to bring a deleted key back. Deleted keys are identified by their name.
+type RestoreKeyRequest struct {
+	Email string `json:"email" validate:"email,required"`
+	Name  string `json:"name" validate:"required"`
+}
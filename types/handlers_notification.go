@@ -27,6 +27,18 @@ type GetNotificationsResponse struct {
 	Note       Notification `json:"notification"`
 }
 
+// GetNotificationHistoryResponse Response structure for a notification history request.
+type GetNotificationHistoryResponse struct {
+	Count         int64          `json:"count"`
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// AcknowledgeNotificationsResponse Response structure for acknowledging notifications.
+type AcknowledgeNotificationsResponse struct {
+	Count int64 `json:"count"`
+}
+
 /*
 	Requests
 */
@@ -39,3 +51,19 @@ type GetNotificationsRequest struct {
 	ReaderName string `json:"reader"`
 }
 
+// GetNotificationHistoryRequest Request structure for the notifications saved over a period of time,
+// newest first. Start and End are unix times; leaving End unset returns everything after Start.
+// Leaving the reader unset returns notifications for every reader on the account.
+type GetNotificationHistoryRequest struct {
+	ReaderName     string `json:"reader"`
+	Start          int64  `json:"start"`
+	End            int64  `json:"end"`
+	Unacknowledged bool   `json:"unacknowledged"`
+	Limit          int    `json:"limit"`
+	Cursor         string `json:"cursor"`
+}
+
+// AcknowledgeNotificationsRequest Request structure for acknowledging notifications by id.
+type AcknowledgeNotificationsRequest struct {
+	Notifications []int64 `json:"notifications"`
+}
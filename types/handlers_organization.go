@@ -0,0 +1,76 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+/*
+	Responses
+*/
+
+// GetOrganizationsResponse Struct used to respond to the request for the organizations an account belongs to.
+type GetOrganizationsResponse struct {
+	Organizations []Membership `json:"organizations"`
+}
+
+// ModifyOrganizationResponse Struct used to respond to Add/Update Organization requests.
+type ModifyOrganizationResponse struct {
+	Organization Organization `json:"organization"`
+}
+
+// GetOrganizationMembersResponse Struct used to respond to the request for an organization's members.
+type GetOrganizationMembersResponse struct {
+	Members []OrganizationMember `json:"members"`
+}
+
+/*
+	Requests
+*/
+
+// GetOrganizationsRequest Struct used for the Get Organizations request.
+type GetOrganizationsRequest struct {
+	Email *string `json:"email"`
+}
+
+// AddOrganizationRequest Struct used for the Add Organization request.
+type AddOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// UpdateOrganizationRequest Struct used for the Update Organization request.
+type UpdateOrganizationRequest struct {
+	Organization int64  `json:"organization" validate:"required"`
+	Name         string `json:"name" validate:"required,max=100"`
+}
+
+// OrganizationRequest Struct used for requests that only need to know the organization.
+type OrganizationRequest struct {
+	Organization int64 `json:"organization" validate:"required"`
+}
+
+// SetOrganizationMemberRequest Struct used to add a member to an organization or change their role.
+type SetOrganizationMemberRequest struct {
+	Organization int64  `json:"organization" validate:"required"`
+	Email        string `json:"email" validate:"email,required"`
+	Role         string `json:"role" validate:"required"`
+}
+
+// RemoveOrganizationMemberRequest Struct used to remove a member from an organization.
+type RemoveOrganizationMemberRequest struct {
+	Organization int64  `json:"organization" validate:"required"`
+	Email        string `json:"email" validate:"email,required"`
+}
@@ -24,14 +24,31 @@ package types
 
 // UploadReadsResponse Response structure for a successful read upload.
 type UploadReadsResponse struct {
-	Count int64 `json:"count"`
+	Count   int64        `json:"count"`
+	Results []ReadResult `json:"results,omitempty"`
+}
+
+const (
+	ReadAccepted  = "accepted"
+	ReadDuplicate = "duplicate"
+	ReadInvalid   = "invalid"
+)
+
+// ReadResult Reports what happened to a single read in an upload. Index refers to the
+// position of the read in the uploaded list.
+type ReadResult struct {
+	Index      int    `json:"index"`
+	Identifier string `json:"identifier"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
 }
 
 // GetReadsResponse Response structure for a read request.
 type GetReadsResponse struct {
-	Count int64         `json:"count"`
-	Reads []Read        `json:"reads"`
-	Note  *Notification `json:"notification"`
+	Count      int64         `json:"count"`
+	Reads      []Read        `json:"reads"`
+	Note       *Notification `json:"notification"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 /*
@@ -44,10 +61,18 @@ type UploadReadsRequest struct {
 }
 
 // GetReadsRequest Request structure for a read request, either time based or read index based.
+// Setting Limit or Cursor returns the reads one page at a time, ordered by time.
 type GetReadsRequest struct {
 	ReaderName string `json:"reader"`
 	Start      int64  `json:"start"`
 	End        int64  `json:"end"`
+	Limit      int    `json:"limit"`
+	Cursor     string `json:"cursor"`
+}
+
+// StreamReadsRequest Request structure for opening a live stream of reads for a reader.
+type StreamReadsRequest struct {
+	ReaderName string `query:"reader" json:"reader"`
 }
 
 // DeleteReadsRequest Request structure for deletion of reads based upon read index values.
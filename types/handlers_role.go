@@ -0,0 +1,48 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+/*
+	Responses
+*/
+
+// GetRolesResponse Struct used to respond to the request for every role and the permissions they can grant.
+type GetRolesResponse struct {
+	Roles       []Role   `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// ModifyRoleResponse Struct used to respond to Save Role requests.
+type ModifyRoleResponse struct {
+	Role Role `json:"role"`
+}
+
+/*
+	Requests
+*/
+
+// SaveRoleRequest Struct used to add a role or change its permissions.
+type SaveRoleRequest struct {
+	Role Role `json:"role" validate:"required"`
+}
+
+// DeleteRoleRequest Struct used for the Delete Role request.
+type DeleteRoleRequest struct {
+	Name string `json:"name" validate:"required"`
+}
@@ -0,0 +1,86 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+/*
+	Responses
+*/
+
+// LoginResponse Struct used to respond to a login that has been completed.
+type LoginResponse struct {
+	AccessToken   string   `json:"access_token"`
+	RefreshToken  string   `json:"refresh_token"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// LoginChallengeResponse Struct used to respond to a login that still needs a two-factor code. If the
+// account has to enroll before it can log in Setup holds the new secret to add to an authenticator app.
+type LoginChallengeResponse struct {
+	Challenge string          `json:"challenge"`
+	Setup     *TwoFactorSetup `json:"setup,omitempty"`
+}
+
+// TwoFactorSetup Struct holding a new TOTP secret and the URI used to show it as a QR code.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// GetTwoFactorResponse Struct used to respond to a request for the two-factor status of an account.
+type GetTwoFactorResponse struct {
+	TwoFactor TwoFactor `json:"two_factor"`
+	Required  bool      `json:"required"`
+}
+
+// RecoveryCodesResponse Struct used to respond with newly generated recovery codes.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+/*
+	Requests
+*/
+
+// LoginTwoFactorRequest Struct used to finish logging in with a TOTP or recovery code.
+type LoginTwoFactorRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+// GetTwoFactorRequest Struct used to request the two-factor status of an account.
+type GetTwoFactorRequest struct {
+	Email *string `json:"email"`
+}
+
+// TwoFactorCodeRequest Struct used for two-factor requests that need a TOTP code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// DisableTwoFactorRequest Struct used to turn off two-factor authentication on an account.
+type DisableTwoFactorRequest struct {
+	Email    *string `json:"email"`
+	Password string  `json:"password"`
+	Code     string  `json:"code"`
+}
+
+// RequireTwoFactorRequest Struct used to set whether admin accounts need two-factor authentication.
+type RequireTwoFactorRequest struct {
+	Required bool `json:"required"`
+}
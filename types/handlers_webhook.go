@@ -0,0 +1,70 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+/*
+	Responses
+*/
+
+// ModifyWebhookResponse Struct used to respond to an Add Webhook request.
+type ModifyWebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+}
+
+// GetWebhooksResponse Struct used to respond to the request for account webhooks.
+type GetWebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// GetWebhookDeliveriesResponse Struct used to respond to the request for a webhook's delivery log.
+type GetWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+/*
+	Requests
+*/
+
+// GetWebhooksRequest Struct used for the Get Webhooks request.
+type GetWebhooksRequest struct {
+	Email        *string `json:"email"`
+	Organization *int64  `json:"organization"`
+}
+
+// AddWebhookRequest Struct used for the Add Webhook request.
+type AddWebhookRequest struct {
+	Email        *string        `json:"email"`
+	Organization *int64         `json:"organization"`
+	Webhook      RequestWebhook `json:"webhook"`
+}
+
+// DeleteWebhookRequest Struct used for the Delete Webhook request.
+type DeleteWebhookRequest struct {
+	Email        *string `json:"email"`
+	Organization *int64  `json:"organization"`
+	Webhook      int64   `json:"webhook"`
+}
+
+// GetWebhookDeliveriesRequest Struct used for the Get Webhook Deliveries request, newest first.
+type GetWebhookDeliveriesRequest struct {
+	Email        *string `json:"email"`
+	Organization *int64  `json:"organization"`
+	Webhook      int64   `json:"webhook"`
+	Limit        int     `json:"limit"`
+}
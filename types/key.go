@@ -19,13 +19,21 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package types
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// KeyPrefixLength is how much of a key's value is kept in the clear so keys can be told apart.
+const KeyPrefixLength = 8
+
 var (
 	timeFormats = [...]string{
 		"2006/01/02",
@@ -42,27 +50,38 @@ var (
 // Key outline for data stored about an PI key
 // Account should be a unique value for the account that owns the Key.
 // Example types are: read (readonly), delete (read, write, delete), write (read, write)
-// Allowed hosts are the hosts the calls are allowed to come from. Default of empty string is all hosts.
+// Allowed hosts are the hosts the calls are allowed to come from, as IP addresses, CIDRs, or hostnames.
+// An empty list allows all hosts.
+// Only a hash of the value is stored, so the value is only known when the key is created or rotated.
+// Keys are listed by their prefix, but using, updating, rotating, or deleting one takes its full value.
+// Keys belonging to an organization are kept under the account that created the organization.
 type Key struct {
 	AccountIdentifier int64      `json:"account_id"`
+	Organization      *int64     `json:"organization,omitempty"`
 	Name              string     `json:"name" validate:"required"`
-	Value             string     `json:"value"`
+	Value             string     `json:"value,omitempty"`
+	Prefix            string     `json:"prefix"`
+	Hash              string     `json:"-"`
 	Type              string     `json:"type" validate:"required"`
+	AllowedHosts      []string   `json:"allowed_hosts"`
 	ValidUntil        *time.Time `json:"valid_until"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`
 }
 
 type RequestKey struct {
-	Name       string `json:"name" validate:"required"`
-	Value      string `json:"value"`
-	Type       string `json:"type" validate:"required"`
-	ValidUntil string `json:"valid_until"`
+	Name         string   `json:"name" validate:"required"`
+	Value        string   `json:"value"`
+	Type         string   `json:"type" validate:"required"`
+	AllowedHosts []string `json:"allowed_hosts"`
+	ValidUntil   string   `json:"valid_until"`
 }
 
 func (k *Key) Equal(other *Key) bool {
 	return k.AccountIdentifier == other.AccountIdentifier &&
 		k.Name == other.Name &&
-		k.Value == other.Value &&
+		k.ValueHash() == other.ValueHash() &&
 		k.Type == other.Type &&
+		slices.Equal(k.AllowedHosts, other.AllowedHosts) &&
 		// This next expression is TRUE if both are nil or both are not nil and they are equal.
 		((k.ValidUntil != nil && other.ValidUntil != nil && k.ValidUntil.Equal(*other.ValidUntil)) || (k.ValidUntil == nil && other.ValidUntil == nil))
 }
@@ -84,7 +103,9 @@ func (k *Key) Validate(validate *validator.Validate) error {
 	if !valid {
 		return errors.New("invalid key type specified")
 	}
-	// TODO: validation on the allowed hosts
+	if err := validateAllowedHosts(validate, k.AllowedHosts); err != nil {
+		return err
+	}
 	return validate.Struct(k)
 }
 
@@ -105,10 +126,71 @@ func (k RequestKey) Validate(validate *validator.Validate) error {
 	if !valid {
 		return errors.New("invalid key type specified")
 	}
-	// TODO: validation on the allowed hosts
+	if err := validateAllowedHosts(validate, k.AllowedHosts); err != nil {
+		return err
+	}
 	return validate.Struct(k)
 }
 
+// validateAllowedHosts Ensures every allowed host is an IP address, a CIDR, or a hostname.
+func validateAllowedHosts(validate *validator.Validate, hosts []string) error {
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if net.ParseIP(host) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(host); err == nil {
+			continue
+		}
+		if host == "" || validate.Var(host, "hostname_rfc1123") != nil {
+			return fmt.Errorf("invalid allowed host specified: '%s'", host)
+		}
+	}
+	return nil
+}
+
+// AllowedHostsValue Returns the allowed hosts in the form they're stored in the database.
+func (k Key) AllowedHostsValue() string {
+	return strings.Join(k.AllowedHosts, ",")
+}
+
+// SetAllowedHosts Sets the allowed hosts from the form they're stored in the database.
+func (k *Key) SetAllowedHosts(value string) {
+	k.AllowedHosts = nil
+	for _, host := range strings.Split(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			k.AllowedHosts = append(k.AllowedHosts, host)
+		}
+	}
+}
+
+// SetDeletedAt Sets when the key was deleted from a unix timestamp in milliseconds, 0 meaning it isn't known.
+func (k *Key) SetDeletedAt(at int64) {
+	k.DeletedAt = unixMilliTime(at)
+}
+
+// HashKey Returns the hash stored in place of a key value.
+func HashKey(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
+
+// KeyPrefix Returns the part of a key value that is stored in the clear.
+func KeyPrefix(value string) string {
+	if len(value) > KeyPrefixLength {
+		return value[:KeyPrefixLength]
+	}
+	return value
+}
+
+// ValueHash Returns the hash of the key value if it is known, otherwise the hash retrieved from the database.
+func (k Key) ValueHash() string {
+	if k.Value != "" {
+		return HashKey(k.Value)
+	}
+	return k.Hash
+}
+
 // Expired Reports whether the key has expired.
 func (k Key) Expired() bool {
 	if k.ValidUntil == nil {
@@ -124,6 +206,9 @@ func (k RequestKey) ToKey() Key {
 		Value: k.Value,
 		Type:  k.Type,
 	}
+	for _, host := range k.AllowedHosts {
+		out.AllowedHosts = append(out.AllowedHosts, strings.TrimSpace(host))
+	}
 	valid, err := time.Parse(time.RFC3339, k.ValidUntil)
 	if err == nil {
 		out.ValidUntil = &valid
@@ -0,0 +1,34 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import "time"
+
+// LoginFailures counts the failed logins from an IP address, whatever account they were for.
+type LoginFailures struct {
+	IP           string     `json:"ip"`
+	Count        int        `json:"count"`
+	LastFailure  time.Time  `json:"last_failure"`
+	BlockedUntil *time.Time `json:"blocked_until,omitempty"`
+}
+
+// Blocked is used to check if logins from the address are currently blocked.
+func (l *LoginFailures) Blocked() bool {
+	return l.BlockedUntil != nil && l.BlockedUntil.After(time.Now())
+}
@@ -0,0 +1,49 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import "time"
+
+const (
+	MigrationUp   = "up"
+	MigrationDown = "down"
+)
+
+// MigrationStep is a single migration run, or reversed, while moving the database between versions.
+type MigrationStep struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	Direction string `json:"direction"`
+}
+
+// MigrationPlan holds the steps needed to move the database from one version to another.
+type MigrationPlan struct {
+	From  int             `json:"from"`
+	To    int             `json:"to"`
+	Steps []MigrationStep `json:"steps"`
+}
+
+// MigrationRecord is an entry in the history of migrations run against the database.
+type MigrationRecord struct {
+	Identifier int64     `json:"id"`
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Direction  string    `json:"direction"`
+	AppliedAt  time.Time `json:"applied_at"`
+}
@@ -19,17 +19,74 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package types
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
 type Notification struct {
-	Identifier int64     `json:"id"`
-	Type       string    `json:"type"`
-	When       time.Time `json:"when"`
+	Identifier   int64      `json:"id"`
+	Reader       string     `json:"reader,omitempty"`
+	Type         string     `json:"type"`
+	When         time.Time  `json:"when"`
+	Acknowledged *time.Time `json:"acknowledged,omitempty"`
+}
+
+// NotificationCursor marks a position in the newest first (when, id) ordering of notifications.
+// It is handed to clients as an opaque string so they can request the next page of notifications.
+type NotificationCursor struct {
+	When       int64 `json:"w"`
+	Identifier int64 `json:"i"`
+}
+
+// Cursor Returns a cursor pointing at this notification.
+func (n *Notification) Cursor() NotificationCursor {
+	return NotificationCursor{
+		When:       n.When.Unix(),
+		Identifier: n.Identifier,
+	}
+}
+
+// Encode Returns the opaque string representation of the cursor.
+func (c NotificationCursor) Encode() string {
+	out, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(out)
+}
+
+// DecodeNotificationCursor Parses a cursor previously returned by Encode.
+func DecodeNotificationCursor(cursor string) (*NotificationCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var out NotificationCursor
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &out, nil
+}
+
+// notificationTypes are the types of notification a reader can send.
+var notificationTypes = []string{
+	"UPS_DISCONNECTED",
+	"UPS_CONNECTED",
+	"UPS_ON_BATTERY",
+	"UPS_LOW_BATTERY",
+	"UPS_ONLINE",
+	"SHUTTING_DOWN",
+	"RESTARTING",
+	"HIGH_TEMP",
+	"MAX_TEMP",
+}
+
+// ValidNotificationType Reports whether a reader can send a notification of the given type.
+func ValidNotificationType(notificationType string) bool {
+	return slices.Contains(notificationTypes, notificationType)
 }
 
 type RequestNotification struct {
@@ -38,12 +95,7 @@ type RequestNotification struct {
 }
 
 func (n *RequestNotification) Validate(validate *validator.Validate) error {
-	valid := false
-	switch n.Type {
-	case "UPS_DISCONNECTED", "UPS_CONNECTED", "UPS_ON_BATTERY", "UPS_LOW_BATTERY", "UPS_ONLINE", "SHUTTING_DOWN", "RESTARTING", "HIGH_TEMP", "MAX_TEMP":
-		valid = true
-	}
-	if !valid {
+	if !ValidNotificationType(n.Type) {
 		return fmt.Errorf("%v is not a valid type", n.Type)
 	}
 	_, err := time.Parse(time.RFC3339, n.When)
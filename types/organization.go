@@ -0,0 +1,74 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"slices"
+	"time"
+)
+
+// Roles an account can have in an organization. Owners manage the organization and its members,
+// managers manage its keys, and viewers can only see its keys and readers.
+const (
+	OrgRoleOwner   = "owner"
+	OrgRoleManager = "manager"
+	OrgRoleViewer  = "viewer"
+)
+
+var orgRoles = []string{
+	OrgRoleOwner,
+	OrgRoleManager,
+	OrgRoleViewer,
+}
+
+// ValidOrgRole is used to check if a role exists.
+func ValidOrgRole(role string) bool {
+	return slices.Contains(orgRoles, role)
+}
+
+// Organization lets several accounts share one set of readers and keys.
+// Its keys are kept under the account that created it, so reads, notifications and alert rules for
+// its readers work the same as they do for that account's own readers. Webhooks are kept under that
+// account as well but are only told about the organization's readers if they're for the organization.
+type Organization struct {
+	Identifier        int64     `json:"id"`
+	AccountIdentifier int64     `json:"-"`
+	Name              string    `json:"name" validate:"required,max=100"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// OrganizationMember is an account that belongs to an organization.
+type OrganizationMember struct {
+	OrganizationIdentifier int64  `json:"-"`
+	AccountIdentifier      int64  `json:"-"`
+	Name                   string `json:"name"`
+	Email                  string `json:"email"`
+	Role                   string `json:"role"`
+}
+
+// Membership is an organization an account belongs to and the role it has there.
+type Membership struct {
+	Organization Organization `json:"organization"`
+	Role         string       `json:"role"`
+}
+
+// CanManageKeys is used to check if the member can add, update, rotate and delete the organization's keys.
+func (m *Membership) CanManageKeys() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleManager
+}
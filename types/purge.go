@@ -0,0 +1,35 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+// PurgeCounts holds the number of rows purging an account removed, or would remove on a dry run.
+type PurgeCounts struct {
+	Accounts          int64 `json:"accounts"`
+	Keys              int64 `json:"keys"`
+	Reads             int64 `json:"reads"`
+	Notifications     int64 `json:"notifications"`
+	Sessions          int64 `json:"sessions"`
+	Webhooks          int64 `json:"webhooks"`
+	WebhookDeliveries int64 `json:"webhook_deliveries"`
+	AlertRules        int64 `json:"alert_rules"`
+	PasswordResets    int64 `json:"password_resets"`
+	TwoFactor         int64 `json:"two_factor"`
+	RecoveryCodes     int64 `json:"recovery_codes"`
+	Memberships       int64 `json:"memberships"`
+}
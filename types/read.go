@@ -19,6 +19,8 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package types
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"strings"
 
@@ -29,7 +31,10 @@ import (
 // a read is either a chip read from a timing system or a manual entry from
 // something like a mobile device
 type Read struct {
-	Key          string `json:"-"`
+	Key string `json:"-"`
+	// ID is the order the read was added in. It's only set on reads that were just added and on
+	// reads gotten in the order they were added.
+	ID           int64  `json:"-"`
 	Identifier   string `json:"identifier" validate:"required"`
 	Seconds      int64  `json:"seconds" validate:"gte=0"`
 	Milliseconds int    `json:"milliseconds" validate:"gte=0"`
@@ -65,3 +70,40 @@ func (r *Read) Equals(other *Read) bool {
 		r.RSSI == other.RSSI
 }
 
+// ReadCursor marks a position in the (seconds, milliseconds, identifier, ident_type) ordering of
+// reads. It is handed to clients as an opaque string so they can request the next page of reads.
+type ReadCursor struct {
+	Seconds      int64  `json:"s"`
+	Milliseconds int    `json:"m"`
+	Identifier   string `json:"i"`
+	IdentType    string `json:"t"`
+}
+
+// Cursor Returns a cursor pointing at this read.
+func (r *Read) Cursor() ReadCursor {
+	return ReadCursor{
+		Seconds:      r.Seconds,
+		Milliseconds: r.Milliseconds,
+		Identifier:   r.Identifier,
+		IdentType:    r.IdentType,
+	}
+}
+
+// Encode Returns the opaque string representation of the cursor.
+func (c ReadCursor) Encode() string {
+	out, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(out)
+}
+
+// DecodeReadCursor Parses a cursor previously returned by Encode.
+func DecodeReadCursor(cursor string) (*ReadCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var out ReadCursor
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &out, nil
+}
@@ -0,0 +1,133 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Permissions a role can grant. Accounts can always manage their own account, keys and settings,
+// these permissions are for everything past that.
+const (
+	PermAccountsView        = "accounts.view"
+	PermAccountsManage      = "accounts.manage"
+	PermAccountsUnlock      = "accounts.unlock"
+	PermKeysCreate          = "keys.create"
+	PermKeysManage          = "keys.manage"
+	PermReadsDelete         = "reads.delete"
+	PermOrganizationsManage = "organizations.manage"
+	PermRetentionView       = "retention.view"
+	PermRolesManage         = "roles.manage"
+)
+
+// Roles every database starts with. Accounts are given a role through their type.
+const (
+	RoleAdmin = "admin"
+	RoleFree  = "free"
+	RolePaid  = "paid"
+)
+
+var permissions = []string{
+	PermAccountsView,
+	PermAccountsManage,
+	PermAccountsUnlock,
+	PermKeysCreate,
+	PermKeysManage,
+	PermReadsDelete,
+	PermOrganizationsManage,
+	PermRetentionView,
+	PermRolesManage,
+}
+
+var roleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,19}$`)
+
+// Role is a named set of permissions given to every account of that type.
+type Role struct {
+	Name        string   `json:"name" validate:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+// DefaultRoles returns the roles a new database is set up with.
+func DefaultRoles() []Role {
+	return []Role{
+		{
+			Name:        RoleAdmin,
+			Permissions: Permissions(),
+		},
+		{
+			Name:        RoleFree,
+			Permissions: []string{PermKeysCreate, PermReadsDelete},
+		},
+		{
+			Name:        RolePaid,
+			Permissions: []string{PermKeysCreate, PermReadsDelete},
+		},
+	}
+}
+
+// Permissions returns every permission a role can grant.
+func Permissions() []string {
+	return slices.Clone(permissions)
+}
+
+// ValidPermission is used to check if a permission exists.
+func ValidPermission(permission string) bool {
+	return slices.Contains(permissions, permission)
+}
+
+// ValidRoleName is used to check if a name can be used for a role. Names are short and lower case.
+func ValidRoleName(name string) bool {
+	return roleNameRegex.MatchString(name)
+}
+
+// Can is used to check if the role grants a permission.
+func (r Role) Can(permission string) bool {
+	return slices.Contains(r.Permissions, permission)
+}
+
+// Validate Ensures valid data in the structure.
+func (r *Role) Validate(validate *validator.Validate) error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	if !ValidRoleName(r.Name) {
+		return fmt.Errorf("invalid role name '%s'", r.Name)
+	}
+	for _, permission := range r.Permissions {
+		if !ValidPermission(permission) {
+			return fmt.Errorf("%v is not a valid permission", permission)
+		}
+	}
+	return nil
+}
+
+// PermissionsValue Returns the permissions in the form they're stored in the database.
+func (r Role) PermissionsValue() string {
+	return strings.Join(uniqueList(r.Permissions), ",")
+}
+
+// SetPermissions Sets the permissions from the form they're stored in the database.
+func (r *Role) SetPermissions(value string) {
+	r.Permissions = splitList(value)
+}
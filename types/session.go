@@ -0,0 +1,46 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Session is a single login to an account. An account can have many sessions open at once,
+// one for each device it was logged in from.
+// Only hashes of the tokens are stored, so the tokens are only known when the session is created or refreshed.
+type Session struct {
+	Identifier        int64     `json:"id"`
+	AccountIdentifier int64     `json:"-"`
+	Token             string    `json:"-"`
+	RefreshToken      string    `json:"-"`
+	UserAgent         string    `json:"user_agent"`
+	IP                string    `json:"ip"`
+	CreatedAt         time.Time `json:"created_at"`
+	LastUsed          time.Time `json:"last_used"`
+	Current           bool      `json:"current"`
+}
+
+// HashToken Returns the hash stored in place of a session token.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
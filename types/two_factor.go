@@ -0,0 +1,29 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+// TwoFactor holds the TOTP (RFC 6238) secret for an account. Until it is enabled the secret belongs to an
+// enrollment that hasn't been confirmed with a code yet. Only the hashes of recovery codes are stored.
+type TwoFactor struct {
+	AccountIdentifier int64  `json:"-"`
+	Secret            string `json:"-"`
+	Enabled           bool   `json:"enabled"`
+	LastStep          int64  `json:"-"`
+	RecoveryCodes     int    `json:"recovery_codes"`
+}
@@ -0,0 +1,168 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Webhook events an account can subscribe to.
+const (
+	WebhookReadsAdded        = "reads.added"
+	WebhookNotificationSaved = "notification.saved"
+	WebhookKeyExpired        = "key.expired"
+)
+
+// Statuses a webhook delivery can be in. Pending deliveries are still queued to be sent.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+var webhookEvents = []string{
+	WebhookReadsAdded,
+	WebhookNotificationSaved,
+	WebhookKeyExpired,
+}
+
+// Webhook is a URL an account wants told about events as they happen.
+// Deliveries are signed with the secret, which is only returned when the webhook is created.
+// Organization webhooks are kept under the account that created the organization, like its keys, and
+// are only told about events for the organization's keys.
+type Webhook struct {
+	Identifier        int64     `json:"id"`
+	AccountIdentifier int64     `json:"-"`
+	Organization      *int64    `json:"organization,omitempty"`
+	URL               string    `json:"url"`
+	Secret            string    `json:"secret,omitempty"`
+	Events            []string  `json:"events"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type RequestWebhook struct {
+	URL    string   `json:"url" validate:"required,url,max=500"`
+	Events []string `json:"events" validate:"required,min=1"`
+}
+
+// Validate Ensures valid data in the structure.
+func (w RequestWebhook) Validate(validate *validator.Validate) error {
+	if err := validate.Struct(w); err != nil {
+		return err
+	}
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook url specified: '%s'", w.URL)
+	}
+	for _, event := range w.Events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("%v is not a valid event", event)
+		}
+	}
+	return nil
+}
+
+// ToWebhook Returns a Webhook struct with proper information.
+func (w RequestWebhook) ToWebhook() Webhook {
+	out := Webhook{
+		URL: strings.TrimSpace(w.URL),
+	}
+	for _, event := range w.Events {
+		if !slices.Contains(out.Events, event) {
+			out.Events = append(out.Events, event)
+		}
+	}
+	return out
+}
+
+// InOrganization Reports whether the webhook is for the organization, or for its account's own keys if org is nil.
+func (w Webhook) InOrganization(org *int64) bool {
+	if w.Organization == nil || org == nil {
+		return w.Organization == nil && org == nil
+	}
+	return *w.Organization == *org
+}
+
+// EventsValue Returns the events in the form they're stored in the database.
+func (w Webhook) EventsValue() string {
+	return strings.Join(w.Events, ",")
+}
+
+// SetEvents Sets the events from the form they're stored in the database.
+func (w *Webhook) SetEvents(value string) {
+	w.Events = nil
+	for _, event := range strings.Split(value, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			w.Events = append(w.Events, event)
+		}
+	}
+}
+
+// Subscribed Reports whether the webhook wants to be told about the event.
+func (w Webhook) Subscribed(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// WebhookDelivery is a single event sent, or waiting to be sent, to a webhook.
+// Deliveries that fail are retried at NextAttempt until they succeed or run out of attempts.
+// The URL and secret are those of the webhook, they're only filled in for deliveries being sent.
+type WebhookDelivery struct {
+	Identifier        int64      `json:"id"`
+	WebhookIdentifier int64      `json:"webhook_id"`
+	URL               string     `json:"-"`
+	Secret            string     `json:"-"`
+	Event             string     `json:"event"`
+	Payload           string     `json:"payload"`
+	Status            string     `json:"status"`
+	Attempts          int        `json:"attempts"`
+	NextAttempt       time.Time  `json:"next_attempt"`
+	ResponseCode      int        `json:"response_code"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	DeliveredAt       *time.Time `json:"delivered_at,omitempty"`
+}
+
+// WebhookPayload is the body sent to a webhook. Only the fields for the event are set.
+type WebhookPayload struct {
+	Event        string        `json:"event"`
+	When         time.Time     `json:"when"`
+	Reader       string        `json:"reader,omitempty"`
+	Reads        []Read        `json:"reads,omitempty"`
+	Notification *Notification `json:"notification,omitempty"`
+	Key          *Key          `json:"key,omitempty"`
+}
+
+// SignWebhook Returns the signature sent with a delivery, an HMAC-SHA256 of the timestamp and body
+// joined by a period. Receivers compute the same value with their secret to check it came from us.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
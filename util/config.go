@@ -19,108 +19,347 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package util
 
 import (
+	"fmt"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+)
 
-	"github.com/pkg/errors"
+const (
+	LockoutBackoff   = "backoff"
+	LockoutPermanent = "permanent"
 )
 
-// GetConfig returns a config struct filled with values stored in local environment variables
+// GetConfig returns a config struct filled with values from the config file named by CONFIG_FILE and
+// local environment variables
 func GetConfig() (*Config, error) {
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		return nil, errors.New("DB_NAME not found in environment")
+	return (&ConfigSource{}).Load()
+}
+
+// configReader looks settings up in the flags, then the environment, then the config file, keeping every
+// invalid setting so they can all be reported at once
+type configReader struct {
+	flags  map[string]string
+	file   map[string]string
+	values []ConfigValue
+	errs   []error
+}
+
+func (r *configReader) read() *Config {
+	dbDriver := r.get("DB_CONNECTOR")
+	switch dbDriver {
+	case "mysql", "postgres", "sqlite", "sqlite3", "memory":
+	default:
+		r.fail("DB_CONNECTOR must be one of mysql, postgres, sqlite, or memory, found '%s'", dbDriver)
 	}
 
-	dbHost := os.Getenv("DB_HOST")
-	if dbHost == "" {
-		return nil, errors.New("DB_HOST not found in environment")
+	// SQLite only needs a file name (DB_NAME) and the in-memory database needs nothing, there's no server to connect to.
+	memoryDB := dbDriver == "memory"
+	noServer := dbDriver == "sqlite" || dbDriver == "sqlite3" || memoryDB
+
+	dbName := r.get("DB_NAME")
+	if dbName == "" && !memoryDB {
+		r.fail("DB_NAME not set")
 	}
 
-	dbPort, err := strconv.Atoi(os.Getenv("DB_PORT"))
-	if err != nil || dbPort < 0 {
-		return nil, errors.New("DB_PORT not found in environment")
+	dbHost := r.get("DB_HOST")
+	if dbHost == "" && !noServer {
+		r.fail("DB_HOST not set")
 	}
 
-	dbUser := os.Getenv("DB_USER")
-	if dbUser == "" {
-		return nil, errors.New("DB_USER not found in environment")
+	dbPort := r.getInt("DB_PORT", 1, 65535)
+	if dbPort == 0 && !noServer {
+		r.fail("DB_PORT not set")
+	}
+
+	dbUser := r.get("DB_USER")
+	if dbUser == "" && !noServer {
+		r.fail("DB_USER not set")
+	}
+
+	dbPassword := r.get("DB_PASSWORD")
+
+	// How often (in seconds) the retention worker runs.
+	recordInterval := r.getInt("RECORD_INTERVAL", 60, math.MaxInt)
+
+	// How long (in days) to keep reads for each role, given as role=days pairs. Roles that aren't
+	// listed, custom roles included, keep them for RETENTION_DEFAULT_DAYS. 0 keeps them forever.
+	retention := make(map[string]int)
+	for _, pair := range strings.Split(r.get("RETENTION_DAYS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		role, value, found := strings.Cut(pair, "=")
+		role = strings.TrimSpace(role)
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if !found || role == "" || err != nil || days < 0 {
+			r.fail("RETENTION_DAYS must be role=days pairs with days a whole number of at least 0, found '%s'", pair)
+			continue
+		}
+		retention[role] = days
 	}
+	retentionDefault := r.getInt("RETENTION_DEFAULT_DAYS", 0, math.MaxInt)
+
+	port := r.getInt("PORT", 1, 65535)
 
-	dbPassword := os.Getenv("DB_PASSWORD")
+	development := r.get("VERSION") != "production"
 
-	dbDriver := os.Getenv("DB_CONNECTOR")
-	if dbDriver == "" {
-		dbDriver = "mysql"
+	autotls := false
+	switch value := r.get("AUTOTLS"); value {
+	case "enabled":
+		autotls = true
+	case "disabled":
+	default:
+		r.fail("AUTOTLS must be either enabled or disabled, found '%s'", value)
 	}
 
-	recordInterval, err := strconv.Atoi(os.Getenv("RECORD_INTERVAL"))
-	if err != nil && recordInterval < 60 {
-		recordInterval = 300
+	domain := r.get("DOMAIN")
+	// Certificates are only issued for DOMAIN outside of development.
+	if autotls && !development && domain == "" {
+		r.fail("DOMAIN not set, it's needed when AUTOTLS is enabled")
 	}
 
-	port, err := strconv.Atoi(os.Getenv("PORT"))
-	if err != nil && port < 200 {
-		port = 8181
+	// PEM files holding the Ed25519 or RSA keys tokens are signed with, separated by commas. The first key
+	// signs new tokens, the rest are only used to verify tokens so keys can be rotated without logging anyone out.
+	var jwtKeyFiles []string
+	for _, file := range strings.Split(r.get("JWT_KEY_FILES"), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			jwtKeyFiles = append(jwtKeyFiles, file)
+		}
 	}
 
-	development := os.Getenv("VERSION") != "production"
+	// With signing keys the shared secrets are only needed to accept tokens issued before the keys were set up.
+	secret_key := r.get("SECRET_KEY")
+	if (secret_key == "" && len(jwtKeyFiles) == 0) || (secret_key != "" && len(secret_key) < 20) {
+		r.fail("SECRET_KEY not set or under 20 characters")
+	}
 
-	autotls := os.Getenv("AUTOTLS") == "enabled"
+	refresh_key := r.get("REFRESH_KEY")
+	if (refresh_key == "" && len(jwtKeyFiles) == 0) || (refresh_key != "" && len(refresh_key) < 20) {
+		r.fail("REFRESH_KEY not set or under 20 characters")
+	}
 
-	secret_key := os.Getenv("SECRET_KEY")
-	if secret_key == "" || len(secret_key) < 20 {
-		return nil, errors.New("SECRET_KEY not set or under 20 characters")
+	admin_email := r.get("ADMIN_EMAIL")
+	admin_name := r.get("ADMIN_NAME")
+	admin_pass := r.get("ADMIN_PASS")
+	if (admin_email == "" || admin_name == "" || admin_pass == "") && admin_email+admin_name+admin_pass != "" {
+		r.fail("ADMIN_EMAIL, ADMIN_NAME, and ADMIN_PASS must be set together")
 	}
 
-	refresh_key := os.Getenv("REFRESH_KEY")
-	if refresh_key == "" || len(refresh_key) < 20 {
-		return nil, errors.New("REFRESH_KEY not set or under 20 characters")
+	// SMTP server used to email alerts, alerts aren't sent if no host is set.
+	smtpHost := r.get("SMTP_HOST")
+	smtpPort := r.getInt("SMTP_PORT", 1, 65535)
+	smtpUser := r.get("SMTP_USER")
+	smtpPassword := r.get("SMTP_PASSWORD")
+	smtpFrom := r.get("SMTP_FROM")
+	if smtpFrom == "" {
+		smtpFrom = r.fallback("SMTP_FROM", smtpUser)
 	}
 
-	admin_email := os.Getenv("ADMIN_EMAIL")
-	admin_name := os.Getenv("ADMIN_NAME")
-	admin_pass := os.Getenv("ADMIN_PASS")
+	// How long (in minutes) to wait before sending the same alert again.
+	alertThrottle := r.getInt("ALERT_THROTTLE_MINUTES", 0, math.MaxInt)
 
-	domain := os.Getenv("DOMAIN")
+	// How accounts are locked after too many wrong passwords. A backoff lock expires on its own after
+	// LOCKOUT_MINUTES, doubling each time the account is locked again before a successful login, up
+	// to LOCKOUT_MAX_MINUTES. A permanent lock, the default, lasts until an admin unlocks the account.
+	lockoutPolicy := r.get("LOCKOUT_POLICY")
+	if lockoutPolicy != LockoutBackoff && lockoutPolicy != LockoutPermanent {
+		r.fail("LOCKOUT_POLICY must be either backoff or permanent, found '%s'", lockoutPolicy)
+	}
+	lockoutMinutes := r.getInt("LOCKOUT_MINUTES", 1, math.MaxInt)
+	lockoutMaxMinutes := r.getInt("LOCKOUT_MAX_MINUTES", 1, math.MaxInt)
+	if lockoutMaxMinutes < lockoutMinutes {
+		r.fail("LOCKOUT_MAX_MINUTES must be at least LOCKOUT_MINUTES")
+	}
+
+	// Failed logins from a single IP address, whatever account they were for, before logins from it are
+	// blocked for IP_LOCKOUT_MINUTES. Failures older than that are forgotten, 0 never blocks an address.
+	ipMaxFailures := r.getInt("IP_MAX_FAILURES", 0, math.MaxInt)
+	ipLockoutMinutes := r.getInt("IP_LOCKOUT_MINUTES", 1, math.MaxInt)
 
 	return &Config{
-		DBName:         dbName,
-		DBHost:         dbHost,
-		DBPort:         dbPort,
-		DBUser:         dbUser,
-		DBPassword:     dbPassword,
-		DBDriver:       dbDriver,
-		RecordInterval: recordInterval,
-		Port:           port,
-		Development:    development,
-		AutoTLS:        autotls,
-		SecretKey:      secret_key,
-		RefreshKey:     refresh_key,
-		AdminEmail:     admin_email,
-		AdminName:      admin_name,
-		AdminPass:      admin_pass,
-		Domain:         domain,
-	}, nil
+		DBName:            dbName,
+		DBHost:            dbHost,
+		DBPort:            dbPort,
+		DBUser:            dbUser,
+		DBPassword:        dbPassword,
+		DBDriver:          dbDriver,
+		RecordInterval:    recordInterval,
+		Retention:         retention,
+		RetentionDefault:  retentionDefault,
+		Port:              port,
+		Development:       development,
+		AutoTLS:           autotls,
+		SecretKey:         secret_key,
+		RefreshKey:        refresh_key,
+		JWTKeyFiles:       jwtKeyFiles,
+		AdminEmail:        admin_email,
+		AdminName:         admin_name,
+		AdminPass:         admin_pass,
+		Domain:            domain,
+		SMTPHost:          smtpHost,
+		SMTPPort:          smtpPort,
+		SMTPUser:          smtpUser,
+		SMTPPassword:      smtpPassword,
+		SMTPFrom:          smtpFrom,
+		AlertThrottle:     alertThrottle,
+		LockoutPolicy:     lockoutPolicy,
+		LockoutMinutes:    lockoutMinutes,
+		LockoutMaxMinutes: lockoutMaxMinutes,
+		IPMaxFailures:     ipMaxFailures,
+		IPLockoutMinutes:  ipLockoutMinutes,
+	}
+}
+
+// get returns the value of a setting from the first place it's set, its default if it isn't set anywhere
+func (r *configReader) get(name string) string {
+	var setting configSetting
+	for _, s := range configSettings {
+		if s.name == name {
+			setting = s
+			break
+		}
+	}
+	layers := []struct {
+		source string
+		lookup func(name string) string
+	}{
+		{SourceFlag, func(name string) string { return r.flags[settingFlag(name)] }},
+		{SourceEnv, os.Getenv},
+		{SourceFile, func(name string) string { return r.file[settingKey(name)] }},
+	}
+	value, source := setting.def, SourceDefault
+	for _, layer := range layers {
+		direct := layer.lookup(name)
+		fromFile := ""
+		if setting.secret {
+			fromFile = layer.lookup(name + "_FILE")
+		}
+		if direct == "" && fromFile == "" {
+			continue
+		}
+		if direct != "" && fromFile != "" {
+			r.fail("%s and %s_FILE can't both be set", name, name)
+		} else if fromFile != "" {
+			data, err := os.ReadFile(fromFile)
+			if err != nil {
+				r.fail("error reading %s_FILE: %v", name, err)
+			}
+			direct = strings.TrimRight(string(data), "\r\n")
+		}
+		value, source = direct, layer.source
+		break
+	}
+	shown := value
+	if setting.secret && value != "" {
+		shown = Redacted
+	}
+	r.values = append(r.values, ConfigValue{
+		Key:    settingKey(name),
+		Value:  shown,
+		Source: source,
+	})
+	return value
+}
+
+// getInt returns the value of a setting as a number, 0 if it isn't set and has no default
+func (r *configReader) getInt(name string, minimum, maximum int) int {
+	value := r.get(name)
+	if value == "" {
+		return 0
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < minimum || number > maximum {
+		if maximum == math.MaxInt {
+			r.fail("%s must be a whole number of at least %d, found '%s'", name, minimum, value)
+		} else {
+			r.fail("%s must be a whole number from %d to %d, found '%s'", name, minimum, maximum, value)
+		}
+	}
+	return number
+}
+
+// fallback uses the value for a setting that wasn't set and has no default of its own
+func (r *configReader) fallback(name, value string) string {
+	for i := range r.values {
+		if r.values[i].Key == settingKey(name) {
+			r.values[i].Value = value
+		}
+	}
+	return value
+}
+
+func (r *configReader) fail(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Errorf(format, args...))
 }
 
 // Config is the struct that holds all of the config values for connecting to a database
 type Config struct {
-	DBName         string
-	DBHost         string
-	DBPort         int
-	DBUser         string
-	DBPassword     string
-	DBDriver       string
-	RecordInterval int
-	Port           int
-	Development    bool
-	AutoTLS        bool
-	SecretKey      string
-	RefreshKey     string
-	AdminEmail     string
-	AdminName      string
-	AdminPass      string
-	Domain         string
+	DBName            string
+	DBHost            string
+	DBPort            int
+	DBUser            string
+	DBPassword        string
+	DBDriver          string
+	RecordInterval    int
+	Retention         map[string]int
+	RetentionDefault  int
+	Port              int
+	Development       bool
+	AutoTLS           bool
+	SecretKey         string
+	RefreshKey        string
+	JWTKeyFiles       []string
+	AdminEmail        string
+	AdminName         string
+	AdminPass         string
+	Domain            string
+	SMTPHost          string
+	SMTPPort          int
+	SMTPUser          string
+	SMTPPassword      string
+	SMTPFrom          string
+	AlertThrottle     int
+	LockoutPolicy     string
+	LockoutMinutes    int
+	LockoutMaxMinutes int
+	IPMaxFailures     int
+	IPLockoutMinutes  int
+}
+
+// RetentionDays returns the number of days reads are kept for accounts with the given role, the default
+// for roles without their own, 0 if they're kept forever.
+func (c *Config) RetentionDays(role string) int {
+	if days, ok := c.Retention[role]; ok {
+		return days
+	}
+	return c.RetentionDefault
 }
 
+// LockoutDuration returns how long an account is locked for when it has already been locked lockCount times
+// since its last successful login, 0 if the lock doesn't expire.
+func (c *Config) LockoutDuration(lockCount int) time.Duration {
+	if c.LockoutPolicy != LockoutBackoff || c.LockoutMinutes < 1 {
+		return 0
+	}
+	duration := time.Duration(c.LockoutMinutes) * time.Minute
+	maxDuration := time.Duration(c.LockoutMaxMinutes) * time.Minute
+	for i := 0; i < lockCount && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+	return duration
+}
+
+// IPLockoutDuration returns how long logins from an IP address are blocked for, and how long its failures
+// are remembered, 0 if addresses are never blocked.
+func (c *Config) IPLockoutDuration() time.Duration {
+	if c.IPMaxFailures < 1 {
+		return 0
+	}
+	return time.Duration(c.IPLockoutMinutes) * time.Minute
+}
@@ -0,0 +1,226 @@
+/*
+Chronokeep Desktop - Race Scoring Software
+Copyright (C) 2026 James Sentinella
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package util
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Where a setting's value came from, lowest precedence first.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Redacted replaces the value of secrets when the configuration is printed.
+const Redacted = "[redacted]"
+
+// configSetting is a value that can be set in the config file, the environment, or with a flag. It's named by
+// its environment variable, its key in the config file is that name in lower case and its flag is the key
+// with dashes. Secrets can also be read from a file named by the same setting with _FILE on the end.
+type configSetting struct {
+	name   string
+	def    string
+	secret bool
+	usage  string
+}
+
+var configSettings = []configSetting{
+	{name: "DB_CONNECTOR", def: "mysql", usage: "database to use, one of mysql, postgres, sqlite, or memory"},
+	{name: "DB_NAME", usage: "name of the database, the file for sqlite"},
+	{name: "DB_HOST", usage: "host of the database server"},
+	{name: "DB_PORT", usage: "port of the database server"},
+	{name: "DB_USER", usage: "user to connect to the database server as"},
+	{name: "DB_PASSWORD", secret: true, usage: "password to connect to the database server with"},
+	{name: "RECORD_INTERVAL", def: "300", usage: "seconds between runs of the read retention worker, at least 60"},
+	{name: "RETENTION_DAYS", usage: "comma separated role=days pairs, the days reads are kept for accounts with each role, 0 keeps them forever"},
+	{name: "RETENTION_DEFAULT_DAYS", def: "0", usage: "days reads are kept for roles not in RETENTION_DAYS, 0 keeps them forever"},
+	{name: "PORT", def: "8181", usage: "port the server listens on"},
+	{name: "VERSION", def: "development", usage: "production turns off development mode"},
+	{name: "AUTOTLS", def: "disabled", usage: "enabled to get certificates for DOMAIN automatically, or disabled"},
+	{name: "JWT_KEY_FILES", usage: "comma separated PEM files with the keys tokens are signed with, the first signs new tokens"},
+	{name: "SECRET_KEY", secret: true, usage: "secret tokens are signed with when there are no key files, at least 20 characters"},
+	{name: "REFRESH_KEY", secret: true, usage: "secret refresh tokens are signed with when there are no key files, at least 20 characters"},
+	{name: "ADMIN_EMAIL", usage: "email of the admin account added when there are no accounts"},
+	{name: "ADMIN_NAME", usage: "name of the admin account added when there are no accounts"},
+	{name: "ADMIN_PASS", secret: true, usage: "password of the admin account added when there are no accounts"},
+	{name: "DOMAIN", usage: "domain certificates are issued for"},
+	{name: "SMTP_HOST", usage: "SMTP server alerts and password resets are emailed through, email is off when not set"},
+	{name: "SMTP_PORT", def: "587", usage: "port of the SMTP server"},
+	{name: "SMTP_USER", usage: "user to log in to the SMTP server as"},
+	{name: "SMTP_PASSWORD", secret: true, usage: "password to log in to the SMTP server with"},
+	{name: "SMTP_FROM", usage: "address email is sent from, SMTP_USER when not set"},
+	{name: "ALERT_THROTTLE_MINUTES", def: "15", usage: "minutes before the same alert is sent again"},
+	{name: "LOCKOUT_POLICY", def: LockoutPermanent, usage: "how accounts are locked after too many wrong passwords, permanent or backoff"},
+	{name: "LOCKOUT_MINUTES", def: "5", usage: "minutes a backoff lock lasts the first time, at least 1"},
+	{name: "LOCKOUT_MAX_MINUTES", def: "1440", usage: "most minutes a backoff lock can last, at least LOCKOUT_MINUTES"},
+	{name: "IP_MAX_FAILURES", def: "20", usage: "failed logins from an IP address before logins from it are blocked, 0 never blocks"},
+	{name: "IP_LOCKOUT_MINUTES", def: "15", usage: "minutes logins from an IP address are blocked for, at least 1"},
+}
+
+// ConfigValue is the effective value of a setting and where it came from.
+type ConfigValue struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// ConfigSource holds the config file and flags a command was given, the environment is read when it's loaded.
+type ConfigSource struct {
+	flags *flag.FlagSet
+	file  *string
+}
+
+// ConfigFlags Adds -config and a flag for every setting to the flag set, returning the source to load the
+// config from once the flags are parsed.
+func ConfigFlags(flags *flag.FlagSet) *ConfigSource {
+	source := &ConfigSource{
+		flags: flags,
+		file:  flags.String("config", "", "YAML or TOML file to read settings from, CONFIG_FILE when not set"),
+	}
+	for _, setting := range configSettings {
+		flags.String(settingFlag(setting.name), setting.def, setting.usage)
+		if setting.secret {
+			flags.String(settingFlag(setting.name)+"-file", "", "file to read "+settingFlag(setting.name)+" from")
+		}
+	}
+	return source
+}
+
+// Load Returns the config from the config file, then the environment, then the flags, each overriding the last.
+// Every invalid setting is reported in the error.
+func (s *ConfigSource) Load() (*Config, error) {
+	config, _, err := s.load()
+	return config, err
+}
+
+// Check Loads the config like Load, also returning the effective value of every setting and where it came
+// from, with secrets redacted.
+func (s *ConfigSource) Check() (*Config, []ConfigValue, error) {
+	config, reader, err := s.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, reader.values, nil
+}
+
+func (s *ConfigSource) load() (*Config, *configReader, error) {
+	reader := &configReader{
+		flags: make(map[string]string),
+	}
+	if s != nil && s.flags != nil {
+		s.flags.Visit(func(f *flag.Flag) {
+			reader.flags[f.Name] = f.Value.String()
+		})
+	}
+	file := os.Getenv("CONFIG_FILE")
+	if s != nil && s.file != nil && *s.file != "" {
+		file = *s.file
+	}
+	if file != "" {
+		values, err := readConfigFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader.file = values
+	}
+	config := reader.read()
+	if len(reader.errs) > 0 {
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(reader.errs...))
+	}
+	return config, reader, nil
+}
+
+// readConfigFile Reads the settings in a YAML or TOML file, which is told by its extension.
+func readConfigFile(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unknown config file type '%s', expected .yaml, .yml, or .toml", filepath.Ext(file))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+	known := make(map[string]bool)
+	for _, setting := range configSettings {
+		known[settingKey(setting.name)] = true
+		if setting.secret {
+			known[settingKey(setting.name)+"_file"] = true
+		}
+	}
+	var errs []error
+	values := make(map[string]string)
+	for key, value := range raw {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("unknown setting '%s' in config file", key))
+			continue
+		}
+		switch value := value.(type) {
+		case nil:
+		case map[string]any:
+			errs = append(errs, fmt.Errorf("%s in config file must be a value, not a table", key))
+		case []any:
+			// Lists are stored the way they're given in the environment.
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	if len(errs) > 0 {
+		// Map order is random, keep the errors in the same order every time.
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Error() < errs[j].Error()
+		})
+		return nil, fmt.Errorf("invalid config file:\n%w", errors.Join(errs...))
+	}
+	return values, nil
+}
+
+// settingKey Returns the key of a setting in the config file.
+func settingKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// settingFlag Returns the flag for a setting.
+func settingFlag(name string) string {
+	return strings.ReplaceAll(settingKey(name), "_", "-")
+}